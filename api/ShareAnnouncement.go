@@ -25,7 +25,7 @@ func CreateShareAnnouncement(c *gin.Context) {
 	var req struct {
 		ShareCode  string `json:"shareCode" binding:"required"`
 		Note       string `json:"note"`
-		ExpireTime string `json:"expireTime"` // 格式: "2006-01-02 15:04:05"
+		ExpireTime string `json:"expireTime"` // RFC3339 或服务器本地时间 "2006-01-02 15:04:05"
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "参数错误"})
@@ -37,16 +37,18 @@ func CreateShareAnnouncement(c *gin.Context) {
 		return
 	}
 
+	var announceExpire model.UTCTime
 	if req.ExpireTime != "" {
-		_, err := time.ParseInLocation("2006-01-02 15:04:05", req.ExpireTime, time.Local)
+		t, err := model.ParseInputTime(req.ExpireTime)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "过期时间格式错误，应为 YYYY-MM-DD HH:mm:ss"})
 			return
 		}
+		announceExpire = model.NewUTCTime(t)
 	}
 
 	// ★★★ 检查分享码是否已过期 ★★★
-	var shareCodeExpireTime sql.NullString
+	var shareCodeExpireTime model.UTCTime
 	err := global.DB.QueryRow(
 		"SELECT expire_time FROM share_codes WHERE code = ? AND status = 1",
 		req.ShareCode,
@@ -65,20 +67,17 @@ func CreateShareAnnouncement(c *gin.Context) {
 	}
 
 	// 判断分享码是否已过期
-	if shareCodeExpireTime.Valid && shareCodeExpireTime.String != "" {
-		expireTime, parseErr := time.ParseInLocation("2006-01-02 15:04:05", shareCodeExpireTime.String, time.Local)
-		if parseErr == nil && time.Now().After(expireTime) {
-			global.GetLog(c).Warnf("发布公告被拒: 分享码已过期 (User: %s, ShareCode: %s, ExpireTime: %s)", userCode, req.ShareCode, shareCodeExpireTime.String)
-			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "发布失败：分享码已过期，不允许发布公告"})
-			return
-		}
+	if shareCodeExpireTime.Expired(time.Now()) {
+		global.GetLog(c).Warnf("发布公告被拒: 分享码已过期 (User: %s, ShareCode: %s, ExpireTime: %s)", userCode, req.ShareCode, shareCodeExpireTime)
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "发布失败：分享码已过期，不允许发布公告"})
+		return
 	}
 
-	createTimeStr := time.Now().Format("2006-01-02 15:04:05")
+	createTime := model.NewUTCTime(time.Now())
 
 	res, err := global.DB.Exec(
 		"INSERT INTO share_announcements (creator_code, share_code, note, expire_time, create_time, status) VALUES (?, ?, ?, ?, ?, 1)",
-		userCode, req.ShareCode, req.Note, req.ExpireTime, createTimeStr,
+		userCode, req.ShareCode, req.Note, announceExpire, createTime,
 	)
 
 	if err != nil {
@@ -94,8 +93,8 @@ func CreateShareAnnouncement(c *gin.Context) {
 		CreatorCode: userCode,
 		ShareCode:   req.ShareCode,
 		Note:        req.Note,
-		CreateTime:  createTimeStr,
-		ExpireTime:  req.ExpireTime,
+		CreateTime:  createTime,
+		ExpireTime:  announceExpire,
 		Status:      1,
 	}
 
@@ -107,14 +106,13 @@ func CreateShareAnnouncement(c *gin.Context) {
 // GetShareAnnouncementList 获取公告列表 (按创建时间倒序，且过滤已过期的)
 // =================================================================================
 func GetShareAnnouncementList(c *gin.Context) {
-	nowStr := time.Now().Format("2006-01-02 15:04:05")
-
 	rows, err := global.DB.Query(`
-		SELECT id, creator_code, share_code, note, create_time, expire_time, status 
+		SELECT id, creator_code, share_code, note, create_time, expire_time, status,
+			` + shareCodeRatingColumnsSQL + `
 		FROM share_announcements 
-		WHERE status = 1 AND expire_time > ?
+		WHERE status = 1 AND expire_time > datetime('now')
 		ORDER BY create_time DESC
-	`, nowStr)
+	`)
 
 	if err != nil {
 		global.GetLog(c).Errorf("查询公告列表失败: %v", err)
@@ -127,7 +125,6 @@ func GetShareAnnouncementList(c *gin.Context) {
 	for rows.Next() {
 		var item model.ShareAnnouncement
 		var note sql.NullString
-		var expireTime sql.NullString
 
 		err := rows.Scan(
 			&item.ID,
@@ -135,18 +132,16 @@ func GetShareAnnouncementList(c *gin.Context) {
 			&item.ShareCode,
 			&note,
 			&item.CreateTime,
-			&expireTime,
+			&item.ExpireTime,
 			&item.Status,
+			&item.Rating.Average,
+			&item.Rating.Count,
 		)
 		if err != nil {
 			continue
 		}
 
 		item.Note = note.String
-		item.ExpireTime = expireTime.String
-
-		item.CreateTime = formatTimeStr(item.CreateTime)
-		item.ExpireTime = formatTimeStr(item.ExpireTime)
 
 		list = append(list, item)
 	}
@@ -186,7 +181,7 @@ func DeleteShareAnnouncement(c *gin.Context) {
 	}
 
 	// 检查分享码是否过期
-	var shareCodeExpireTime sql.NullString
+	var shareCodeExpireTime model.UTCTime
 	err = global.DB.QueryRow(
 		"SELECT expire_time FROM share_codes WHERE code = ? AND status = 1",
 		shareCode,
@@ -198,13 +193,10 @@ func DeleteShareAnnouncement(c *gin.Context) {
 		return
 	}
 
-	if shareCodeExpireTime.Valid && shareCodeExpireTime.String != "" {
-		expireTime, parseErr := time.ParseInLocation("2006-01-02 15:04:05", shareCodeExpireTime.String, time.Local)
-		if parseErr == nil && time.Now().After(expireTime) {
-			global.GetLog(c).Warnf("删除公告被拒: 分享码已过期 (User: %s, ID: %s, ShareCode: %s)", currentUserCode, id, shareCode)
-			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "删除失败：分享码已过期，不允许删除公告"})
-			return
-		}
+	if shareCodeExpireTime.Expired(time.Now()) {
+		global.GetLog(c).Warnf("删除公告被拒: 分享码已过期 (User: %s, ID: %s, ShareCode: %s)", currentUserCode, id, shareCode)
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "删除失败：分享码已过期，不允许删除公告"})
+		return
 	}
 
 	res, err := global.DB.Exec(
@@ -251,16 +243,18 @@ func UpdateShareAnnouncement(c *gin.Context) {
 		return
 	}
 
+	var announceExpire model.UTCTime
 	if req.ExpireTime != "" {
-		_, err := time.ParseInLocation("2006-01-02 15:04:05", req.ExpireTime, time.Local)
+		t, err := model.ParseInputTime(req.ExpireTime)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "过期时间格式错误"})
 			return
 		}
+		announceExpire = model.NewUTCTime(t)
 	}
 
 	// ★★★ 检查公告是否已过期 ★★★
-	var currentExpireTime sql.NullString
+	var currentExpireTime model.UTCTime
 	var shareCode string
 	err := global.DB.QueryRow(
 		"SELECT expire_time, share_code FROM share_announcements WHERE id = ? AND creator_code = ? AND status = 1",
@@ -280,17 +274,14 @@ func UpdateShareAnnouncement(c *gin.Context) {
 	}
 
 	// 判断是否已过期
-	if currentExpireTime.Valid && currentExpireTime.String != "" {
-		expireTime, parseErr := time.ParseInLocation("2006-01-02 15:04:05", currentExpireTime.String, time.Local)
-		if parseErr == nil && time.Now().After(expireTime) {
-			global.GetLog(c).Warnf("更新公告被拒: 公告已过期 (User: %s, ID: %s, ExpireTime: %s)", currentUserCode, id, currentExpireTime.String)
-			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "更新失败：公告已过期，不允许修改"})
-			return
-		}
+	if currentExpireTime.Expired(time.Now()) {
+		global.GetLog(c).Warnf("更新公告被拒: 公告已过期 (User: %s, ID: %s, ExpireTime: %s)", currentUserCode, id, currentExpireTime)
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "更新失败：公告已过期，不允许修改"})
+		return
 	}
 
 	// ★★★ 检查分享码是否已过期 ★★★
-	var shareCodeExpireTime sql.NullString
+	var shareCodeExpireTime model.UTCTime
 	err = global.DB.QueryRow(
 		"SELECT expire_time FROM share_codes WHERE code = ? AND status = 1",
 		shareCode,
@@ -302,18 +293,15 @@ func UpdateShareAnnouncement(c *gin.Context) {
 		return
 	}
 
-	if shareCodeExpireTime.Valid && shareCodeExpireTime.String != "" {
-		expireTime, parseErr := time.ParseInLocation("2006-01-02 15:04:05", shareCodeExpireTime.String, time.Local)
-		if parseErr == nil && time.Now().After(expireTime) {
-			global.GetLog(c).Warnf("更新公告被拒: 分享码已过期 (User: %s, ID: %s, ShareCode: %s)", currentUserCode, id, shareCode)
-			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "更新失败：分享码已过期，不允许修改公告"})
-			return
-		}
+	if shareCodeExpireTime.Expired(time.Now()) {
+		global.GetLog(c).Warnf("更新公告被拒: 分享码已过期 (User: %s, ID: %s, ShareCode: %s)", currentUserCode, id, shareCode)
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "更新失败：分享码已过期，不允许修改公告"})
+		return
 	}
 
 	res, err := global.DB.Exec(
 		"UPDATE share_announcements SET note = ?, expire_time = ? WHERE id = ? AND creator_code = ? AND status = 1",
-		req.Note, req.ExpireTime, id, currentUserCode,
+		req.Note, announceExpire, id, currentUserCode,
 	)
 
 	if err != nil {
@@ -2,13 +2,15 @@ package api
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"os"
 	"practice_problems/deepseek"
 	"practice_problems/global"
 	"practice_problems/middleware"
+	"practice_problems/model"
+	"strconv"
 	"sync"
 	"time"
 
@@ -31,11 +33,12 @@ var upgrader = websocket.Upgrader{
 type UserInputObj struct {
 	Topic   string `json:"topic"`   // 题目：Java内存模型
 	Content string `json:"content"` // 回答：我觉得是...
+	PointID int    `json:"pointId"` // 选填：题目对应的知识点 (用于选取科目模板并注入知识点内容)
 }
 
 // WSMessage WebSocket 消息通用载荷
 type WSMessage struct {
-	Type    string      `json:"type"`    // 消息类型: init, chat, error, quota_exhausted
+	Type    string      `json:"type"`    // 消息类型: init, chat, usage, error, quota_exhausted
 	Content interface{} `json:"content"` // 消息内容
 }
 
@@ -43,10 +46,16 @@ type WSMessage struct {
 type AIInterviewSession struct {
 	UserID      int
 	Username    string
+	SessionID   int64 // ai_interview_sessions.id，流水关联用
+	UserCode    string
+	Language    string // 面试语言 (为空时使用科目设置)
+	PointID     int    // 连接时指定的知识点，消息未携带 pointId 时使用
 	StartTime   time.Time
-	UsedSeconds int64
-	Quota       int64
+	UsedSeconds int64 // 本次会话累计时长
+	Balance     int64 // 最近一次结算后的账本余额
+	Uncharged   int64 // 距上次结算尚未扣除的秒数
 	Conn        *websocket.Conn
+	token       string // 建立连接时使用的 Token，登出时据此断开
 	mu          sync.Mutex
 	stopTimer   chan struct{}
 	closed      bool
@@ -55,22 +64,29 @@ type AIInterviewSession struct {
 	// Key: 题目名称 (Topic)
 	// Value: 该题目的聊天上下文 (System + Assistant + User...)
 	TopicHistories map[string][]openai.ChatCompletionMessage
+	// 每个题目的滚动摘要 (较早的对话被压缩后存放在这里)
+	TopicSummaries map[string]string
+
+	// Token 用量统计 (含摘要调用)
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
 }
 
-// LoadPromptTemplate 读取 prompt.txt 文件
-// 如果文件不存在，返回默认的保底 Prompt
-func LoadPromptTemplate() string {
-	content, err := os.ReadFile("uploads/prompt.txt")
-	if err != nil {
-		// 保底提示词
-		return `你是一位专业的面试官。当前面试题目是：「%s」。
-请注意：
-1. 我会发送用户的【回答】给你。
-2. 请评估回答是否正确。若正确，请进行深挖追问；若错误，请指出。`
-	}
-	return string(content)
+// interviewContextBudget 面试上下文预算
+// deepseek-chat 上下文为 64K，这里留足回复 (MaxTokens 8192) 与估算误差的余量
+var interviewContextBudget = deepseek.ContextBudget{
+	MaxTokens:  24000,
+	KeepRecent: 6, // 压缩时保留最近 3 轮原文
 }
 
+// aiQuotaCheckpointSeconds 会话进行中每隔多少秒把已用时长写入账本
+// 进程崩溃时最多丢失这段时间的扣费
+const aiQuotaCheckpointSeconds = 15
+
+// maxPromptContentRunes 注入 System Prompt 的知识点正文最大字符数
+const maxPromptContentRunes = 4000
+
 // ==========================================
 // 2. Controller 入口
 // ==========================================
@@ -80,6 +96,8 @@ func AIInterviewWebSocket(c *gin.Context) {
 	// 1. 获取参数
 	token := c.Query("token")
 	initTopic := c.Query("point_title")
+	initPointID, _ := strconv.Atoi(c.Query("point_id"))
+	language := c.Query("lang")
 
 	// ==========================================
 	// 🔥 核心鉴权逻辑 (升级前检查)
@@ -130,9 +148,8 @@ func AIInterviewWebSocket(c *gin.Context) {
 		return
 	}
 
-	// 3. 检查用户配额 (从数据库查)
-	var aiQuota int64
-	err = global.DB.QueryRow("SELECT COALESCE(ai_quota, 0) FROM users WHERE id = ?", claims.UserID).Scan(&aiQuota)
+	// 3. 检查用户配额 (以账本余额为准)
+	aiQuota, err := getAIQuotaBalance(global.DB, claims.UserID)
 	if err != nil {
 		sendErrorAndClose("error", 500, "数据库查询失败")
 		return
@@ -142,28 +159,54 @@ func AIInterviewWebSocket(c *gin.Context) {
 		return
 	}
 
-	// 4. 初始化 Session
+	// 4. 并发会话限制 (防止多个会话同时消耗同一份时长)
+	if !acquireAISessionSlot(claims.UserID) {
+		sendErrorAndClose("quota_error", 429, fmt.Sprintf("您已有 %d 个进行中的 AI 面试，请先结束后再开始新的面试", global.AIMaxConcurrentSessions))
+		return
+	}
+
+	// 5. 登记会话 (用于流水关联与用量统计)
+	startTime := time.Now()
+	result, err := global.DB.Exec("INSERT INTO ai_interview_sessions (user_id, start_time) VALUES (?, ?)",
+		claims.UserID, model.FormatDBTime(startTime))
+	if err != nil {
+		releaseAISessionSlot(claims.UserID)
+		sendErrorAndClose("error", 500, "数据库写入失败")
+		return
+	}
+	sessionID, _ := result.LastInsertId()
+
+	// 6. 初始化 Session
 	session := &AIInterviewSession{
 		UserID:         claims.UserID,
 		Username:       claims.Username,
-		StartTime:      time.Now(),
-		Quota:          aiQuota,
+		UserCode:       claims.UserCode,
+		Language:       language,
+		PointID:        initPointID,
+		SessionID:      sessionID,
+		StartTime:      startTime,
+		Balance:        aiQuota,
 		Conn:           conn,
+		token:          token,
 		stopTimer:      make(chan struct{}),
 		closed:         false,
 		TopicHistories: make(map[string][]openai.ChatCompletionMessage),
+		TopicSummaries: make(map[string]string),
 	}
 
-	// 5. 发送初始化成功消息
+	// 登记到在线会话，登出或重置密码时可主动断开
+	registerAIInterviewSession(session)
+
+	// 7. 发送初始化成功消息
 	session.sendRawMessage(WSMessage{Type: "init", Content: map[string]interface{}{"quota": aiQuota}})
 
-	// 6. 发送静态欢迎语 (回显题目)
+	// 8. 发送静态欢迎语 (回显题目)
 	if initTopic != "" {
 		welcomeMsg := fmt.Sprintf("同学你好，我是你的 AI 面试官。\n\n基于题目 **「%s」**，请简要介绍一下你的理解。", initTopic)
 		session.sendRawMessage(WSMessage{Type: "chat", Content: welcomeMsg})
 	}
 
-	// 7. 启动
+	// 9. 启动
 	go session.startTimer()
 	session.handleMessages()
 }
@@ -200,18 +243,23 @@ func (s *AIInterviewSession) startTimer() {
 			}
 
 			s.UsedSeconds++
-			remaining := s.Quota - s.UsedSeconds
+			s.Uncharged++
+			remaining := s.Balance - s.Uncharged
+			needCheckpoint := s.Uncharged >= aiQuotaCheckpointSeconds
+			s.mu.Unlock()
+
+			// 定期结算：写入账本并刷新余额 (其他途径的发放/扣回也会在这里生效)
+			if needCheckpoint && remaining > 0 {
+				remaining = s.checkpoint()
+			}
 
 			// 配额耗尽处理
 			if remaining <= 0 {
 				// 发送耗尽通知
-				exhaustedMsg, _ := json.Marshal(WSMessage{Type: "quota_exhausted", Content: "时长已耗尽"})
-				s.Conn.WriteMessage(websocket.TextMessage, exhaustedMsg)
-				s.mu.Unlock()
+				s.sendRawMessage(WSMessage{Type: "quota_exhausted", Content: "时长已耗尽"})
 				s.close() // 强制关闭
 				return
 			}
-			s.mu.Unlock()
 
 		case <-s.stopTimer:
 			return
@@ -219,6 +267,33 @@ func (s *AIInterviewSession) startTimer() {
 	}
 }
 
+// checkpoint 把未结算的时长写入账本，返回结算后的剩余时长
+// 写入失败时保留未结算时长，下次再试
+func (s *AIInterviewSession) checkpoint() int64 {
+	s.mu.Lock()
+	pending := s.Uncharged
+	s.Uncharged = 0
+	s.mu.Unlock()
+
+	if pending <= 0 {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.Balance - s.Uncharged
+	}
+
+	_, balance, err := chargeAIQuota(s.UserID, s.SessionID, pending)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.Uncharged += pending
+		global.GetLog(nil).Errorf("[AI Interview] 用户[%s] 时长结算失败: %v", s.Username, err)
+	} else {
+		s.Balance = balance
+	}
+	return s.Balance - s.Uncharged
+}
+
 // handleMessages 循环读取前端消息
 func (s *AIInterviewSession) handleMessages() {
 	defer s.close() // 循环结束（连接断开）时自动触发清理
@@ -248,17 +323,30 @@ func (s *AIInterviewSession) handleMessages() {
 
 			topic, _ := contentMap["topic"].(string)
 			answer, _ := contentMap["content"].(string)
+			pointID := s.PointID
+			if v, ok := contentMap["pointId"].(float64); ok && v > 0 {
+				pointID = int(v)
+			}
 
 			// 必须要有题目和回答才处理
 			if topic != "" && answer != "" {
-				s.handleChatLogic(topic, answer)
+				s.handleChatLogic(topic, answer, pointID)
 			}
 		}
 	}
 }
 
 // handleChatLogic 核心业务逻辑：组装上下文 -> 裁剪(防爆) -> 调用 AI
-func (s *AIInterviewSession) handleChatLogic(topic string, userAnswer string) {
+func (s *AIInterviewSession) handleChatLogic(topic string, userAnswer string, pointID int) {
+	// 新题目需要查库生成 System Prompt，放在加锁之前
+	s.mu.Lock()
+	_, exists := s.TopicHistories[topic]
+	s.mu.Unlock()
+	var systemPrompt string
+	if !exists {
+		systemPrompt = s.buildSystemPrompt(topic, pointID)
+	}
+
 	s.mu.Lock()
 
 	// 1. 获取或创建该题目的聊天历史
@@ -266,9 +354,6 @@ func (s *AIInterviewSession) handleChatLogic(topic string, userAnswer string) {
 
 	if !exists {
 		// --- 情况 A: 新题目，初始化上下文 ---
-		// 动态读取 Prompt
-		tpl := LoadPromptTemplate()
-		systemPrompt := fmt.Sprintf(tpl, topic)
 
 		// 伪造 AI 的上一句提问 (为了让 AI 知道它问了什么)
 		fakeAiQuestion := fmt.Sprintf("同学你好，我是你的 AI 面试官。基于题目「%s」，请简要介绍一下你的理解。", topic)
@@ -289,36 +374,9 @@ func (s *AIInterviewSession) handleChatLogic(topic string, userAnswer string) {
 	// 2. 更新 Map (防止数据丢失)
 	s.TopicHistories[topic] = history
 
-	// =====================================================
-	// 【防爆逻辑】裁剪历史记录，防止 Token 爆炸
-	// 策略：保留 SystemPrompt + 最近的 N 轮
-	// =====================================================
-	const MaxHistoryRounds = 20 // 保留最近 20 条消息 (约 10 轮对话)
-
-	// 用于发送给 AI 的临时切片
-	var inputHistory []openai.ChatCompletionMessage
-
-	// history[0] 是 System Prompt，我们要永久保留
-	// 如果总长度超过了限制
-	if len(history) > MaxHistoryRounds {
-		inputHistory = make([]openai.ChatCompletionMessage, 0, MaxHistoryRounds+1)
-
-		// 1. 必须保留 System Prompt (他是面试官的身份设定)
-		inputHistory = append(inputHistory, history[0])
-
-		// 2. 计算截断点，保留后半截
-		// 比如总共 100 条，Max=20。我们取后 19 条拼在 System 后面
-		cutoffIndex := len(history) - (MaxHistoryRounds - 1)
-		if cutoffIndex < 1 {
-			cutoffIndex = 1
-		}
-
-		inputHistory = append(inputHistory, history[cutoffIndex:]...)
-	} else {
-		// 没超过限制，全发
-		inputHistory = make([]openai.ChatCompletionMessage, len(history))
-		copy(inputHistory, history)
-	}
+	summary := s.TopicSummaries[topic]
+	snapshot := make([]openai.ChatCompletionMessage, len(history))
+	copy(snapshot, history)
 
 	s.mu.Unlock() // 解锁，让 AI 慢慢思考
 
@@ -326,12 +384,40 @@ func (s *AIInterviewSession) handleChatLogic(topic string, userAnswer string) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
 	defer cancel()
 
-	reply, err := deepseek.Chat(ctx, inputHistory)
+	// =====================================================
+	// 【防爆逻辑】按 Token 预算组装上下文
+	// 策略：永久保留 SystemPrompt，超预算时把较早的对话压缩成滚动摘要
+	// =====================================================
+	fit, err := interviewContextBudget.Fit(ctx, snapshot, summary)
+	if err != nil {
+		global.GetLog(nil).Errorf("[AI Interview] Build Context Error: %v", err)
+		s.sendRawMessage(WSMessage{Type: "error", Content: "AI 上下文组装失败，请重试"})
+		return
+	}
+	s.addUsage(fit.Usage)
+
+	if fit.Folded > 0 {
+		// 被折叠的消息已进入摘要，从历史中移除，避免重复压缩
+		s.mu.Lock()
+		currentHist := s.TopicHistories[topic]
+		if len(currentHist) > fit.Folded {
+			compacted := make([]openai.ChatCompletionMessage, 0, len(currentHist)-fit.Folded)
+			compacted = append(compacted, currentHist[0])
+			compacted = append(compacted, currentHist[1+fit.Folded:]...)
+			s.TopicHistories[topic] = compacted
+			s.TopicSummaries[topic] = fit.Summary
+		}
+		s.mu.Unlock()
+		global.GetLog(nil).Infof("[AI Interview] 用户[%s] 题目[%s] 上下文超出预算，已将 %d 条消息压缩为摘要", s.Username, topic, fit.Folded)
+	}
+
+	reply, usage, err := deepseek.ChatWithUsage(ctx, fit.Messages)
 	if err != nil {
 		global.GetLog(nil).Error("[AI Interview] Chat Error: %v", err)
 		s.sendRawMessage(WSMessage{Type: "error", Content: "AI 思考超时或服务繁忙，请重试"})
 		return
 	}
+	s.addUsage(usage)
 
 	// 4. 收到 AI 回复，存入历史记录并发送给前端
 	s.mu.Lock()
@@ -348,6 +434,98 @@ func (s *AIInterviewSession) handleChatLogic(topic string, userAnswer string) {
 
 	// 发送给前端
 	s.sendRawMessage(WSMessage{Type: "chat", Content: reply})
+	s.sendRawMessage(WSMessage{Type: "usage", Content: s.usageSnapshot()})
+}
+
+// buildSystemPrompt 根据知识点所属科目的模板设置生成 System Prompt
+// 未指定知识点或无权访问时，只使用题目名称渲染全局默认模板
+func (s *AIInterviewSession) buildSystemPrompt(topic string, pointID int) string {
+	vars := model.PromptVars{Topic: topic, Language: s.Language}
+	subjectID := 0
+
+	if pointID > 0 {
+		var content sql.NullString
+		var difficulty int
+		err := global.DB.QueryRow(`
+			SELECT p.content, p.difficulty, c.subject_id
+			FROM knowledge_points p
+			JOIN knowledge_categories c ON p.categorie_id = c.id
+			JOIN subjects s ON c.subject_id = s.id
+			LEFT JOIN user_subjects us ON us.subject_id = s.id AND us.user_id = ?
+			WHERE p.id = ? AND p.deleted_at IS NULL
+			  AND (
+			      s.creator_code = ?
+			      OR
+			      (us.id IS NOT NULL AND us.status = 1 AND (us.expire_time IS NULL OR us.expire_time > datetime('now')))
+			  )`, s.UserID, pointID, s.UserCode).Scan(&content, &difficulty, &subjectID)
+		if err == nil {
+			vars.Content = content.String
+			// 知识点正文过长时截断，避免 System Prompt 占满上下文预算
+			if r := []rune(vars.Content); len(r) > maxPromptContentRunes {
+				vars.Content = string(r[:maxPromptContentRunes]) + "……"
+			}
+			vars.Difficulty = difficultyLabel(difficulty)
+		} else if err != sql.ErrNoRows {
+			global.GetLog(nil).Errorf("[AI Interview] 查询知识点失败 (PointID: %d): %v", pointID, err)
+		}
+	}
+
+	return BuildInterviewPrompt(subjectID, vars)
+}
+
+// addUsage 累加 Token 用量 (线程安全)
+func (s *AIInterviewSession) addUsage(usage openai.Usage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.PromptTokens += usage.PromptTokens
+	s.CompletionTokens += usage.CompletionTokens
+	s.TotalTokens += usage.TotalTokens
+}
+
+// usageSnapshot 当前会话累计的 Token 用量
+func (s *AIInterviewSession) usageSnapshot() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return map[string]interface{}{
+		"promptTokens":     s.PromptTokens,
+		"completionTokens": s.CompletionTokens,
+		"totalTokens":      s.TotalTokens,
+	}
+}
+
+// aiInterviewSessions 在线的 AI 面试会话
+var aiInterviewSessions = struct {
+	sync.Mutex
+	m map[*AIInterviewSession]bool
+}{m: make(map[*AIInterviewSession]bool)}
+
+func registerAIInterviewSession(s *AIInterviewSession) {
+	aiInterviewSessions.Lock()
+	aiInterviewSessions.m[s] = true
+	aiInterviewSessions.Unlock()
+}
+
+func unregisterAIInterviewSession(s *AIInterviewSession) {
+	aiInterviewSessions.Lock()
+	delete(aiInterviewSessions.m, s)
+	aiInterviewSessions.Unlock()
+}
+
+// closeAIInterviewSessions 通知并关闭满足条件的会话 (已用时长照常结算)
+func closeAIInterviewSessions(match func(s *AIInterviewSession) bool) {
+	aiInterviewSessions.Lock()
+	var targets []*AIInterviewSession
+	for s := range aiInterviewSessions.m {
+		if match(s) {
+			targets = append(targets, s)
+		}
+	}
+	aiInterviewSessions.Unlock()
+
+	for _, s := range targets {
+		s.sendRawMessage(WSMessage{Type: "error", Content: map[string]interface{}{"code": 401, "message": "登录已失效，请重新登录"}})
+		s.close()
+	}
 }
 
 // close 清理资源并保存数据
@@ -360,23 +538,27 @@ func (s *AIInterviewSession) close() {
 	s.closed = true
 	s.mu.Unlock()
 
+	unregisterAIInterviewSession(s)
 	// 停止计时器
 	close(s.stopTimer)
 	// 关闭连接
 	s.Conn.Close()
 
-	// 结算扣费
-	if s.UsedSeconds > 0 {
-		newQuota := s.Quota - s.UsedSeconds
-		if newQuota < 0 {
-			newQuota = 0
-		}
-		// 更新数据库
-		_, err := global.DB.Exec("UPDATE users SET ai_quota = ? WHERE id = ?", newQuota, s.UserID)
-		if err != nil {
-			global.GetLog(nil).Error("Failed to update user quota: %v", err)
-		} else {
-			fmt.Printf("[AI Session End] User: %s, Used: %ds, Remaining: %ds\n", s.Username, s.UsedSeconds, newQuota)
-		}
+	// 结算剩余未扣的时长，并释放并发名额
+	remaining := s.checkpoint()
+	releaseAISessionSlot(s.UserID)
+	fmt.Printf("[AI Session End] User: %s, Used: %ds, Remaining: %ds\n", s.Username, s.UsedSeconds, remaining)
+
+	// 记录本次会话的 Token 用量 (成本统计)
+	_, err := global.DB.Exec(`UPDATE ai_interview_sessions 
+		SET end_time = ?, used_seconds = ?, prompt_tokens = ?, completion_tokens = ?, total_tokens = ? 
+		WHERE id = ?`,
+		model.FormatDBTime(time.Now()),
+		s.UsedSeconds, s.PromptTokens, s.CompletionTokens, s.TotalTokens, s.SessionID)
+	if err != nil {
+		global.GetLog(nil).Errorf("Failed to save ai session usage: %v", err)
+	} else {
+		global.GetLog(nil).Infof("[AI Session End] User: %s, Tokens: prompt=%d completion=%d total=%d",
+			s.Username, s.PromptTokens, s.CompletionTokens, s.TotalTokens)
 	}
 }
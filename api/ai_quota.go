@@ -0,0 +1,400 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"practice_problems/global"
+	"practice_problems/model"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==========================================
+// AI 面试时长账本
+// users.ai_quota 只作为余额缓存，真实余额以 ai_quota_ledger 流水汇总为准
+// ==========================================
+
+// 流水类型
+const (
+	AIQuotaEntryGrant   = "grant"   // 管理员发放
+	AIQuotaEntryRevoke  = "revoke"  // 管理员扣回
+	AIQuotaEntryConsume = "consume" // 面试消耗
+	AIQuotaEntryAdjust  = "adjust"  // 系统调整 (如历史余额迁移)
+	AIQuotaEntryRefund  = "refund"  // 调用失败退回预扣
+)
+
+// sqlQueryer *sql.DB 与 *sql.Tx 的公共查询接口
+type sqlQueryer interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// getAIQuotaBalance 汇总流水得到用户当前余额 (秒)
+func getAIQuotaBalance(q sqlQueryer, userID int) (int64, error) {
+	var balance int64
+	err := q.QueryRow("SELECT COALESCE(SUM(change_seconds), 0) FROM ai_quota_ledger WHERE user_id = ?", userID).Scan(&balance)
+	return balance, err
+}
+
+// appendAIQuotaLedger 追加一条流水并同步余额缓存，返回变动后的余额
+// sessionID / operatorID 为 0 时写入 NULL
+func appendAIQuotaLedger(tx *sql.Tx, userID int, change int64, entryType string, sessionID int64, operatorID int, remark string) (int64, error) {
+	balance, err := getAIQuotaBalance(tx, userID)
+	if err != nil {
+		return 0, err
+	}
+	balance += change
+
+	var sessionArg, operatorArg interface{}
+	if sessionID > 0 {
+		sessionArg = sessionID
+	}
+	if operatorID > 0 {
+		operatorArg = operatorID
+	}
+
+	_, err = tx.Exec(`INSERT INTO ai_quota_ledger
+		(user_id, change_seconds, balance_after, entry_type, session_id, operator_id, remark)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		userID, change, balance, entryType, sessionArg, operatorArg, remark)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err = tx.Exec("UPDATE users SET ai_quota = ? WHERE id = ?", balance, userID); err != nil {
+		return 0, err
+	}
+	return balance, nil
+}
+
+// chargeAIQuota 扣除面试消耗的时长 (不会把余额扣成负数)
+// 返回实际扣除的秒数与扣除后的余额
+func chargeAIQuota(userID int, sessionID int64, seconds int64) (int64, int64, error) {
+	tx, err := global.DB.Begin()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	balance, err := getAIQuotaBalance(tx, userID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	charge := seconds
+	if charge > balance {
+		charge = balance
+	}
+	if charge <= 0 {
+		return 0, balance, nil
+	}
+
+	balance, err = appendAIQuotaLedger(tx, userID, -charge, AIQuotaEntryConsume, sessionID, 0, "")
+	if err != nil {
+		return 0, 0, err
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, 0, err
+	}
+	return charge, balance, nil
+}
+
+// reserveAIQuota 预扣固定时长：余额判断与扣除在同一条条件 INSERT 中完成，
+// 并发请求不会把余额扣成负数。余额不足时返回 ok=false 与当前余额
+func reserveAIQuota(userID int, cost int64, remark string) (bool, int64, error) {
+	tx, err := global.DB.Begin()
+	if err != nil {
+		return false, 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`INSERT INTO ai_quota_ledger (user_id, change_seconds, balance_after, entry_type, remark)
+		SELECT ?, ?, t.balance - ?, ?, ?
+		FROM (SELECT COALESCE(SUM(change_seconds), 0) AS balance FROM ai_quota_ledger WHERE user_id = ?) t
+		WHERE t.balance >= ?`,
+		userID, -cost, cost, AIQuotaEntryConsume, remark, userID, cost)
+	if err != nil {
+		return false, 0, err
+	}
+	balance, err := getAIQuotaBalance(tx, userID)
+	if err != nil {
+		return false, 0, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, balance, nil
+	}
+	if _, err = tx.Exec("UPDATE users SET ai_quota = ? WHERE id = ?", balance, userID); err != nil {
+		return false, 0, err
+	}
+	if err = tx.Commit(); err != nil {
+		return false, 0, err
+	}
+	return true, balance, nil
+}
+
+// refundAIQuota 退回 reserveAIQuota 预扣的时长，返回退回后的余额
+func refundAIQuota(userID int, cost int64, remark string) (int64, error) {
+	tx, err := global.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	balance, err := appendAIQuotaLedger(tx, userID, cost, AIQuotaEntryRefund, 0, 0, remark)
+	if err != nil {
+		return 0, err
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+	return balance, nil
+}
+
+// ==========================================
+// 并发会话限制 (同一用户同时进行的面试数)
+// ==========================================
+
+var (
+	aiSessionMu    sync.Mutex
+	aiSessionCount = make(map[int]int)
+)
+
+// acquireAISessionSlot 占用一个会话名额，超出上限返回 false
+func acquireAISessionSlot(userID int) bool {
+	aiSessionMu.Lock()
+	defer aiSessionMu.Unlock()
+
+	limit := global.AIMaxConcurrentSessions
+	if limit < 1 {
+		limit = 1
+	}
+	if aiSessionCount[userID] >= limit {
+		return false
+	}
+	aiSessionCount[userID]++
+	return true
+}
+
+// releaseAISessionSlot 释放会话名额
+func releaseAISessionSlot(userID int) {
+	aiSessionMu.Lock()
+	defer aiSessionMu.Unlock()
+
+	aiSessionCount[userID]--
+	if aiSessionCount[userID] <= 0 {
+		delete(aiSessionCount, userID)
+	}
+}
+
+// ==========================================
+// 用户接口
+// ==========================================
+
+// GetMyAIQuota 获取当前用户的 AI 时长余额与流水
+func GetMyAIQuota(c *gin.Context) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "msg": "未授权"})
+		return
+	}
+	userID, _ := userIDVal.(int)
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	balance, err := getAIQuotaBalance(global.DB, userID)
+	if err != nil {
+		global.GetLog(c).Errorf("查询 AI 时长余额失败 (UserID: %d): %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+
+	list, total, err := queryAIQuotaLedger(userID, page, pageSize)
+	if err != nil {
+		global.GetLog(c).Errorf("查询 AI 时长流水失败 (UserID: %d): %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "success",
+		"data": gin.H{
+			"balance":        balance,
+			"activeSessions": getActiveAISessionCount(userID),
+			"list":           list,
+			"total":          total,
+			"page":           page,
+			"pageSize":       pageSize,
+		},
+	})
+}
+
+// getActiveAISessionCount 当前用户进行中的面试会话数
+func getActiveAISessionCount(userID int) int {
+	aiSessionMu.Lock()
+	defer aiSessionMu.Unlock()
+	return aiSessionCount[userID]
+}
+
+// queryAIQuotaLedger 分页查询某用户的流水 (倒序)
+func queryAIQuotaLedger(userID int, page, pageSize int) ([]model.AIQuotaLedgerEntry, int, error) {
+	var total int
+	if err := global.DB.QueryRow("SELECT COUNT(*) FROM ai_quota_ledger WHERE user_id = ?", userID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := global.DB.Query(`
+		SELECT id, user_id, change_seconds, balance_after, entry_type, session_id, operator_id, COALESCE(remark, ''), create_time
+		FROM ai_quota_ledger
+		WHERE user_id = ?
+		ORDER BY id DESC
+		LIMIT ? OFFSET ?`, userID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	list := make([]model.AIQuotaLedgerEntry, 0)
+	for rows.Next() {
+		var item model.AIQuotaLedgerEntry
+		var sessionID sql.NullInt64
+		var operatorID sql.NullInt64
+		if err := rows.Scan(&item.ID, &item.UserID, &item.ChangeSeconds, &item.BalanceAfter, &item.EntryType,
+			&sessionID, &operatorID, &item.Remark, &item.CreateTime); err != nil {
+			continue
+		}
+		if sessionID.Valid {
+			item.SessionID = &sessionID.Int64
+		}
+		if operatorID.Valid {
+			op := int(operatorID.Int64)
+			item.OperatorID = &op
+		}
+		list = append(list, item)
+	}
+	return list, total, nil
+}
+
+// ==========================================
+// 管理员接口
+// ==========================================
+
+// AdminGrantAIQuota 管理员发放 AI 时长
+func AdminGrantAIQuota(c *gin.Context) {
+	changeAIQuotaByAdmin(c, AIQuotaEntryGrant)
+}
+
+// AdminRevokeAIQuota 管理员扣回 AI 时长
+func AdminRevokeAIQuota(c *gin.Context) {
+	changeAIQuotaByAdmin(c, AIQuotaEntryRevoke)
+}
+
+// changeAIQuotaByAdmin 发放 / 扣回的公共逻辑
+func changeAIQuotaByAdmin(c *gin.Context, entryType string) {
+	var req model.AIQuotaChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "参数错误"})
+		return
+	}
+	operatorIDVal, _ := c.Get("userID")
+	operatorID, _ := operatorIDVal.(int)
+	operatorCodeVal, _ := c.Get("userCode")
+	operatorCode, _ := operatorCodeVal.(string)
+
+	tx, err := global.DB.Begin()
+	if err != nil {
+		global.GetLog(c).Errorf("开启事务失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "系统错误"})
+		return
+	}
+	defer tx.Rollback()
+
+	var exists int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM users WHERE id = ?", req.UserID).Scan(&exists); err != nil || exists == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "用户不存在"})
+		return
+	}
+
+	change := req.Seconds
+	if entryType == AIQuotaEntryRevoke {
+		balance, err := getAIQuotaBalance(tx, req.UserID)
+		if err != nil {
+			global.GetLog(c).Errorf("查询 AI 时长余额失败 (UserID: %d): %v", req.UserID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+			return
+		}
+		if req.Seconds > balance {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "扣回时长超过用户当前余额", "data": gin.H{"balance": balance}})
+			return
+		}
+		change = -req.Seconds
+	}
+
+	balance, err := appendAIQuotaLedger(tx, req.UserID, change, entryType, 0, operatorID, req.Remark)
+	if err != nil {
+		global.GetLog(c).Errorf("写入 AI 时长流水失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "操作失败"})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		global.GetLog(c).Errorf("提交事务失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "操作失败"})
+		return
+	}
+
+	global.GetLog(c).Infof("管理员[%s] 调整用户[%d] AI 时长成功: %s %+d 秒, 余额 %d 秒", operatorCode, req.UserID, entryType, change, balance)
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "操作成功", "data": gin.H{"balance": balance}})
+}
+
+// AdminGetAIQuotaLedger 管理员查看指定用户的余额与流水
+func AdminGetAIQuotaLedger(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Query("user_id"))
+	if err != nil || userID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "缺少 user_id"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	balance, err := getAIQuotaBalance(global.DB, userID)
+	if err != nil {
+		global.GetLog(c).Errorf("查询 AI 时长余额失败 (UserID: %d): %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+
+	list, total, err := queryAIQuotaLedger(userID, page, pageSize)
+	if err != nil {
+		global.GetLog(c).Errorf("查询 AI 时长流水失败 (UserID: %d): %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "success",
+		"data": gin.H{
+			"balance":        balance,
+			"activeSessions": getActiveAISessionCount(userID),
+			"list":           list,
+			"total":          total,
+			"page":           page,
+			"pageSize":       pageSize,
+		},
+	})
+}
@@ -0,0 +1,402 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"practice_problems/global"
+	"practice_problems/model"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==========================================
+// 绑定锚点：bind_text + 出现序号 + 前后文
+// 内容修改后先按原文 (忽略空白差异) 匹配并用前后文挑选重复项，找不到时再模糊定位
+// 都失败时标记为失效，等待作者修复，不再直接删除
+// ==========================================
+
+const (
+	anchorContextRunes  = 32  // 前后文各保留的字符数
+	anchorMinSimilarity = 0.6 // 模糊定位时新旧文字的最低相似度
+	anchorMaxCandidates = 50  // 模糊定位时最多尝试的前文位置数
+)
+
+// bindingAnchor 绑定在内容中的位置
+type bindingAnchor struct {
+	Text       string
+	Occurrence int
+	Prefix     string
+	Suffix     string
+}
+
+// anchorWhitespace 匹配空白及 HTML 中的不换行空格
+const anchorWhitespace = `(?:\s|&nbsp;|\x{00A0})+`
+
+// lastRunes / firstRunes 截取字符串首尾 n 个字符 (按 rune，避免截断中文)
+func lastRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	r := []rune(s)
+	return string(r[len(r)-n:])
+}
+
+func firstRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+// anchorAt 以 content[start:end] 为锚定文字生成锚点
+func anchorAt(content string, start, end int) bindingAnchor {
+	text := content[start:end]
+	return bindingAnchor{
+		Text:       text,
+		Occurrence: strings.Count(content[:start], text),
+		Prefix:     lastRunes(content[:start], anchorContextRunes),
+		Suffix:     firstRunes(content[end:], anchorContextRunes),
+	}
+}
+
+// contextScore 候选位置与锚点前后文的吻合程度 (公共字节数)
+func contextScore(content string, start, end int, a bindingAnchor) int {
+	score := 0
+	before := content[:start]
+	for i := 1; i <= len(a.Prefix) && i <= len(before); i++ {
+		if before[len(before)-i] != a.Prefix[len(a.Prefix)-i] {
+			break
+		}
+		score++
+	}
+	after := content[end:]
+	for i := 0; i < len(a.Suffix) && i < len(after); i++ {
+		if after[i] != a.Suffix[i] {
+			break
+		}
+		score++
+	}
+	return score
+}
+
+// pickCandidate 在多个候选位置中挑选前后文最吻合的一个，同分时取最接近原出现序号的
+func pickCandidate(content string, spans [][2]int, a bindingAnchor) (int, int) {
+	best, bestScore, bestDist := -1, -1, 0
+	for i, sp := range spans {
+		score := contextScore(content, sp[0], sp[1], a)
+		dist := i - a.Occurrence
+		if dist < 0 {
+			dist = -dist
+		}
+		if score > bestScore || (score == bestScore && dist < bestDist) {
+			best, bestScore, bestDist = i, score, dist
+		}
+	}
+	return spans[best][0], spans[best][1]
+}
+
+// newAnchor 创建绑定时生成锚点：优先用前端提供的前后文定位，其次按出现序号
+func newAnchor(content, text string, occurrence int, prefix, suffix string) (bindingAnchor, bool) {
+	var spans [][2]int
+	for offset := 0; ; {
+		i := strings.Index(content[offset:], text)
+		if i < 0 {
+			break
+		}
+		spans = append(spans, [2]int{offset + i, offset + i + len(text)})
+		offset += i + len(text)
+	}
+	if len(spans) == 0 {
+		return bindingAnchor{Text: text, Occurrence: occurrence, Prefix: prefix, Suffix: suffix}, false
+	}
+	if occurrence < 0 || occurrence >= len(spans) {
+		occurrence = 0
+	}
+	start, end := spans[occurrence][0], spans[occurrence][1]
+	if prefix != "" || suffix != "" {
+		start, end = pickCandidate(content, spans, bindingAnchor{Text: text, Occurrence: occurrence, Prefix: prefix, Suffix: suffix})
+	}
+	return anchorAt(content, start, end), true
+}
+
+// resolveAnchor 在修改后的内容中重新定位锚点
+func resolveAnchor(content string, a bindingAnchor) (bindingAnchor, bool) {
+	if a.Text == "" {
+		return a, false
+	}
+
+	// 1. 精确匹配或仅空白不同 (换行、多个空格、&nbsp;)，重复出现时按前后文挑选
+	if fields := strings.Fields(a.Text); len(fields) > 0 {
+		quoted := make([]string, len(fields))
+		for i, f := range fields {
+			quoted[i] = regexp.QuoteMeta(f)
+		}
+		if re, err := regexp.Compile(strings.Join(quoted, anchorWhitespace)); err == nil {
+			if locs := re.FindAllStringIndex(content, -1); len(locs) > 0 {
+				spans := make([][2]int, len(locs))
+				for i, loc := range locs {
+					spans[i] = [2]int{loc[0], loc[1]}
+				}
+				start, end := pickCandidate(content, spans, a)
+				return anchorAt(content, start, end), true
+			}
+		}
+	}
+
+	// 2. 模糊定位：收集候选区间，取与原文最相似的一处
+	//    候选来自原前后文之间 (完整或仅紧邻的几个字符)，以及原文首尾几个字符之间
+	maxGap := len(a.Text)*2 + 64
+	var spans [][2]int
+	if a.Prefix != "" && a.Suffix != "" {
+		spans = append(spans, boundedSpans(content, a.Prefix, a.Suffix, maxGap, false)...)
+		spans = append(spans, boundedSpans(content, lastRunes(a.Prefix, 8), firstRunes(a.Suffix, 8), maxGap, false)...)
+	}
+	if n := utf8.RuneCountInString(a.Text); n >= 6 {
+		k := min(4, n/3)
+		spans = append(spans, boundedSpans(content, firstRunes(a.Text, k), lastRunes(a.Text, k), maxGap, true)...)
+	}
+
+	bestSim, bestScore := 0.0, -1
+	bestStart, bestEnd := -1, -1
+	for _, sp := range spans {
+		if !utf8.ValidString(content[sp[0]:sp[1]]) {
+			continue
+		}
+		sim := textSimilarity(a.Text, content[sp[0]:sp[1]])
+		score := contextScore(content, sp[0], sp[1], a)
+		if sim > bestSim || (sim == bestSim && score > bestScore) {
+			bestSim, bestScore, bestStart, bestEnd = sim, score, sp[0], sp[1]
+		}
+	}
+	if bestStart >= 0 && bestSim >= anchorMinSimilarity {
+		return anchorAt(content, bestStart, bestEnd), true
+	}
+	return a, false
+}
+
+// boundedSpans 查找 left 与其后 maxGap 字节内最近的 right 所夹的区间
+// inclusive 为 true 时区间包含 left/right 本身 (用于原文首尾字符)，否则只取两者之间 (用于前后文)
+func boundedSpans(content, left, right string, maxGap int, inclusive bool) [][2]int {
+	var spans [][2]int
+	if left == "" || right == "" {
+		return spans
+	}
+	offset := 0
+	for n := 0; n < anchorMaxCandidates; n++ {
+		i := strings.Index(content[offset:], left)
+		if i < 0 {
+			break
+		}
+		leftStart := offset + i
+		leftEnd := leftStart + len(left)
+		offset = leftStart + 1
+
+		window := content[leftEnd:]
+		if len(window) > maxGap+len(right) {
+			window = window[:maxGap+len(right)]
+		}
+		j := strings.Index(window, right)
+		if j < 0 {
+			continue
+		}
+		if inclusive {
+			spans = append(spans, [2]int{leftStart, leftEnd + j + len(right)})
+		} else if j > 0 {
+			spans = append(spans, [2]int{leftEnd, leftEnd + j})
+		}
+	}
+	return spans
+}
+
+// textSimilarity 基于编辑距离的相似度 (0~1)
+func textSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	maxLen := max(len(ra), len(rb))
+	return 1 - float64(prev[len(rb)])/float64(maxLen)
+}
+
+// reanchorBindings 内容保存后重新定位该知识点的全部绑定
+// 定位成功的更新锚点 (失效的绑定也会自动恢复)，失败的标记为失效；返回本次新失效的绑定与恢复的失效绑定数
+func reanchorBindings(c *gin.Context, tx *sql.Tx, pointID string, newContent string) ([]model.PointBinding, int, error) {
+	rows, err := tx.Query(`SELECT id, source_subject_id, source_point_id, target_subject_id, target_point_id, bind_text, user_id, create_time,
+			IFNULL(occurrence, 0), IFNULL(anchor_prefix, ''), IFNULL(anchor_suffix, ''), IFNULL(status, 0)
+		FROM point_bindings WHERE source_point_id = ?`, pointID)
+	if err != nil {
+		return nil, 0, err
+	}
+	var bindings []model.PointBinding
+	for rows.Next() {
+		var b model.PointBinding
+		if rows.Scan(&b.ID, &b.SourceSubjectID, &b.SourcePointID, &b.TargetSubjectID, &b.TargetPointID, &b.BindText, &b.UserID, &b.CreateTime,
+			&b.Occurrence, &b.AnchorPrefix, &b.AnchorSuffix, &b.Status) == nil {
+			bindings = append(bindings, b)
+		}
+	}
+	rows.Close()
+
+	now := model.FormatDBTime(time.Now())
+	var broken []model.PointBinding
+	repaired := 0
+	for _, b := range bindings {
+		a, ok := resolveAnchor(newContent, bindingAnchor{Text: b.BindText, Occurrence: b.Occurrence, Prefix: b.AnchorPrefix, Suffix: b.AnchorSuffix})
+		if ok {
+			_, err = tx.Exec(`UPDATE point_bindings SET bind_text = ?, occurrence = ?, anchor_prefix = ?, anchor_suffix = ?, status = ?, broken_time = NULL
+				WHERE id = ?`, a.Text, a.Occurrence, a.Prefix, a.Suffix, model.BindingStatusNormal, b.ID)
+			if err != nil {
+				return nil, 0, err
+			}
+			if b.Status == model.BindingStatusBroken {
+				repaired++
+			}
+			if a.Text != b.BindText || b.Status == model.BindingStatusBroken {
+				global.GetLog(c).Infof("绑定重新定位 (ID: %d, PointID: %s): %q -> %q", b.ID, pointID, b.BindText, a.Text)
+			}
+			continue
+		}
+		if b.Status == model.BindingStatusBroken {
+			continue
+		}
+		if _, err = tx.Exec("UPDATE point_bindings SET status = ?, broken_time = ? WHERE id = ?", model.BindingStatusBroken, now, b.ID); err != nil {
+			return nil, 0, err
+		}
+		global.GetLog(c).Infof("绑定无法定位，标记为失效 (ID: %d, PointID: %s)", b.ID, pointID)
+		b.Status = model.BindingStatusBroken
+		broken = append(broken, b)
+	}
+	return broken, repaired, nil
+}
+
+// =================================================================================
+// GetBrokenBindings 科目失效绑定报告 (编辑者及以上)
+// =================================================================================
+func GetBrokenBindings(c *gin.Context) {
+	subjectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+	userCodeVal, _ := c.Get("userCode")
+	userCode, _ := userCodeVal.(string)
+
+	var creatorCode string
+	if err := global.DB.QueryRow("SELECT creator_code FROM subjects WHERE id = ? AND deleted_at IS NULL", subjectID).Scan(&creatorCode); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "科目不存在"})
+		return
+	}
+	if !hasSubjectRole(subjectID, creatorCode, userCode, RoleEditor) {
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "无权查看该科目的绑定报告"})
+		return
+	}
+
+	rows, err := global.DB.Query(`
+		SELECT pb.id, pb.source_point_id, sp.title, sc.id, sc.categorie_name,
+			pb.target_subject_id, pb.target_point_id, COALESCE(tp.title, ''),
+			pb.bind_text, IFNULL(pb.anchor_prefix, ''), IFNULL(pb.anchor_suffix, ''), pb.broken_time
+		FROM point_bindings pb
+		JOIN knowledge_points sp ON pb.source_point_id = sp.id
+		JOIN knowledge_categories sc ON sp.categorie_id = sc.id
+		LEFT JOIN knowledge_points tp ON pb.target_point_id = tp.id
+		WHERE sc.subject_id = ? AND pb.status = ? AND sp.deleted_at IS NULL
+		ORDER BY pb.broken_time DESC, pb.id DESC`, subjectID, model.BindingStatusBroken)
+	if err != nil {
+		global.GetLog(c).Errorf("查询失效绑定失败 (SubjectID: %d): %v", subjectID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+	defer rows.Close()
+
+	list := make([]model.BrokenBinding, 0)
+	for rows.Next() {
+		var b model.BrokenBinding
+		var brokenTime sql.NullString
+		if err := rows.Scan(&b.ID, &b.SourcePointID, &b.SourcePointTitle, &b.SourceCategoryID, &b.SourceCategory,
+			&b.TargetSubjectID, &b.TargetPointID, &b.TargetPointTitle,
+			&b.BindText, &b.AnchorPrefix, &b.AnchorSuffix, &brokenTime); err != nil {
+			continue
+		}
+		if brokenTime.Valid {
+			t := formatTimeStr(brokenTime.String)
+			b.BrokenTime = &t
+		}
+		list = append(list, b)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "success", "data": gin.H{"list": list, "total": len(list)}})
+}
+
+// =================================================================================
+// RepairBinding 修复绑定：重新指定锚定文字 (编辑者及以上)
+// =================================================================================
+func RepairBinding(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "无效的ID"})
+		return
+	}
+	var req model.RepairBindingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "参数错误: " + err.Error()})
+		return
+	}
+	userCodeVal, _ := c.Get("userCode")
+	userCode, _ := userCodeVal.(string)
+
+	var subjectCreatorCode string
+	var permSubjectID int
+	var content sql.NullString
+	err = global.DB.QueryRow(`
+		SELECT s.creator_code, s.id, p.content
+		FROM point_bindings pb
+		JOIN knowledge_points p ON pb.source_point_id = p.id
+		JOIN knowledge_categories c ON p.categorie_id = c.id
+		JOIN subjects s ON c.subject_id = s.id
+		WHERE pb.id = ? AND p.deleted_at IS NULL`, id).Scan(&subjectCreatorCode, &permSubjectID, &content)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "绑定不存在"})
+		return
+	}
+	if !hasSubjectRole(permSubjectID, subjectCreatorCode, userCode, RoleEditor) {
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "修复失败：您没有该科目的编辑权限"})
+		return
+	}
+
+	a, ok := newAnchor(content.String, req.BindText, req.Occurrence, "", "")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "知识点内容中找不到该文字"})
+		return
+	}
+	_, err = global.DB.Exec(`UPDATE point_bindings SET bind_text = ?, occurrence = ?, anchor_prefix = ?, anchor_suffix = ?, status = ?, broken_time = NULL
+		WHERE id = ?`, a.Text, a.Occurrence, a.Prefix, a.Suffix, model.BindingStatusNormal, id)
+	if err != nil {
+		global.GetLog(c).Errorf("修复绑定失败 (ID: %d): %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "修复失败"})
+		return
+	}
+
+	global.GetLog(c).Infof("用户[%s] 修复绑定 (ID: %d): %q", userCode, id, a.Text)
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "修复成功", "data": gin.H{"bindText": a.Text, "occurrence": a.Occurrence}})
+}
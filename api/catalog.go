@@ -0,0 +1,520 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"practice_problems/global"
+	"practice_problems/model"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==========================================
+// 科目目录：作者把科目发布到目录 (标题、简介、封面、标签、试看知识点)，其他用户可搜索、排序并一键申请
+// 每条目录信息关联作者的一个分享码，一键申请即使用该分享码 (与手动输入分享码绑定走同一流程：名额、审核、授权时长)
+// 分享码删除、过期、不再包含该科目或科目被删除后，目录中自动不再展示，作者换一个分享码重新保存即可
+// ==========================================
+
+// 目录信息的长度限制
+const (
+	listingTitleMaxLen  = 50
+	listingDescMaxLen   = 2000
+	listingCoverMaxLen  = 500
+	listingMaxTags      = 10
+	listingTagMaxLen    = 20
+	listingMaxSamples   = 5
+	listingSummaryRunes = 120 // 试看知识点摘要长度
+)
+
+// catalogVisibleSQL 目录中可见的科目 (已上架、科目正常、分享码有效且仍属于作者并只包含该科目)
+// 一键申请会开通分享码中的全部科目，分享码后来加入了其他科目时不再展示，避免顺带开通未公开的科目
+const catalogVisibleSQL = `l.status = 1 AND s.status = 1 AND s.deleted_at IS NULL
+	AND sc.status = 1 AND sc.expire_time > datetime('now') AND sc.creator_id = u.id
+	AND EXISTS (SELECT 1 FROM share_code_subjects scs WHERE scs.share_code_id = sc.id AND scs.subject_id = s.id)
+	AND NOT EXISTS (SELECT 1 FROM share_code_subjects scs WHERE scs.share_code_id = sc.id AND scs.subject_id != s.id)`
+
+// catalogSelectSQL 目录列表与详情共用的查询字段与连接，占位符依次为当前用户的 user_code、id、id
+const catalogSelectSQL = `
+	SELECT l.subject_id, s.name, l.title, IFNULL(l.description, ''), IFNULL(l.cover, ''), IFNULL(l.tags, '[]'),
+		IFNULL(NULLIF(u.nickname, ''), u.username),
+		(SELECT COUNT(*) FROM knowledge_points p JOIN knowledge_categories kc ON p.categorie_id = kc.id
+			WHERE kc.subject_id = s.id AND kc.deleted_at IS NULL AND p.deleted_at IS NULL),
+		(SELECT COUNT(*) FROM questions q JOIN knowledge_points p ON q.knowledge_point_id = p.id
+			JOIN knowledge_categories kc ON p.categorie_id = kc.id
+			WHERE kc.subject_id = s.id AND kc.deleted_at IS NULL AND p.deleted_at IS NULL AND q.deleted_at IS NULL),
+		(SELECT COUNT(DISTINCT scu.user_id) FROM share_code_usage scu
+			JOIN share_code_subjects scs ON scs.share_code_id = scu.share_code_id
+			WHERE scs.subject_id = s.id) AS redemptions,
+		sc.duration_str, IFNULL(sc.require_approval, 0),
+		IFNULL(sc.max_uses, 0) > 0 AND sc.used_count >= sc.max_uses,
+		CASE
+			WHEN s.creator_code = ? OR EXISTS (SELECT 1 FROM user_subjects us
+				WHERE us.user_id = ? AND us.subject_id = s.id AND us.status = 1 AND ` + subscriptionReadableSQL + `) THEN 'owned'
+			WHEN EXISTS (SELECT 1 FROM share_code_requests r
+				WHERE r.share_code_id = sc.id AND r.user_id = ? AND r.status = 0) THEN 'pending'
+			ELSE 'none'
+		END,
+		l.publish_time, l.update_time,
+		` + subjectRatingColumnsSQL + `
+	FROM subject_listings l
+	JOIN subjects s ON l.subject_id = s.id
+	JOIN share_codes sc ON l.share_code_id = sc.id
+	LEFT JOIN users u ON s.creator_code = u.user_code`
+
+// htmlTagPattern 知识点内容为富文本，生成摘要时去掉标签
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// likeEscaper 转义 LIKE 通配符，配合 ESCAPE '\' 使用
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// plainSummary 富文本转为单行纯文本摘要
+func plainSummary(content string, n int) string {
+	text := html.UnescapeString(htmlTagPattern.ReplaceAllString(content, " "))
+	return firstRunes(strings.Join(strings.Fields(text), " "), n)
+}
+
+// scanCatalogListing 读取一行目录信息
+func scanCatalogListing(scanner interface{ Scan(...interface{}) error }) (model.SubjectListing, error) {
+	var l model.SubjectListing
+	var tags string
+	err := scanner.Scan(&l.SubjectID, &l.SubjectName, &l.Title, &l.Description, &l.Cover, &tags,
+		&l.CreatorName, &l.PointCount, &l.QuestionCount, &l.Redemptions,
+		&l.Duration, &l.RequireApproval, &l.Full, &l.Access, &l.PublishTime, &l.UpdateTime,
+		&l.Rating.Average, &l.Rating.Count)
+	if err != nil {
+		return l, err
+	}
+	if json.Unmarshal([]byte(tags), &l.Tags) != nil || l.Tags == nil {
+		l.Tags = []string{}
+	}
+	return l, nil
+}
+
+// normalizeListingTags 标签去空格、去重，校验数量与长度
+func normalizeListingTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool)
+	result := make([]string, 0, len(tags))
+	for _, t := range tags {
+		t = strings.TrimSpace(t)
+		if t == "" || seen[strings.ToLower(t)] {
+			continue
+		}
+		if utf8.RuneCountInString(t) > listingTagMaxLen {
+			return nil, fmt.Errorf("单个标签最多%d字", listingTagMaxLen)
+		}
+		seen[strings.ToLower(t)] = true
+		result = append(result, t)
+	}
+	if len(result) > listingMaxTags {
+		return nil, fmt.Errorf("标签最多%d个", listingMaxTags)
+	}
+	return result, nil
+}
+
+// =================================================================================
+// GetCatalog 科目目录 (keyword 搜索标题/简介/科目名/标签，tag 按标签筛选，sort=popular 按申请人数、rating 按评分，默认按发布时间)
+// =================================================================================
+func GetCatalog(c *gin.Context) {
+	userID, userCode := currentUser(c)
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	where := catalogVisibleSQL
+	var args []interface{}
+	if keyword := strings.TrimSpace(c.Query("keyword")); keyword != "" {
+		if utf8.RuneCountInString(keyword) > 50 {
+			keyword = firstRunes(keyword, 50)
+		}
+		// 转义通配符，关键字按字面匹配
+		pattern := "%" + likeEscaper.Replace(keyword) + "%"
+		where += ` AND (l.title LIKE ? ESCAPE '\' OR l.description LIKE ? ESCAPE '\' OR s.name LIKE ? ESCAPE '\' OR l.tags LIKE ? ESCAPE '\')`
+		args = append(args, pattern, pattern, pattern, pattern)
+	}
+	if tag := strings.TrimSpace(c.Query("tag")); tag != "" {
+		where += " AND EXISTS (SELECT 1 FROM json_each(l.tags) WHERE json_each.value = ?)"
+		args = append(args, tag)
+	}
+
+	orderBy := "l.publish_time DESC, l.subject_id DESC"
+	switch c.Query("sort") {
+	case "popular":
+		orderBy = "redemptions DESC, " + orderBy
+	case "rating":
+		orderBy = "rating_avg DESC, rating_count DESC, " + orderBy
+	}
+
+	var total int
+	if err := global.DB.QueryRow(`SELECT COUNT(*) FROM subject_listings l
+		JOIN subjects s ON l.subject_id = s.id
+		JOIN share_codes sc ON l.share_code_id = sc.id
+		LEFT JOIN users u ON s.creator_code = u.user_code
+		WHERE `+where, args...).Scan(&total); err != nil {
+		global.GetLog(c).Errorf("统计科目目录失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+
+	queryArgs := append([]interface{}{userCode, userID, userID}, args...)
+	rows, err := global.DB.Query(catalogSelectSQL+`
+		WHERE `+where+`
+		ORDER BY `+orderBy+` LIMIT ? OFFSET ?`, append(queryArgs, pageSize, (page-1)*pageSize)...)
+	if err != nil {
+		global.GetLog(c).Errorf("查询科目目录失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+	defer rows.Close()
+
+	list := make([]model.SubjectListing, 0)
+	for rows.Next() {
+		l, err := scanCatalogListing(rows)
+		if err != nil {
+			global.GetLog(c).Warnf("读取科目目录失败: %v", err)
+			continue
+		}
+		list = append(list, l)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "success", "data": gin.H{
+		"list": list, "total": total, "page": page, "pageSize": pageSize,
+	}})
+}
+
+// =================================================================================
+// GetCatalogDetail 目录中科目的详情 (含试看知识点)
+// =================================================================================
+func GetCatalogDetail(c *gin.Context) {
+	subjectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+	userID, userCode := currentUser(c)
+
+	l, err := scanCatalogListing(global.DB.QueryRow(catalogSelectSQL+`
+		WHERE l.subject_id = ? AND `+catalogVisibleSQL, userCode, userID, userID, subjectID))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "该科目未在目录中发布"})
+		return
+	} else if err != nil {
+		global.GetLog(c).Errorf("查询目录详情失败 (SubjectID: %d): %v", subjectID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+
+	samples, err := loadListingSamples(subjectID)
+	if err != nil {
+		global.GetLog(c).Errorf("查询试看知识点失败 (SubjectID: %d): %v", subjectID, err)
+	}
+	l.SamplePoints = samples
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "success", "data": l})
+}
+
+// loadListingSamples 读取试看知识点 (按作者设置的顺序，已删除或移出科目的跳过)
+func loadListingSamples(subjectID int) ([]model.CatalogSamplePoint, error) {
+	var raw string
+	if err := global.DB.QueryRow("SELECT IFNULL(sample_point_ids, '[]') FROM subject_listings WHERE subject_id = ?", subjectID).Scan(&raw); err != nil {
+		return []model.CatalogSamplePoint{}, err
+	}
+	var ids []int
+	json.Unmarshal([]byte(raw), &ids)
+	samples := make([]model.CatalogSamplePoint, 0, len(ids))
+	if len(ids) == 0 {
+		return samples, nil
+	}
+
+	placeholders, args := idArgs(ids)
+	rows, err := global.DB.Query(`
+		SELECT p.id, p.title, kc.categorie_name, IFNULL(p.content, '')
+		FROM knowledge_points p
+		JOIN knowledge_categories kc ON p.categorie_id = kc.id
+		WHERE p.id IN (`+placeholders+`) AND kc.subject_id = ?
+		  AND p.deleted_at IS NULL AND kc.deleted_at IS NULL`, append(args, subjectID)...)
+	if err != nil {
+		return samples, err
+	}
+	defer rows.Close()
+
+	found := make(map[int]model.CatalogSamplePoint)
+	for rows.Next() {
+		var p model.CatalogSamplePoint
+		var content string
+		if err := rows.Scan(&p.ID, &p.Title, &p.Category, &content); err != nil {
+			continue
+		}
+		p.Summary = plainSummary(content, listingSummaryRunes)
+		found[p.ID] = p
+	}
+	for _, id := range ids {
+		if p, ok := found[id]; ok {
+			samples = append(samples, p)
+		}
+	}
+	return samples, rows.Err()
+}
+
+// =================================================================================
+// RequestCatalogAccess 一键申请目录中的科目 (使用目录关联的分享码，需审核时提交申请)
+// =================================================================================
+func RequestCatalogAccess(c *gin.Context) {
+	subjectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+	userID, _ := currentUser(c)
+
+	// 与手动绑定共用限流：按用户与 IP 限制尝试频率，连续失败锁定一段时间
+	guardKeys := bindGuardKeys(userID, c.ClientIP())
+	if ok, wait := acquireBindAttempt(guardKeys); !ok {
+		global.GetLog(c).Warnf("目录申请科目被限流 (User: %d, IP: %s)", userID, c.ClientIP())
+		respondBindLocked(c, wait)
+		return
+	}
+	bindFailed := func(status int, msg string) {
+		if recordBindFailure(guardKeys) {
+			global.GetLog(c).Warnf("目录申请科目失败次数过多，已锁定 (User: %d, IP: %s)", userID, c.ClientIP())
+		}
+		c.JSON(status, gin.H{"code": status, "msg": msg})
+	}
+
+	var code string
+	err = global.DB.QueryRow(`SELECT sc.code
+		FROM subject_listings l
+		JOIN subjects s ON l.subject_id = s.id
+		JOIN share_codes sc ON l.share_code_id = sc.id
+		LEFT JOIN users u ON s.creator_code = u.user_code
+		WHERE l.subject_id = ? AND `+catalogVisibleSQL, subjectID).Scan(&code)
+	if err == sql.ErrNoRows {
+		bindFailed(http.StatusNotFound, "该科目未在目录中发布或暂不可申请")
+		return
+	} else if err != nil {
+		global.GetLog(c).Errorf("查询目录分享码失败 (SubjectID: %d): %v", subjectID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "系统错误"})
+		return
+	}
+
+	global.GetLog(c).Infof("用户[%d] 通过科目目录申请科目 (SubjectID: %d)", userID, subjectID)
+	redeemShareCode(c, userID, code, bindFailed)
+}
+
+// =================================================================================
+// GetSubjectListing 作者查看科目的目录信息 (含分享码与上架状态)
+// =================================================================================
+func GetSubjectListing(c *gin.Context) {
+	subjectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+	if !checkSubjectCreator(c, subjectID, "查看目录信息") {
+		return
+	}
+
+	var title, description, cover, tags, sampleIDs, shareCode string
+	var status int
+	var publishTime, updateTime, codeExpire model.UTCTime
+	var codeActive bool
+	err = global.DB.QueryRow(`
+		SELECT l.title, IFNULL(l.description, ''), IFNULL(l.cover, ''), IFNULL(l.tags, '[]'), IFNULL(l.sample_point_ids, '[]'),
+			l.status, l.publish_time, l.update_time, IFNULL(sc.code, ''), sc.expire_time,
+			IFNULL(sc.status = 1 AND sc.expire_time > datetime('now')
+				AND EXISTS (SELECT 1 FROM share_code_subjects scs WHERE scs.share_code_id = sc.id AND scs.subject_id = l.subject_id), 0)
+		FROM subject_listings l
+		LEFT JOIN share_codes sc ON l.share_code_id = sc.id
+		WHERE l.subject_id = ?`, subjectID).Scan(&title, &description, &cover, &tags, &sampleIDs,
+		&status, &publishTime, &updateTime, &shareCode, &codeExpire, &codeActive)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "该科目尚未发布到目录", "data": nil})
+		return
+	} else if err != nil {
+		global.GetLog(c).Errorf("查询目录信息失败 (SubjectID: %d): %v", subjectID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+
+	tagList := []string{}
+	json.Unmarshal([]byte(tags), &tagList)
+	sampleList := []int{}
+	json.Unmarshal([]byte(sampleIDs), &sampleList)
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "success", "data": gin.H{
+		"subjectId":       subjectID,
+		"title":           title,
+		"description":     description,
+		"cover":           cover,
+		"tags":            tagList,
+		"samplePointIds":  sampleList,
+		"status":          status,
+		"publishTime":     publishTime,
+		"updateTime":      updateTime,
+		"shareCode":       shareCode,
+		"shareCodeExpire": codeExpire,
+		// 分享码失效后目录中不再展示，需要换一个分享码
+		"shareCodeActive": codeActive,
+	}})
+}
+
+// =================================================================================
+// SaveSubjectListing 发布 / 修改科目目录信息 (仅作者，保存即上架)
+// =================================================================================
+func SaveSubjectListing(c *gin.Context) {
+	subjectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+	var req model.SaveSubjectListingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "参数错误"})
+		return
+	}
+	if !checkSubjectCreator(c, subjectID, "发布到目录") {
+		return
+	}
+	userID, _ := currentUser(c)
+
+	req.Title = strings.TrimSpace(req.Title)
+	req.Description = strings.TrimSpace(req.Description)
+	req.Cover = strings.TrimSpace(req.Cover)
+	if req.Title == "" || utf8.RuneCountInString(req.Title) > listingTitleMaxLen {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": fmt.Sprintf("标题不能为空且最多%d字", listingTitleMaxLen)})
+		return
+	}
+	if utf8.RuneCountInString(req.Description) > listingDescMaxLen {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": fmt.Sprintf("简介最多%d字", listingDescMaxLen)})
+		return
+	}
+	if len(req.Cover) > listingCoverMaxLen || (req.Cover != "" && !strings.HasPrefix(req.Cover, "/uploads/") &&
+		!strings.HasPrefix(req.Cover, "https://") && !strings.HasPrefix(req.Cover, "http://")) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "封面地址无效，请使用上传的图片"})
+		return
+	}
+	tags, err := normalizeListingTags(req.Tags)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": err.Error()})
+		return
+	}
+
+	// 试看知识点：去重，必须属于该科目且未删除
+	sampleIDs := make([]int, 0, len(req.SamplePointIDs))
+	seen := make(map[int]bool)
+	for _, id := range req.SamplePointIDs {
+		if !seen[id] {
+			seen[id] = true
+			sampleIDs = append(sampleIDs, id)
+		}
+	}
+	if len(sampleIDs) > listingMaxSamples {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": fmt.Sprintf("试看知识点最多%d个", listingMaxSamples)})
+		return
+	}
+	if len(sampleIDs) > 0 {
+		placeholders, args := idArgs(sampleIDs)
+		var valid int
+		global.DB.QueryRow(`SELECT COUNT(*) FROM knowledge_points p
+			JOIN knowledge_categories kc ON p.categorie_id = kc.id
+			WHERE p.id IN (`+placeholders+`) AND kc.subject_id = ? AND p.deleted_at IS NULL AND kc.deleted_at IS NULL`,
+			append(args, subjectID)...).Scan(&valid)
+		if valid != len(sampleIDs) {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "试看知识点必须属于该科目"})
+			return
+		}
+	}
+
+	// 分享码：必须是自己创建的、有效的、只包含该科目的 (一键申请会开通分享码中的全部科目)
+	code := normalizeShareCode(req.ShareCode)
+	var shareCodeID int
+	var codeExpire model.UTCTime
+	var otherSubjects int
+	err = global.DB.QueryRow(`SELECT sc.id, sc.expire_time,
+			(SELECT COUNT(*) FROM share_code_subjects scs WHERE scs.share_code_id = sc.id AND scs.subject_id != ?)
+		FROM share_codes sc
+		WHERE sc.code = ? AND sc.creator_id = ? AND sc.status = 1
+		  AND EXISTS (SELECT 1 FROM share_code_subjects scs WHERE scs.share_code_id = sc.id AND scs.subject_id = ?)`,
+		subjectID, code, userID, subjectID).Scan(&shareCodeID, &codeExpire, &otherSubjects)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "分享码不存在、不属于您或不包含该科目"})
+		return
+	} else if err != nil {
+		global.GetLog(c).Errorf("查询目录分享码失败 (SubjectID: %d): %v", subjectID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "系统错误"})
+		return
+	}
+	if codeExpire.Expired(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "该分享码已过期，请选择有效的分享码"})
+		return
+	}
+	if otherSubjects > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "目录分享码只能包含该科目，请为其单独创建分享码"})
+		return
+	}
+
+	tagsJSON, _ := json.Marshal(tags)
+	samplesJSON, _ := json.Marshal(sampleIDs)
+	// 重新上架时刷新发布时间，修改已上架的信息时保留
+	_, err = global.DB.Exec(`
+		INSERT INTO subject_listings (subject_id, title, description, cover, tags, sample_point_ids, share_code_id, status)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(subject_id) DO UPDATE SET
+			title = excluded.title, description = excluded.description, cover = excluded.cover,
+			tags = excluded.tags, sample_point_ids = excluded.sample_point_ids, share_code_id = excluded.share_code_id,
+			publish_time = CASE WHEN subject_listings.status = ? THEN subject_listings.publish_time ELSE CURRENT_TIMESTAMP END,
+			status = excluded.status, update_time = CURRENT_TIMESTAMP`,
+		subjectID, req.Title, req.Description, req.Cover, string(tagsJSON), string(samplesJSON), shareCodeID,
+		model.ListingPublished, model.ListingPublished)
+	if err != nil {
+		global.GetLog(c).Errorf("保存目录信息失败 (SubjectID: %d): %v", subjectID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "保存失败"})
+		return
+	}
+
+	global.GetLog(c).Infof("用户[%d] 发布科目到目录 (SubjectID: %d, ShareCode: %s)", userID, subjectID, code)
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "已发布到科目目录"})
+}
+
+// =================================================================================
+// UnpublishSubjectListing 从目录下架 (仅作者，保留已填写的信息，重新保存即可上架)
+// =================================================================================
+func UnpublishSubjectListing(c *gin.Context) {
+	subjectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+	if !checkSubjectCreator(c, subjectID, "从目录下架") {
+		return
+	}
+
+	res, err := global.DB.Exec(`UPDATE subject_listings SET status = ?, update_time = CURRENT_TIMESTAMP
+		WHERE subject_id = ? AND status = ?`, model.ListingUnpublished, subjectID, model.ListingPublished)
+	if err != nil {
+		global.GetLog(c).Errorf("下架目录失败 (SubjectID: %d): %v", subjectID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "操作失败"})
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "该科目未在目录中发布"})
+		return
+	}
+
+	userID, _ := currentUser(c)
+	global.GetLog(c).Infof("用户[%d] 从目录下架科目 (SubjectID: %d)", userID, subjectID)
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "已从目录下架"})
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCatalogShareCodeCoversOnlyListedSubject(t *testing.T) {
+	setupTestDB(t)
+
+	authorID := createTestUser(t, "author")
+	learnerID := createTestUser(t, "learner")
+	publicID := createTestSubject(t, "public", authorID, "author")
+	paidID := createTestSubject(t, "paid", authorID, "author")
+	params := gin.Params{{Key: "id", Value: strconv.Itoa(publicID)}}
+
+	mustExec(t, "INSERT INTO share_codes (id, code, creator_id, duration_str, expire_time) VALUES (1, 'BUNDLE01', ?, '30d', '2999-01-01 00:00:00')", authorID)
+	mustExec(t, "INSERT INTO share_code_subjects (share_code_id, subject_id) VALUES (1, ?), (1, ?)", publicID, paidID)
+	mustExec(t, "INSERT INTO share_codes (id, code, creator_id, duration_str, expire_time) VALUES (2, 'SINGLE01', ?, '30d', '2999-01-01 00:00:00')", authorID)
+	mustExec(t, "INSERT INTO share_code_subjects (share_code_id, subject_id) VALUES (2, ?)", publicID)
+
+	// 包含其他科目的分享码不能用于目录
+	if w := callHandler(SaveSubjectListing, authorID, "author", params, `{"title":"公开课","shareCode":"bundle01"}`); w.Code != 400 {
+		t.Errorf("listing with bundle code: want 400, got %d (%s)", w.Code, w.Body.String())
+	}
+	if w := callHandler(SaveSubjectListing, authorID, "author", params, `{"title":"公开课","shareCode":"single01"}`); w.Code != 200 {
+		t.Fatalf("listing with single code: status %d, body %s", w.Code, w.Body.String())
+	}
+
+	// 上架后分享码又加入了其他科目：目录中不再可申请，也不会开通该科目
+	mustExec(t, "INSERT INTO share_code_subjects (share_code_id, subject_id) VALUES (2, ?)", paidID)
+	if w := callHandler(RequestCatalogAccess, learnerID, "learner", params, ""); w.Code != 404 {
+		t.Errorf("request access via widened code: want 404, got %d (%s)", w.Code, w.Body.String())
+	}
+	if g, ok := loadGrant(t, learnerID, paidID); ok && g.status == 1 {
+		t.Errorf("unlisted subject granted through catalog: %+v", g)
+	}
+
+	mustExec(t, "DELETE FROM share_code_subjects WHERE share_code_id = 2 AND subject_id = ?", paidID)
+	if w := callHandler(RequestCatalogAccess, learnerID, "learner", params, ""); w.Code != 200 {
+		t.Fatalf("request access: status %d, body %s", w.Code, w.Body.String())
+	}
+	if g, ok := loadGrant(t, learnerID, publicID); !ok || g.status != 1 {
+		t.Errorf("listed subject not granted: %+v (exists %v)", g, ok)
+	}
+}
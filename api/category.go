@@ -8,7 +8,6 @@ import (
 	"practice_problems/model"
 	"regexp"
 	"strconv"
-	"strings"
 	_ "time"
 
 	"github.com/gin-gonic/gin"
@@ -37,11 +36,11 @@ func GetCategoryList(c *gin.Context) {
 		SELECT 1 
 		FROM subjects s
 		LEFT JOIN user_subjects us ON s.id = us.subject_id AND us.user_id = ?
-		WHERE s.id = ? 
+		WHERE s.id = ? AND s.deleted_at IS NULL
 		  AND (
 		      s.creator_code = ?
 		      OR
-		      (us.id IS NOT NULL AND us.status = 1 AND (us.expire_time IS NULL OR us.expire_time > datetime('now', 'localtime')))
+		      (us.id IS NOT NULL AND us.status = 1 AND ` + subscriptionReadableSQL + `)
 		  )
 	`
 
@@ -64,9 +63,22 @@ func GetCategoryList(c *gin.Context) {
 	}
 	offset := (page - 1) * pageSize
 
+	// 可选 parent_id：0 只查顶级分类，N 只查该分类的直属子分类；不传时返回全部 (兼容平铺列表)
+	where := "subject_id = ? AND deleted_at IS NULL"
+	whereArgs := []interface{}{subjectIDStr}
+	if parentStr := c.Query("parent_id"); parentStr != "" {
+		parentID, err := strconv.Atoi(parentStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "parent_id 参数错误"})
+			return
+		}
+		where += " AND IFNULL(parent_id, 0) = ?"
+		whereArgs = append(whereArgs, parentID)
+	}
+
 	// 查询总数
 	var total int
-	countErr := global.DB.QueryRow("SELECT COUNT(*) FROM knowledge_categories WHERE subject_id = ?", subjectIDStr).Scan(&total)
+	countErr := global.DB.QueryRow("SELECT COUNT(*) FROM knowledge_categories WHERE "+where, whereArgs...).Scan(&total)
 	if countErr != nil {
 		global.GetLog(c).Errorf("查询分类总数失败: %v", countErr)
 		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
@@ -74,10 +86,10 @@ func GetCategoryList(c *gin.Context) {
 	}
 
 	// 分页查询分类列表
-	fields := "id, subject_id, categorie_name, create_time, update_time, sort_order, difficulty"
-	sqlStr := fmt.Sprintf("SELECT %s FROM knowledge_categories WHERE subject_id = ? ORDER BY sort_order ASC, id DESC LIMIT ? OFFSET ?", fields)
+	fields := "id, subject_id, parent_id, categorie_name, create_time, update_time, sort_order, difficulty, version"
+	sqlStr := fmt.Sprintf("SELECT %s FROM knowledge_categories WHERE %s ORDER BY sort_order ASC, id DESC LIMIT ? OFFSET ?", fields, where)
 
-	rows, err := global.DB.Query(sqlStr, subjectIDStr, pageSize, offset)
+	rows, err := global.DB.Query(sqlStr, append(whereArgs, pageSize, offset)...)
 	if err != nil {
 		// ★★★ Error: 数据库查询出错需要记录 ★★★
 		global.GetLog(c).Errorf("查询分类列表失败 (SubjectID: %s): %v", subjectIDStr, err)
@@ -92,11 +104,13 @@ func GetCategoryList(c *gin.Context) {
 		err := rows.Scan(
 			&item.ID,
 			&item.SubjectID,
+			&item.ParentID,
 			&item.CategoryName,
 			&item.CreateTime,
 			&item.UpdateTime,
 			&item.SortOrder,
 			&item.Difficulty,
+			&item.Version,
 		)
 		if err != nil {
 			continue
@@ -131,33 +145,49 @@ func CreateCategory(c *gin.Context) {
 
 	// --- 权限检查 ---
 	var subjectCreatorCode string
+	var permSubjectID int
 	var creatorName string
 	var creatorEmail sql.NullString
 
 	checkSQL := `
-		SELECT s.creator_code, IFNULL(u.nickname, u.username), u.email
+		SELECT s.creator_code, s.id, IFNULL(u.nickname, u.username), u.email
 		FROM subjects s
 		LEFT JOIN users u ON s.creator_code = u.user_code
-		WHERE s.id = ?
+		WHERE s.id = ? AND s.deleted_at IS NULL
 	`
-	err := global.DB.QueryRow(checkSQL, req.SubjectID).Scan(&subjectCreatorCode, &creatorName, &creatorEmail)
+	err := global.DB.QueryRow(checkSQL, req.SubjectID).Scan(&subjectCreatorCode, &permSubjectID, &creatorName, &creatorEmail)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "所属科目不存在"})
 		return
 	}
 
-	if subjectCreatorCode != currentUserCodeStr {
+	if !hasSubjectRole(permSubjectID, subjectCreatorCode, currentUserCodeStr, RoleEditor) {
 		// ★★★ Warn: 记录越权操作尝试 ★★★
 		global.GetLog(c).Warnf("创建分类失败: 无权操作 (User: %s, Subject: %d)", currentUserCodeStr, req.SubjectID)
 		contactInfo := getContactInfo(creatorName, creatorEmail)
-		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "创建失败：您不是该科目的作者，请联系 " + contactInfo})
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "创建失败：您没有该科目的编辑权限，请联系 " + contactInfo})
 		return
 	}
 
+	// --- 上级分类校验 (不传为顶级分类) ---
+	parent := parentKey(req.ParentID)
+	if parent != 0 {
+		msg, err := checkCategoryParent(global.DB, req.SubjectID, parent, 1)
+		if err != nil {
+			global.GetLog(c).Errorf("校验上级分类失败: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "系统错误"})
+			return
+		}
+		if msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": msg})
+			return
+		}
+	}
+
 	// --- ★★★ 新增：生成带序号的名称 ★★★ ---
-	// 1. 统计当前科目下已有多少个分类
+	// 1. 统计同级已有多少个分类
 	var count int
-	err = global.DB.QueryRow("SELECT COUNT(*) FROM knowledge_categories WHERE subject_id = ?", req.SubjectID).Scan(&count)
+	err = global.DB.QueryRow("SELECT COUNT(*) FROM knowledge_categories WHERE subject_id = ? AND IFNULL(parent_id, 0) = ? AND deleted_at IS NULL", req.SubjectID, parent).Scan(&count)
 	if err != nil {
 		global.GetLog(c).Errorf("统计分类数量失败: %v", err)
 		c.JSON(500, gin.H{"code": 500, "msg": "系统错误"})
@@ -169,13 +199,13 @@ func CreateCategory(c *gin.Context) {
 
 	// --- 计算排序并插入 ---
 	var currentMinSort int
-	sqlQueryMin := "SELECT COALESCE(MIN(sort_order), 0) FROM knowledge_categories WHERE subject_id = ?"
-	_ = global.DB.QueryRow(sqlQueryMin, req.SubjectID).Scan(&currentMinSort)
+	sqlQueryMin := "SELECT COALESCE(MIN(sort_order), 0) FROM knowledge_categories WHERE subject_id = ? AND IFNULL(parent_id, 0) = ? AND deleted_at IS NULL"
+	_ = global.DB.QueryRow(sqlQueryMin, req.SubjectID, parent).Scan(&currentMinSort)
 	newSortOrder := currentMinSort - 1
 
 	// 注意：这里使用 finalCategoryName 和 req.Difficulty
-	sqlStr := "INSERT INTO knowledge_categories (subject_id, categorie_name, sort_order, difficulty) VALUES (?, ?, ?, ?)"
-	result, err := global.DB.Exec(sqlStr, req.SubjectID, finalCategoryName, newSortOrder, req.Difficulty)
+	sqlStr := "INSERT INTO knowledge_categories (subject_id, parent_id, categorie_name, sort_order, difficulty) VALUES (?, ?, ?, ?, ?)"
+	result, err := global.DB.Exec(sqlStr, req.SubjectID, parentValue(parent), finalCategoryName, newSortOrder, req.Difficulty)
 
 	if err != nil {
 		// ★★★ Error: 数据库插入失败 ★★★
@@ -187,6 +217,7 @@ func CreateCategory(c *gin.Context) {
 	newID, _ := result.LastInsertId()
 
 	// ★★★ Info: 记录成功创建 ★★★
+	pushContentChange(c, req.SubjectID, "category", newID, "create")
 	global.GetLog(c).Infof("用户[%s] 创建分类成功: ID=%d, Name=%s", currentUserCodeStr, newID, finalCategoryName)
 	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "创建成功", "data": gin.H{"id": newID, "name": finalCategoryName}})
 }
@@ -209,35 +240,49 @@ func UpdateCategory(c *gin.Context) {
 
 	// --- 权限检查 & 获取旧数据 ---
 	var subjectCreatorCode string
+	var permSubjectID int
 	var creatorName string
 	var creatorEmail sql.NullString
 	// ★★★ 新增变量接收旧数据
 	var currentCategoryName string
 	var currentSubjectID int
+	var currentVersion int
+	var currentParent int
 
 	// ★★★ 修改 SQL: 多查询了 c.categorie_name 和 c.subject_id
 	checkSQL := `
-		SELECT s.creator_code, c.categorie_name, c.subject_id, IFNULL(u.nickname, u.username), u.email
+		SELECT s.creator_code, s.id, c.categorie_name, c.subject_id, c.version, IFNULL(c.parent_id, 0), IFNULL(u.nickname, u.username), u.email
 		FROM knowledge_categories c
 		JOIN subjects s ON c.subject_id = s.id
 		LEFT JOIN users u ON s.creator_code = u.user_code
-		WHERE c.id = ?
+		WHERE c.id = ? AND c.deleted_at IS NULL
 	`
-	err := global.DB.QueryRow(checkSQL, id).Scan(&subjectCreatorCode, &currentCategoryName, &currentSubjectID, &creatorName, &creatorEmail)
+	err := global.DB.QueryRow(checkSQL, id).Scan(&subjectCreatorCode, &permSubjectID, &currentCategoryName, &currentSubjectID, &currentVersion, &currentParent, &creatorName, &creatorEmail)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "分类不存在"})
 		return
 	}
 
-	if subjectCreatorCode != currentUserCodeStr {
+	if !hasSubjectRole(permSubjectID, subjectCreatorCode, currentUserCodeStr, RoleEditor) {
 		global.GetLog(c).Warnf("修改分类失败: 无权操作 (User: %s, CategoryID: %d)", currentUserCodeStr, id)
 		contactInfo := getContactInfo(creatorName, creatorEmail)
 		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "修改失败：请联系科目作者 " + contactInfo})
 		return
 	}
 
+	// --- 乐观锁：版本不一致说明已被他人修改 ---
+	clientVersion, ok := resolveEditVersion(c, req.Version, currentVersion)
+	if !ok {
+		return
+	}
+	if clientVersion != currentVersion {
+		global.GetLog(c).Warnf("修改分类版本冲突 (User: %s, CategoryID: %d, Client: %d, Server: %d)", currentUserCodeStr, id, clientVersion, currentVersion)
+		respondCategoryConflict(c, id)
+		return
+	}
+
 	// --- 执行更新 ---
-	query := "UPDATE knowledge_categories SET update_time = CURRENT_TIMESTAMP"
+	query := "UPDATE knowledge_categories SET update_time = CURRENT_TIMESTAMP, version = version + 1"
 	var args []interface{}
 
 	// ★★★ 核心修改：处理分类名称更新 ★★★
@@ -261,9 +306,9 @@ func UpdateCategory(c *gin.Context) {
 			finalName = fmt.Sprintf("%s %s", oldMatches[1], cleanNewName)
 		} else {
 			// 情况B: 旧名称没有序号，自动生成
-			// 统计该科目下有多少分类 (包含自己)
+			// 统计同级有多少分类 (包含自己)
 			var count int
-			global.DB.QueryRow("SELECT COUNT(*) FROM knowledge_categories WHERE subject_id = ?", currentSubjectID).Scan(&count)
+			global.DB.QueryRow("SELECT COUNT(*) FROM knowledge_categories WHERE subject_id = ? AND IFNULL(parent_id, 0) = ? AND deleted_at IS NULL", currentSubjectID, currentParent).Scan(&count)
 
 			// 生成新序号
 			finalName = fmt.Sprintf("%d. %s", count, cleanNewName)
@@ -287,18 +332,39 @@ func UpdateCategory(c *gin.Context) {
 		return
 	}
 
-	query += " WHERE id = ?"
-	args = append(args, id)
+	query += " WHERE id = ? AND version = ?"
+	args = append(args, id, clientVersion)
 
-	_, err = global.DB.Exec(query, args...)
+	res, err := global.DB.Exec(query, args...)
 	if err != nil {
 		global.GetLog(c).Errorf("更新分类DB错误 (ID: %d): %v", id, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "更新失败"})
 		return
 	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		// 校验之后、写入之前被他人抢先保存
+		respondCategoryConflict(c, id)
+		return
+	}
 
+	pushContentChange(c, permSubjectID, "category", int64(id), "update")
 	global.GetLog(c).Infof("用户[%s] 更新分类成功 (ID: %d)", currentUserCodeStr, id)
-	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "更新成功"})
+	setVersionHeader(c, clientVersion+1)
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "更新成功", "data": gin.H{"version": clientVersion + 1}})
+}
+
+// respondCategoryConflict 分类版本冲突，附带服务器当前内容
+func respondCategoryConflict(c *gin.Context, id int) {
+	var item model.KnowledgeCategory
+	err := global.DB.QueryRow(`
+		SELECT id, subject_id, parent_id, categorie_name, create_time, update_time, sort_order, difficulty, version
+		FROM knowledge_categories WHERE id = ? AND deleted_at IS NULL`, id).Scan(
+		&item.ID, &item.SubjectID, &item.ParentID, &item.CategoryName, &item.CreateTime, &item.UpdateTime, &item.SortOrder, &item.Difficulty, &item.Version)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "分类不存在"})
+		return
+	}
+	respondVersionConflict(c, item.Version, item)
 }
 
 // =================================================================================
@@ -312,61 +378,55 @@ func DeleteCategory(c *gin.Context) {
 
 	// --- 权限检查 ---
 	var subjectCreatorCode string
+	var permSubjectID int
 	var creatorName string
 	var creatorEmail sql.NullString
 
 	checkSQL := `
-		SELECT s.creator_code, IFNULL(u.nickname, u.username), u.email
+		SELECT s.creator_code, s.id, IFNULL(u.nickname, u.username), u.email
 		FROM knowledge_categories c
 		JOIN subjects s ON c.subject_id = s.id
 		LEFT JOIN users u ON s.creator_code = u.user_code
-		WHERE c.id = ?
+		WHERE c.id = ? AND c.deleted_at IS NULL
 	`
-	err := global.DB.QueryRow(checkSQL, id).Scan(&subjectCreatorCode, &creatorName, &creatorEmail)
+	err := global.DB.QueryRow(checkSQL, id).Scan(&subjectCreatorCode, &permSubjectID, &creatorName, &creatorEmail)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "分类不存在"})
 		return
 	}
 
-	if subjectCreatorCode != currentUserCodeStr {
+	if !hasSubjectRole(permSubjectID, subjectCreatorCode, currentUserCodeStr, RoleEditor) {
 		global.GetLog(c).Warnf("删除分类失败: 无权操作 (User: %s, CategoryID: %d)", currentUserCodeStr, id)
 		contactInfo := getContactInfo(creatorName, creatorEmail)
 		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "删除失败：请联系科目作者 " + contactInfo})
 		return
 	}
 
-	// --- 执行删除 ---
-	sqlStr := "DELETE FROM knowledge_categories WHERE id = ?"
-	result, err := global.DB.Exec(sqlStr, id)
-	if err != nil {
-		if strings.Contains(err.Error(), "FOREIGN KEY") {
-			// 这种属于业务逻辑阻止，不算系统错误，也可以记一下 Info 或 Warn
-			global.GetLog(c).Infof("删除分类失败(外键约束): ID=%d", id)
-			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "删除失败：该分类下仍有知识点"})
-			return
-		}
+	// --- 移入回收站 (子分类及其下的知识点、题目一并软删除) ---
+	err = softDelete(model.TrashTypeCategory, id, currentUserCodeStr)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "未找到该分类"})
+		return
+	} else if err != nil {
 		global.GetLog(c).Errorf("删除分类DB错误 (ID: %d): %v", id, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "删除失败"})
 		return
 	}
 
-	rows, _ := result.RowsAffected()
-	if rows == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "未找到该分类"})
-		return
-	}
-
+	pushContentChange(c, permSubjectID, "category", int64(id), "delete")
 	global.GetLog(c).Infof("用户[%s] 删除分类成功 (ID: %d)", currentUserCodeStr, id)
 	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "删除成功"})
 }
 
 // UpdateCategorySortRequest 排序请求
 type UpdateCategorySortRequest struct {
-	Action string `json:"action" binding:"required,oneof=top up down"`
+	Action   string `json:"action" binding:"required,oneof=top up down move"`
+	ParentID *int   `json:"parentId"` // move: 目标上级分类，null 或 0 为顶级
+	Position *int   `json:"position"` // move: 在新同级中的位置 (从 0 开始)，不传放到最后
 }
 
 // =================================================================================
-// UpdateCategorySort 排序
+// UpdateCategorySort 排序 (同级之间) 与移动 (调整上级分类)
 // =================================================================================
 func UpdateCategorySort(c *gin.Context) {
 	idStr := c.Param("id")
@@ -383,25 +443,27 @@ func UpdateCategorySort(c *gin.Context) {
 
 	// --- 权限检查 ---
 	var subjectCreatorCode string
+	var permSubjectID int
 	var creatorName string
 	var creatorEmail sql.NullString
 	var currentSubjectID int
 	var currentSortOrder int
+	var currentParent int
 
 	checkSQL := `
-		SELECT s.creator_code, IFNULL(u.nickname, u.username), u.email, c.subject_id, c.sort_order
+		SELECT s.creator_code, s.id, IFNULL(u.nickname, u.username), u.email, c.subject_id, c.sort_order, IFNULL(c.parent_id, 0)
 		FROM knowledge_categories c
 		JOIN subjects s ON c.subject_id = s.id
 		LEFT JOIN users u ON s.creator_code = u.user_code
-		WHERE c.id = ?
+		WHERE c.id = ? AND c.deleted_at IS NULL
 	`
-	err := global.DB.QueryRow(checkSQL, id).Scan(&subjectCreatorCode, &creatorName, &creatorEmail, &currentSubjectID, &currentSortOrder)
+	err := global.DB.QueryRow(checkSQL, id).Scan(&subjectCreatorCode, &permSubjectID, &creatorName, &creatorEmail, &currentSubjectID, &currentSortOrder, &currentParent)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "分类不存在"})
 		return
 	}
 
-	if subjectCreatorCode != currentUserCodeStr {
+	if !hasSubjectRole(permSubjectID, subjectCreatorCode, currentUserCodeStr, RoleEditor) {
 		global.GetLog(c).Warnf("分类排序失败: 无权操作 (User: %s, CategoryID: %d)", currentUserCodeStr, id)
 		contactInfo := getContactInfo(creatorName, creatorEmail)
 		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "排序失败：请联系科目作者 " + contactInfo})
@@ -415,15 +477,15 @@ func UpdateCategorySort(c *gin.Context) {
 	switch req.Action {
 	case "top":
 		var minSort int
-		_ = tx.QueryRow("SELECT COALESCE(MIN(sort_order), 0) FROM knowledge_categories WHERE subject_id = ?", currentSubjectID).Scan(&minSort)
+		_ = tx.QueryRow("SELECT COALESCE(MIN(sort_order), 0) FROM knowledge_categories WHERE subject_id = ? AND IFNULL(parent_id, 0) = ? AND deleted_at IS NULL", currentSubjectID, currentParent).Scan(&minSort)
 		_, _ = tx.Exec("UPDATE knowledge_categories SET sort_order = ? WHERE id = ?", minSort-1, id)
 
 	case "up":
 		var targetID, targetSort int
 		err = tx.QueryRow(`
 			SELECT id, sort_order FROM knowledge_categories 
-			WHERE subject_id = ? AND sort_order < ? 
-			ORDER BY sort_order DESC LIMIT 1`, currentSubjectID, currentSortOrder).Scan(&targetID, &targetSort)
+			WHERE subject_id = ? AND IFNULL(parent_id, 0) = ? AND deleted_at IS NULL AND sort_order < ? 
+			ORDER BY sort_order DESC LIMIT 1`, currentSubjectID, currentParent, currentSortOrder).Scan(&targetID, &targetSort)
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "已是第一位"})
 			return
@@ -435,14 +497,24 @@ func UpdateCategorySort(c *gin.Context) {
 		var targetID, targetSort int
 		err = tx.QueryRow(`
 			SELECT id, sort_order FROM knowledge_categories 
-			WHERE subject_id = ? AND sort_order > ? 
-			ORDER BY sort_order ASC LIMIT 1`, currentSubjectID, currentSortOrder).Scan(&targetID, &targetSort)
+			WHERE subject_id = ? AND IFNULL(parent_id, 0) = ? AND deleted_at IS NULL AND sort_order > ?
+			ORDER BY sort_order ASC LIMIT 1`, currentSubjectID, currentParent, currentSortOrder).Scan(&targetID, &targetSort)
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "已是最后一位"})
 			return
 		}
 		_, _ = tx.Exec("UPDATE knowledge_categories SET sort_order = ? WHERE id = ?", currentSortOrder, targetID)
 		_, _ = tx.Exec("UPDATE knowledge_categories SET sort_order = ? WHERE id = ?", targetSort, id)
+
+	case "move":
+		if msg, err := moveCategoryTx(tx, id, currentSubjectID, parentKey(req.ParentID), req.Position); err != nil {
+			global.GetLog(c).Errorf("移动分类失败 (ID: %d): %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "移动失败"})
+			return
+		} else if msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": msg})
+			return
+		}
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -451,10 +523,63 @@ func UpdateCategorySort(c *gin.Context) {
 		return
 	}
 
+	pushContentChange(c, permSubjectID, "category", int64(id), "sort")
 	global.GetLog(c).Infof("用户[%s] 排序分类成功 (ID: %d, Action: %s)", currentUserCodeStr, id, req.Action)
 	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "排序成功"})
 }
 
+// moveCategoryTx 把分类 (连同子树) 移到新的上级分类下，并在新同级中按 position 重新编排顺序
+// 校验不通过时返回提示信息
+func moveCategoryTx(tx *sql.Tx, id, subjectID, parent int, position *int) (string, error) {
+	if parent != 0 {
+		inSubtree, err := isCategoryInSubtree(tx, id, parent)
+		if err != nil {
+			return "", err
+		}
+		if inSubtree {
+			return "不能移动到自身或其子分类下", nil
+		}
+		height, err := subtreeHeight(tx, id)
+		if err != nil {
+			return "", err
+		}
+		if msg, err := checkCategoryParent(tx, subjectID, parent, height); msg != "" || err != nil {
+			return msg, err
+		}
+	}
+
+	rows, err := tx.Query(`SELECT id FROM knowledge_categories
+		WHERE subject_id = ? AND IFNULL(parent_id, 0) = ? AND deleted_at IS NULL AND id != ?
+		ORDER BY sort_order ASC, id DESC`, subjectID, parent, id)
+	if err != nil {
+		return "", err
+	}
+	var siblings []int
+	for rows.Next() {
+		var sid int
+		if err := rows.Scan(&sid); err == nil {
+			siblings = append(siblings, sid)
+		}
+	}
+	rows.Close()
+
+	pos := len(siblings)
+	if position != nil && *position >= 0 && *position < pos {
+		pos = *position
+	}
+	ordered := append(append(append([]int{}, siblings[:pos]...), id), siblings[pos:]...)
+
+	if _, err := tx.Exec("UPDATE knowledge_categories SET parent_id = ? WHERE id = ?", parentValue(parent), id); err != nil {
+		return "", err
+	}
+	for i, sid := range ordered {
+		if _, err := tx.Exec("UPDATE knowledge_categories SET sort_order = ? WHERE id = ?", i, sid); err != nil {
+			return "", err
+		}
+	}
+	return "", nil
+}
+
 // 辅助函数
 func getContactInfo(name string, email sql.NullString) string {
 	if email.Valid && email.String != "" {
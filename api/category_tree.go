@@ -0,0 +1,228 @@
+package api
+
+import (
+	"net/http"
+	"practice_problems/global"
+	"practice_problems/model"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==========================================
+// 分类层级：章 → 节 → 小节，knowledge_categories.parent_id 为 NULL 的是顶级分类
+// 排序只在同级分类之间进行；移动时校验不能移到自身子树下且不超过最大层级
+// ==========================================
+
+const categoryMaxDepth = 5 // 分类最大层级 (顶级为 1)
+
+// descendantCategoriesSQL 分类自身及全部未删除的子孙分类 ID (参数为根分类 ID)
+// 使用 UNION 去重，历史数据中即使出现环也不会无限递归
+const descendantCategoriesSQL = `
+	WITH RECURSIVE sub(id) AS (
+		SELECT ?
+		UNION
+		SELECT c.id FROM knowledge_categories c JOIN sub ON c.parent_id = sub.id WHERE c.deleted_at IS NULL
+	)
+	SELECT id FROM sub`
+
+// parentKey 同级分类的比较键，顶级分类为 0 (配合 IFNULL(parent_id, 0) 使用)
+func parentKey(parentID *int) int {
+	if parentID == nil {
+		return 0
+	}
+	return *parentID
+}
+
+// parentValue parent_id 写入数据库的值，0 表示顶级
+func parentValue(key int) interface{} {
+	if key == 0 {
+		return nil
+	}
+	return key
+}
+
+// categoryDepth 分类所在层级 (顶级为 1)
+func categoryDepth(q sqlQueryer, id int) (int, error) {
+	var depth int
+	err := q.QueryRow(`
+		WITH RECURSIVE up(id, parent_id, depth) AS (
+			SELECT id, parent_id, 1 FROM knowledge_categories WHERE id = ?
+			UNION ALL
+			SELECT c.id, c.parent_id, up.depth + 1 FROM knowledge_categories c JOIN up ON c.id = up.parent_id
+			WHERE up.depth < 64
+		)
+		SELECT COALESCE(MAX(depth), 0) FROM up`, id).Scan(&depth)
+	return depth, err
+}
+
+// subtreeHeight 以该分类为根的子树高度 (叶子为 1)
+func subtreeHeight(q sqlQueryer, id int) (int, error) {
+	var height int
+	err := q.QueryRow(`
+		WITH RECURSIVE sub(id, depth) AS (
+			SELECT ?, 1
+			UNION ALL
+			SELECT c.id, sub.depth + 1 FROM knowledge_categories c JOIN sub ON c.parent_id = sub.id
+			WHERE c.deleted_at IS NULL AND sub.depth < 64
+		)
+		SELECT MAX(depth) FROM sub`, id).Scan(&height)
+	return height, err
+}
+
+// isCategoryInSubtree 判断 id 是否为 root 自身或其子孙
+func isCategoryInSubtree(q sqlQueryer, root, id int) (bool, error) {
+	var count int
+	err := q.QueryRow(`SELECT COUNT(*) FROM (`+descendantCategoriesSQL+`) WHERE id = ?`, root, id).Scan(&count)
+	return count > 0, err
+}
+
+// checkCategoryParent 校验上级分类：存在、同科目、层级未超限；失败时返回提示信息
+// childHeight 为待挂载子树的高度 (新建分类为 1)
+func checkCategoryParent(q sqlQueryer, subjectID, parentID, childHeight int) (string, error) {
+	var parentSubjectID int
+	err := q.QueryRow("SELECT subject_id FROM knowledge_categories WHERE id = ? AND deleted_at IS NULL", parentID).Scan(&parentSubjectID)
+	if err != nil || parentSubjectID != subjectID {
+		return "上级分类不存在或不属于该科目", nil
+	}
+	depth, err := categoryDepth(q, parentID)
+	if err != nil {
+		return "", err
+	}
+	if depth+childHeight > categoryMaxDepth {
+		return "分类层级最多 " + strconv.Itoa(categoryMaxDepth) + " 级", nil
+	}
+	return "", nil
+}
+
+// loadCategoryTree 读取科目的完整分类树，并统计子树知识点、题目数
+// 上级分类已删除或不存在的分类挂到顶级，保证不会丢失
+func loadCategoryTree(subjectID int) ([]*model.CategoryTreeNode, error) {
+	rows, err := global.DB.Query(`
+		SELECT c.id, c.subject_id, c.parent_id, c.categorie_name, c.create_time, c.update_time, c.sort_order, c.difficulty, c.version,
+			(SELECT COUNT(*) FROM knowledge_points p WHERE p.categorie_id = c.id AND p.deleted_at IS NULL),
+			(SELECT COUNT(*) FROM questions q JOIN knowledge_points p ON q.knowledge_point_id = p.id
+			 WHERE p.categorie_id = c.id AND p.deleted_at IS NULL AND q.deleted_at IS NULL)
+		FROM knowledge_categories c
+		WHERE c.subject_id = ? AND c.deleted_at IS NULL
+		ORDER BY c.sort_order ASC, c.id DESC`, subjectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var all []*model.CategoryTreeNode
+	byID := make(map[int]*model.CategoryTreeNode)
+	for rows.Next() {
+		n := &model.CategoryTreeNode{Children: make([]*model.CategoryTreeNode, 0)}
+		if err := rows.Scan(&n.ID, &n.SubjectID, &n.ParentID, &n.CategoryName, &n.CreateTime, &n.UpdateTime,
+			&n.SortOrder, &n.Difficulty, &n.Version, &n.PointCount, &n.QuestionCount); err != nil {
+			continue
+		}
+		all = append(all, n)
+		byID[n.ID] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	roots := make([]*model.CategoryTreeNode, 0)
+	for _, n := range all {
+		if parent, ok := byID[parentKey(n.ParentID)]; ok && parent != n {
+			parent.Children = append(parent.Children, n)
+		} else {
+			roots = append(roots, n)
+		}
+	}
+
+	// 自上而下标记层级并汇总子树计数，visited 防止历史数据中的环
+	visited := make(map[int]bool)
+	var walk func(n *model.CategoryTreeNode, depth int)
+	walk = func(n *model.CategoryTreeNode, depth int) {
+		visited[n.ID] = true
+		n.Depth = depth
+		n.SubtreePointCount = n.PointCount
+		n.SubtreeQuestionCount = n.QuestionCount
+		kept := n.Children[:0]
+		for _, child := range n.Children {
+			if visited[child.ID] {
+				continue
+			}
+			walk(child, depth+1)
+			n.SubtreePointCount += child.SubtreePointCount
+			n.SubtreeQuestionCount += child.SubtreeQuestionCount
+			kept = append(kept, child)
+		}
+		n.Children = kept
+	}
+	for _, n := range roots {
+		walk(n, 1)
+	}
+	for _, n := range all {
+		if !visited[n.ID] {
+			// 环上的分类没有可达的顶级祖先，作为顶级返回
+			n.ParentID = nil
+			roots = append(roots, n)
+			walk(n, 1)
+		}
+	}
+	return roots, nil
+}
+
+// categoryPreorder 分类在树中的先序位置，用于按目录顺序排列知识点
+func categoryPreorder(subjectID int) (map[int]int, error) {
+	roots, err := loadCategoryTree(subjectID)
+	if err != nil {
+		return nil, err
+	}
+	order := make(map[int]int)
+	var walk func(nodes []*model.CategoryTreeNode)
+	walk = func(nodes []*model.CategoryTreeNode) {
+		for _, n := range nodes {
+			order[n.ID] = len(order)
+			walk(n.Children)
+		}
+	}
+	walk(roots)
+	return order, nil
+}
+
+// =================================================================================
+// GetCategoryTree 科目分类树 (含子树知识点、题目数)
+// =================================================================================
+func GetCategoryTree(c *gin.Context) {
+	subjectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+	userID, userCode := currentUser(c)
+
+	readable, err := readableSubjects(userID, userCode)
+	if err != nil {
+		global.GetLog(c).Errorf("查询可访问科目失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+	if !readable[subjectID] {
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "无权访问该科目或授权已过期"})
+		return
+	}
+
+	tree, err := loadCategoryTree(subjectID)
+	if err != nil {
+		global.GetLog(c).Errorf("查询分类树失败 (SubjectID: %d): %v", subjectID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+
+	totalQuestions := 0
+	for _, n := range tree {
+		totalQuestions += n.SubtreeQuestionCount
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "success", "data": gin.H{
+		"list":           tree,
+		"maxDepth":       categoryMaxDepth,
+		"totalQuestions": totalQuestions,
+	}})
+}
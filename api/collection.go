@@ -6,6 +6,7 @@ import (
 	"math/rand"
 	"net/http"
 	"practice_problems/global"
+	"practice_problems/model"
 	"strconv"
 	"time"
 
@@ -81,8 +82,8 @@ func CheckCollectionPermission(c *gin.Context, collectionID int) (*CollectionPer
 		return result, nil
 	}
 
-	// 非所有者：检查是否有授权
-	var expireTime sql.NullString
+	// 非所有者：检查是否有授权 (expire_time 为 UTC，NULL 为永久)
+	var expireTime model.UTCTime
 	err = global.DB.QueryRow(`
 		SELECT expire_time 
 		FROM collection_permissions 
@@ -99,38 +100,7 @@ func CheckCollectionPermission(c *gin.Context, collectionID int) (*CollectionPer
 	}
 
 	// 检查授权是否过期
-	if !expireTime.Valid {
-		// NULL 表示永久有效
-		result.HasPermission = true
-	} else {
-		// 解析过期时间，尝试多种格式
-		var expireT time.Time
-		var parseErr error
-
-		// 尝试常见的日期时间格式
-		formats := []string{
-			"2006-01-02 15:04:05",
-			"2006-01-02T15:04:05Z",
-			"2006-01-02T15:04:05",
-			time.RFC3339,
-			time.RFC3339Nano,
-		}
-
-		for _, format := range formats {
-			expireT, parseErr = time.Parse(format, expireTime.String)
-			if parseErr == nil {
-				break
-			}
-		}
-
-		// 如果所有格式都失败了，返回错误
-		if parseErr != nil {
-			return nil, fmt.Errorf("时间解析错误: 无法解析时间格式 '%s'", expireTime.String)
-		}
-
-		// 判断是否过期
-		result.HasPermission = time.Now().Before(expireT)
-	}
+	result.HasPermission = !expireTime.Expired(time.Now())
 	return result, nil
 }
 
@@ -226,7 +196,7 @@ func GetCollections(c *gin.Context) {
 		LEFT JOIN collection_permissions cp ON c.id = cp.collection_id AND cp.user_code = ?
 		WHERE c.user_id = ?  -- 自己的集合
 		   OR c.is_public = 1  -- 公有集合
-		   OR (cp.user_code = ? AND (cp.expire_time IS NULL OR cp.expire_time > datetime('now', 'localtime')))  -- 授权且未过期
+		   OR (cp.user_code = ? AND (cp.expire_time IS NULL OR cp.expire_time > datetime('now')))  -- 授权且未过期
 		ORDER BY c.create_time DESC
 	`
 	rows, err := global.DB.Query(sqlStr, userCodeStr, userID, userCodeStr)
@@ -468,7 +438,7 @@ func AddPointToCollection(c *gin.Context) {
 		FROM knowledge_points p
 		JOIN knowledge_categories c ON p.categorie_id = c.id
 		JOIN subjects s ON c.subject_id = s.id
-		WHERE p.id = ?
+		WHERE p.id = ? AND p.deleted_at IS NULL
 	`, req.PointID).Scan(&creatorCode, &subjectID, &categoryID)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -597,7 +567,7 @@ func GetCollectionPoints(c *gin.Context) {
 
 	// 查询总数
 	var total int
-	err = global.DB.QueryRow("SELECT COUNT(*) FROM collection_items WHERE collection_id = ?", collectionID).Scan(&total)
+	err = global.DB.QueryRow("SELECT COUNT(*) FROM collection_items ci JOIN knowledge_points p ON ci.point_id = p.id WHERE ci.collection_id = ? AND p.deleted_at IS NULL", collectionID).Scan(&total)
 	if err != nil {
 		global.GetLog(c).Errorf("查询集合项总数失败: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "系统错误"})
@@ -616,7 +586,7 @@ func GetCollectionPoints(c *gin.Context) {
 		JOIN knowledge_points p ON ci.point_id = p.id
 		JOIN subjects s ON ci.subject_id = s.id
 		JOIN knowledge_categories c ON ci.category_id = c.id
-		WHERE ci.collection_id = ?
+		WHERE ci.collection_id = ? AND p.deleted_at IS NULL
 		ORDER BY ci.sort_order DESC, ci.create_time DESC
 		LIMIT ? OFFSET ?
 	`
@@ -1028,7 +998,7 @@ func GetCollectionQuestions(c *gin.Context) {
 	questionsIDSQL := fmt.Sprintf(`
 		SELECT q.id
 		FROM questions q
-		WHERE q.knowledge_point_id IN (%s)
+		WHERE q.knowledge_point_id IN (%s) AND q.deleted_at IS NULL
 	`, placeholders)
 
 	questionIDRows, err := global.DB.Query(questionsIDSQL, args...)
@@ -1206,7 +1176,7 @@ func BatchAddPointsToCollection(c *gin.Context) {
 			SELECT s.creator_code, s.id
 			FROM knowledge_categories c
 			JOIN subjects s ON c.subject_id = s.id
-			WHERE c.id = ?
+			WHERE c.id = ? AND c.deleted_at IS NULL
 		`, req.CategoryID).Scan(&creatorCode, &subjectID)
 		if err != nil {
 			if err == sql.ErrNoRows {
@@ -1228,7 +1198,7 @@ func BatchAddPointsToCollection(c *gin.Context) {
 			SELECT p.id, c.subject_id, p.categorie_id
 			FROM knowledge_points p
 			JOIN knowledge_categories c ON p.categorie_id = c.id
-			WHERE p.categorie_id = ?
+			WHERE p.categorie_id = ? AND p.deleted_at IS NULL
 			AND p.id NOT IN (
 				SELECT point_id FROM collection_items WHERE collection_id = ?
 			)
@@ -1238,7 +1208,7 @@ func BatchAddPointsToCollection(c *gin.Context) {
 		// 按科目分享
 		// 验证科目是否属于当前用户
 		var creatorCode string
-		err = global.DB.QueryRow("SELECT creator_code FROM subjects WHERE id = ?", req.SubjectID).Scan(&creatorCode)
+		err = global.DB.QueryRow("SELECT creator_code FROM subjects WHERE id = ? AND deleted_at IS NULL", req.SubjectID).Scan(&creatorCode)
 		if err != nil {
 			if err == sql.ErrNoRows {
 				c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "科目不存在"})
@@ -1259,7 +1229,7 @@ func BatchAddPointsToCollection(c *gin.Context) {
 			SELECT p.id, c.subject_id, p.categorie_id
 			FROM knowledge_points p
 			JOIN knowledge_categories c ON p.categorie_id = c.id
-			WHERE c.subject_id = ?
+			WHERE c.subject_id = ? AND p.deleted_at IS NULL
 			AND p.id NOT IN (
 				SELECT point_id FROM collection_items WHERE collection_id = ?
 			)
@@ -1411,7 +1381,7 @@ func AddCollectionPermission(c *gin.Context) {
 
 	var req struct {
 		UserCode   string `json:"userCode" binding:"required"`
-		ExpireTime string `json:"expireTime"` // 可选，格式: "2006-01-02 15:04:05" 或空表示永久
+		ExpireTime string `json:"expireTime"` // 可选，RFC3339 或服务器本地时间 "2006-01-02 15:04:05"，空表示永久
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -1439,14 +1409,10 @@ func AddCollectionPermission(c *gin.Context) {
 	}
 
 	// 解析过期时间
-	var expireTime sql.NullString
-	if req.ExpireTime != "" {
-		_, parseErr := time.Parse("2006-01-02 15:04:05", req.ExpireTime)
-		if parseErr != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "过期时间格式错误，应为 YYYY-MM-DD HH:MM:SS"})
-			return
-		}
-		expireTime = sql.NullString{String: req.ExpireTime, Valid: true}
+	expireTime, parseErr := model.ParseExpireInput(req.ExpireTime)
+	if parseErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "过期时间格式错误，应为 YYYY-MM-DD HH:MM:SS"})
+		return
 	}
 
 	// 插入或更新授权记录
@@ -1465,6 +1431,19 @@ func AddCollectionPermission(c *gin.Context) {
 		return
 	}
 
+	var collectionName string
+	global.DB.QueryRow("SELECT name FROM collections WHERE id = ?", collectionID).Scan(&collectionName)
+	expireText := "永久有效"
+	if expireTime.Valid {
+		expireText = "有效期至 " + expireTime.Time.In(time.Local).Format(reminderTimeLayout)
+	}
+	notifyUsers(c, []int{targetUserID}, notifyEvent{
+		Type:    model.NotifyCollectionGranted,
+		Title:   "获得集合授权",
+		Content: fmt.Sprintf("%s 授予了您集合「%s」的访问权限，%s", userDisplayName(permResult.OwnerUserID), collectionName, expireText),
+		Link:    fmt.Sprintf("/collection?id=%d", collectionID),
+	})
+
 	global.GetLog(c).Infof("用户[%v] 为集合[%d]添加授权成功: UserCode=%s, ExpireTime=%v",
 		permResult.OwnerUserID, collectionID, req.UserCode, req.ExpireTime)
 	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "授权成功"})
@@ -1555,8 +1534,9 @@ func GetCollectionPermissions(c *gin.Context) {
 
 	var list []gin.H
 	for rows.Next() {
-		var userCode, createTime string
-		var nickname, email, expireTime sql.NullString
+		var userCode string
+		var nickname, email sql.NullString
+		var expireTime, createTime model.UTCTime
 
 		err := rows.Scan(&userCode, &nickname, &email, &expireTime, &createTime)
 		if err != nil {
@@ -1573,16 +1553,13 @@ func GetCollectionPermissions(c *gin.Context) {
 		if email.Valid {
 			emailStr = email.String
 		}
-		expireTimeStr := ""
-		if expireTime.Valid {
-			expireTimeStr = expireTime.String
-		}
 
 		list = append(list, gin.H{
 			"userCode":   userCode,
 			"nickname":   nicknameStr,
 			"email":      emailStr,
-			"expireTime": expireTimeStr,
+			"expireTime": expireTime.String(), // RFC3339，永久为空串
+			"expired":    expireTime.Expired(time.Now()),
 			"createTime": createTime,
 		})
 	}
@@ -1629,7 +1606,7 @@ func UpdateCollectionPermission(c *gin.Context) {
 
 	var req struct {
 		UserCode   string `json:"userCode" binding:"required"`
-		ExpireTime string `json:"expireTime"` // 可选，格式: "2006-01-02 15:04:05" 或空表示永久
+		ExpireTime string `json:"expireTime"` // 可选，RFC3339 或服务器本地时间 "2006-01-02 15:04:05"，空表示永久
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -1647,14 +1624,10 @@ func UpdateCollectionPermission(c *gin.Context) {
 	}
 
 	// 解析过期时间
-	var expireTime sql.NullString
-	if req.ExpireTime != "" {
-		_, parseErr := time.Parse("2006-01-02 15:04:05", req.ExpireTime)
-		if parseErr != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "过期时间格式错误，应为 YYYY-MM-DD HH:MM:SS"})
-			return
-		}
-		expireTime = sql.NullString{String: req.ExpireTime, Valid: true}
+	expireTime, parseErr := model.ParseExpireInput(req.ExpireTime)
+	if parseErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "过期时间格式错误，应为 YYYY-MM-DD HH:MM:SS"})
+		return
 	}
 
 	// 更新授权时间
@@ -1865,7 +1838,7 @@ func FindPointInCollections(c *gin.Context) {
 		)
 		WHERE ci.point_id = ? 
 		  AND (c.user_id = ? OR c.is_public = 1 OR (
-			  c.is_public = 0 AND cp.expire_time IS NOT NULL AND 
+			  c.is_public = 0 AND cp.id IS NOT NULL AND
 			  (cp.expire_time IS NULL OR cp.expire_time > datetime('now'))
 		  ))
 		ORDER BY 
@@ -1946,7 +1919,7 @@ func getCollectionPointsPage(c *gin.Context, collectionID int, page int, pageSiz
 	// 查询总数
 	var total int
 	err := global.DB.QueryRow(
-		"SELECT COUNT(*) FROM collection_items WHERE collection_id = ?",
+		"SELECT COUNT(*) FROM collection_items ci JOIN knowledge_points p ON ci.point_id = p.id WHERE ci.collection_id = ? AND p.deleted_at IS NULL",
 		collectionID,
 	).Scan(&total)
 
@@ -1968,7 +1941,7 @@ func getCollectionPointsPage(c *gin.Context, collectionID int, page int, pageSiz
 		JOIN knowledge_points p ON ci.point_id = p.id
 		JOIN knowledge_categories c ON p.categorie_id = c.id
 		JOIN subjects s ON c.subject_id = s.id
-		WHERE ci.collection_id = ?
+		WHERE ci.collection_id = ? AND p.deleted_at IS NULL
 		ORDER BY ci.sort_order ASC
 		LIMIT ? OFFSET ?
 	`
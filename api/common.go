@@ -175,17 +175,18 @@ func UploadImage(c *gin.Context) {
 
 		// 查询 creator_code 和 local_image_names
 		querySql := `
-			SELECT s.creator_code, COALESCE(kp.local_image_names, '[]')
+			SELECT s.creator_code, s.id, COALESCE(kp.local_image_names, '[]')
 			FROM knowledge_points kp
 			INNER JOIN knowledge_categories kc ON kp.categorie_id = kc.id
 			INNER JOIN subjects s ON kc.subject_id = s.id
-			WHERE kp.id = ?
+			WHERE kp.id = ? AND kp.deleted_at IS NULL
 		`
 
 		var ownerCode string
+		var ownerSubjectID int
 		var localImageNamesStr string
 
-		err := global.DB.QueryRow(querySql, pointID).Scan(&ownerCode, &localImageNamesStr)
+		err := global.DB.QueryRow(querySql, pointID).Scan(&ownerCode, &ownerSubjectID, &localImageNamesStr)
 
 		if err != nil {
 			if err == sql.ErrNoRows {
@@ -198,7 +199,7 @@ func UploadImage(c *gin.Context) {
 		}
 
 		// 1. 验证权限
-		if ownerCode != currentUserCode {
+		if !hasSubjectRole(ownerSubjectID, ownerCode, currentUserCode, RoleEditor) {
 			global.GetLog(c).Warnf("上传图片被拒: 越权操作 (User: %s, PointID: %d)", currentUserCode, pointID)
 			c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "无权修改此知识点的内容"})
 			return
@@ -256,6 +257,7 @@ func UploadImage(c *gin.Context) {
 	}
 
 	// 6. 更新数据库 (使用 JSON 格式更新)
+	respData := gin.H{"url": webPath, "path": webPath}
 	if bizType == "point" {
 		pointID, _ := strconv.Atoi(targetIDStr)
 
@@ -271,20 +273,21 @@ func UploadImage(c *gin.Context) {
 
 		newJsonBytes, _ := json.Marshal(currentImages)
 
-		updateSql := `UPDATE knowledge_points SET local_image_names = ? WHERE id = ?`
-		_, err := global.DB.Exec(updateSql, string(newJsonBytes), pointID)
+		// 图片列表变化会使知识点版本 +1 并记录修订，返回新版本供后续修改使用
+		version, err := updatePointImages(pointID, string(newJsonBytes), currentUserCode)
 		if err != nil {
 			global.GetLog(c).Errorf("图片上传成功但DB更新失败 (PointID: %d): %v", pointID, err)
 			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "数据库更新失败"})
 			return
 		}
+		respData["version"] = version
 	}
 
 	global.GetLog(c).Infof("用户[%s] 上传图片成功: %s", currentUserCode, webPath)
 
 	c.JSON(http.StatusOK, gin.H{
 		"code": 200, "msg": "上传成功",
-		"data": gin.H{"url": webPath, "path": webPath},
+		"data": respData,
 	})
 }
 
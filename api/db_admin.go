@@ -501,10 +501,12 @@ func UpdateTableRow(c *gin.Context) {
 			if isDisabled {
 				// 获取被禁用用户的user_code并清除token
 				if targetID, ok := req.Where["id"]; ok {
+					var userID int
 					var userCode string
-					err := global.DB.QueryRow("SELECT user_code FROM users WHERE id = ?", targetID).Scan(&userCode)
+					err := global.DB.QueryRow("SELECT id, user_code FROM users WHERE id = ?", targetID).Scan(&userID, &userCode)
 					if err == nil && userCode != "" {
 						global.ClearUserTokens(userCode)
+						disconnectUserConnections(userID)
 						global.GetLog(c).Infof("用户被禁用，已清除token: userCode=%s", userCode)
 					}
 				}
@@ -0,0 +1,244 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"practice_problems/global"
+	"practice_problems/mailer"
+	"practice_problems/model"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==========================================
+// 邮件令牌与邮箱验证
+// 令牌明文只出现在邮件链接中，数据库只存 SHA-256 摘要；一次性使用，签发新令牌时同用途的旧令牌作废
+// 注册或修改邮箱后发送验证邮件，验证通过前不会向该邮箱发送提醒类邮件
+// 验证链接 (GET) 只展示确认页，用户点击确认 (POST) 才使用令牌，避免邮件安全扫描预取链接时把令牌用掉
+// ==========================================
+
+// 邮件令牌用途
+const (
+	tokenPurposeVerifyEmail   = "verify_email"
+	tokenPurposeResetPassword = "reset_password"
+)
+
+// verificationResendCooldown 重发验证邮件的最小间隔
+const verificationResendCooldown = time.Minute
+
+// errInvalidEmailToken 令牌不存在、已使用或已过期
+var errInvalidEmailToken = errors.New("链接无效或已过期")
+
+// hashEmailToken 令牌摘要
+func hashEmailToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// issueEmailToken 签发一次性令牌并作废该用户同用途的旧令牌，返回令牌明文
+func issueEmailToken(userID int, purpose, email string, ttl time.Duration) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	raw := base64.RawURLEncoding.EncodeToString(b)
+
+	tx, err := global.DB.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE email_tokens SET used_time = CURRENT_TIMESTAMP
+		WHERE user_id = ? AND purpose = ? AND used_time IS NULL`, userID, purpose); err != nil {
+		return "", err
+	}
+	if _, err := tx.Exec(`INSERT INTO email_tokens (user_id, purpose, token_hash, email, expire_time) VALUES (?, ?, ?, ?, ?)`,
+		userID, purpose, hashEmailToken(raw), email, model.NewUTCTime(time.Now().Add(ttl))); err != nil {
+		return "", err
+	}
+	return raw, tx.Commit()
+}
+
+// consumeEmailToken 校验并使用令牌 (同一条 UPDATE 完成校验与标记，并发下也只能用一次)，返回用户 ID 与签发时的邮箱
+// q 传入事务时，令牌与后续写入一起提交，事务回滚则令牌仍可使用
+func consumeEmailToken(q sqlQueryer, purpose, raw string) (int, string, error) {
+	var userID int
+	var email string
+	err := q.QueryRow(`
+		UPDATE email_tokens SET used_time = CURRENT_TIMESTAMP
+		WHERE token_hash = ? AND purpose = ? AND used_time IS NULL AND expire_time > datetime('now')
+		RETURNING user_id, IFNULL(email, '')`, hashEmailToken(strings.TrimSpace(raw)), purpose).Scan(&userID, &email)
+	if err == sql.ErrNoRows {
+		return 0, "", errInvalidEmailToken
+	}
+	return userID, email, err
+}
+
+// validateEmail 校验邮箱格式 (只接受纯地址，不带显示名)
+func validateEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email
+}
+
+// verificationRecentlySent 冷却时间内是否已给该用户发过验证邮件
+func verificationRecentlySent(userID int) bool {
+	var recent int
+	global.DB.QueryRow(`SELECT COUNT(*) FROM email_tokens WHERE user_id = ? AND purpose = ? AND create_time > datetime('now', ?)`,
+		userID, tokenPurposeVerifyEmail, fmt.Sprintf("-%d seconds", int(verificationResendCooldown.Seconds()))).Scan(&recent)
+	return recent > 0
+}
+
+// sendVerificationEmail 签发验证令牌并把验证邮件写入发件箱
+func sendVerificationEmail(userID int, email string) error {
+	ttl := time.Duration(global.EmailVerifyExpireHours) * time.Hour
+	token, err := issueEmailToken(userID, tokenPurposeVerifyEmail, email, ttl)
+	if err != nil {
+		return err
+	}
+	return mailer.Enqueue(email, mailer.TemplateVerifyEmail, map[string]interface{}{
+		"Name":        userDisplayName(userID),
+		"Link":        global.SiteBaseURL + "/api/v1/auth/verify-email?token=" + url.QueryEscape(token),
+		"ExpireHours": global.EmailVerifyExpireHours,
+	})
+}
+
+// verifyEmailPage 验证确认页与结果页 (Token 为空时只显示 Message)
+var verifyEmailPage = template.Must(template.New("verify_email").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1"><title>邮箱验证 - {{.SiteName}}</title></head>
+<body style="font-family: sans-serif; max-width: 480px; margin: 80px auto; text-align: center;">
+<h2>邮箱验证</h2>
+<p>{{.Message}}</p>
+{{if .Token}}<form method="POST" action="{{.Action}}">
+<input type="hidden" name="token" value="{{.Token}}">
+<button type="submit" style="padding: 8px 24px; font-size: 16px;">确认验证</button>
+</form>{{end}}
+</body>
+</html>`))
+
+// renderVerifyEmailPage 输出验证页面
+func renderVerifyEmailPage(c *gin.Context, status int, message, token string) {
+	c.Status(status)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	verifyEmailPage.Execute(c.Writer, gin.H{
+		"SiteName": global.MailSiteName,
+		"Message":  message,
+		"Token":    token,
+		"Action":   c.Request.URL.Path,
+	})
+}
+
+// =================================================================================
+// VerifyEmailPage 邮箱验证确认页 (公开接口，验证邮件中的链接直接打开，不使用令牌)
+// =================================================================================
+func VerifyEmailPage(c *gin.Context) {
+	token := strings.TrimSpace(c.Query("token"))
+	if token == "" {
+		renderVerifyEmailPage(c, http.StatusBadRequest, "缺少验证令牌", "")
+		return
+	}
+	renderVerifyEmailPage(c, http.StatusOK, "请点击下方按钮完成邮箱验证", token)
+}
+
+// =================================================================================
+// VerifyEmail 确认邮箱验证 (公开接口)
+// 确认页表单提交时返回结果页，JSON 请求 ({"token": "..."}) 返回 JSON
+// =================================================================================
+func VerifyEmail(c *gin.Context) {
+	fromPage := c.ContentType() != "application/json"
+	respond := func(status int, msg string, data gin.H) {
+		if fromPage {
+			renderVerifyEmailPage(c, status, msg, "")
+			return
+		}
+		body := gin.H{"code": status, "msg": msg}
+		if data != nil {
+			body["data"] = data
+		}
+		c.JSON(status, body)
+	}
+
+	token := c.PostForm("token")
+	if !fromPage {
+		var req struct {
+			Token string `json:"token"`
+		}
+		c.ShouldBindJSON(&req)
+		token = req.Token
+	}
+	if strings.TrimSpace(token) == "" {
+		respond(http.StatusBadRequest, "缺少验证令牌", nil)
+		return
+	}
+
+	userID, email, err := consumeEmailToken(global.DB, tokenPurposeVerifyEmail, token)
+	if err == errInvalidEmailToken {
+		respond(http.StatusBadRequest, "验证链接无效或已过期，请重新发送验证邮件", nil)
+		return
+	} else if err != nil {
+		global.GetLog(c).Errorf("校验邮箱验证令牌失败: %v", err)
+		respond(http.StatusInternalServerError, "系统错误", nil)
+		return
+	}
+
+	// 签发后邮箱又被修改过，则该链接不能验证新邮箱
+	res, err := global.DB.Exec("UPDATE users SET email_verified = 1 WHERE id = ? AND email = ?", userID, email)
+	if err != nil {
+		global.GetLog(c).Errorf("更新邮箱验证状态失败 (User: %d): %v", userID, err)
+		respond(http.StatusInternalServerError, "系统错误", nil)
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		respond(http.StatusBadRequest, "邮箱已变更，请重新发送验证邮件", nil)
+		return
+	}
+
+	global.GetLog(c).Infof("用户[%d] 邮箱验证成功: %s", userID, email)
+	respond(http.StatusOK, "邮箱验证成功", gin.H{"email": email})
+}
+
+// =================================================================================
+// ResendVerificationEmail 重新发送验证邮件
+// =================================================================================
+func ResendVerificationEmail(c *gin.Context) {
+	userID, _ := currentUser(c)
+
+	var email string
+	var verified bool
+	if err := global.DB.QueryRow("SELECT IFNULL(email, ''), IFNULL(email_verified, 0) FROM users WHERE id = ?", userID).Scan(&email, &verified); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "用户不存在"})
+		return
+	}
+	if email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "请先在个人资料中填写邮箱"})
+		return
+	}
+	if verified {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "邮箱已验证，无需重复验证"})
+		return
+	}
+
+	if verificationRecentlySent(userID) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"code": 429, "msg": "发送过于频繁，请稍后再试"})
+		return
+	}
+
+	if err := sendVerificationEmail(userID, email); err != nil {
+		global.GetLog(c).Errorf("发送验证邮件失败 (User: %d): %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "发送失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "验证邮件已发送，请查收"})
+}
@@ -0,0 +1,210 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"practice_problems/global"
+	"practice_problems/mailer"
+	"practice_problems/model"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==========================================
+// 授权到期提醒与宽限期
+// 1. 后台任务定期扫描即将到期的科目授权、集合授权，写入站内通知，并给已验证的邮箱发送邮件 (经发件箱异步发送)
+//    通知按 (授权记录, 到期时间) 去重，续期后到期时间变化会重新提醒
+// 2. 科目可设置宽限期 (subjects.grace_days)：到期后宽限期内仍可查看，但不能答题、不能使用 AI
+// ==========================================
+
+// subscriptionReadableSQL 科目授权可读 (未过期或仍在宽限期内)，us 为 user_subjects 别名
+// 需要写操作 (答题、AI) 的地方仍按 expire_time > datetime('now') 严格判断
+const subscriptionReadableSQL = `(us.expire_time IS NULL OR us.expire_time > datetime('now',
+	'-' || (SELECT IFNULL(grace_days, 0) FROM subjects WHERE id = us.subject_id) || ' days'))`
+
+// reminderTimeLayout 通知正文中的时间格式 (服务器时区)
+const reminderTimeLayout = "2006-01-02 15:04"
+
+// StartExpiryReminder 启动到期提醒任务 (启动时执行一次，之后按配置间隔执行)
+func StartExpiryReminder() {
+	if !global.ExpiryReminderEnabled {
+		return
+	}
+	go func() {
+		RunExpiryReminders()
+		ticker := time.NewTicker(time.Duration(global.ExpiryReminderIntervalMinutes) * time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			RunExpiryReminders()
+		}
+	}()
+}
+
+// expiryReminder 一条待发送的提醒
+type expiryReminder struct {
+	userID  int
+	email   string
+	kind    string
+	refKey  string
+	title   string
+	content string
+	link    string
+}
+
+// RunExpiryReminders 扫描并发送到期提醒
+func RunExpiryReminders() {
+	window := fmt.Sprintf("+%d hours", global.ExpiryReminderWindowHours)
+	var reminders []expiryReminder
+
+	// 1. 即将到期的科目授权
+	rows, err := global.DB.Query(`
+		SELECT us.id, us.user_id, CASE WHEN u.email_verified = 1 THEN IFNULL(u.email, '') ELSE '' END, s.id, s.name, us.expire_time, IFNULL(s.grace_days, 0)
+		FROM user_subjects us
+		JOIN subjects s ON us.subject_id = s.id
+		JOIN users u ON us.user_id = u.id
+		WHERE us.status = 1 AND s.deleted_at IS NULL AND s.creator_code != u.user_code
+		  AND us.expire_time > datetime('now') AND us.expire_time <= datetime('now', ?)`, window)
+	if err != nil {
+		global.GetLog(nil).Errorf("查询即将到期的科目授权失败: %v", err)
+	} else {
+		for rows.Next() {
+			var relID, userID, subjectID, graceDays int
+			var email, name string
+			var expire model.UTCTime
+			if err := rows.Scan(&relID, &userID, &email, &subjectID, &name, &expire, &graceDays); err != nil || !expire.Valid {
+				continue
+			}
+			content := fmt.Sprintf("您对科目「%s」的访问授权将于 %s 到期，请及时联系作者续期。", name, expire.Time.In(time.Local).Format(reminderTimeLayout))
+			if graceDays > 0 {
+				content += fmt.Sprintf("到期后 %d 天内仍可查看内容 (只读)，但不能答题。", graceDays)
+			}
+			reminders = append(reminders, expiryReminder{
+				userID: userID, email: email, kind: model.NotifySubjectExpiring,
+				refKey:  fmt.Sprintf("expiring:subject:%d:%s", relID, model.FormatDBTime(expire.Time)),
+				title:   fmt.Sprintf("科目「%s」授权即将到期", name),
+				content: content,
+				link:    fmt.Sprintf("/?subjectId=%d", subjectID),
+			})
+		}
+		rows.Close()
+	}
+
+	// 2. 已到期、处于宽限期的科目授权
+	rows, err = global.DB.Query(`
+		SELECT us.id, us.user_id, CASE WHEN u.email_verified = 1 THEN IFNULL(u.email, '') ELSE '' END, s.id, s.name, us.expire_time, s.grace_days
+		FROM user_subjects us
+		JOIN subjects s ON us.subject_id = s.id
+		JOIN users u ON us.user_id = u.id
+		WHERE us.status = 1 AND s.deleted_at IS NULL AND s.creator_code != u.user_code
+		  AND IFNULL(s.grace_days, 0) > 0
+		  AND us.expire_time <= datetime('now')
+		  AND us.expire_time > datetime('now', '-' || s.grace_days || ' days')`)
+	if err != nil {
+		global.GetLog(nil).Errorf("查询宽限期内的科目授权失败: %v", err)
+	} else {
+		for rows.Next() {
+			var relID, userID, subjectID, graceDays int
+			var email, name string
+			var expire model.UTCTime
+			if err := rows.Scan(&relID, &userID, &email, &subjectID, &name, &expire, &graceDays); err != nil || !expire.Valid {
+				continue
+			}
+			graceEnd := expire.Time.AddDate(0, 0, graceDays)
+			reminders = append(reminders, expiryReminder{
+				userID: userID, email: email, kind: model.NotifySubjectGrace,
+				refKey: fmt.Sprintf("grace:subject:%d:%s", relID, model.FormatDBTime(expire.Time)),
+				title:  fmt.Sprintf("科目「%s」授权已到期", name),
+				content: fmt.Sprintf("您对科目「%s」的访问授权已到期，%s 前仍可查看内容 (只读)，之后将无法访问。请联系作者续期。",
+					name, graceEnd.In(time.Local).Format(reminderTimeLayout)),
+				link: fmt.Sprintf("/?subjectId=%d", subjectID),
+			})
+		}
+		rows.Close()
+	}
+
+	// 3. 即将到期的集合授权
+	rows, err = global.DB.Query(`
+		SELECT cp.id, u.id, CASE WHEN u.email_verified = 1 THEN IFNULL(u.email, '') ELSE '' END, c.id, c.name, cp.expire_time
+		FROM collection_permissions cp
+		JOIN collections c ON cp.collection_id = c.id
+		JOIN users u ON cp.user_code = u.user_code
+		WHERE c.is_public = 0
+		  AND cp.expire_time > datetime('now') AND cp.expire_time <= datetime('now', ?)`, window)
+	if err != nil {
+		global.GetLog(nil).Errorf("查询即将到期的集合授权失败: %v", err)
+	} else {
+		for rows.Next() {
+			var permID, userID, collectionID int
+			var email, name string
+			var expire model.UTCTime
+			if err := rows.Scan(&permID, &userID, &email, &collectionID, &name, &expire); err != nil || !expire.Valid {
+				continue
+			}
+			reminders = append(reminders, expiryReminder{
+				userID: userID, email: email, kind: model.NotifyCollectionExpiring,
+				refKey: fmt.Sprintf("expiring:collection:%d:%s", permID, model.FormatDBTime(expire.Time)),
+				title:  fmt.Sprintf("集合「%s」授权即将到期", name),
+				content: fmt.Sprintf("您对集合「%s」的访问授权将于 %s 到期，请及时联系集合创建者续期。",
+					name, expire.Time.In(time.Local).Format(reminderTimeLayout)),
+				link: fmt.Sprintf("/collection?id=%d", collectionID),
+			})
+		}
+		rows.Close()
+	}
+
+	// 4. 写入通知；只有首次写入的提醒才发邮件，避免每次扫描重复发送
+	sent, mailed := 0, 0
+	for _, r := range reminders {
+		created, err := createNotification(r.userID, r.kind, r.title, r.content, r.link, r.refKey)
+		if err != nil {
+			global.GetLog(nil).Errorf("写入到期提醒失败 (User: %d, Ref: %s): %v", r.userID, r.refKey, err)
+			continue
+		}
+		if !created {
+			continue
+		}
+		sent++
+		if global.ExpiryReminderEmail && r.email != "" && notificationEmailEnabled(r.userID, r.kind) {
+			if err := mailer.Enqueue(r.email, mailer.TemplateNotification, map[string]interface{}{
+				"Title": r.title, "Content": r.content, "Link": global.SiteBaseURL + r.link,
+			}); err != nil {
+				global.GetLog(nil).Warnf("到期提醒邮件入队失败 (User: %d, Email: %s): %v", r.userID, r.email, err)
+				continue
+			}
+			mailed++
+		}
+	}
+	if sent > 0 {
+		global.GetLog(nil).Infof("到期提醒: 新增通知 %d 条，邮件入队 %d 封", sent, mailed)
+	}
+}
+
+// =================================================================================
+// UpdateSubjectGracePeriod 设置科目到期宽限期 (仅所有者，0 为不设宽限期)
+// =================================================================================
+func UpdateSubjectGracePeriod(c *gin.Context) {
+	subjectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+	var req model.UpdateGracePeriodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "宽限期为 0~30 天"})
+		return
+	}
+	if !checkSubjectCreator(c, subjectID, "设置宽限期") {
+		return
+	}
+
+	if _, err := global.DB.Exec("UPDATE subjects SET grace_days = ? WHERE id = ?", req.GraceDays, subjectID); err != nil {
+		global.GetLog(c).Errorf("设置宽限期失败 (SubjectID: %d): %v", subjectID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "设置失败"})
+		return
+	}
+
+	global.GetLog(c).Infof("科目[%d] 宽限期设置为 %d 天", subjectID, req.GraceDays)
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "设置成功", "data": gin.H{"graceDays": req.GraceDays}})
+}
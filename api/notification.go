@@ -0,0 +1,353 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"practice_problems/global"
+	"practice_problems/model"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==========================================
+// 站内通知：由后台任务或业务操作写入，用户在通知列表中查看
+// ref_key 非空时同一用户同一 ref_key 只写入一次 (重复触发的提醒自动去重)
+// 用户可按类型关闭站内通知 (notification_preferences.in_app = 0)，关闭后该类型不再写入，也不再发邮件
+// 新写入的通知同时通过实时推送 (push.go) 发给在线的连接
+// ==========================================
+
+// notificationAllowedSQL 用户未关闭该类型的站内通知，参数依次为 user_id、type
+const notificationAllowedSQL = `NOT EXISTS (SELECT 1 FROM notification_preferences np
+	WHERE np.user_id = ? AND np.type = ? AND np.in_app = 0)`
+
+// notifyEvent 一条业务通知
+type notifyEvent struct {
+	Type    string
+	Title   string
+	Content string
+	Link    string
+	RefKey  string // 去重键，空表示不去重
+}
+
+// notifyTarget 事务中收集、提交后再发送的通知
+type notifyTarget struct {
+	userID int
+	event  notifyEvent
+}
+
+// createNotification 写入一条通知，返回是否新写入 (ref_key 重复或用户关闭了该类型时返回 false)
+func createNotification(userID int, notifyType, title, content, link, refKey string) (bool, error) {
+	var ref interface{}
+	if refKey != "" {
+		ref = refKey
+	}
+	n := model.Notification{Type: notifyType, Title: title, Content: content, Link: link}
+	err := global.DB.QueryRow(`INSERT OR IGNORE INTO notifications (user_id, type, title, content, link, ref_key)
+		SELECT ?, ?, ?, ?, ?, ? WHERE `+notificationAllowedSQL+`
+		RETURNING id, create_time`,
+		userID, notifyType, title, content, link, ref, userID, notifyType).Scan(&n.ID, &n.CreateTime)
+	if err == sql.ErrNoRows {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	pushNotification(userID, n)
+	return true, nil
+}
+
+// notifyUsers 业务操作完成后通知相关用户，失败只记日志，不影响业务结果
+func notifyUsers(c *gin.Context, userIDs []int, ev notifyEvent) {
+	for _, uid := range userIDs {
+		if _, err := createNotification(uid, ev.Type, ev.Title, ev.Content, ev.Link, ev.RefKey); err != nil {
+			global.GetLog(c).Errorf("写入通知失败 (User: %d, Type: %s): %v", uid, ev.Type, err)
+		}
+	}
+}
+
+// notifySubjectSubscribers 通知科目的订阅者 (授权可读的用户，不含作者与操作人)
+func notifySubjectSubscribers(c *gin.Context, subjectID, operatorID int, ev notifyEvent) {
+	rows, err := global.DB.Query(`INSERT OR IGNORE INTO notifications (user_id, type, title, content, link)
+		SELECT us.user_id, ?, ?, ?, ?
+		FROM user_subjects us
+		JOIN subjects s ON us.subject_id = s.id
+		JOIN users u ON us.user_id = u.id
+		WHERE us.subject_id = ? AND us.status = 1 AND us.user_id != ? AND u.user_code != s.creator_code
+		  AND `+subscriptionReadableSQL+`
+		  AND NOT EXISTS (SELECT 1 FROM notification_preferences np
+			WHERE np.user_id = us.user_id AND np.type = ? AND np.in_app = 0)
+		RETURNING id, user_id, create_time`,
+		ev.Type, ev.Title, ev.Content, ev.Link, subjectID, operatorID, ev.Type)
+	if err != nil {
+		global.GetLog(c).Errorf("通知科目订阅者失败 (SubjectID: %d, Type: %s): %v", subjectID, ev.Type, err)
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var userID int
+		n := model.Notification{Type: ev.Type, Title: ev.Title, Content: ev.Content, Link: ev.Link}
+		if err := rows.Scan(&n.ID, &userID, &n.CreateTime); err == nil {
+			pushNotification(userID, n)
+		}
+	}
+}
+
+// userDisplayName 通知正文中展示的用户名 (昵称优先)
+func userDisplayName(userID int) string {
+	var name string
+	global.DB.QueryRow("SELECT IFNULL(NULLIF(nickname, ''), username) FROM users WHERE id = ?", userID).Scan(&name)
+	return name
+}
+
+// subjectNamesText 科目名称列表，用 "、" 连接
+func subjectNamesText(subjectIDs []int) string {
+	if len(subjectIDs) == 0 {
+		return ""
+	}
+	placeholders, args := idArgs(subjectIDs)
+	rows, err := global.DB.Query("SELECT name FROM subjects WHERE id IN ("+placeholders+") ORDER BY id", args...)
+	if err != nil {
+		return ""
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err == nil {
+			names = append(names, "「"+name+"」")
+		}
+	}
+	return strings.Join(names, "、")
+}
+
+// notificationEmailEnabled 用户是否接收该类型的邮件 (未设置时按类型默认值)
+func notificationEmailEnabled(userID int, notifyType string) bool {
+	nt, ok := model.FindNotificationType(notifyType)
+	if !ok || !nt.EmailCapable {
+		return false
+	}
+	var email sql.NullBool
+	err := global.DB.QueryRow("SELECT email FROM notification_preferences WHERE user_id = ? AND type = ?",
+		userID, notifyType).Scan(&email)
+	if err != nil || !email.Valid {
+		return nt.DefaultEmail
+	}
+	return email.Bool
+}
+
+// =================================================================================
+// GetNotifications 我的通知列表 (unread=1 只看未读，type 按类型筛选)
+// =================================================================================
+func GetNotifications(c *gin.Context) {
+	userID, _ := currentUser(c)
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	where := "user_id = ?"
+	args := []interface{}{userID}
+	if c.Query("unread") == "1" {
+		where += " AND is_read = 0"
+	}
+	if t := c.Query("type"); t != "" {
+		where += " AND type = ?"
+		args = append(args, t)
+	}
+
+	var total, unread int
+	global.DB.QueryRow("SELECT COUNT(*) FROM notifications WHERE "+where, args...).Scan(&total)
+	global.DB.QueryRow("SELECT COUNT(*) FROM notifications WHERE user_id = ? AND is_read = 0", userID).Scan(&unread)
+
+	rows, err := global.DB.Query(`
+		SELECT id, type, title, IFNULL(content, ''), IFNULL(link, ''), is_read, create_time
+		FROM notifications WHERE `+where+`
+		ORDER BY id DESC LIMIT ? OFFSET ?`, append(args, pageSize, (page-1)*pageSize)...)
+	if err != nil {
+		global.GetLog(c).Errorf("查询通知失败 (User: %d): %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+	defer rows.Close()
+
+	list := make([]model.Notification, 0)
+	for rows.Next() {
+		var n model.Notification
+		if err := rows.Scan(&n.ID, &n.Type, &n.Title, &n.Content, &n.Link, &n.IsRead, &n.CreateTime); err != nil {
+			continue
+		}
+		list = append(list, n)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "success", "data": gin.H{
+		"list": list, "total": total, "unread": unread, "page": page, "pageSize": pageSize,
+	}})
+}
+
+// =================================================================================
+// GetUnreadNotificationCount 未读通知数 (供顶部角标轮询)
+// =================================================================================
+func GetUnreadNotificationCount(c *gin.Context) {
+	userID, _ := currentUser(c)
+
+	var unread int
+	if err := global.DB.QueryRow("SELECT COUNT(*) FROM notifications WHERE user_id = ? AND is_read = 0", userID).Scan(&unread); err != nil {
+		global.GetLog(c).Errorf("查询未读通知数失败 (User: %d): %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "success", "data": gin.H{"unread": unread}})
+}
+
+// =================================================================================
+// MarkNotificationRead 标记单条通知为已读
+// =================================================================================
+func MarkNotificationRead(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+	userID, _ := currentUser(c)
+
+	var exists int
+	global.DB.QueryRow("SELECT COUNT(*) FROM notifications WHERE id = ? AND user_id = ?", id, userID).Scan(&exists)
+	if exists == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "通知不存在"})
+		return
+	}
+
+	if _, err := global.DB.Exec(`UPDATE notifications SET is_read = 1, read_time = CURRENT_TIMESTAMP
+		WHERE id = ? AND user_id = ? AND is_read = 0`, id, userID); err != nil {
+		global.GetLog(c).Errorf("标记通知已读失败 (ID: %d): %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "操作失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "已标记为已读"})
+}
+
+// =================================================================================
+// MarkAllNotificationsRead 全部标记为已读 (可用 type 限定类型)
+// =================================================================================
+func MarkAllNotificationsRead(c *gin.Context) {
+	userID, _ := currentUser(c)
+
+	sqlStr := "UPDATE notifications SET is_read = 1, read_time = CURRENT_TIMESTAMP WHERE user_id = ? AND is_read = 0"
+	args := []interface{}{userID}
+	if t := c.Query("type"); t != "" {
+		sqlStr += " AND type = ?"
+		args = append(args, t)
+	}
+
+	res, err := global.DB.Exec(sqlStr, args...)
+	if err != nil {
+		global.GetLog(c).Errorf("全部标记已读失败 (User: %d): %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "操作失败"})
+		return
+	}
+	n, _ := res.RowsAffected()
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "已全部标记为已读", "data": gin.H{"updated": n}})
+}
+
+// =================================================================================
+// GetNotificationPreferences 我的通知偏好 (所有类型，未设置的按默认值)
+// =================================================================================
+func GetNotificationPreferences(c *gin.Context) {
+	userID, _ := currentUser(c)
+
+	rows, err := global.DB.Query("SELECT type, IFNULL(in_app, 1), email FROM notification_preferences WHERE user_id = ?", userID)
+	if err != nil {
+		global.GetLog(c).Errorf("查询通知偏好失败 (User: %d): %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+	defer rows.Close()
+
+	type savedPref struct {
+		inApp bool
+		email sql.NullBool
+	}
+	saved := make(map[string]savedPref)
+	for rows.Next() {
+		var t string
+		var p savedPref
+		if err := rows.Scan(&t, &p.inApp, &p.email); err == nil {
+			saved[t] = p
+		}
+	}
+
+	list := make([]model.NotificationPreference, 0, len(model.NotificationTypes))
+	for _, nt := range model.NotificationTypes {
+		pref := model.NotificationPreference{
+			Type: nt.Type, Name: nt.Name, InApp: true, Email: nt.DefaultEmail, EmailCapable: nt.EmailCapable,
+		}
+		if p, ok := saved[nt.Type]; ok {
+			pref.InApp = p.inApp
+			if p.email.Valid {
+				pref.Email = p.email.Bool
+			}
+		}
+		if !nt.EmailCapable {
+			pref.Email = false
+		}
+		list = append(list, pref)
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "success", "data": list})
+}
+
+// =================================================================================
+// UpdateNotificationPreferences 修改通知偏好 (可批量，未传的字段保持不变)
+// =================================================================================
+func UpdateNotificationPreferences(c *gin.Context) {
+	var req model.UpdateNotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "参数错误"})
+		return
+	}
+	userID, _ := currentUser(c)
+
+	for _, item := range req.Items {
+		nt, ok := model.FindNotificationType(item.Type)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "未知的通知类型: " + item.Type})
+			return
+		}
+		if item.Email != nil && *item.Email && !nt.EmailCapable {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "该类型不支持邮件通知: " + nt.Name})
+			return
+		}
+	}
+
+	tx, err := global.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "事务开启失败"})
+		return
+	}
+	defer tx.Rollback()
+
+	for _, item := range req.Items {
+		_, err := tx.Exec(`
+			INSERT INTO notification_preferences (user_id, type, in_app, email) VALUES (?, ?, IFNULL(?, 1), ?)
+			ON CONFLICT(user_id, type) DO UPDATE SET
+				in_app = IFNULL(?, in_app), email = IFNULL(?, email), update_time = CURRENT_TIMESTAMP`,
+			userID, item.Type, item.InApp, item.Email, item.InApp, item.Email)
+		if err != nil {
+			global.GetLog(c).Errorf("保存通知偏好失败 (User: %d, Type: %s): %v", userID, item.Type, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "保存失败"})
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "保存失败"})
+		return
+	}
+
+	global.GetLog(c).Infof("用户[%d] 更新通知偏好: %d 项", userID, len(req.Items))
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "保存成功"})
+}
@@ -0,0 +1,243 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"net/url"
+	"practice_problems/global"
+	"practice_problems/mailer"
+	"practice_problems/model"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ==========================================
+// 自助找回密码
+// 1. 申请重置：邮箱方式把重置链接发到已验证的邮箱；TOTP 方式校验动态码后直接返回重置令牌
+// 2. 确认重置：凭一次性令牌设置新密码，成功后作废该用户所有登录 Token 与其余重置令牌
+// 申请接口按账号与 IP 限流；无论账号是否存在，邮箱方式都返回相同的提示，避免被用来探测账号
+// ==========================================
+
+// 找回密码方式
+const (
+	resetMethodEmail = "email"
+	resetMethodTotp  = "totp"
+)
+
+// resetRateWindow 限流统计窗口
+const resetRateWindow = time.Hour
+
+// resetGuard 找回密码限流记录 (内存，与 bindGuard 一致，服务重启后清零)
+var resetGuard = struct {
+	sync.Mutex
+	Data map[string][]time.Time
+}{
+	Data: make(map[string][]time.Time),
+}
+
+// acquireResetAttempt 滑动窗口限流，任一 key 超过上限即拒绝并返回需要等待的时长；允许时计数
+func acquireResetAttempt(limits map[string]int) (bool, time.Duration) {
+	resetGuard.Lock()
+	defer resetGuard.Unlock()
+
+	now := time.Now()
+	cutoff := now.Add(-resetRateWindow)
+	if len(resetGuard.Data) > 10000 {
+		for k, times := range resetGuard.Data {
+			if len(pruneTimes(times, cutoff)) == 0 {
+				delete(resetGuard.Data, k)
+			}
+		}
+	}
+
+	var wait time.Duration
+	for k, limit := range limits {
+		times := pruneTimes(resetGuard.Data[k], cutoff)
+		resetGuard.Data[k] = times
+		if len(times) >= limit {
+			wait = max(wait, times[0].Add(resetRateWindow).Sub(now))
+		}
+	}
+	if wait > 0 {
+		return false, wait
+	}
+	for k := range limits {
+		resetGuard.Data[k] = append(resetGuard.Data[k], now)
+	}
+	return true, 0
+}
+
+// =================================================================================
+// RequestPasswordReset 申请重置密码 (公开接口)
+// =================================================================================
+func RequestPasswordReset(c *gin.Context) {
+	var req model.PasswordResetRequestReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "参数错误"})
+		return
+	}
+	req.Username = strings.TrimSpace(req.Username)
+	if req.Method == "" {
+		req.Method = resetMethodEmail
+	}
+	if req.Method != resetMethodEmail && req.Method != resetMethodTotp {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "不支持的找回方式"})
+		return
+	}
+
+	if ok, wait := acquireResetAttempt(map[string]int{
+		"account:" + strings.ToLower(req.Username): global.PasswordResetAccountPerHour,
+		"ip:" + c.ClientIP():                       global.PasswordResetIPPerHour,
+	}); !ok {
+		global.GetLog(c).Warnf("找回密码被限流 (Username: %s, IP: %s)", req.Username, c.ClientIP())
+		respondBindLocked(c, wait)
+		return
+	}
+
+	var userID, status int
+	var email string
+	var emailVerified bool
+	err := global.DB.QueryRow(`SELECT id, IFNULL(status, 0), IFNULL(email, ''), IFNULL(email_verified, 0) FROM users WHERE username = ?`,
+		req.Username).Scan(&userID, &status, &email, &emailVerified)
+	if err != nil && err != sql.ErrNoRows {
+		global.GetLog(c).Errorf("找回密码查询用户失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "系统错误"})
+		return
+	}
+	found := err == nil && status == 0
+	ttl := time.Duration(global.PasswordResetTokenMinutes) * time.Minute
+
+	if req.Method == resetMethodTotp {
+		// TOTP 方式需要用户已绑定动态码；错误信息不区分账号不存在与验证码错误
+		if !found || VerifyTotpForOperation(userID, strings.TrimSpace(req.TotpCode)) != nil {
+			global.GetLog(c).Warnf("找回密码 TOTP 校验失败 (Username: %s, IP: %s)", req.Username, c.ClientIP())
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "账号或动态验证码错误"})
+			return
+		}
+		token, err := issueEmailToken(userID, tokenPurposeResetPassword, "", ttl)
+		if err != nil {
+			global.GetLog(c).Errorf("签发重置令牌失败 (User: %d): %v", userID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "系统错误"})
+			return
+		}
+		global.GetLog(c).Infof("用户[%d] 通过 TOTP 申请重置密码", userID)
+		c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "验证通过，请设置新密码", "data": gin.H{
+			"reset_token": token, "expire_minutes": global.PasswordResetTokenMinutes,
+		}})
+		return
+	}
+
+	// 邮箱方式：只发往已验证的邮箱，结果统一返回
+	if found && email != "" && emailVerified {
+		token, err := issueEmailToken(userID, tokenPurposeResetPassword, email, ttl)
+		if err == nil {
+			err = mailer.Enqueue(email, mailer.TemplatePasswordReset, map[string]interface{}{
+				"Name":          userDisplayName(userID),
+				"Link":          global.SiteBaseURL + "/login?reset_token=" + url.QueryEscape(token),
+				"ExpireMinutes": global.PasswordResetTokenMinutes,
+			})
+		}
+		if err != nil {
+			global.GetLog(c).Errorf("发送重置密码邮件失败 (User: %d): %v", userID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "系统错误"})
+			return
+		}
+		global.GetLog(c).Infof("用户[%d] 申请重置密码，邮件已入队", userID)
+	} else {
+		global.GetLog(c).Infof("找回密码未发送邮件 (Username: %s, 存在: %v, 已验证邮箱: %v)", req.Username, found, emailVerified)
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "如果该账号已绑定并验证邮箱，重置邮件已发送，请查收"})
+}
+
+// =================================================================================
+// ConfirmPasswordReset 凭重置令牌设置新密码 (公开接口)
+// =================================================================================
+func ConfirmPasswordReset(c *gin.Context) {
+	var req model.PasswordResetConfirmReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "参数错误"})
+		return
+	}
+
+	if ok, wait := acquireResetAttempt(map[string]int{
+		"confirm-ip:" + c.ClientIP(): global.PasswordResetIPPerHour,
+	}); !ok {
+		respondBindLocked(c, wait)
+		return
+	}
+
+	// 与注册、修改密码一致：前端 MD5 -> 后端 MD5 -> Bcrypt
+	hash, err := bcrypt.GenerateFromPassword([]byte(md5V(req.NewPassword)), bcrypt.DefaultCost)
+	if err != nil {
+		global.GetLog(c).Errorf("重置密码加密失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "系统错误"})
+		return
+	}
+
+	// 使用令牌与修改密码放在同一事务：任何一步失败都回滚，令牌不会被白白用掉
+	tx, err := global.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "系统错误"})
+		return
+	}
+	defer tx.Rollback()
+
+	userID, tokenEmail, err := consumeEmailToken(tx, tokenPurposeResetPassword, req.Token)
+	if err == errInvalidEmailToken {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "重置链接无效或已过期，请重新申请"})
+		return
+	} else if err != nil {
+		global.GetLog(c).Errorf("校验重置令牌失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "系统错误"})
+		return
+	}
+
+	var userCode, email string
+	var status int
+	if err := tx.QueryRow("SELECT user_code, IFNULL(email, ''), IFNULL(status, 0) FROM users WHERE id = ?", userID).
+		Scan(&userCode, &email, &status); err != nil || status != 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "账号不存在或已被禁用"})
+		return
+	}
+	// 通过邮件签发的令牌，签发后邮箱又被修改过则作废
+	if tokenEmail != "" && tokenEmail != email {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "重置链接无效或已过期，请重新申请"})
+		return
+	}
+
+	if _, err := tx.Exec("UPDATE users SET password = ? WHERE id = ?", string(hash), userID); err != nil {
+		global.GetLog(c).Errorf("重置密码写入失败 (User: %d): %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "重置失败"})
+		return
+	}
+	if _, err := tx.Exec(`UPDATE email_tokens SET used_time = CURRENT_TIMESTAMP
+		WHERE user_id = ? AND purpose = ? AND used_time IS NULL`, userID, tokenPurposeResetPassword); err != nil {
+		global.GetLog(c).Errorf("作废重置令牌失败 (User: %d): %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "重置失败"})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "重置失败"})
+		return
+	}
+
+	// 所有已登录的会话失效，需要用新密码重新登录；已建立的推送与 AI 面试长连接一并断开
+	global.ClearUserTokens(userCode)
+	disconnectUserConnections(userID)
+
+	if tokenEmail != "" {
+		if err := mailer.Enqueue(tokenEmail, mailer.TemplateNotification, map[string]interface{}{
+			"Title":   "您的密码已重置",
+			"Content": "您的账号密码刚刚通过找回密码功能完成重置，所有设备已退出登录。如果这不是您本人的操作，请立即联系管理员。",
+		}); err != nil {
+			global.GetLog(c).Warnf("密码重置通知邮件入队失败 (User: %d): %v", userID, err)
+		}
+	}
+
+	global.GetLog(c).Infof("用户[%s] 重置密码成功，已清除全部登录 Token", userCode)
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "密码已重置，请使用新密码登录"})
+}
@@ -35,7 +35,7 @@ func GetPointList(c *gin.Context) {
 		SELECT 1 
 		FROM knowledge_categories c
 		JOIN user_subjects us ON c.subject_id = us.subject_id
-		WHERE c.id = ? AND us.user_id = ?
+		WHERE c.id = ? AND c.deleted_at IS NULL AND us.user_id = ?
 	`
 	err := global.DB.QueryRow(checkPermSQL, catID, userID).Scan(&hasPerm)
 	if err != nil || hasPerm != 1 {
@@ -56,7 +56,7 @@ func GetPointList(c *gin.Context) {
 
 	// 查询总数
 	var total int
-	countErr := global.DB.QueryRow("SELECT COUNT(*) FROM knowledge_points WHERE categorie_id = ?", catID).Scan(&total)
+	countErr := global.DB.QueryRow("SELECT COUNT(*) FROM knowledge_points WHERE categorie_id = ? AND deleted_at IS NULL", catID).Scan(&total)
 	if countErr != nil {
 		global.GetLog(c).Errorf("查询知识点总数失败: %v", countErr)
 		c.JSON(500, gin.H{"code": 500, "msg": "查询失败"})
@@ -64,7 +64,7 @@ func GetPointList(c *gin.Context) {
 	}
 
 	// 分页查询
-	sqlStr := "SELECT id, title, create_time, sort_order, difficulty FROM knowledge_points WHERE categorie_id = ? ORDER BY sort_order ASC, id DESC LIMIT ? OFFSET ?"
+	sqlStr := "SELECT id, title, create_time, sort_order, difficulty FROM knowledge_points WHERE categorie_id = ? AND deleted_at IS NULL ORDER BY sort_order ASC, id DESC LIMIT ? OFFSET ?"
 
 	rows, err := global.DB.Query(sqlStr, catID, pageSize, offset)
 	if err != nil {
@@ -113,19 +113,23 @@ func GetPointList(c *gin.Context) {
 // getPointDetailData 统一的知识点详情获取逻辑（内部函数）
 // 用于主页和集合页面共享数据获取逻辑
 // =================================================================================
+// pointDetail 知识点详情 (version 用于乐观锁，修改时需回传)
+type pointDetail struct {
+	ID              int    `json:"id"`
+	CategoryID      int    `json:"categoryId"`
+	Title           string `json:"title"`
+	Content         string `json:"content"`
+	ReferenceLinks  string `json:"referenceLinks"`
+	LocalImageNames string `json:"localImageNames"`
+	UpdateTime      string `json:"updateTime"`
+	VideoUrl        string `json:"videoUrl"`
+	Difficulty      int    `json:"difficulty"`
+	Version         int    `json:"version"`
+}
+
 func getPointDetailData(pointID int) (gin.H, error) {
 	// 1. 获取知识点详情
-	var point struct {
-		ID              int    `json:"id"`
-		CategoryID      int    `json:"categoryId"`
-		Title           string `json:"title"`
-		Content         string `json:"content"`
-		ReferenceLinks  string `json:"referenceLinks"`
-		LocalImageNames string `json:"localImageNames"`
-		UpdateTime      string `json:"updateTime"`
-		VideoUrl        string `json:"videoUrl"`
-		Difficulty      int    `json:"difficulty"`
-	}
+	var point pointDetail
 
 	sqlStr := `
 		SELECT 
@@ -134,9 +138,10 @@ func getPointDetailData(pointID int) (gin.H, error) {
 			COALESCE(local_image_names, '[]') as local_image_names,
 			update_time,
 			COALESCE(video_url, '[]') as video_url,
-			COALESCE(difficulty, 0) as difficulty
+			COALESCE(difficulty, 0) as difficulty,
+			version
 		FROM knowledge_points 
-		WHERE id = ?
+		WHERE id = ? AND deleted_at IS NULL
 	`
 
 	err := global.DB.QueryRow(sqlStr, pointID).Scan(
@@ -149,6 +154,7 @@ func getPointDetailData(pointID int) (gin.H, error) {
 		&point.UpdateTime,
 		&point.VideoUrl,
 		&point.Difficulty,
+		&point.Version,
 	)
 
 	if err != nil {
@@ -162,13 +168,14 @@ func getPointDetailData(pointID int) (gin.H, error) {
 			pb.bind_text,
 			pb.target_point_id,
 			pb.target_subject_id,
+			IFNULL(pb.status, 0),
 			c.id as target_category_id,
 			p.title as target_point_title,
 			c.categorie_name as target_category_name
 		FROM point_bindings pb
 		JOIN knowledge_points p ON pb.target_point_id = p.id
 		JOIN knowledge_categories c ON p.categorie_id = c.id
-		WHERE pb.source_point_id = ?
+		WHERE pb.source_point_id = ? AND p.deleted_at IS NULL
 		ORDER BY pb.create_time DESC
 	`
 
@@ -184,9 +191,9 @@ func getPointDetailData(pointID int) (gin.H, error) {
 
 	var bindings []gin.H
 	for bindingRows.Next() {
-		var id, targetPointID, targetSubjectID, targetCategoryID int
+		var id, targetPointID, targetSubjectID, status, targetCategoryID int
 		var bindText, targetPointTitle, targetCategoryName string
-		err := bindingRows.Scan(&id, &bindText, &targetPointID, &targetSubjectID, &targetCategoryID, &targetPointTitle, &targetCategoryName)
+		err := bindingRows.Scan(&id, &bindText, &targetPointID, &targetSubjectID, &status, &targetCategoryID, &targetPointTitle, &targetCategoryName)
 		if err == nil {
 			bindings = append(bindings, gin.H{
 				"id":                 id,
@@ -196,6 +203,7 @@ func getPointDetailData(pointID int) (gin.H, error) {
 				"targetCategoryId":   targetCategoryID,
 				"targetPointTitle":   targetPointTitle,
 				"targetCategoryName": targetCategoryName,
+				"status":             status, // 1: 失效，前端不应高亮
 			})
 		}
 	}
@@ -230,7 +238,7 @@ func GetPointDetail(c *gin.Context) {
 		FROM knowledge_points p
 		JOIN knowledge_categories c ON p.categorie_id = c.id
 		JOIN user_subjects us ON c.subject_id = us.subject_id
-		WHERE p.id = ? AND us.user_id = ?
+		WHERE p.id = ? AND p.deleted_at IS NULL AND us.user_id = ?
 	`
 	err = global.DB.QueryRow(checkPermSQL, id, userID).Scan(&hasPerm)
 	if err != nil || hasPerm != 1 {
@@ -250,6 +258,9 @@ func GetPointDetail(c *gin.Context) {
 		return
 	}
 
+	if point, ok := data["point"].(pointDetail); ok {
+		setVersionHeader(c, point.Version)
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"code": 200,
 		"msg":  "success",
@@ -272,27 +283,28 @@ func CreatePoint(c *gin.Context) {
 
 	// --- 权限检查 ---
 	var subjectCreatorCode string
+	var permSubjectID int
 	var creatorName string
 	var creatorEmail sql.NullString
 
 	checkSQL := `
-		SELECT s.creator_code, IFNULL(u.nickname, u.username), u.email
+		SELECT s.creator_code, s.id, IFNULL(u.nickname, u.username), u.email
 		FROM knowledge_categories c
 		JOIN subjects s ON c.subject_id = s.id
 		LEFT JOIN users u ON s.creator_code = u.user_code
-		WHERE c.id = ?
+		WHERE c.id = ? AND c.deleted_at IS NULL
 	`
-	err := global.DB.QueryRow(checkSQL, req.CategoryID).Scan(&subjectCreatorCode, &creatorName, &creatorEmail)
+	err := global.DB.QueryRow(checkSQL, req.CategoryID).Scan(&subjectCreatorCode, &permSubjectID, &creatorName, &creatorEmail)
 	if err != nil {
 		c.JSON(404, gin.H{"code": 404, "msg": "所属分类不存在"})
 		return
 	}
 
-	if subjectCreatorCode != currentUserCodeStr {
+	if !hasSubjectRole(permSubjectID, subjectCreatorCode, currentUserCodeStr, RoleEditor) {
 		// ★★★ Warn ★★★
 		global.GetLog(c).Warnf("创建知识点被拒: 无权操作 (User: %s, CatID: %d)", currentUserCodeStr, req.CategoryID)
 		contactInfo := getContactInfo(creatorName, creatorEmail)
-		c.JSON(403, gin.H{"code": 403, "msg": "创建失败：您不是该科目的作者，请联系 " + contactInfo})
+		c.JSON(403, gin.H{"code": 403, "msg": "创建失败：您没有该科目的编辑权限，请联系 " + contactInfo})
 		return
 	}
 
@@ -300,7 +312,7 @@ func CreatePoint(c *gin.Context) {
 
 	// 1. 获取当前分类下已有的知识点数量，用于生成序号
 	var count int
-	err = global.DB.QueryRow("SELECT COUNT(*) FROM knowledge_points WHERE categorie_id = ?", req.CategoryID).Scan(&count)
+	err = global.DB.QueryRow("SELECT COUNT(*) FROM knowledge_points WHERE categorie_id = ? AND deleted_at IS NULL", req.CategoryID).Scan(&count)
 	if err != nil {
 		global.GetLog(c).Errorf("统计知识点数量失败: %v", err)
 		c.JSON(500, gin.H{"code": 500, "msg": "系统错误"})
@@ -313,7 +325,7 @@ func CreatePoint(c *gin.Context) {
 
 	// 3. 计算排序值 (保留你原有的逻辑，如果你希望按序号正序排，这里可能需要调整，暂保持原样)
 	var currentMin int
-	row := global.DB.QueryRow("SELECT COALESCE(MIN(sort_order), 0) FROM knowledge_points WHERE categorie_id = ?", req.CategoryID)
+	row := global.DB.QueryRow("SELECT COALESCE(MIN(sort_order), 0) FROM knowledge_points WHERE categorie_id = ? AND deleted_at IS NULL", req.CategoryID)
 	row.Scan(&currentMin)
 	newSortOrder := currentMin - 1
 
@@ -332,45 +344,11 @@ func CreatePoint(c *gin.Context) {
 
 	id, _ := res.LastInsertId()
 	// ★★★ Info ★★★
+	pushContentChange(c, permSubjectID, "point", id, "create")
 	global.GetLog(c).Infof("用户[%s] 创建知识点成功: ID=%d, Title=%s", currentUserCodeStr, id, newTitle)
 	c.JSON(200, gin.H{"code": 200, "msg": "创建成功", "data": gin.H{"id": id, "title": newTitle}})
 }
 
-// =================================================================================
-// cleanupOrphanedBindings 清理内容中不再存在的绑定文本
-// =================================================================================
-func cleanupOrphanedBindings(c *gin.Context, pointID string, newContent string) {
-	// 查询该知识点的所有绑定
-	rows, err := global.DB.Query("SELECT id, bind_text FROM point_bindings WHERE source_point_id = ?", pointID)
-	if err != nil {
-		global.GetLog(c).Warnf("查询绑定列表失败: %v", err)
-		return
-	}
-	defer rows.Close()
-
-	var toDelete []int
-	for rows.Next() {
-		var bindID int
-		var bindText string
-		if rows.Scan(&bindID, &bindText) == nil {
-			// 检查绑定文本是否还存在于新内容中
-			if !strings.Contains(newContent, bindText) {
-				toDelete = append(toDelete, bindID)
-			}
-		}
-	}
-
-	// 删除不再匹配的绑定
-	for _, bindID := range toDelete {
-		_, err := global.DB.Exec("DELETE FROM point_bindings WHERE id = ?", bindID)
-		if err != nil {
-			global.GetLog(c).Warnf("删除孤立绑定失败 (ID: %d): %v", bindID, err)
-		} else {
-			global.GetLog(c).Infof("自动清理不匹配的绑定 (ID: %d, PointID: %s)", bindID, pointID)
-		}
-	}
-}
-
 // =================================================================================
 // UpdatePoint 修改知识点
 // =================================================================================
@@ -390,40 +368,52 @@ func UpdatePoint(c *gin.Context) {
 	var currentSubjectId int
 	var currentTitle string   // 新增：当前数据库中的标题
 	var currentCategoryId int // 新增：当前数据库中的分类ID
+	var currentVersion int
 	var creatorName string
 	var creatorEmail sql.NullString
 
 	// 修改 SQL：多查了 p.title 和 p.categorie_id
 	checkSQL := `
-		SELECT s.creator_code, s.id, p.title, p.categorie_id, IFNULL(u.nickname, u.username), u.email
+		SELECT s.creator_code, s.id, p.title, p.categorie_id, p.version, IFNULL(u.nickname, u.username), u.email
 		FROM knowledge_points p
 		JOIN knowledge_categories c ON p.categorie_id = c.id
 		JOIN subjects s ON c.subject_id = s.id
 		LEFT JOIN users u ON s.creator_code = u.user_code
-		WHERE p.id = ?
+		WHERE p.id = ? AND p.deleted_at IS NULL
 	`
 	// Scan 增加变量接收
-	err := global.DB.QueryRow(checkSQL, id).Scan(&subjectCreatorCode, &currentSubjectId, &currentTitle, &currentCategoryId, &creatorName, &creatorEmail)
+	err := global.DB.QueryRow(checkSQL, id).Scan(&subjectCreatorCode, &currentSubjectId, &currentTitle, &currentCategoryId, &currentVersion, &creatorName, &creatorEmail)
 	if err != nil {
 		c.JSON(404, gin.H{"code": 404, "msg": "知识点不存在"})
 		return
 	}
 
-	if subjectCreatorCode != currentUserCodeStr {
+	if !hasSubjectRole(currentSubjectId, subjectCreatorCode, currentUserCodeStr, RoleEditor) {
 		global.GetLog(c).Warnf("修改知识点被拒: 无权操作 (User: %s, PointID: %s)", currentUserCodeStr, id)
 		contactInfo := getContactInfo(creatorName, creatorEmail)
 		c.JSON(403, gin.H{"code": 403, "msg": "修改失败：请联系科目作者 " + contactInfo})
 		return
 	}
 
+	// --- 乐观锁：版本不一致说明已被他人修改 ---
+	clientVersion, ok := resolveEditVersion(c, req.Version, currentVersion)
+	if !ok {
+		return
+	}
+	if clientVersion != currentVersion {
+		global.GetLog(c).Warnf("修改知识点版本冲突 (User: %s, PointID: %s, Client: %d, Server: %d)", currentUserCodeStr, id, clientVersion, currentVersion)
+		respondPointConflict(c, id)
+		return
+	}
+
 	// --- 核心修改：检查并准备 SQL 更新 ---
-	query := "UPDATE knowledge_points SET update_time = CURRENT_TIMESTAMP"
+	query := "UPDATE knowledge_points SET update_time = CURRENT_TIMESTAMP, version = version + 1"
 	var args []interface{}
 
 	// 1. 处理分类移动逻辑
 	if req.CategoryID != nil && *req.CategoryID > 0 {
 		var targetSubjectId int
-		err := global.DB.QueryRow("SELECT subject_id FROM knowledge_categories WHERE id = ?", *req.CategoryID).Scan(&targetSubjectId)
+		err := global.DB.QueryRow("SELECT subject_id FROM knowledge_categories WHERE id = ? AND deleted_at IS NULL", *req.CategoryID).Scan(&targetSubjectId)
 		if err != nil {
 			if err == sql.ErrNoRows {
 				c.JSON(400, gin.H{"code": 400, "msg": "目标分类不存在"})
@@ -478,7 +468,7 @@ func UpdatePoint(c *gin.Context) {
 			// 逻辑：统计当前分类下有多少个知识点（包含自己），作为序号。
 			// 注意：因为自己已经在数据库里了，所以 COUNT(*) 是包含自己的。
 			var count int
-			global.DB.QueryRow("SELECT COUNT(*) FROM knowledge_points WHERE categorie_id = ?", currentCategoryId).Scan(&count)
+			global.DB.QueryRow("SELECT COUNT(*) FROM knowledge_points WHERE categorie_id = ? AND deleted_at IS NULL", currentCategoryId).Scan(&count)
 
 			// 如果 count 是 5，即生成 "5. 新标题"
 			finalTitle = fmt.Sprintf("%d. %s", count, cleanUserTitle)
@@ -521,22 +511,78 @@ func UpdatePoint(c *gin.Context) {
 		return
 	}
 
-	query += " WHERE id = ?"
-	args = append(args, id)
+	query += " WHERE id = ? AND version = ?"
+	args = append(args, id, clientVersion)
+
+	// 更新、绑定清理与修订记录放在同一事务中
+	tx, err := global.DB.Begin()
+	if err != nil {
+		global.GetLog(c).Errorf("开启事务失败: %v", err)
+		c.JSON(500, gin.H{"code": 500, "msg": "系统错误"})
+		return
+	}
+	defer tx.Rollback()
+
+	pointID, _ := strconv.Atoi(id)
+	// 首次修改前先保存原始内容，保证能回滚到最初版本
+	if err := ensureInitialRevisionTx(tx, pointID); err != nil {
+		global.GetLog(c).Errorf("记录知识点原始版本失败 (ID: %s): %v", id, err)
+		c.JSON(500, gin.H{"code": 500, "msg": "更新失败"})
+		return
+	}
 
-	_, err = global.DB.Exec(query, args...)
+	res, err := tx.Exec(query, args...)
 	if err != nil {
 		global.GetLog(c).Errorf("更新知识点DB错误 (ID: %s): %v", id, err)
 		c.JSON(500, gin.H{"code": 500, "msg": "更新失败"})
 		return
 	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		// 校验之后、写入之前被他人抢先保存
+		tx.Rollback()
+		respondPointConflict(c, id)
+		return
+	}
 
+	// 内容变化后重新定位绑定，无法定位的标记为失效 (记入本次修订)
+	var broken []model.PointBinding
 	if req.Content != "" {
-		cleanupOrphanedBindings(c, id, req.Content)
+		broken, _, err = reanchorBindings(c, tx, id, req.Content)
+		if err != nil {
+			global.GetLog(c).Errorf("重新定位绑定失败 (ID: %s): %v", id, err)
+			c.JSON(500, gin.H{"code": 500, "msg": "更新失败"})
+			return
+		}
+	}
+
+	if err := recordPointRevisionTx(tx, pointID, model.RevisionActionUpdate, 0, broken, currentUserCodeStr); err != nil {
+		global.GetLog(c).Errorf("记录知识点修订失败 (ID: %s): %v", id, err)
+		c.JSON(500, gin.H{"code": 500, "msg": "更新失败"})
+		return
 	}
 
+	if err := tx.Commit(); err != nil {
+		global.GetLog(c).Errorf("提交知识点更新失败 (ID: %s): %v", id, err)
+		c.JSON(500, gin.H{"code": 500, "msg": "更新失败"})
+		return
+	}
+
+	pushContentChange(c, currentSubjectId, "point", int64(pointID), "update")
 	global.GetLog(c).Infof("用户[%s] 更新知识点成功 (ID: %s)", currentUserCodeStr, id)
-	c.JSON(200, gin.H{"code": 200, "msg": "更新成功"})
+	setVersionHeader(c, clientVersion+1)
+	c.JSON(200, gin.H{"code": 200, "msg": "更新成功", "data": gin.H{"version": clientVersion + 1, "brokenBindings": len(broken)}})
+}
+
+// respondPointConflict 知识点版本冲突，附带服务器当前内容
+func respondPointConflict(c *gin.Context, id string) {
+	pointID, _ := strconv.Atoi(id)
+	data, err := getPointDetailData(pointID)
+	if err != nil {
+		c.JSON(404, gin.H{"code": 404, "msg": "知识点不存在"})
+		return
+	}
+	point, _ := data["point"].(pointDetail)
+	respondVersionConflict(c, point.Version, data)
 }
 
 // =================================================================================
@@ -549,44 +595,41 @@ func DeletePoint(c *gin.Context) {
 
 	// --- 权限检查 ---
 	var subjectCreatorCode string
+	var permSubjectID int
 	var creatorName string
 	var creatorEmail sql.NullString
 
 	checkSQL := `
-		SELECT s.creator_code, IFNULL(u.nickname, u.username), u.email
+		SELECT s.creator_code, s.id, IFNULL(u.nickname, u.username), u.email
 		FROM knowledge_points p
 		JOIN knowledge_categories c ON p.categorie_id = c.id
 		JOIN subjects s ON c.subject_id = s.id
 		LEFT JOIN users u ON s.creator_code = u.user_code
-		WHERE p.id = ?
+		WHERE p.id = ? AND p.deleted_at IS NULL
 	`
-	err := global.DB.QueryRow(checkSQL, id).Scan(&subjectCreatorCode, &creatorName, &creatorEmail)
+	err := global.DB.QueryRow(checkSQL, id).Scan(&subjectCreatorCode, &permSubjectID, &creatorName, &creatorEmail)
 	if err != nil {
 		c.JSON(404, gin.H{"code": 404, "msg": "知识点不存在"})
 		return
 	}
 
-	if subjectCreatorCode != currentUserCodeStr {
+	if !hasSubjectRole(permSubjectID, subjectCreatorCode, currentUserCodeStr, RoleEditor) {
 		global.GetLog(c).Warnf("删除知识点被拒: 无权操作 (User: %s, PointID: %s)", currentUserCodeStr, id)
 		contactInfo := getContactInfo(creatorName, creatorEmail)
 		c.JSON(403, gin.H{"code": 403, "msg": "删除失败：请联系科目作者 " + contactInfo})
 		return
 	}
 
-	// --- 执行删除 ---
-	_, err = global.DB.Exec("DELETE FROM knowledge_points WHERE id = ?", id)
-
+	// --- 移入回收站 (知识点下的题目一并软删除) ---
+	pointID, _ := strconv.Atoi(id)
+	err = softDelete(model.TrashTypePoint, pointID, currentUserCodeStr)
 	if err != nil {
-		if strings.Contains(err.Error(), "FOREIGN KEY constraint failed") {
-			global.GetLog(c).Infof("删除知识点失败(外键约束): ID=%s", id)
-			c.JSON(500, gin.H{"code": 500, "msg": "删除失败：该知识点下仍有题目，请先删除题目"})
-			return
-		}
 		global.GetLog(c).Errorf("删除知识点DB错误 (ID: %s): %v", id, err)
 		c.JSON(500, gin.H{"code": 500, "msg": "删除失败"})
 		return
 	}
 
+	pushContentChange(c, permSubjectID, "point", int64(pointID), "delete")
 	global.GetLog(c).Infof("用户[%s] 删除知识点成功 (ID: %s)", currentUserCodeStr, id)
 	c.JSON(200, gin.H{"code": 200, "msg": "删除成功"})
 }
@@ -613,27 +656,28 @@ func UpdatePointSort(c *gin.Context) {
 
 	// --- 权限检查 ---
 	var subjectCreatorCode string
+	var permSubjectID int
 	var creatorName string
 	var creatorEmail sql.NullString
 	var currentCategoryID int
 	var currentSortOrder int
 
 	checkSQL := `
-		SELECT s.creator_code, IFNULL(u.nickname, u.username), u.email, p.categorie_id, p.sort_order
+		SELECT s.creator_code, s.id, IFNULL(u.nickname, u.username), u.email, p.categorie_id, p.sort_order
 		FROM knowledge_points p
 		JOIN knowledge_categories c ON p.categorie_id = c.id
 		JOIN subjects s ON c.subject_id = s.id
 		LEFT JOIN users u ON s.creator_code = u.user_code
-		WHERE p.id = ?
+		WHERE p.id = ? AND p.deleted_at IS NULL
 	`
-	err := global.DB.QueryRow(checkSQL, id).Scan(&subjectCreatorCode, &creatorName, &creatorEmail, &currentCategoryID, &currentSortOrder)
+	err := global.DB.QueryRow(checkSQL, id).Scan(&subjectCreatorCode, &permSubjectID, &creatorName, &creatorEmail, &currentCategoryID, &currentSortOrder)
 
 	if err != nil {
 		c.JSON(404, gin.H{"code": 404, "msg": "知识点不存在"})
 		return
 	}
 
-	if subjectCreatorCode != currentUserCodeStr {
+	if !hasSubjectRole(permSubjectID, subjectCreatorCode, currentUserCodeStr, RoleEditor) {
 		global.GetLog(c).Warnf("知识点排序被拒: 无权操作 (User: %s, PointID: %d)", currentUserCodeStr, id)
 		contactInfo := getContactInfo(creatorName, creatorEmail)
 		c.JSON(403, gin.H{"code": 403, "msg": "排序失败：请联系科目作者 " + contactInfo})
@@ -650,12 +694,12 @@ func UpdatePointSort(c *gin.Context) {
 
 	if req.Action == "top" {
 		var minSort int
-		tx.QueryRow("SELECT COALESCE(MIN(sort_order), 0) FROM knowledge_points WHERE categorie_id = ?", currentCategoryID).Scan(&minSort)
+		tx.QueryRow("SELECT COALESCE(MIN(sort_order), 0) FROM knowledge_points WHERE categorie_id = ? AND deleted_at IS NULL", currentCategoryID).Scan(&minSort)
 		tx.Exec("UPDATE knowledge_points SET sort_order = ? WHERE id = ?", minSort-1, id)
 
 	} else if req.Action == "up" {
 		var prevID, prevSort int
-		err = tx.QueryRow("SELECT id, sort_order FROM knowledge_points WHERE categorie_id = ? AND sort_order < ? ORDER BY sort_order DESC LIMIT 1", currentCategoryID, currentSortOrder).Scan(&prevID, &prevSort)
+		err = tx.QueryRow("SELECT id, sort_order FROM knowledge_points WHERE categorie_id = ? AND deleted_at IS NULL AND sort_order < ? ORDER BY sort_order DESC LIMIT 1", currentCategoryID, currentSortOrder).Scan(&prevID, &prevSort)
 		if err == nil {
 			tx.Exec("UPDATE knowledge_points SET sort_order = ? WHERE id = ?", prevSort, id)
 			tx.Exec("UPDATE knowledge_points SET sort_order = ? WHERE id = ?", currentSortOrder, prevID)
@@ -663,7 +707,7 @@ func UpdatePointSort(c *gin.Context) {
 
 	} else if req.Action == "down" {
 		var nextID, nextSort int
-		err = tx.QueryRow("SELECT id, sort_order FROM knowledge_points WHERE categorie_id = ? AND sort_order > ? ORDER BY sort_order ASC LIMIT 1", currentCategoryID, currentSortOrder).Scan(&nextID, &nextSort)
+		err = tx.QueryRow("SELECT id, sort_order FROM knowledge_points WHERE categorie_id = ? AND deleted_at IS NULL AND sort_order > ? ORDER BY sort_order ASC LIMIT 1", currentCategoryID, currentSortOrder).Scan(&nextID, &nextSort)
 		if err == nil {
 			tx.Exec("UPDATE knowledge_points SET sort_order = ? WHERE id = ?", nextSort, id)
 			tx.Exec("UPDATE knowledge_points SET sort_order = ? WHERE id = ?", currentSortOrder, nextID)
@@ -676,6 +720,7 @@ func UpdatePointSort(c *gin.Context) {
 		return
 	}
 
+	pushContentChange(c, permSubjectID, "point", int64(id), "sort")
 	global.GetLog(c).Infof("用户[%s] 排序知识点成功 (ID: %d, Action: %s)", currentUserCodeStr, id, req.Action)
 	c.JSON(200, gin.H{"code": 200, "msg": "排序成功"})
 }
@@ -719,7 +764,7 @@ func SearchPoints(c *gin.Context) {
 		FROM knowledge_points p
 		INNER JOIN knowledge_categories c ON p.categorie_id = c.id
 		INNER JOIN subjects s ON c.subject_id = s.id
-		WHERE p.title LIKE ?
+		WHERE p.title LIKE ? AND p.deleted_at IS NULL
 		  AND s.id IN (SELECT subject_id FROM user_subjects WHERE user_id = ?)
 		ORDER BY s.name, c.categorie_name, p.id DESC
 		LIMIT 50
@@ -787,20 +832,21 @@ func DeletePointImage(c *gin.Context) {
 
 	// --- 权限检查 ---
 	var subjectCreatorCode string
+	var permSubjectID int
 	checkSQL := `
-		SELECT s.creator_code
+		SELECT s.creator_code, s.id
 		FROM knowledge_points p
 		JOIN knowledge_categories c ON p.categorie_id = c.id
 		JOIN subjects s ON c.subject_id = s.id
-		WHERE p.id = ?
+		WHERE p.id = ? AND p.deleted_at IS NULL
 	`
-	err := global.DB.QueryRow(checkSQL, id).Scan(&subjectCreatorCode)
+	err := global.DB.QueryRow(checkSQL, id).Scan(&subjectCreatorCode, &permSubjectID)
 	if err != nil {
 		c.JSON(404, gin.H{"code": 404, "msg": "知识点不存在"})
 		return
 	}
 
-	if subjectCreatorCode != currentUserCodeStr {
+	if !hasSubjectRole(permSubjectID, subjectCreatorCode, currentUserCodeStr, RoleEditor) {
 		global.GetLog(c).Warnf("删除图片被拒: 无权操作 (User: %s, PointID: %s)", currentUserCodeStr, id)
 		c.JSON(403, gin.H{"code": 403, "msg": "无权删除图片"})
 		return
@@ -842,16 +888,15 @@ func DeletePointImage(c *gin.Context) {
 
 	newJsonBytes, _ := json.Marshal(newImages)
 
-	_, err = global.DB.Exec("UPDATE knowledge_points SET local_image_names = ? WHERE id = ?", string(newJsonBytes), id)
+	// 文件保留给历史修订回滚使用，无引用后由回收站清理任务删除
+	pointID, _ := strconv.Atoi(id)
+	version, err := updatePointImages(pointID, string(newJsonBytes), currentUserCodeStr)
 	if err != nil {
 		global.GetLog(c).Errorf("删除图片更新DB失败: %v", err)
 		c.JSON(500, gin.H{"code": 500, "msg": "数据库更新失败"})
 		return
 	}
 
-	diskPath := strings.TrimPrefix(req.FilePath, "/")
-	RemoveFileFromDisk(diskPath)
-
 	global.GetLog(c).Infof("用户[%s] 删除图片成功: %s", currentUserCodeStr, req.FilePath)
-	c.JSON(200, gin.H{"code": 200, "msg": "图片删除成功"})
+	c.JSON(200, gin.H{"code": 200, "msg": "图片删除成功", "data": gin.H{"version": version}})
 }
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"database/sql"
 	"net/http"
 	"practice_problems/global"
 	"practice_problems/model"
@@ -24,30 +25,39 @@ func CreateBinding(c *gin.Context) {
 
 	// --- 权限校验：检查当前用户是否是源知识点的作者 ---
 	var subjectCreatorCode string
+	var permSubjectID int
+	var sourceContent sql.NullString
 	checkSQL := `
-		SELECT s.creator_code
+		SELECT s.creator_code, s.id, p.content
 		FROM knowledge_points p
 		JOIN knowledge_categories c ON p.categorie_id = c.id
 		JOIN subjects s ON c.subject_id = s.id
-		WHERE p.id = ?
+		WHERE p.id = ? AND p.deleted_at IS NULL
 	`
-	err := global.DB.QueryRow(checkSQL, req.SourcePointID).Scan(&subjectCreatorCode)
+	err := global.DB.QueryRow(checkSQL, req.SourcePointID).Scan(&subjectCreatorCode, &permSubjectID, &sourceContent)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "知识点不存在"})
 		return
 	}
 
-	if subjectCreatorCode != currentUserCodeStr {
-		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "绑定失败：您不是该科目的作者，无权绑定"})
+	if !hasSubjectRole(permSubjectID, subjectCreatorCode, currentUserCodeStr, RoleEditor) {
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "绑定失败：您没有该科目的编辑权限，无权绑定"})
 		return
 	}
 
-	// 检查是否已存在相同绑定
+	// 生成锚点：出现序号 + 前后文，用于内容修改后重新定位 (内容中暂未找到时按请求值保存)
+	occurrence := 0
+	if req.Occurrence != nil {
+		occurrence = *req.Occurrence
+	}
+	anchor, _ := newAnchor(sourceContent.String, req.BindText, occurrence, req.AnchorPrefix, req.AnchorSuffix)
+
+	// 检查是否已存在相同绑定 (同一段文字的同一处)
 	var count int
 	err = global.DB.QueryRow(`
 		SELECT COUNT(*) FROM point_bindings 
-		WHERE source_point_id = ? AND target_point_id = ? AND bind_text = ?
-	`, req.SourcePointID, req.TargetPointID, req.BindText).Scan(&count)
+		WHERE source_point_id = ? AND target_point_id = ? AND bind_text = ? AND IFNULL(occurrence, 0) = ?
+	`, req.SourcePointID, req.TargetPointID, anchor.Text, anchor.Occurrence).Scan(&count)
 	if err == nil && count > 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "该绑定已存在"})
 		return
@@ -55,9 +65,11 @@ func CreateBinding(c *gin.Context) {
 
 	// 插入绑定
 	result, err := global.DB.Exec(`
-		INSERT INTO point_bindings (source_subject_id, source_point_id, target_subject_id, target_point_id, bind_text, user_id)
-		VALUES (?, ?, ?, ?, ?, ?)
-	`, req.SourceSubjectID, req.SourcePointID, req.TargetSubjectID, req.TargetPointID, req.BindText, userID)
+		INSERT INTO point_bindings (source_subject_id, source_point_id, target_subject_id, target_point_id, bind_text, user_id,
+			occurrence, anchor_prefix, anchor_suffix)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, req.SourceSubjectID, req.SourcePointID, req.TargetSubjectID, req.TargetPointID, anchor.Text, userID,
+		anchor.Occurrence, anchor.Prefix, anchor.Suffix)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "创建绑定失败: " + err.Error()})
 		return
@@ -75,6 +87,7 @@ func GetBindingsByPoint(c *gin.Context) {
 		SELECT 
 			pb.id, pb.source_subject_id, pb.source_point_id, pb.target_subject_id, pb.target_point_id, 
 			pb.bind_text, pb.user_id, pb.create_time,
+			IFNULL(pb.occurrence, 0), IFNULL(pb.anchor_prefix, ''), IFNULL(pb.anchor_suffix, ''), IFNULL(pb.status, 0), pb.broken_time,
 			COALESCE(ss.name, '') as source_subject_name,
 			COALESCE(sp.title, '') as source_point_title,
 			COALESCE(ts.name, '') as target_subject_name,
@@ -84,7 +97,7 @@ func GetBindingsByPoint(c *gin.Context) {
 		LEFT JOIN knowledge_points sp ON pb.source_point_id = sp.id
 		LEFT JOIN subjects ts ON pb.target_subject_id = ts.id
 		LEFT JOIN knowledge_points tp ON pb.target_point_id = tp.id
-		WHERE pb.source_point_id = ?
+		WHERE pb.source_point_id = ? AND tp.deleted_at IS NULL
 		ORDER BY pb.create_time DESC
 	`, pointID)
 	if err != nil {
@@ -96,14 +109,20 @@ func GetBindingsByPoint(c *gin.Context) {
 	var bindings []model.BindingWithDetails
 	for rows.Next() {
 		var b model.BindingWithDetails
+		var brokenTime sql.NullString
 		err := rows.Scan(
 			&b.ID, &b.SourceSubjectID, &b.SourcePointID, &b.TargetSubjectID, &b.TargetPointID,
 			&b.BindText, &b.UserID, &b.CreateTime,
+			&b.Occurrence, &b.AnchorPrefix, &b.AnchorSuffix, &b.Status, &brokenTime,
 			&b.SourceSubjectName, &b.SourcePointTitle, &b.TargetSubjectName, &b.TargetPointTitle,
 		)
 		if err != nil {
 			continue
 		}
+		if brokenTime.Valid {
+			t := formatTimeStr(brokenTime.String)
+			b.BrokenTime = &t
+		}
 		bindings = append(bindings, b)
 	}
 
@@ -124,22 +143,23 @@ func DeleteBinding(c *gin.Context) {
 
 	// --- 权限校验：检查当前用户是否是源知识点的作者 ---
 	var subjectCreatorCode string
+	var permSubjectID int
 	checkSQL := `
-		SELECT s.creator_code
+		SELECT s.creator_code, s.id
 		FROM point_bindings pb
 		JOIN knowledge_points p ON pb.source_point_id = p.id
 		JOIN knowledge_categories c ON p.categorie_id = c.id
 		JOIN subjects s ON c.subject_id = s.id
 		WHERE pb.id = ?
 	`
-	err = global.DB.QueryRow(checkSQL, id).Scan(&subjectCreatorCode)
+	err = global.DB.QueryRow(checkSQL, id).Scan(&subjectCreatorCode, &permSubjectID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "绑定不存在"})
 		return
 	}
 
-	if subjectCreatorCode != currentUserCodeStr {
-		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "删除失败：您不是该科目的作者，无权删除"})
+	if !hasSubjectRole(permSubjectID, subjectCreatorCode, currentUserCodeStr, RoleEditor) {
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "删除失败：您没有该科目的编辑权限，无权删除"})
 		return
 	}
 
@@ -156,7 +176,7 @@ func DeleteBinding(c *gin.Context) {
 func GetCategoriesBySubjectForBinding(c *gin.Context) {
 	subjectID := c.Param("subjectId")
 
-	rows, err := global.DB.Query("SELECT id, categorie_name FROM knowledge_categories WHERE subject_id = ? ORDER BY sort_order", subjectID)
+	rows, err := global.DB.Query("SELECT id, categorie_name, parent_id FROM knowledge_categories WHERE subject_id = ? AND deleted_at IS NULL ORDER BY sort_order", subjectID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
 		return
@@ -167,8 +187,9 @@ func GetCategoriesBySubjectForBinding(c *gin.Context) {
 	for rows.Next() {
 		var id int
 		var name string
-		if err := rows.Scan(&id, &name); err == nil {
-			categories = append(categories, gin.H{"id": id, "name": name})
+		var parentID *int
+		if err := rows.Scan(&id, &name, &parentID); err == nil {
+			categories = append(categories, gin.H{"id": id, "name": name, "parentId": parentID})
 		}
 	}
 
@@ -181,7 +202,7 @@ func GetPointsByCategoryForBinding(c *gin.Context) {
 
 	rows, err := global.DB.Query(`
 		SELECT id, title FROM knowledge_points 
-		WHERE categorie_id = ?
+		WHERE categorie_id = ? AND deleted_at IS NULL
 		ORDER BY sort_order
 	`, categoryID)
 	if err != nil {
@@ -0,0 +1,467 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"practice_problems/global"
+	"practice_problems/model"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==========================================
+// 知识图谱：反向链接、科目图谱、N 跳邻域与悬空绑定检测
+// 绑定可以跨科目，另一端所在科目当前用户无权访问时只计数不返回
+// ==========================================
+
+const (
+	graphMaxNodes   = 300 // 单次图谱返回的节点上限
+	graphMaxDepth   = 3   // 邻域查询的最大跳数
+	graphEdgeFields = `pb.id, pb.source_point_id, pb.target_point_id, pb.bind_text, IFNULL(pb.status, 0)`
+)
+
+// readableSubjects 查询用户可访问的科目 (创建者或有效授权)
+func readableSubjects(userID int, userCode string) (map[int]bool, error) {
+	rows, err := global.DB.Query(`
+		SELECT id FROM subjects
+		WHERE deleted_at IS NULL
+		  AND (
+		      creator_code = ?
+		      OR id IN (
+		          SELECT us.subject_id FROM user_subjects us
+		          WHERE us.user_id = ? AND us.status = 1 AND `+subscriptionReadableSQL+`
+		      )
+		  )`, userCode, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	readable := make(map[int]bool)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err == nil {
+			readable[id] = true
+		}
+	}
+	return readable, rows.Err()
+}
+
+// idArgs 生成 IN 子句的占位符与参数
+func idArgs(ids []int) (string, []interface{}) {
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return strings.Trim(strings.Repeat("?,", len(ids)), ","), args
+}
+
+// loadGraphNodes 批量读取未删除的知识点节点
+func loadGraphNodes(ids []int) (map[int]model.GraphNode, error) {
+	nodes := make(map[int]model.GraphNode, len(ids))
+	if len(ids) == 0 {
+		return nodes, nil
+	}
+	placeholders, args := idArgs(ids)
+	rows, err := global.DB.Query(fmt.Sprintf(`
+		SELECT p.id, p.title, c.id, c.categorie_name, s.id, s.name
+		FROM knowledge_points p
+		JOIN knowledge_categories c ON p.categorie_id = c.id
+		JOIN subjects s ON c.subject_id = s.id
+		WHERE p.id IN (%s) AND p.deleted_at IS NULL`, placeholders), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var n model.GraphNode
+		if err := rows.Scan(&n.ID, &n.Title, &n.CategoryID, &n.CategoryName, &n.SubjectID, &n.SubjectName); err == nil {
+			nodes[n.ID] = n
+		}
+	}
+	return nodes, rows.Err()
+}
+
+// queryGraphEdges 按条件查询绑定边
+func queryGraphEdges(where string, args ...interface{}) ([]model.GraphEdge, error) {
+	rows, err := global.DB.Query(`SELECT `+graphEdgeFields+`
+		FROM point_bindings pb
+		LEFT JOIN knowledge_points sp ON pb.source_point_id = sp.id
+		LEFT JOIN knowledge_categories sc ON sp.categorie_id = sc.id
+		LEFT JOIN knowledge_points tp ON pb.target_point_id = tp.id
+		LEFT JOIN knowledge_categories tc ON tp.categorie_id = tc.id
+		WHERE `+where+`
+		ORDER BY pb.id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var edges []model.GraphEdge
+	for rows.Next() {
+		var e model.GraphEdge
+		if err := rows.Scan(&e.ID, &e.Source, &e.Target, &e.BindText, &e.Status); err == nil {
+			edges = append(edges, e)
+		}
+	}
+	return edges, rows.Err()
+}
+
+// currentUser 读取当前登录用户 ID 与编码
+func currentUser(c *gin.Context) (int, string) {
+	userIDVal, _ := c.Get("userID")
+	userID, _ := userIDVal.(int)
+	userCodeVal, _ := c.Get("userCode")
+	userCode, _ := userCodeVal.(string)
+	return userID, userCode
+}
+
+// =================================================================================
+// GetPointBacklinks 反向链接：哪些知识点绑定到了当前知识点
+// =================================================================================
+func GetPointBacklinks(c *gin.Context) {
+	pointID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+	userID, userCode := currentUser(c)
+
+	readable, err := readableSubjects(userID, userCode)
+	if err != nil {
+		global.GetLog(c).Errorf("查询可访问科目失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+	center, err := loadGraphNodes([]int{pointID})
+	if err != nil {
+		global.GetLog(c).Errorf("查询知识点失败 (PointID: %d): %v", pointID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+	node, ok := center[pointID]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "知识点不存在"})
+		return
+	}
+	if !readable[node.SubjectID] {
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "无权访问该知识点"})
+		return
+	}
+
+	rows, err := global.DB.Query(`
+		SELECT pb.id, sp.id, sp.title, sc.id, sc.categorie_name, ss.id, ss.name,
+			pb.bind_text, IFNULL(pb.status, 0), pb.create_time
+		FROM point_bindings pb
+		JOIN knowledge_points sp ON pb.source_point_id = sp.id
+		JOIN knowledge_categories sc ON sp.categorie_id = sc.id
+		JOIN subjects ss ON sc.subject_id = ss.id
+		WHERE pb.target_point_id = ? AND sp.deleted_at IS NULL
+		ORDER BY pb.create_time DESC, pb.id DESC`, pointID)
+	if err != nil {
+		global.GetLog(c).Errorf("查询反向链接失败 (PointID: %d): %v", pointID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+	defer rows.Close()
+
+	list := make([]model.PointBacklink, 0)
+	hidden := 0
+	for rows.Next() {
+		var b model.PointBacklink
+		if err := rows.Scan(&b.BindingID, &b.SourcePointID, &b.SourcePointTitle, &b.SourceCategoryID, &b.SourceCategoryName,
+			&b.SourceSubjectID, &b.SourceSubjectName, &b.BindText, &b.Status, &b.CreateTime); err != nil {
+			continue
+		}
+		if !readable[b.SourceSubjectID] {
+			hidden++
+			continue
+		}
+		b.CreateTime = formatTimeStr(b.CreateTime)
+		list = append(list, b)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "success", "data": gin.H{"list": list, "total": len(list), "hidden": hidden}})
+}
+
+// =================================================================================
+// GetSubjectGraph 科目知识图谱：科目内全部知识点 + 跨科目绑定的另一端
+// =================================================================================
+func GetSubjectGraph(c *gin.Context) {
+	subjectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+	userID, userCode := currentUser(c)
+
+	readable, err := readableSubjects(userID, userCode)
+	if err != nil {
+		global.GetLog(c).Errorf("查询可访问科目失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+	if !readable[subjectID] {
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "科目不存在或无权访问"})
+		return
+	}
+
+	graph := model.KnowledgeGraph{Nodes: make([]model.GraphNode, 0), Edges: make([]model.GraphEdge, 0)}
+	included := make(map[int]bool)
+
+	rows, err := global.DB.Query(`
+		SELECT p.id, p.title, c.id, c.categorie_name, s.id, s.name
+		FROM knowledge_points p
+		JOIN knowledge_categories c ON p.categorie_id = c.id
+		JOIN subjects s ON c.subject_id = s.id
+		WHERE s.id = ? AND p.deleted_at IS NULL
+		ORDER BY c.sort_order, p.sort_order, p.id`, subjectID)
+	if err != nil {
+		global.GetLog(c).Errorf("查询科目知识点失败 (SubjectID: %d): %v", subjectID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+	for rows.Next() {
+		var n model.GraphNode
+		if err := rows.Scan(&n.ID, &n.Title, &n.CategoryID, &n.CategoryName, &n.SubjectID, &n.SubjectName); err != nil {
+			continue
+		}
+		if len(graph.Nodes) >= graphMaxNodes {
+			graph.Truncated = true
+			continue
+		}
+		graph.Nodes = append(graph.Nodes, n)
+		included[n.ID] = true
+	}
+	rows.Close()
+
+	edges, err := queryGraphEdges("(sc.subject_id = ? OR tc.subject_id = ?)", subjectID, subjectID)
+	if err != nil {
+		global.GetLog(c).Errorf("查询科目绑定失败 (SubjectID: %d): %v", subjectID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+
+	// 补齐跨科目的另一端
+	var externalIDs []int
+	for _, e := range edges {
+		for _, id := range []int{e.Source, e.Target} {
+			if !included[id] {
+				externalIDs = append(externalIDs, id)
+			}
+		}
+	}
+	external, err := loadGraphNodes(externalIDs)
+	if err != nil {
+		global.GetLog(c).Errorf("查询跨科目知识点失败 (SubjectID: %d): %v", subjectID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+
+	for _, e := range edges {
+		visible := true
+		for _, id := range []int{e.Source, e.Target} {
+			if included[id] {
+				continue
+			}
+			n, ok := external[id]
+			switch {
+			case !ok:
+				graph.Dangling++
+			case !readable[n.SubjectID]:
+				graph.HiddenEdges++
+			case n.SubjectID == subjectID || len(graph.Nodes) >= graphMaxNodes:
+				// 本科目中被截断的节点，或已达上限
+				graph.Truncated = true
+			default:
+				n.External = true
+				graph.Nodes = append(graph.Nodes, n)
+				included[id] = true
+				continue
+			}
+			visible = false
+			break
+		}
+		if visible {
+			graph.Edges = append(graph.Edges, e)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "success", "data": graph})
+}
+
+// =================================================================================
+// GetPointGraph 知识点 N 跳邻域 (双向沿绑定扩展，depth 默认 1，最大 3)
+// =================================================================================
+func GetPointGraph(c *gin.Context) {
+	pointID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+	depth, _ := strconv.Atoi(c.DefaultQuery("depth", "1"))
+	if depth < 1 {
+		depth = 1
+	}
+	if depth > graphMaxDepth {
+		depth = graphMaxDepth
+	}
+	userID, userCode := currentUser(c)
+
+	readable, err := readableSubjects(userID, userCode)
+	if err != nil {
+		global.GetLog(c).Errorf("查询可访问科目失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+	center, err := loadGraphNodes([]int{pointID})
+	if err != nil {
+		global.GetLog(c).Errorf("查询知识点失败 (PointID: %d): %v", pointID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+	root, ok := center[pointID]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "知识点不存在"})
+		return
+	}
+	if !readable[root.SubjectID] {
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "无权访问该知识点"})
+		return
+	}
+
+	graph := model.KnowledgeGraph{Nodes: []model.GraphNode{root}, Edges: make([]model.GraphEdge, 0)}
+	visited := map[int]bool{pointID: true}
+	seenEdges := make(map[int]bool)
+	frontier := []int{pointID}
+
+	// 逐层扩展；最后一层只补边不再加点，保证同层节点之间的绑定也能返回
+	for d := 1; d <= depth+1 && len(frontier) > 0; d++ {
+		placeholders, args := idArgs(frontier)
+		edges, err := queryGraphEdges(fmt.Sprintf("(pb.source_point_id IN (%s) OR pb.target_point_id IN (%s))", placeholders, placeholders),
+			append(args, args...)...)
+		if err != nil {
+			global.GetLog(c).Errorf("查询知识点邻域失败 (PointID: %d): %v", pointID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+			return
+		}
+
+		var candidates []int
+		for _, e := range edges {
+			for _, id := range []int{e.Source, e.Target} {
+				if !visited[id] {
+					candidates = append(candidates, id)
+				}
+			}
+		}
+		loaded, err := loadGraphNodes(candidates)
+		if err != nil {
+			global.GetLog(c).Errorf("查询邻域知识点失败 (PointID: %d): %v", pointID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+			return
+		}
+
+		var next []int
+		for _, e := range edges {
+			if seenEdges[e.ID] {
+				continue
+			}
+			visible := true
+			for _, id := range []int{e.Source, e.Target} {
+				if visited[id] {
+					continue
+				}
+				n, ok := loaded[id]
+				switch {
+				case !ok:
+					graph.Dangling++
+				case !readable[n.SubjectID]:
+					graph.HiddenEdges++
+				case d > depth:
+					// 超出查询深度，留给下一次展开
+				case len(graph.Nodes) >= graphMaxNodes:
+					graph.Truncated = true
+				default:
+					n.Depth = d
+					n.External = n.SubjectID != root.SubjectID
+					graph.Nodes = append(graph.Nodes, n)
+					visited[id] = true
+					next = append(next, id)
+					continue
+				}
+				visible = false
+				break
+			}
+			// 每条边只处理一次，避免两端都在前沿时重复返回或重复计数
+			seenEdges[e.ID] = true
+			if visible {
+				graph.Edges = append(graph.Edges, e)
+			}
+		}
+		frontier = next
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "success", "data": graph})
+}
+
+// =================================================================================
+// GetDanglingBindings 悬空绑定检测：科目内知识点绑定的目标已被删除 (编辑者及以上)
+// =================================================================================
+func GetDanglingBindings(c *gin.Context) {
+	subjectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+	_, userCode := currentUser(c)
+
+	var creatorCode string
+	if err := global.DB.QueryRow("SELECT creator_code FROM subjects WHERE id = ? AND deleted_at IS NULL", subjectID).Scan(&creatorCode); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "科目不存在"})
+		return
+	}
+	if !hasSubjectRole(subjectID, creatorCode, userCode, RoleEditor) {
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "无权查看该科目的绑定报告"})
+		return
+	}
+
+	// 删除科目/分类会级联软删除其下知识点，只需检查目标知识点本身
+	rows, err := global.DB.Query(`
+		SELECT pb.id, sp.id, sp.title, sc.id, sc.categorie_name,
+			pb.target_subject_id, pb.target_point_id, COALESCE(tp.title, ''),
+			pb.bind_text, tp.id IS NULL, pb.create_time
+		FROM point_bindings pb
+		JOIN knowledge_points sp ON pb.source_point_id = sp.id
+		JOIN knowledge_categories sc ON sp.categorie_id = sc.id
+		LEFT JOIN knowledge_points tp ON pb.target_point_id = tp.id
+		WHERE sc.subject_id = ? AND sp.deleted_at IS NULL
+		  AND (tp.id IS NULL OR tp.deleted_at IS NOT NULL)
+		ORDER BY pb.id DESC`, subjectID)
+	if err != nil {
+		global.GetLog(c).Errorf("查询悬空绑定失败 (SubjectID: %d): %v", subjectID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+	defer rows.Close()
+
+	list := make([]model.DanglingBinding, 0)
+	for rows.Next() {
+		var b model.DanglingBinding
+		var missing bool
+		if err := rows.Scan(&b.ID, &b.SourcePointID, &b.SourcePointTitle, &b.SourceCategoryID, &b.SourceCategoryName,
+			&b.TargetSubjectID, &b.TargetPointID, &b.TargetPointTitle, &b.BindText, &missing, &b.CreateTime); err != nil {
+			continue
+		}
+		b.Reason = model.DanglingReasonDeleted
+		if missing {
+			b.Reason = model.DanglingReasonMissing
+		}
+		b.CreateTime = formatTimeStr(b.CreateTime)
+		list = append(list, b)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "success", "data": gin.H{"list": list, "total": len(list)}})
+}
@@ -101,7 +101,7 @@ func SavePointNote(c *gin.Context) {
 
 	// 检查知识点是否存在
 	var pointExists bool
-	err = global.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM knowledge_points WHERE id = ?)", pointID).Scan(&pointExists)
+	err = global.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM knowledge_points WHERE id = ? AND deleted_at IS NULL)", pointID).Scan(&pointExists)
 	if err != nil {
 		global.GetLog(c).Errorf("检查知识点是否存在失败: %v", err)
 		c.JSON(500, gin.H{"code": 500, "msg": "检查知识点失败"})
@@ -0,0 +1,537 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"practice_problems/global"
+	"practice_problems/model"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==========================================
+// 知识点关系：前置 / 延伸 / 相关
+// 前置与延伸都表示 "先学 from 再学 to"，构成有向无环图，新增时做环检测；
+// 学习路径按拓扑序输出，推荐下一个知识点时结合用户的练习掌握情况
+// ==========================================
+
+const (
+	masteryMinAnswered = 3   // 判定掌握至少需要作答的题数 (题目不足时按全部题目)
+	masteryMinRate     = 0.8 // 判定掌握的最低正确率
+)
+
+// orderingRelation 是否为决定学习顺序的关系类型
+func orderingRelation(relationType string) bool {
+	return relationType == model.RelationPrerequisite || relationType == model.RelationExtends
+}
+
+// findOrderingPath 沿前置/延伸关系查找 start 到 goal 的路径，不存在时返回 nil
+// 回收站中的知识点也参与检查，避免恢复后形成环
+func findOrderingPath(start, goal int) ([]int, error) {
+	parent := map[int]int{start: start}
+	queue := []int{start}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		if cur == goal {
+			path := []int{goal}
+			for path[0] != start {
+				path = append([]int{parent[path[0]]}, path...)
+			}
+			return path, nil
+		}
+
+		rows, err := global.DB.Query(`SELECT to_point_id FROM point_relations
+			WHERE from_point_id = ? AND relation_type IN (?, ?)`, cur, model.RelationPrerequisite, model.RelationExtends)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var next int
+			if err := rows.Scan(&next); err != nil {
+				continue
+			}
+			if _, ok := parent[next]; !ok {
+				parent[next] = cur
+				queue = append(queue, next)
+			}
+		}
+		rows.Close()
+	}
+	return nil, nil
+}
+
+// =================================================================================
+// CreatePointRelation 创建知识点关系 (源知识点所在科目编辑者及以上，且可访问目标知识点)
+// =================================================================================
+func CreatePointRelation(c *gin.Context) {
+	var req model.CreatePointRelationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "参数错误: " + err.Error()})
+		return
+	}
+	if req.FromPointID == req.ToPointID {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "不能与自身建立关系"})
+		return
+	}
+	if !checkPointRole(c, req.FromPointID, RoleEditor, "创建知识点关系") {
+		return
+	}
+
+	userID, userCode := currentUser(c)
+	readable, err := readableSubjects(userID, userCode)
+	if err != nil {
+		global.GetLog(c).Errorf("查询可访问科目失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "创建失败"})
+		return
+	}
+	targets, err := loadGraphNodes([]int{req.ToPointID})
+	if err != nil {
+		global.GetLog(c).Errorf("查询目标知识点失败 (PointID: %d): %v", req.ToPointID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "创建失败"})
+		return
+	}
+	target, ok := targets[req.ToPointID]
+	if !ok || !readable[target.SubjectID] {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "目标知识点不存在或无权访问"})
+		return
+	}
+
+	from, to := req.FromPointID, req.ToPointID
+	if req.RelationType == model.RelationRelated && from > to {
+		// 相关关系无方向，统一按 from < to 存储，便于去重
+		from, to = to, from
+	}
+
+	var exists int
+	global.DB.QueryRow(`SELECT COUNT(*) FROM point_relations WHERE from_point_id = ? AND to_point_id = ? AND relation_type = ?`,
+		from, to, req.RelationType).Scan(&exists)
+	if exists > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "该关系已存在"})
+		return
+	}
+
+	// 环检测：若已能从 to 沿学习顺序走到 from，再加 from -> to 就会成环
+	if orderingRelation(req.RelationType) {
+		path, err := findOrderingPath(to, from)
+		if err != nil {
+			global.GetLog(c).Errorf("知识点关系环检测失败: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "创建失败"})
+			return
+		}
+		if path != nil {
+			cycle := append([]int{from}, path...)
+			global.GetLog(c).Warnf("创建知识点关系被拒: 形成环 %v (User: %s)", cycle, userCode)
+			c.JSON(http.StatusConflict, gin.H{"code": 409, "msg": "该关系会形成循环依赖", "data": gin.H{"cycle": cycle}})
+			return
+		}
+	}
+
+	// owner_point_id 记录本次校验编辑权限的知识点，删除时按它鉴权 (related 关系可能已交换方向)
+	res, err := global.DB.Exec(`INSERT INTO point_relations (from_point_id, to_point_id, relation_type, note, creator_code, owner_point_id)
+		VALUES (?, ?, ?, ?, ?, ?)`, from, to, req.RelationType, req.Note, userCode, req.FromPointID)
+	if err != nil {
+		global.GetLog(c).Errorf("创建知识点关系失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "创建失败"})
+		return
+	}
+	id, _ := res.LastInsertId()
+	global.GetLog(c).Infof("创建知识点关系成功 (ID: %d, %d -%s-> %d)", id, from, req.RelationType, to)
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "创建成功", "data": gin.H{"id": id}})
+}
+
+// =================================================================================
+// DeletePointRelation 删除知识点关系
+// =================================================================================
+func DeletePointRelation(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+
+	var fromPointID, toPointID int
+	var ownerPointID sql.NullInt64
+	if err := global.DB.QueryRow("SELECT from_point_id, to_point_id, owner_point_id FROM point_relations WHERE id = ?", id).
+		Scan(&fromPointID, &toPointID, &ownerPointID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "关系不存在"})
+		return
+	}
+	if ownerPointID.Valid {
+		if !checkPointRole(c, int(ownerPointID.Int64), RoleEditor, "删除知识点关系") {
+			return
+		}
+	} else {
+		// 旧数据未记录授权端：任一端知识点的编辑者均可删除
+		_, userCode := currentUser(c)
+		if !hasPointRole(toPointID, userCode, RoleEditor) && !checkPointRole(c, fromPointID, RoleEditor, "删除知识点关系") {
+			return
+		}
+	}
+
+	if _, err := global.DB.Exec("DELETE FROM point_relations WHERE id = ?", id); err != nil {
+		global.GetLog(c).Errorf("删除知识点关系失败 (ID: %d): %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "删除失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "删除成功"})
+}
+
+// =================================================================================
+// GetPointRelations 知识点的全部关系 (出与入)，另一端无权访问时只计数
+// =================================================================================
+func GetPointRelations(c *gin.Context) {
+	pointID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+	userID, userCode := currentUser(c)
+
+	readable, err := readableSubjects(userID, userCode)
+	if err != nil {
+		global.GetLog(c).Errorf("查询可访问科目失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+	center, err := loadGraphNodes([]int{pointID})
+	if err != nil {
+		global.GetLog(c).Errorf("查询知识点失败 (PointID: %d): %v", pointID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+	if node, ok := center[pointID]; !ok || !readable[node.SubjectID] {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "知识点不存在或无权访问"})
+		return
+	}
+
+	rows, err := global.DB.Query(`
+		SELECT r.id, r.from_point_id, r.to_point_id, r.relation_type, IFNULL(r.note, ''), IFNULL(r.creator_code, ''), r.create_time,
+			o.id, o.title, oc.subject_id
+		FROM point_relations r
+		JOIN knowledge_points o ON o.id = CASE WHEN r.from_point_id = ? THEN r.to_point_id ELSE r.from_point_id END
+		JOIN knowledge_categories oc ON o.categorie_id = oc.id
+		WHERE (r.from_point_id = ? OR r.to_point_id = ?) AND o.deleted_at IS NULL
+		ORDER BY r.relation_type, r.id`, pointID, pointID, pointID)
+	if err != nil {
+		global.GetLog(c).Errorf("查询知识点关系失败 (PointID: %d): %v", pointID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+	defer rows.Close()
+
+	list := make([]model.PointRelationDetail, 0)
+	hidden := 0
+	for rows.Next() {
+		var r model.PointRelationDetail
+		if err := rows.Scan(&r.ID, &r.FromPointID, &r.ToPointID, &r.RelationType, &r.Note, &r.CreatorCode, &r.CreateTime,
+			&r.OtherPointID, &r.OtherPointTitle, &r.OtherSubjectID); err != nil {
+			continue
+		}
+		if !readable[r.OtherSubjectID] {
+			hidden++
+			continue
+		}
+		r.Direction = "outgoing"
+		if r.ToPointID == pointID {
+			r.Direction = "incoming"
+		}
+		r.CreateTime = formatTimeStr(r.CreateTime)
+		list = append(list, r)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "success", "data": gin.H{"list": list, "total": len(list), "hidden": hidden}})
+}
+
+// pathScope 解析学习路径的范围 (科目，可选分类)，并校验访问权限；失败时直接写回响应
+// 同时返回当前用户可访问的科目，用于过滤范围外的前置知识点
+func pathScope(c *gin.Context) (subjectID int, categoryID int, readable map[int]bool, ok bool) {
+	subjectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return 0, 0, nil, false
+	}
+	if s := c.Query("categoryId"); s != "" {
+		if categoryID, err = strconv.Atoi(s); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "分类ID参数错误"})
+			return 0, 0, nil, false
+		}
+	}
+
+	userID, userCode := currentUser(c)
+	readable, err = readableSubjects(userID, userCode)
+	if err != nil {
+		global.GetLog(c).Errorf("查询可访问科目失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return 0, 0, nil, false
+	}
+	if !readable[subjectID] {
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "科目不存在或无权访问"})
+		return 0, 0, nil, false
+	}
+	return subjectID, categoryID, readable, true
+}
+
+// buildLearningPath 按前置关系对范围内的知识点做拓扑排序 (指定分类时包含其子分类)
+// 无依赖关系的知识点保持目录 (分类树先序)、知识点原有的排序；残留环中的知识点排在最后并标记
+// 范围外的前置知识点只保留 readable 中科目的，学习者看不到的知识点既不展示也不阻塞学习
+func buildLearningPath(subjectID, categoryID int, readable map[int]bool) ([]model.LearningPathNode, error) {
+	query := `
+		SELECT p.id, p.title, c.id, c.categorie_name
+		FROM knowledge_points p
+		JOIN knowledge_categories c ON p.categorie_id = c.id
+		WHERE c.subject_id = ? AND p.deleted_at IS NULL`
+	args := []interface{}{subjectID}
+	if categoryID > 0 {
+		query += " AND c.id IN (" + descendantCategoriesSQL + ")"
+		args = append(args, categoryID)
+	}
+	query += " ORDER BY p.sort_order, p.id"
+
+	rows, err := global.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	var nodes []*model.LearningPathNode
+	index := make(map[int]int) // pointID -> 原排序位置
+	for rows.Next() {
+		n := &model.LearningPathNode{Prerequisites: make([]int, 0), External: make([]int, 0)}
+		if err := rows.Scan(&n.PointID, &n.Title, &n.CategoryID, &n.CategoryName); err != nil {
+			continue
+		}
+		nodes = append(nodes, n)
+	}
+	rows.Close()
+	if len(nodes) == 0 {
+		return []model.LearningPathNode{}, nil
+	}
+
+	preorder, err := categoryPreorder(subjectID)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return preorder[nodes[i].CategoryID] < preorder[nodes[j].CategoryID]
+	})
+	for i, n := range nodes {
+		index[n.PointID] = i
+	}
+
+	ids := make([]int, len(nodes))
+	for i, n := range nodes {
+		ids[i] = n.PointID
+	}
+	placeholders, idList := idArgs(ids)
+	rows, err = global.DB.Query(fmt.Sprintf(`
+		SELECT DISTINCT r.from_point_id, r.to_point_id, fc.subject_id
+		FROM point_relations r
+		JOIN knowledge_points fp ON r.from_point_id = fp.id
+		JOIN knowledge_categories fc ON fp.categorie_id = fc.id
+		WHERE r.to_point_id IN (%s) AND r.relation_type IN (?, ?) AND fp.deleted_at IS NULL
+		ORDER BY r.from_point_id`, placeholders),
+		append(idList, model.RelationPrerequisite, model.RelationExtends)...)
+	if err != nil {
+		return nil, err
+	}
+	dependents := make(map[int][]int)
+	indegree := make(map[int]int)
+	for rows.Next() {
+		var from, to, fromSubject int
+		if err := rows.Scan(&from, &to, &fromSubject); err != nil {
+			continue
+		}
+		n := nodes[index[to]]
+		if _, inScope := index[from]; !inScope {
+			if readable[fromSubject] {
+				n.External = append(n.External, from)
+			}
+			continue
+		}
+		n.Prerequisites = append(n.Prerequisites, from)
+		dependents[from] = append(dependents[from], to)
+		indegree[to]++
+	}
+	rows.Close()
+
+	// Kahn 算法，每次取原排序最靠前的可学知识点
+	var ready []int
+	for i, n := range nodes {
+		if indegree[n.PointID] == 0 {
+			ready = append(ready, i)
+		}
+	}
+	path := make([]model.LearningPathNode, 0, len(nodes))
+	done := make(map[int]bool)
+	for len(ready) > 0 {
+		sort.Ints(ready)
+		n := nodes[ready[0]]
+		ready = ready[1:]
+		for _, pre := range n.Prerequisites {
+			if lv := nodes[index[pre]].Level + 1; lv > n.Level {
+				n.Level = lv
+			}
+		}
+		done[n.PointID] = true
+		path = append(path, *n)
+		for _, next := range dependents[n.PointID] {
+			indegree[next]--
+			if indegree[next] == 0 {
+				ready = append(ready, index[next])
+			}
+		}
+	}
+	for _, n := range nodes {
+		if !done[n.PointID] {
+			n.InCycle = true
+			path = append(path, *n)
+		}
+	}
+	return path, nil
+}
+
+// loadMastery 统计用户对知识点的掌握情况 (每道题只看最近一次作答)
+func loadMastery(userID int, pointIDs []int) (map[int]model.PointMastery, error) {
+	result := make(map[int]model.PointMastery, len(pointIDs))
+	for _, id := range pointIDs {
+		result[id] = model.PointMastery{PointID: id, Status: model.MasteryNoQuestions}
+	}
+	if len(pointIDs) == 0 {
+		return result, nil
+	}
+
+	placeholders, idList := idArgs(pointIDs)
+	rows, err := global.DB.Query(fmt.Sprintf(`
+		SELECT q.knowledge_point_id, COUNT(*),
+			SUM(CASE WHEN la.id IS NOT NULL THEN 1 ELSE 0 END),
+			SUM(CASE WHEN la.is_correct = 1 THEN 1 ELSE 0 END)
+		FROM questions q
+		LEFT JOIN question_attempts la ON la.id = (
+			SELECT a.id FROM question_attempts a
+			WHERE a.question_id = q.id AND a.user_id = ?
+			ORDER BY a.id DESC LIMIT 1
+		)
+		WHERE q.knowledge_point_id IN (%s) AND q.deleted_at IS NULL
+		GROUP BY q.knowledge_point_id`, placeholders), append([]interface{}{userID}, idList...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m model.PointMastery
+		if err := rows.Scan(&m.PointID, &m.QuestionCount, &m.AnsweredCount, &m.CorrectCount); err != nil {
+			continue
+		}
+		if m.AnsweredCount > 0 {
+			m.CorrectRate = float64(m.CorrectCount) / float64(m.AnsweredCount)
+		}
+		switch {
+		case m.AnsweredCount == 0:
+			m.Status = model.MasteryNotStarted
+		case m.AnsweredCount >= min(masteryMinAnswered, m.QuestionCount) && m.CorrectRate >= masteryMinRate:
+			m.Status = model.MasteryMastered
+		default:
+			m.Status = model.MasteryLearning
+		}
+		result[m.PointID] = m
+	}
+	return result, rows.Err()
+}
+
+// =================================================================================
+// GetLearningPath 科目 (或分类) 的学习路径
+// =================================================================================
+func GetLearningPath(c *gin.Context) {
+	subjectID, categoryID, readable, ok := pathScope(c)
+	if !ok {
+		return
+	}
+
+	path, err := buildLearningPath(subjectID, categoryID, readable)
+	if err != nil {
+		global.GetLog(c).Errorf("生成学习路径失败 (SubjectID: %d): %v", subjectID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "success", "data": gin.H{"list": path, "total": len(path)}})
+}
+
+// =================================================================================
+// GetNextRecommendedPoint 推荐下一个学习的知识点：
+// 按学习路径顺序，取第一个尚未掌握、且全部前置知识点已掌握 (或无题可练) 的知识点
+// =================================================================================
+func GetNextRecommendedPoint(c *gin.Context) {
+	subjectID, categoryID, readable, ok := pathScope(c)
+	if !ok {
+		return
+	}
+	userID, _ := currentUser(c)
+
+	path, err := buildLearningPath(subjectID, categoryID, readable)
+	if err != nil {
+		global.GetLog(c).Errorf("生成学习路径失败 (SubjectID: %d): %v", subjectID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+
+	var ids []int
+	for _, n := range path {
+		ids = append(ids, n.PointID)
+		ids = append(ids, n.External...)
+	}
+	mastery, err := loadMastery(userID, ids)
+	if err != nil {
+		global.GetLog(c).Errorf("统计掌握情况失败 (UserID: %d): %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+	satisfied := func(id int) bool {
+		s := mastery[id].Status
+		return s == model.MasteryMastered || s == model.MasteryNoQuestions
+	}
+
+	mastered, trackable := 0, 0
+	var next *model.LearningPathNode
+	blocked := make([]gin.H, 0)
+	for i := range path {
+		n := path[i]
+		m := mastery[n.PointID]
+		if m.Status == model.MasteryNoQuestions {
+			continue
+		}
+		trackable++
+		if m.Status == model.MasteryMastered {
+			mastered++
+			continue
+		}
+		var missing []int
+		for _, pre := range append(append([]int{}, n.Prerequisites...), n.External...) {
+			if !satisfied(pre) {
+				missing = append(missing, pre)
+			}
+		}
+		if len(missing) > 0 {
+			blocked = append(blocked, gin.H{"pointId": n.PointID, "missingPrerequisites": missing})
+			continue
+		}
+		if next == nil {
+			next = &path[i]
+		}
+	}
+
+	data := gin.H{
+		"point":     next,
+		"mastery":   nil,
+		"mastered":  mastered,
+		"total":     trackable,
+		"completed": trackable > 0 && mastered == trackable,
+		"blocked":   blocked,
+	}
+	if next != nil {
+		data["mastery"] = mastery[next.PointID]
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "success", "data": data})
+}
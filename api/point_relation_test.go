@@ -0,0 +1,65 @@
+package api
+
+import (
+	"encoding/json"
+	"practice_problems/model"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLearningPathHidesUnreadablePrerequisites(t *testing.T) {
+	setupTestDB(t)
+
+	authorID := createTestUser(t, "author")
+	outsiderID := createTestUser(t, "outsider")
+	learnerID := createTestUser(t, "learner")
+	subjectY := createTestSubject(t, "Y", authorID, "author")
+	subjectX := createTestSubject(t, "X", outsiderID, "outsider")
+
+	// outsider 订阅了 Y，learner 只订阅了 Y
+	mustExec(t, "INSERT INTO user_subjects (user_id, subject_id, status, expire_time) VALUES (?, ?, 1, NULL)", outsiderID, subjectY)
+	mustExec(t, "INSERT INTO user_subjects (user_id, subject_id, status, expire_time) VALUES (?, ?, 1, NULL)", learnerID, subjectY)
+
+	mustExec(t, "INSERT INTO knowledge_categories (id, subject_id, categorie_name) VALUES (1, ?, 'y'), (2, ?, 'x')", subjectY, subjectX)
+	mustExec(t, "INSERT INTO knowledge_points (id, categorie_id, title) VALUES (1, 1, 'y-point'), (2, 2, 'x-point')")
+	mustExec(t, "INSERT INTO questions (knowledge_point_id, question_text, correct_answer) VALUES (1, 'qy', 1), (2, 'qx', 1)")
+
+	// outsider 把自己科目的知识点设为 Y 知识点的前置
+	body := `{"fromPointId":2,"toPointId":1,"relationType":"` + model.RelationPrerequisite + `"}`
+	if w := callHandler(CreatePointRelation, outsiderID, "outsider", nil, body); w.Code != 200 {
+		t.Fatalf("create relation: status %d, body %s", w.Code, w.Body.String())
+	}
+
+	params := gin.Params{{Key: "id", Value: strconv.Itoa(subjectY)}}
+	w := callHandler(GetLearningPath, learnerID, "learner", params, "")
+	var pathResp struct {
+		Data struct {
+			List []model.LearningPathNode `json:"list"`
+		} `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &pathResp)
+	if len(pathResp.Data.List) != 1 || len(pathResp.Data.List[0].External) != 0 {
+		t.Errorf("learner sees unreadable prerequisite: %s", w.Body.String())
+	}
+
+	w = callHandler(GetNextRecommendedPoint, learnerID, "learner", params, "")
+	var nextResp struct {
+		Data struct {
+			Point   *model.LearningPathNode `json:"point"`
+			Blocked []json.RawMessage       `json:"blocked"`
+		} `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &nextResp)
+	if nextResp.Data.Point == nil || nextResp.Data.Point.PointID != 1 || len(nextResp.Data.Blocked) != 0 {
+		t.Errorf("learner blocked by unreadable prerequisite: %s", w.Body.String())
+	}
+
+	// 能访问 X 的用户仍会看到该前置知识点
+	w = callHandler(GetLearningPath, outsiderID, "outsider", params, "")
+	json.Unmarshal(w.Body.Bytes(), &pathResp)
+	if len(pathResp.Data.List) != 1 || len(pathResp.Data.List[0].External) != 1 {
+		t.Errorf("outsider should see own prerequisite: %s", w.Body.String())
+	}
+}
@@ -0,0 +1,486 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"practice_problems/global"
+	"practice_problems/model"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==========================================
+// 知识点修订历史：每次保存记录完整快照，支持列表、差异对比与回滚
+// 保存时失效的绑定记在对应修订上，回滚时重新定位或重新创建
+// ==========================================
+
+// diffMaxCells 行级 LCS 的最大计算量，超过后整段按删除 + 新增展示
+const diffMaxCells = 4000000
+
+// recordPointRevisionTx 以知识点当前内容为快照写入一条修订
+// rollbackFrom 为 0 表示非回滚
+func recordPointRevisionTx(tx *sql.Tx, pointID int, action string, rollbackFrom int, removed []model.PointBinding, editorCode string) error {
+	var removedJSON interface{}
+	if len(removed) > 0 {
+		b, _ := json.Marshal(removed)
+		removedJSON = string(b)
+	}
+	var rollbackArg interface{}
+	if rollbackFrom > 0 {
+		rollbackArg = rollbackFrom
+	}
+	_, err := tx.Exec(`
+		INSERT INTO point_revisions (point_id, revision_no, title, content, reference_links, local_image_names, video_url, difficulty,
+			action, rollback_from, removed_bindings, editor_code)
+		SELECT id, (SELECT IFNULL(MAX(revision_no), 0) + 1 FROM point_revisions WHERE point_id = ?),
+			title, IFNULL(content, ''), IFNULL(reference_links, ''), IFNULL(local_image_names, ''), IFNULL(video_url, ''), IFNULL(difficulty, 0),
+			?, ?, ?, ?
+		FROM knowledge_points WHERE id = ?`,
+		pointID, action, rollbackArg, removedJSON, editorCode, pointID)
+	return err
+}
+
+// ensureInitialRevisionTx 知识点还没有任何修订时，先把当前内容记为初始版本
+func ensureInitialRevisionTx(tx *sql.Tx, pointID int) error {
+	var count int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM point_revisions WHERE point_id = ?", pointID).Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	return recordPointRevisionTx(tx, pointID, model.RevisionActionInitial, 0, nil, "")
+}
+
+// updatePointImages 更新知识点图片列表并记录一条修订 (同一事务)，返回更新后的版本号
+// 图片文件本身不在这里删除，由回收站清理任务在确认无引用后统一清理
+func updatePointImages(pointID int, imagesJSON string, editorCode string) (int, error) {
+	tx, err := global.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if err := ensureInitialRevisionTx(tx, pointID); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec("UPDATE knowledge_points SET local_image_names = ?, version = version + 1 WHERE id = ?", imagesJSON, pointID); err != nil {
+		return 0, err
+	}
+	if err := recordPointRevisionTx(tx, pointID, model.RevisionActionUpdate, 0, nil, editorCode); err != nil {
+		return 0, err
+	}
+	var version int
+	if err := tx.QueryRow("SELECT version FROM knowledge_points WHERE id = ?", pointID).Scan(&version); err != nil {
+		return 0, err
+	}
+	return version, tx.Commit()
+}
+
+// loadPointRevision 读取某个知识点下的一条完整修订
+func loadPointRevision(pointID, revID int) (*model.PointRevisionDetail, error) {
+	var r model.PointRevisionDetail
+	var rollbackFrom sql.NullInt64
+	var removedJSON sql.NullString
+	err := global.DB.QueryRow(`
+		SELECT r.id, r.point_id, r.revision_no, IFNULL(r.title, ''), r.action, r.rollback_from, IFNULL(r.editor_code, ''),
+			IFNULL(u.nickname, IFNULL(u.username, '')), r.create_time,
+			IFNULL(r.content, ''), IFNULL(r.reference_links, ''), IFNULL(r.local_image_names, ''), IFNULL(r.video_url, ''),
+			IFNULL(r.difficulty, 0), r.removed_bindings
+		FROM point_revisions r
+		LEFT JOIN users u ON r.editor_code = u.user_code
+		WHERE r.id = ? AND r.point_id = ?`, revID, pointID).Scan(
+		&r.ID, &r.PointID, &r.RevisionNo, &r.Title, &r.Action, &rollbackFrom, &r.EditorCode,
+		&r.EditorName, &r.CreateTime,
+		&r.Content, &r.ReferenceLinks, &r.LocalImageNames, &r.VideoUrl,
+		&r.Difficulty, &removedJSON)
+	if err != nil {
+		return nil, err
+	}
+	if rollbackFrom.Valid {
+		v := int(rollbackFrom.Int64)
+		r.RollbackFrom = &v
+	}
+	r.RemovedBindings = make([]model.PointBinding, 0)
+	if removedJSON.Valid && removedJSON.String != "" {
+		json.Unmarshal([]byte(removedJSON.String), &r.RemovedBindings)
+	}
+	r.RemovedBindingCount = len(r.RemovedBindings)
+	r.CreateTime = formatTimeStr(r.CreateTime)
+	return &r, nil
+}
+
+// =================================================================================
+// GetPointRevisions 获取知识点修订列表 (科目成员可见)
+// =================================================================================
+func GetPointRevisions(c *gin.Context) {
+	pointID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+	if !checkPointRole(c, pointID, RoleViewer, "查看修订历史") {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	var total int
+	global.DB.QueryRow("SELECT COUNT(*) FROM point_revisions WHERE point_id = ?", pointID).Scan(&total)
+
+	rows, err := global.DB.Query(`
+		SELECT r.id, r.point_id, r.revision_no, IFNULL(r.title, ''), r.action, r.rollback_from, IFNULL(r.editor_code, ''),
+			IFNULL(u.nickname, IFNULL(u.username, '')), r.create_time, r.removed_bindings
+		FROM point_revisions r
+		LEFT JOIN users u ON r.editor_code = u.user_code
+		WHERE r.point_id = ?
+		ORDER BY r.revision_no DESC
+		LIMIT ? OFFSET ?`, pointID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		global.GetLog(c).Errorf("查询修订列表失败 (PointID: %d): %v", pointID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+	defer rows.Close()
+
+	list := make([]model.PointRevision, 0)
+	for rows.Next() {
+		var r model.PointRevision
+		var rollbackFrom sql.NullInt64
+		var removedJSON sql.NullString
+		if err := rows.Scan(&r.ID, &r.PointID, &r.RevisionNo, &r.Title, &r.Action, &rollbackFrom, &r.EditorCode,
+			&r.EditorName, &r.CreateTime, &removedJSON); err != nil {
+			continue
+		}
+		if rollbackFrom.Valid {
+			v := int(rollbackFrom.Int64)
+			r.RollbackFrom = &v
+		}
+		if removedJSON.Valid && removedJSON.String != "" {
+			var removed []model.PointBinding
+			if json.Unmarshal([]byte(removedJSON.String), &removed) == nil {
+				r.RemovedBindingCount = len(removed)
+			}
+		}
+		r.CreateTime = formatTimeStr(r.CreateTime)
+		list = append(list, r)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "success", "data": gin.H{"list": list, "total": total}})
+}
+
+// =================================================================================
+// GetPointRevision 获取单条修订的完整快照
+// =================================================================================
+func GetPointRevision(c *gin.Context) {
+	pointID, err1 := strconv.Atoi(c.Param("id"))
+	revID, err2 := strconv.Atoi(c.Param("revId"))
+	if err1 != nil || err2 != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+	if !checkPointRole(c, pointID, RoleViewer, "查看修订历史") {
+		return
+	}
+
+	rev, err := loadPointRevision(pointID, revID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "修订不存在"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "success", "data": rev})
+}
+
+// =================================================================================
+// DiffPointRevisions 对比两条修订 (?from=&to=)
+// to 缺省为最新修订，from 缺省为 to 的上一条修订
+// =================================================================================
+func DiffPointRevisions(c *gin.Context) {
+	pointID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+	if !checkPointRole(c, pointID, RoleViewer, "查看修订历史") {
+		return
+	}
+
+	toID, _ := strconv.Atoi(c.Query("to"))
+	if toID <= 0 {
+		global.DB.QueryRow("SELECT id FROM point_revisions WHERE point_id = ? ORDER BY revision_no DESC LIMIT 1", pointID).Scan(&toID)
+	}
+	to, err := loadPointRevision(pointID, toID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "修订不存在"})
+		return
+	}
+
+	fromID, _ := strconv.Atoi(c.Query("from"))
+	if fromID <= 0 {
+		err = global.DB.QueryRow("SELECT id FROM point_revisions WHERE point_id = ? AND revision_no < ? ORDER BY revision_no DESC LIMIT 1",
+			pointID, to.RevisionNo).Scan(&fromID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "没有可对比的上一版本"})
+			return
+		}
+	}
+	from, err := loadPointRevision(pointID, fromID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "修订不存在"})
+		return
+	}
+
+	// 非正文字段只列出变化项
+	fields := make([]model.FieldChange, 0)
+	addField := func(name string, a, b interface{}) {
+		if a != b {
+			fields = append(fields, model.FieldChange{Field: name, From: a, To: b})
+		}
+	}
+	addField("title", from.Title, to.Title)
+	addField("referenceLinks", from.ReferenceLinks, to.ReferenceLinks)
+	addField("localImageNames", from.LocalImageNames, to.LocalImageNames)
+	addField("videoUrl", from.VideoUrl, to.VideoUrl)
+	addField("difficulty", from.Difficulty, to.Difficulty)
+
+	lines := diffLines(from.Content, to.Content)
+	added, deleted := 0, 0
+	for _, l := range lines {
+		switch l.Op {
+		case "add":
+			added++
+		case "delete":
+			deleted++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "success",
+		"data": gin.H{
+			"from":    from.PointRevision,
+			"to":      to.PointRevision,
+			"fields":  fields,
+			"lines":   lines,
+			"added":   added,
+			"deleted": deleted,
+		},
+	})
+}
+
+// diffLines 按行对比两段文本 (公共前后缀 + LCS)
+func diffLines(oldText, newText string) []model.DiffLine {
+	a := strings.Split(oldText, "\n")
+	b := strings.Split(newText, "\n")
+	if oldText == "" {
+		a = nil
+	}
+	if newText == "" {
+		b = nil
+	}
+
+	result := make([]model.DiffLine, 0, len(a)+len(b))
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		result = append(result, model.DiffLine{Op: "equal", OldLine: prefix + 1, NewLine: prefix + 1, Text: a[prefix]})
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	midA := a[prefix : len(a)-suffix]
+	midB := b[prefix : len(b)-suffix]
+	n, m := len(midA), len(midB)
+
+	if n*m > diffMaxCells {
+		// 变化过大，不做逐行比对
+		for i, line := range midA {
+			result = append(result, model.DiffLine{Op: "delete", OldLine: prefix + i + 1, Text: line})
+		}
+		for j, line := range midB {
+			result = append(result, model.DiffLine{Op: "add", NewLine: prefix + j + 1, Text: line})
+		}
+	} else {
+		// lcs[i][j] = midA[i:] 与 midB[j:] 的最长公共子序列长度
+		lcs := make([][]int, n+1)
+		for i := range lcs {
+			lcs[i] = make([]int, m+1)
+		}
+		for i := n - 1; i >= 0; i-- {
+			for j := m - 1; j >= 0; j-- {
+				if midA[i] == midB[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else if lcs[i+1][j] >= lcs[i][j+1] {
+					lcs[i][j] = lcs[i+1][j]
+				} else {
+					lcs[i][j] = lcs[i][j+1]
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < n || j < m {
+			switch {
+			case i < n && j < m && midA[i] == midB[j]:
+				result = append(result, model.DiffLine{Op: "equal", OldLine: prefix + i + 1, NewLine: prefix + j + 1, Text: midA[i]})
+				i++
+				j++
+			case i < n && (j == m || lcs[i+1][j] >= lcs[i][j+1]):
+				result = append(result, model.DiffLine{Op: "delete", OldLine: prefix + i + 1, Text: midA[i]})
+				i++
+			default:
+				result = append(result, model.DiffLine{Op: "add", NewLine: prefix + j + 1, Text: midB[j]})
+				j++
+			}
+		}
+	}
+
+	for k := 0; k < suffix; k++ {
+		oi := len(a) - suffix + k
+		nj := len(b) - suffix + k
+		result = append(result, model.DiffLine{Op: "equal", OldLine: oi + 1, NewLine: nj + 1, Text: a[oi]})
+	}
+	return result
+}
+
+// =================================================================================
+// RollbackPointRevision 回滚知识点到指定修订 (编辑者及以上)
+// 恢复该修订的完整快照，并恢复之后各次保存中失效或被删除、且在恢复后的内容中仍能定位的绑定
+// 回滚本身也记为一条新修订
+// =================================================================================
+func RollbackPointRevision(c *gin.Context) {
+	pointID, err1 := strconv.Atoi(c.Param("id"))
+	revID, err2 := strconv.Atoi(c.Param("revId"))
+	if err1 != nil || err2 != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+	if !checkPointRole(c, pointID, RoleEditor, "回滚知识点") {
+		return
+	}
+	currentUserCode, _ := c.Get("userCode")
+	currentUserCodeStr, _ := currentUserCode.(string)
+
+	rev, err := loadPointRevision(pointID, revID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "修订不存在"})
+		return
+	}
+
+	tx, err := global.DB.Begin()
+	if err != nil {
+		global.GetLog(c).Errorf("开启事务失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "系统错误"})
+		return
+	}
+	defer tx.Rollback()
+
+	// 1. 恢复快照
+	_, err = tx.Exec(`
+		UPDATE knowledge_points
+		SET title = ?, content = ?, reference_links = ?, local_image_names = ?, video_url = ?, difficulty = ?,
+			update_time = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id = ? AND deleted_at IS NULL`,
+		rev.Title, rev.Content, rev.ReferenceLinks, rev.LocalImageNames, rev.VideoUrl, rev.Difficulty, pointID)
+	if err != nil {
+		global.GetLog(c).Errorf("回滚知识点失败 (PointID: %d, RevID: %d): %v", pointID, revID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "回滚失败"})
+		return
+	}
+
+	// 2. 收集被撤销的那些保存所失效的绑定 (旧版本中这些绑定会被直接删除)
+	rows, err := tx.Query("SELECT removed_bindings FROM point_revisions WHERE point_id = ? AND revision_no > ? AND removed_bindings IS NOT NULL ORDER BY revision_no",
+		pointID, rev.RevisionNo)
+	if err != nil {
+		global.GetLog(c).Errorf("查询失效绑定记录失败 (PointID: %d): %v", pointID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "回滚失败"})
+		return
+	}
+	var candidates []model.PointBinding
+	for rows.Next() {
+		var removedJSON string
+		if rows.Scan(&removedJSON) != nil {
+			continue
+		}
+		var removed []model.PointBinding
+		if json.Unmarshal([]byte(removedJSON), &removed) == nil {
+			candidates = append(candidates, removed...)
+		}
+	}
+	rows.Close()
+
+	// 3. 已被删除的绑定重新创建 (在恢复后的内容中能定位、目标知识点未删除、且不重复)
+	//    仍然存在的失效绑定由下一步重新定位时自动恢复
+	recreated := 0
+	for _, b := range candidates {
+		var exists int
+		tx.QueryRow(`SELECT COUNT(*) FROM point_bindings
+			WHERE id = ? OR (source_point_id = ? AND target_point_id = ? AND bind_text = ?)`,
+			b.ID, pointID, b.TargetPointID, b.BindText).Scan(&exists)
+		if exists > 0 {
+			continue
+		}
+		a, ok := resolveAnchor(rev.Content, bindingAnchor{Text: b.BindText, Occurrence: b.Occurrence, Prefix: b.AnchorPrefix, Suffix: b.AnchorSuffix})
+		if !ok {
+			continue
+		}
+		var targetAlive int
+		tx.QueryRow("SELECT COUNT(*) FROM knowledge_points WHERE id = ? AND deleted_at IS NULL", b.TargetPointID).Scan(&targetAlive)
+		if targetAlive == 0 {
+			continue
+		}
+		_, err = tx.Exec(`
+			INSERT INTO point_bindings (source_subject_id, source_point_id, target_subject_id, target_point_id, bind_text, user_id,
+				occurrence, anchor_prefix, anchor_suffix)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			b.SourceSubjectID, pointID, b.TargetSubjectID, b.TargetPointID, a.Text, b.UserID, a.Occurrence, a.Prefix, a.Suffix)
+		if err != nil {
+			global.GetLog(c).Errorf("回滚时恢复绑定失败 (PointID: %d, BindText: %s): %v", pointID, b.BindText, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "回滚失败"})
+			return
+		}
+		recreated++
+	}
+
+	// 4. 按回滚后的内容重新定位全部绑定，并记为新修订
+	broken, repaired, err := reanchorBindings(c, tx, strconv.Itoa(pointID), rev.Content)
+	if err != nil {
+		global.GetLog(c).Errorf("重新定位绑定失败 (PointID: %d): %v", pointID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "回滚失败"})
+		return
+	}
+	if err := recordPointRevisionTx(tx, pointID, model.RevisionActionRollback, revID, broken, currentUserCodeStr); err != nil {
+		global.GetLog(c).Errorf("记录知识点修订失败 (PointID: %d): %v", pointID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "回滚失败"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		global.GetLog(c).Errorf("提交回滚失败 (PointID: %d): %v", pointID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "回滚失败"})
+		return
+	}
+
+	var version int
+	global.DB.QueryRow("SELECT version FROM knowledge_points WHERE id = ?", pointID).Scan(&version)
+
+	global.GetLog(c).Infof("用户[%s] 回滚知识点 (ID: %d) 到修订 #%d，恢复绑定 %d 个，失效绑定 %d 个",
+		currentUserCodeStr, pointID, rev.RevisionNo, recreated+repaired, len(broken))
+	setVersionHeader(c, version)
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "回滚成功",
+		"data": gin.H{"revisionNo": rev.RevisionNo, "recreatedBindings": recreated + repaired, "brokenBindings": len(broken), "version": version},
+	})
+}
@@ -0,0 +1,607 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"net/http"
+	"practice_problems/global"
+	"practice_problems/model"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==========================================
+// AI 提示词模板
+// 优先级：科目自定义内容 > 科目选定模板 > 全局默认模板 > 内置保底模板
+// ==========================================
+
+// defaultInterviewPrompt 内置保底提示词 (数据库中没有任何可用模板时使用)
+const defaultInterviewPrompt = `你是一位专业的面试官。当前面试题目是：「{{.Topic}}」。
+{{if .Content}}该题目对应的知识点内容如下，请以此作为评估依据：
+{{.Content}}
+{{end}}{{if .Difficulty}}题目难度：{{.Difficulty}}。
+{{end}}请注意：
+1. 我会发送用户的【回答】给你。
+2. 请评估回答是否正确。若正确，请进行深挖追问；若错误，请指出。
+3. 请使用{{.Language}}与用户交流。`
+
+// defaultPromptLanguage 未指定语言时的默认值
+const defaultPromptLanguage = "中文"
+
+// samplePromptVars 校验与预览模板时使用的示例变量
+var samplePromptVars = model.PromptVars{
+	Topic:      "Java 内存模型",
+	Content:    "JMM 定义了线程与主内存之间的抽象关系……",
+	Difficulty: "中等",
+	Language:   defaultPromptLanguage,
+}
+
+// difficultyLabel 把数据库中的难度值转成模板可读的描述
+func difficultyLabel(d int) string {
+	switch d {
+	case 0:
+		return "简单"
+	case 1:
+		return "中等"
+	case 2:
+		return "困难"
+	case 3:
+		return "重点"
+	default:
+		return ""
+	}
+}
+
+// promptMaxTemplateOutput 模板自身产生的文字上限 (不含变量内容)
+const promptMaxTemplateOutput = 64 << 10
+
+var (
+	errPromptTooLong  = errors.New("渲染结果过长")
+	errPromptPipeline = errors.New("模板只支持 {{.变量}}，不支持函数、管道与变量声明")
+)
+
+// cappedBuffer 超过上限后拒绝写入，使模板执行立即中止
+type cappedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > b.limit {
+		return 0, errPromptTooLong
+	}
+	return b.Buffer.Write(p)
+}
+
+// checkPromptNodes 模板可由科目创建者提交，只允许变量替换与 if / with 条件，
+// 禁止 range、template、函数调用与变量声明，避免构造出耗尽 CPU 或内存的模板
+func checkPromptNodes(node parse.Node) error {
+	switch n := node.(type) {
+	case nil:
+		return nil
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			if err := checkPromptNodes(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *parse.TextNode, *parse.CommentNode:
+		return nil
+	case *parse.ActionNode:
+		return checkPromptPipe(n.Pipe)
+	case *parse.IfNode:
+		return checkPromptBranch(&n.BranchNode)
+	case *parse.WithNode:
+		return checkPromptBranch(&n.BranchNode)
+	default:
+		return errors.New("模板只支持 {{.变量}}、{{if}} 与 {{with}}")
+	}
+}
+
+func checkPromptBranch(b *parse.BranchNode) error {
+	if err := checkPromptPipe(b.Pipe); err != nil {
+		return err
+	}
+	if err := checkPromptNodes(b.List); err != nil {
+		return err
+	}
+	return checkPromptNodes(b.ElseList)
+}
+
+// checkPromptPipe 管道只能是单个 {{.}} 或 {{.Field}}
+func checkPromptPipe(p *parse.PipeNode) error {
+	if p == nil {
+		return nil
+	}
+	if len(p.Decl) > 0 || len(p.Cmds) != 1 || len(p.Cmds[0].Args) != 1 {
+		return errPromptPipeline
+	}
+	switch p.Cmds[0].Args[0].(type) {
+	case *parse.FieldNode, *parse.DotNode:
+		return nil
+	default:
+		return errPromptPipeline
+	}
+}
+
+// renderPrompt 渲染模板，引用不存在的变量会返回错误
+// 输出上限为模板自身 64KB 加上各变量内容一份
+func renderPrompt(content string, vars model.PromptVars) (string, error) {
+	if vars.Language == "" {
+		vars.Language = defaultPromptLanguage
+	}
+	tpl, err := template.New("prompt").Option("missingkey=error").Parse(content)
+	if err != nil {
+		return "", err
+	}
+	if len(tpl.Templates()) > 1 {
+		return "", errors.New("模板不支持 define / block")
+	}
+	if tpl.Tree != nil {
+		if err := checkPromptNodes(tpl.Tree.Root); err != nil {
+			return "", err
+		}
+	}
+	buf := &cappedBuffer{limit: promptMaxTemplateOutput + len(vars.Topic) + len(vars.Content) + len(vars.Difficulty) + len(vars.Language)}
+	if err := tpl.Execute(buf, vars); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// validatePromptTemplate 用示例变量试渲染一次，确保语法与变量名正确
+func validatePromptTemplate(content string) error {
+	_, err := renderPrompt(content, samplePromptVars)
+	return err
+}
+
+// getPromptTemplate 获取指定名称的模板，version 为 0 时取最新版本
+func getPromptTemplate(name string, version int) (*model.PromptTemplate, error) {
+	query := `
+		SELECT id, name, version, COALESCE(description, ''), content, is_default, COALESCE(creator_code, ''), create_time
+		FROM prompt_templates
+		WHERE name = ? AND status = 1`
+	args := []interface{}{name}
+	if version > 0 {
+		query += " AND version = ?"
+		args = append(args, version)
+	}
+	query += " ORDER BY version DESC LIMIT 1"
+
+	var t model.PromptTemplate
+	err := global.DB.QueryRow(query, args...).Scan(&t.ID, &t.Name, &t.Version, &t.Description, &t.Content, &t.IsDefault, &t.CreatorCode, &t.CreateTime)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// getDefaultPromptContent 全局默认模板内容 (未设置时返回内置模板)
+func getDefaultPromptContent() string {
+	var name string
+	err := global.DB.QueryRow("SELECT name FROM prompt_templates WHERE is_default = 1 AND status = 1 LIMIT 1").Scan(&name)
+	if err == nil {
+		if t, err := getPromptTemplate(name, 0); err == nil {
+			return t.Content
+		}
+	}
+	return defaultInterviewPrompt
+}
+
+// resolveSubjectPrompt 按优先级解析科目使用的模板内容与语言
+func resolveSubjectPrompt(subjectID int) (string, string) {
+	if subjectID <= 0 {
+		return getDefaultPromptContent(), defaultPromptLanguage
+	}
+
+	var templateName, customContent, language sql.NullString
+	err := global.DB.QueryRow("SELECT template_name, custom_content, language FROM subject_prompts WHERE subject_id = ?", subjectID).
+		Scan(&templateName, &customContent, &language)
+	if err != nil {
+		return getDefaultPromptContent(), defaultPromptLanguage
+	}
+
+	lang := defaultPromptLanguage
+	if language.Valid && language.String != "" {
+		lang = language.String
+	}
+	if customContent.Valid && strings.TrimSpace(customContent.String) != "" {
+		return customContent.String, lang
+	}
+	if templateName.Valid && templateName.String != "" {
+		if t, err := getPromptTemplate(templateName.String, 0); err == nil {
+			return t.Content, lang
+		}
+	}
+	return getDefaultPromptContent(), lang
+}
+
+// BuildInterviewPrompt 生成某科目下的面试 System Prompt
+// 模板渲染失败时退回内置模板，保证面试不中断
+func BuildInterviewPrompt(subjectID int, vars model.PromptVars) string {
+	content, lang := resolveSubjectPrompt(subjectID)
+	if vars.Language == "" {
+		vars.Language = lang
+	}
+
+	prompt, err := renderPrompt(content, vars)
+	if err != nil {
+		global.GetLog(nil).Errorf("渲染提示词模板失败 (SubjectID: %d): %v", subjectID, err)
+		prompt, _ = renderPrompt(defaultInterviewPrompt, vars)
+	}
+	return prompt
+}
+
+// queryLatestPromptTemplates 每个模板名只返回最新版本
+func queryLatestPromptTemplates() ([]model.PromptTemplate, error) {
+	rows, err := global.DB.Query(`
+		SELECT t.id, t.name, t.version, COALESCE(t.description, ''), t.content, t.is_default, COALESCE(t.creator_code, ''), t.create_time
+		FROM prompt_templates t
+		WHERE t.status = 1
+		  AND t.version = (SELECT MAX(version) FROM prompt_templates WHERE name = t.name AND status = 1)
+		ORDER BY t.is_default DESC, t.name ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]model.PromptTemplate, 0)
+	for rows.Next() {
+		var t model.PromptTemplate
+		if err := rows.Scan(&t.ID, &t.Name, &t.Version, &t.Description, &t.Content, &t.IsDefault, &t.CreatorCode, &t.CreateTime); err != nil {
+			continue
+		}
+		list = append(list, t)
+	}
+	return list, nil
+}
+
+// =================================================================================
+// GetPromptTemplates 获取可选模板列表 (所有登录用户，供科目创建者挑选)
+// =================================================================================
+func GetPromptTemplates(c *gin.Context) {
+	list, err := queryLatestPromptTemplates()
+	if err != nil {
+		global.GetLog(c).Errorf("查询提示词模板失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "success", "data": list})
+}
+
+// =================================================================================
+// AdminGetPromptTemplateVersions 获取某个模板的全部版本
+// =================================================================================
+func AdminGetPromptTemplateVersions(c *gin.Context) {
+	name := c.Param("name")
+
+	rows, err := global.DB.Query(`
+		SELECT id, name, version, COALESCE(description, ''), content, is_default, COALESCE(creator_code, ''), create_time
+		FROM prompt_templates
+		WHERE name = ? AND status = 1
+		ORDER BY version DESC`, name)
+	if err != nil {
+		global.GetLog(c).Errorf("查询模板版本失败 (Name: %s): %v", name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+	defer rows.Close()
+
+	list := make([]model.PromptTemplate, 0)
+	for rows.Next() {
+		var t model.PromptTemplate
+		if err := rows.Scan(&t.ID, &t.Name, &t.Version, &t.Description, &t.Content, &t.IsDefault, &t.CreatorCode, &t.CreateTime); err != nil {
+			continue
+		}
+		list = append(list, t)
+	}
+	if len(list) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "模板不存在"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "success", "data": list})
+}
+
+// =================================================================================
+// AdminCreatePromptTemplate 创建模板 (版本号从 1 开始，重建已删除的模板时接续历史版本号)
+// =================================================================================
+func AdminCreatePromptTemplate(c *gin.Context) {
+	var req model.CreatePromptTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "参数错误"})
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len([]rune(req.Name)) > 50 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "模板名称长度应在1-50个字符之间"})
+		return
+	}
+	if err := validatePromptTemplate(req.Content); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "模板内容有误: " + err.Error()})
+		return
+	}
+
+	userCode, _ := c.Get("userCode")
+
+	var count int
+	global.DB.QueryRow("SELECT COUNT(*) FROM prompt_templates WHERE name = ? AND status = 1", req.Name).Scan(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"code": 409, "msg": "模板名称已存在，请使用修改接口生成新版本"})
+		return
+	}
+
+	// 已删除的同名模板：版本号接在历史之后，避免与旧版本冲突
+	var maxVersion int
+	global.DB.QueryRow("SELECT COALESCE(MAX(version), 0) FROM prompt_templates WHERE name = ?", req.Name).Scan(&maxVersion)
+	newVersion := maxVersion + 1
+
+	res, err := global.DB.Exec(`INSERT INTO prompt_templates (name, version, description, content, is_default, status, creator_code)
+		VALUES (?, ?, ?, ?, 0, 1, ?)`, req.Name, newVersion, req.Description, req.Content, userCode)
+	if err != nil {
+		global.GetLog(c).Errorf("创建提示词模板失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "创建失败"})
+		return
+	}
+	id, _ := res.LastInsertId()
+
+	global.GetLog(c).Infof("管理员[%v] 创建提示词模板成功 (Name: %s)", userCode, req.Name)
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "创建成功", "data": gin.H{"id": id, "name": req.Name, "version": newVersion}})
+}
+
+// =================================================================================
+// AdminUpdatePromptTemplate 修改模板 (保留旧版本，写入新版本)
+// =================================================================================
+func AdminUpdatePromptTemplate(c *gin.Context) {
+	name := c.Param("name")
+
+	var req model.UpdatePromptTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "参数错误"})
+		return
+	}
+	if err := validatePromptTemplate(req.Content); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "模板内容有误: " + err.Error()})
+		return
+	}
+
+	latest, err := getPromptTemplate(name, 0)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "模板不存在"})
+		return
+	} else if err != nil {
+		global.GetLog(c).Errorf("查询提示词模板失败 (Name: %s): %v", name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+
+	userCode, _ := c.Get("userCode")
+	description := req.Description
+	if description == "" {
+		description = latest.Description
+	}
+
+	// 版本号取全部历史的最大值 + 1 (包括已删除的)，避免版本号复用
+	var maxVersion int
+	global.DB.QueryRow("SELECT COALESCE(MAX(version), 0) FROM prompt_templates WHERE name = ?", name).Scan(&maxVersion)
+	newVersion := maxVersion + 1
+
+	_, err = global.DB.Exec(`INSERT INTO prompt_templates (name, version, description, content, is_default, status, creator_code)
+		VALUES (?, ?, ?, ?, ?, 1, ?)`, name, newVersion, description, req.Content, latest.IsDefault, userCode)
+	if err != nil {
+		global.GetLog(c).Errorf("保存提示词模板新版本失败 (Name: %s): %v", name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "更新失败"})
+		return
+	}
+
+	global.GetLog(c).Infof("管理员[%v] 更新提示词模板成功 (Name: %s, Version: %d)", userCode, name, newVersion)
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "更新成功", "data": gin.H{"name": name, "version": newVersion}})
+}
+
+// =================================================================================
+// AdminDeletePromptTemplate 删除模板 (全部版本置为无效)
+// =================================================================================
+func AdminDeletePromptTemplate(c *gin.Context) {
+	name := c.Param("name")
+	userCode, _ := c.Get("userCode")
+
+	res, err := global.DB.Exec("UPDATE prompt_templates SET status = 0, is_default = 0 WHERE name = ? AND status = 1", name)
+	if err != nil {
+		global.GetLog(c).Errorf("删除提示词模板失败 (Name: %s): %v", name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "删除失败"})
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "模板不存在"})
+		return
+	}
+
+	global.GetLog(c).Infof("管理员[%v] 删除提示词模板成功 (Name: %s)", userCode, name)
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "删除成功"})
+}
+
+// =================================================================================
+// AdminSetDefaultPromptTemplate 设为全局默认模板
+// =================================================================================
+func AdminSetDefaultPromptTemplate(c *gin.Context) {
+	name := c.Param("name")
+	userCode, _ := c.Get("userCode")
+
+	if _, err := getPromptTemplate(name, 0); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "模板不存在"})
+		return
+	}
+
+	_, err := global.DB.Exec("UPDATE prompt_templates SET is_default = CASE WHEN name = ? AND status = 1 THEN 1 ELSE 0 END", name)
+	if err != nil {
+		global.GetLog(c).Errorf("设置默认提示词模板失败 (Name: %s): %v", name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "设置失败"})
+		return
+	}
+
+	global.GetLog(c).Infof("管理员[%v] 设置默认提示词模板成功 (Name: %s)", userCode, name)
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "设置成功"})
+}
+
+// =================================================================================
+// AdminPreviewPromptTemplate 预览模板渲染结果
+// =================================================================================
+func AdminPreviewPromptTemplate(c *gin.Context) {
+	var req model.PreviewPromptTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "参数错误"})
+		return
+	}
+
+	content := req.Content
+	if content == "" {
+		if req.Name == "" {
+			content = defaultInterviewPrompt
+		} else {
+			t, err := getPromptTemplate(req.Name, req.Version)
+			if err != nil {
+				c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "模板不存在"})
+				return
+			}
+			content = t.Content
+		}
+	}
+
+	vars := req.Vars
+	if vars == (model.PromptVars{}) {
+		vars = samplePromptVars
+	}
+
+	rendered, err := renderPrompt(content, vars)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "模板渲染失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "success", "data": gin.H{"rendered": rendered, "vars": vars}})
+}
+
+// checkSubjectCreator 校验当前用户是否为科目创建者，失败时直接写回响应
+func checkSubjectCreator(c *gin.Context, subjectID int, action string) bool {
+	currentUserCode, _ := c.Get("userCode")
+	currentUserCodeStr, _ := currentUserCode.(string)
+
+	var creatorCode string
+	var creatorName string
+	var creatorEmail sql.NullString
+	err := global.DB.QueryRow(`
+		SELECT s.creator_code, IFNULL(u.nickname, u.username), u.email
+		FROM subjects s
+		LEFT JOIN users u ON s.creator_code = u.user_code
+		WHERE s.id = ? AND s.deleted_at IS NULL`, subjectID).Scan(&creatorCode, &creatorName, &creatorEmail)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "未找到该科目"})
+		return false
+	} else if err != nil {
+		global.GetLog(c).Errorf("查询科目创建者失败 (SubjectID: %d): %v", subjectID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "系统繁忙"})
+		return false
+	}
+
+	if creatorCode != currentUserCodeStr {
+		global.GetLog(c).Warnf("%s被拒: 无权操作 (User: %s, SubjectID: %d)", action, currentUserCodeStr, subjectID)
+		contactInfo := getContactInfo(creatorName, creatorEmail)
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": action + "失败：您不是该科目的作者，请联系 " + contactInfo})
+		return false
+	}
+	return true
+}
+
+// =================================================================================
+// GetSubjectPrompt 获取科目的提示词设置 (仅创建者)
+// =================================================================================
+func GetSubjectPrompt(c *gin.Context) {
+	subjectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+	if !checkSubjectCreator(c, subjectID, "查看科目提示词") {
+		return
+	}
+
+	var templateName, customContent, language sql.NullString
+	global.DB.QueryRow("SELECT template_name, custom_content, language FROM subject_prompts WHERE subject_id = ?", subjectID).
+		Scan(&templateName, &customContent, &language)
+
+	content, lang := resolveSubjectPrompt(subjectID)
+	vars := samplePromptVars
+	vars.Language = lang
+	preview, _ := renderPrompt(content, vars)
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "success",
+		"data": gin.H{
+			"templateName":  templateName.String,
+			"customContent": customContent.String,
+			"language":      lang,
+			"effective":     content,
+			"preview":       preview,
+		},
+	})
+}
+
+// =================================================================================
+// UpdateSubjectPrompt 设置科目使用的模板或自定义提示词 (仅创建者)
+// =================================================================================
+func UpdateSubjectPrompt(c *gin.Context) {
+	subjectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+
+	var req model.SubjectPromptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "参数错误"})
+		return
+	}
+	if !checkSubjectCreator(c, subjectID, "修改科目提示词") {
+		return
+	}
+
+	if req.TemplateName != "" {
+		if _, err := getPromptTemplate(req.TemplateName, 0); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "所选模板不存在"})
+			return
+		}
+	}
+	if strings.TrimSpace(req.CustomContent) != "" {
+		if err := validatePromptTemplate(req.CustomContent); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "自定义提示词有误: " + err.Error()})
+			return
+		}
+	}
+
+	_, err = global.DB.Exec(`
+		INSERT INTO subject_prompts (subject_id, template_name, custom_content, language)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(subject_id) DO UPDATE SET
+			template_name = excluded.template_name,
+			custom_content = excluded.custom_content,
+			language = excluded.language,
+			update_time = CURRENT_TIMESTAMP`,
+		subjectID, req.TemplateName, req.CustomContent, req.Language)
+	if err != nil {
+		global.GetLog(c).Errorf("保存科目提示词设置失败 (SubjectID: %d): %v", subjectID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "保存失败"})
+		return
+	}
+
+	userCode, _ := c.Get("userCode")
+	global.GetLog(c).Infof("用户[%v] 更新科目提示词设置成功 (SubjectID: %d)", userCode, subjectID)
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "保存成功"})
+}
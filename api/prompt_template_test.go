@@ -0,0 +1,66 @@
+package api
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestValidatePromptTemplateRestrictsSyntax(t *testing.T) {
+	if err := validatePromptTemplate(defaultInterviewPrompt); err != nil {
+		t.Fatalf("builtin template rejected: %v", err)
+	}
+	if err := validatePromptTemplate(`{{with .Content}}参考：{{.}}{{else}}无{{end}}`); err != nil {
+		t.Errorf("with/else rejected: %v", err)
+	}
+
+	for name, content := range map[string]string{
+		"range":    `{{range 1000000000}}xxxxxxxx{{end}}`,
+		"function": `{{printf "%01000000000d" 1}}`,
+		"pipeline": `{{.Topic | len}}`,
+		"variable": `{{$x := .Topic}}{{$x}}`,
+		"define":   `{{define "a"}}x{{end}}{{.Topic}}`,
+		"template": `{{template "prompt" .}}`,
+		"too long": strings.Repeat("x", 2*promptMaxTemplateOutput),
+	} {
+		if err := validatePromptTemplate(content); err == nil {
+			t.Errorf("%s: want error, got nil", name)
+		}
+	}
+}
+
+func TestRecreateDeletedPromptTemplate(t *testing.T) {
+	setupTestDB(t)
+	adminID := createTestUser(t, "admin")
+	params := gin.Params{{Key: "name", Value: "strict"}}
+
+	if w := callHandler(AdminCreatePromptTemplate, adminID, "admin", nil, `{"name":"strict","content":"{{.Topic}}"}`); w.Code != 200 {
+		t.Fatalf("create: status %d, body %s", w.Code, w.Body.String())
+	}
+	if w := callHandler(AdminCreatePromptTemplate, adminID, "admin", nil, `{"name":"strict","content":"{{.Topic}}"}`); w.Code != 409 {
+		t.Errorf("create duplicate: want 409, got %d", w.Code)
+	}
+	if w := callHandler(AdminDeletePromptTemplate, adminID, "admin", params, ""); w.Code != 200 {
+		t.Fatalf("delete: status %d, body %s", w.Code, w.Body.String())
+	}
+
+	// 删除后可以重新使用该名称，版本号接续历史
+	w := callHandler(AdminCreatePromptTemplate, adminID, "admin", nil, `{"name":"strict","content":"{{.Topic}} {{.Language}}"}`)
+	if w.Code != 200 {
+		t.Fatalf("recreate: status %d, body %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data struct {
+			Version int `json:"version"`
+		} `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Data.Version != 2 {
+		t.Errorf("recreated version = %d, want 2", resp.Data.Version)
+	}
+	if tpl, err := getPromptTemplate("strict", 0); err != nil || tpl.Version != 2 {
+		t.Errorf("recreated template not active: %+v, %v", tpl, err)
+	}
+}
@@ -0,0 +1,229 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"practice_problems/global"
+	"practice_problems/middleware"
+	"practice_problems/model"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==========================================
+// 实时推送 (Server-Sent Events)
+// GET /api/v1/events/stream?token=xxx&subjects=1,2
+//   - EventSource 不能带请求头，Token 通过 query 传递 (同 AI 面试 WebSocket)，也兼容 Authorization 头
+//   - 连接建立后先发 ready 事件 (含 connId)，之后可通过 PUT /events/subscriptions 调整订阅的科目
+//   - 断线重连时浏览器自动带 Last-Event-ID，服务端从缓冲区补发；缓冲区不足时发 reset 事件，客户端应全量刷新
+//   - 定期发送 heartbeat 事件保持连接，同时重新校验 Token，已登出或过期的连接直接断开
+// ==========================================
+
+// pushRetryMillis 建议客户端的重连间隔
+const pushRetryMillis = 3000
+
+// PushEventStream 建立推送连接
+func PushEventStream(c *gin.Context) {
+	userID, userCode, token, ok := authenticateStream(c)
+	if !ok {
+		return
+	}
+
+	subjects, err := filterReadableSubjects(userID, userCode, parseIDList(c.Query("subjects")))
+	if err != nil {
+		global.GetLog(c).Errorf("推送连接查询可读科目失败 (User: %d): %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "系统错误"})
+		return
+	}
+
+	lastIDStr := c.GetHeader("Last-Event-ID")
+	if lastIDStr == "" {
+		lastIDStr = c.Query("lastEventId")
+	}
+	lastID, _ := strconv.ParseUint(strings.TrimSpace(lastIDStr), 10, 64)
+
+	pc, replay, reset := hub.register(userID, token, subjects, lastID)
+	defer hub.unregister(pc)
+
+	w := c.Writer
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no") // 关闭 Nginx 缓冲
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", pushRetryMillis)
+	writeSSE(w, 0, "ready", gin.H{
+		"connId":      pc.id,
+		"subjects":    subjectKeys(subjects),
+		"lastEventId": hub.lastEventID(),
+	})
+	if reset {
+		writeSSE(w, 0, "reset", gin.H{"msg": "部分事件已无法补发，请重新加载数据"})
+	}
+	for _, ev := range replay {
+		writeSSERaw(w, ev.ID, ev.Type, ev.Data)
+	}
+	w.Flush()
+
+	global.GetLog(c).Infof("推送连接建立 (User: %d, Conn: %s, Subjects: %d, Replay: %d)", userID, pc.id, len(subjects), len(replay))
+
+	heartbeat := time.NewTicker(time.Duration(global.PushHeartbeatSeconds) * time.Second)
+	defer heartbeat.Stop()
+
+	ctx := c.Request.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			global.GetLog(c).Debugf("推送连接关闭 (User: %d, Conn: %s)", userID, pc.id)
+			return
+		case <-pc.done:
+			global.GetLog(c).Infof("推送连接被服务端断开 (User: %d, Conn: %s)", userID, pc.id)
+			return
+		case ev := <-pc.ch:
+			writeSSERaw(w, ev.ID, ev.Type, ev.Data)
+			w.Flush()
+		case now := <-heartbeat.C:
+			if !streamTokenValid(token) {
+				writeSSE(w, 0, "unauthorized", gin.H{"msg": "登录已失效，请重新登录"})
+				w.Flush()
+				global.GetLog(c).Infof("推送连接 Token 已失效，断开 (User: %d, Conn: %s)", userID, pc.id)
+				return
+			}
+			writeSSE(w, 0, "heartbeat", gin.H{"time": now.Unix()})
+			w.Flush()
+		}
+	}
+}
+
+// =================================================================================
+// UpdatePushSubscriptions 调整推送连接订阅的科目 (整体替换)
+// =================================================================================
+func UpdatePushSubscriptions(c *gin.Context) {
+	var req model.UpdatePushSubscriptionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "参数错误"})
+		return
+	}
+	userID, userCode := currentUser(c)
+
+	subjects, err := filterReadableSubjects(userID, userCode, req.SubjectIDs)
+	if err != nil {
+		global.GetLog(c).Errorf("推送订阅查询可读科目失败 (User: %d): %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "系统错误"})
+		return
+	}
+	if !hub.setSubjects(req.ConnID, userID, subjects) {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "推送连接不存在或已断开"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "订阅成功", "data": gin.H{"subjects": subjectKeys(subjects)}})
+}
+
+// authenticateStream 推送连接鉴权 (query token 或 Authorization 头)，失败时已写入响应
+func authenticateStream(c *gin.Context) (int, string, string, bool) {
+	token := c.Query("token")
+	if token == "" {
+		if parts := strings.SplitN(c.GetHeader("Authorization"), " ", 2); len(parts) == 2 && parts[0] == "Bearer" {
+			token = parts[1]
+		}
+	}
+	if token == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "msg": "未携带 Token"})
+		return 0, "", "", false
+	}
+
+	exists, userCode := global.VerifyToken(token)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "msg": "Token 已失效或已登出"})
+		return 0, "", "", false
+	}
+	claims, err := middleware.ParseToken(token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "msg": "Token 解析失败"})
+		return 0, "", "", false
+	}
+	c.Set("userID", claims.UserID)
+	c.Set("userCode", userCode)
+	return claims.UserID, userCode, token, true
+}
+
+// streamTokenValid 长连接期间重新校验 Token：仍在白名单中且未过期
+func streamTokenValid(token string) bool {
+	if exists, _ := global.VerifyToken(token); !exists {
+		return false
+	}
+	_, err := middleware.ParseToken(token)
+	return err == nil
+}
+
+// filterReadableSubjects 过滤出用户可读的科目 (创建者、协作成员或有效授权)，最多 global.PushMaxSubjects 个
+func filterReadableSubjects(userID int, userCode string, ids []int) (map[int]bool, error) {
+	result := make(map[int]bool)
+	if len(ids) == 0 {
+		return result, nil
+	}
+	readable, err := readableSubjects(userID, userCode)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		if len(result) >= global.PushMaxSubjects {
+			break
+		}
+		if readable[id] {
+			result[id] = true
+			continue
+		}
+		var role string
+		if global.DB.QueryRow("SELECT role FROM subject_members WHERE subject_id = ? AND user_id = ?", id, userID).Scan(&role) == nil {
+			result[id] = true
+		}
+	}
+	return result, nil
+}
+
+// parseIDList 解析逗号分隔的 ID 列表，忽略非法项
+func parseIDList(s string) []int {
+	var ids []int
+	for _, part := range strings.Split(s, ",") {
+		if id, err := strconv.Atoi(strings.TrimSpace(part)); err == nil && id > 0 {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// subjectKeys 订阅集合转为列表
+func subjectKeys(m map[int]bool) []int {
+	ids := make([]int, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// writeSSE 写入一条事件，id 为 0 时不带 id 字段 (不影响客户端的 Last-Event-ID)
+func writeSSE(w gin.ResponseWriter, id uint64, event string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	writeSSERaw(w, id, event, payload)
+}
+
+// writeSSERaw 写入已序列化的事件
+func writeSSERaw(w gin.ResponseWriter, id uint64, event string, data []byte) {
+	if id > 0 {
+		fmt.Fprintf(w, "id: %d\n", id)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+}
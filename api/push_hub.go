@@ -0,0 +1,252 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"practice_problems/global"
+	"practice_problems/model"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==========================================
+// 实时推送中心 (进程内发布/订阅)
+// 1. 事件带递增 ID，最近 global.PushReplayBufferSize 条保存在环形缓冲区，断线重连时按 Last-Event-ID 补发
+// 2. 事件按用户 (通知) 或按科目 (内容变更) 投递；连接在建立时及之后可调整订阅的科目
+// 3. 每个连接有固定长度的发送队列，队列满说明客户端太慢，直接断开，由客户端重连补发
+// 4. 连接记录建立时使用的 Token，登出、重置密码、禁用账号时按 Token 或用户立即断开
+// ==========================================
+
+// 推送事件类型
+const (
+	PushEventNotification  = "notification"    // 新的站内通知
+	PushEventContentChange = "content_changed" // 订阅科目的内容变更
+)
+
+// pushEvent 一条推送事件
+type pushEvent struct {
+	ID        uint64
+	Type      string
+	UserID    int    // >0 时只投递给该用户
+	SubjectID int    // >0 时投递给订阅了该科目的连接
+	Data      []byte // JSON
+}
+
+// pushConn 一个推送连接
+type pushConn struct {
+	id       string
+	userID   int
+	token    string       // 建立连接时使用的 Token
+	subjects map[int]bool // 订阅的科目 (由 hub.mu 保护)
+	ch       chan *pushEvent
+	done     chan struct{} // 被服务端断开时关闭
+	once     sync.Once
+}
+
+// kick 断开连接 (可重复调用)
+func (pc *pushConn) kick() {
+	pc.once.Do(func() { close(pc.done) })
+}
+
+// matches 事件是否投递给该连接
+func (pc *pushConn) matches(ev *pushEvent) bool {
+	if ev.UserID > 0 {
+		return ev.UserID == pc.userID
+	}
+	return ev.SubjectID > 0 && pc.subjects[ev.SubjectID]
+}
+
+// pushHub 推送中心
+type pushHub struct {
+	mu     sync.Mutex
+	seq    uint64
+	buffer []*pushEvent // 环形缓冲区，按 ID 递增
+	start  int          // buffer 中最早事件的下标
+	conns  map[string]*pushConn
+}
+
+var hub = &pushHub{conns: make(map[string]*pushConn)}
+
+// pushQueueSize 每个连接的发送队列长度
+const pushQueueSize = 64
+
+// publish 发布事件，data 序列化为 JSON
+func (h *pushHub) publish(eventType string, userID, subjectID int, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		global.GetLog(nil).Errorf("推送事件序列化失败 (Type: %s): %v", eventType, err)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.seq++
+	ev := &pushEvent{ID: h.seq, Type: eventType, UserID: userID, SubjectID: subjectID, Data: payload}
+	h.appendBuffer(ev)
+
+	for _, pc := range h.conns {
+		if !pc.matches(ev) {
+			continue
+		}
+		select {
+		case pc.ch <- ev:
+		default:
+			global.GetLog(nil).Warnf("推送队列已满，断开连接 (Conn: %s, User: %d)", pc.id, pc.userID)
+			pc.kick()
+		}
+	}
+}
+
+// appendBuffer 写入环形缓冲区 (调用方持有锁)
+func (h *pushHub) appendBuffer(ev *pushEvent) {
+	size := global.PushReplayBufferSize
+	if size <= 0 {
+		return
+	}
+	if len(h.buffer) < size {
+		h.buffer = append(h.buffer, ev)
+		return
+	}
+	h.buffer[h.start] = ev
+	h.start = (h.start + 1) % len(h.buffer)
+}
+
+// register 注册连接并取出需要补发的事件
+// lastID 为客户端最后收到的事件 ID (0 表示新连接，不补发)；缓冲区已不含 lastID 之后的全部事件时 reset 为 true，客户端应全量刷新
+func (h *pushHub) register(userID int, token string, subjects map[int]bool, lastID uint64) (pc *pushConn, replay []*pushEvent, reset bool) {
+	pc = &pushConn{
+		id:       newPushConnID(),
+		userID:   userID,
+		token:    token,
+		subjects: subjects,
+		ch:       make(chan *pushEvent, pushQueueSize),
+		done:     make(chan struct{}),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// 在同一把锁内完成注册与补发快照，保证补发与实时事件之间不丢不重
+	h.conns[pc.id] = pc
+	if lastID == 0 || lastID == h.seq {
+		return pc, nil, false
+	}
+	if lastID > h.seq {
+		// 服务重启后 ID 重新计数，客户端的 ID 已无意义
+		return pc, nil, true
+	}
+
+	n := len(h.buffer)
+	if n == 0 || h.buffer[h.start].ID > lastID+1 {
+		reset = true
+	}
+	for i := 0; i < n; i++ {
+		ev := h.buffer[(h.start+i)%n]
+		if ev.ID > lastID && pc.matches(ev) {
+			replay = append(replay, ev)
+		}
+	}
+	return pc, replay, reset
+}
+
+// unregister 注销连接
+func (h *pushHub) unregister(pc *pushConn) {
+	h.mu.Lock()
+	delete(h.conns, pc.id)
+	h.mu.Unlock()
+	pc.kick()
+}
+
+// setSubjects 替换连接订阅的科目，连接不存在或不属于该用户时返回 false
+func (h *pushHub) setSubjects(connID string, userID int, subjects map[int]bool) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	pc, ok := h.conns[connID]
+	if !ok || pc.userID != userID {
+		return false
+	}
+	pc.subjects = subjects
+	return true
+}
+
+// disconnectUser 断开用户的全部推送连接 (Token 被全部清除时调用)，返回断开的连接数
+func (h *pushHub) disconnectUser(userID int) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n := 0
+	for _, pc := range h.conns {
+		if pc.userID == userID {
+			pc.kick()
+			n++
+		}
+	}
+	return n
+}
+
+// disconnectToken 断开使用指定 Token 建立的推送连接 (登出时调用)，返回断开的连接数
+func (h *pushHub) disconnectToken(token string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n := 0
+	for _, pc := range h.conns {
+		if pc.token == token {
+			pc.kick()
+			n++
+		}
+	}
+	return n
+}
+
+// lastEventID 当前最新的事件 ID
+func (h *pushHub) lastEventID() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.seq
+}
+
+// newPushConnID 连接 ID
+func newPushConnID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// =================================================================================
+// 业务侧发布入口
+// =================================================================================
+
+// disconnectUserConnections 断开用户的全部长连接 (SSE 推送与 AI 面试 WebSocket)，用于重置密码、禁用账号等清除全部 Token 的场景
+func disconnectUserConnections(userID int) {
+	hub.disconnectUser(userID)
+	closeAIInterviewSessions(func(s *AIInterviewSession) bool { return s.UserID == userID })
+}
+
+// disconnectTokenConnections 断开使用指定 Token 建立的长连接，用于登出
+func disconnectTokenConnections(token string) {
+	hub.disconnectToken(token)
+	closeAIInterviewSessions(func(s *AIInterviewSession) bool { return s.token == token })
+}
+
+// pushNotification 推送一条新通知给用户
+func pushNotification(userID int, n model.Notification) {
+	hub.publish(PushEventNotification, userID, 0, n)
+}
+
+// pushContentChange 推送科目内容变更 (entity: subject/category/point/question，action: create/update/delete/sort)
+// 事件带操作人 ID，客户端可以忽略自己触发的变更
+func pushContentChange(c *gin.Context, subjectID int, entity string, entityID int64, action string) {
+	if subjectID <= 0 {
+		return
+	}
+	operatorID, _ := currentUser(c)
+	hub.publish(PushEventContentChange, 0, subjectID, gin.H{
+		"subjectId":  subjectID,
+		"entity":     entity,
+		"id":         entityID,
+		"action":     action,
+		"operatorId": operatorID,
+	})
+}
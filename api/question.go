@@ -3,7 +3,6 @@ package api
 import (
 	"database/sql"
 	"fmt"
-	"log"
 	"math/rand"
 	_ "net/http"
 	"practice_problems/global"
@@ -84,7 +83,7 @@ func GetQuestionList(c *gin.Context) {
 			FROM knowledge_points p
 			JOIN knowledge_categories c ON p.categorie_id = c.id 
 			JOIN subjects s ON c.subject_id = s.id
-			WHERE p.id = ?
+			WHERE p.id = ? AND p.deleted_at IS NULL
 		`
 		err = global.DB.QueryRow(findSubjectSQL, pointID).Scan(&subjectID, &creatorCode)
 	} else {
@@ -93,7 +92,7 @@ func GetQuestionList(c *gin.Context) {
 			SELECT s.id, s.creator_code
 			FROM knowledge_categories c
 			JOIN subjects s ON c.subject_id = s.id
-			WHERE c.id = ?
+			WHERE c.id = ? AND c.deleted_at IS NULL
 		`
 		err = global.DB.QueryRow(findSubjectSQL, categoryID).Scan(&subjectID, &creatorCode)
 	}
@@ -116,11 +115,11 @@ func GetQuestionList(c *gin.Context) {
 		// 2. 我是订阅者 (使用了 userID)
 		checkBindSQL := `
 			SELECT count(*) 
-			FROM user_subjects 
-			WHERE user_id = ? 
-			  AND subject_id = ? 
-			  AND status = 1 
-			  AND (expire_time IS NULL OR expire_time > datetime('now', 'localtime'))
+			FROM user_subjects us
+			WHERE us.user_id = ? 
+			  AND us.subject_id = ? 
+			  AND us.status = 1 
+			  AND ` + subscriptionReadableSQL + `
 		`
 		var count int
 		err := global.DB.QueryRow(checkBindSQL, userID, subjectID).Scan(&count)
@@ -142,14 +141,23 @@ func GetQuestionList(c *gin.Context) {
 
 	// 第一阶段：只查询题目ID（轻量级）
 	if pointID != "" {
-		idSQL := `SELECT id FROM questions WHERE knowledge_point_id = ?`
+		idSQL := `SELECT id FROM questions WHERE knowledge_point_id = ? AND deleted_at IS NULL`
 		idRows, idQueryErr = global.DB.Query(idSQL, pointID)
+	} else if c.DefaultQuery("recursive", "1") == "0" {
+		idSQL := `
+			SELECT q.id 
+			FROM questions q
+			JOIN knowledge_points p ON q.knowledge_point_id = p.id
+			WHERE p.categorie_id = ? AND p.deleted_at IS NULL AND q.deleted_at IS NULL
+		`
+		idRows, idQueryErr = global.DB.Query(idSQL, categoryID)
 	} else {
+		// 默认包含全部子分类下的题目 (recursive=0 时只取本分类)
 		idSQL := `
 			SELECT q.id 
 			FROM questions q
 			JOIN knowledge_points p ON q.knowledge_point_id = p.id
-			WHERE p.categorie_id = ?
+			WHERE p.categorie_id IN (` + descendantCategoriesSQL + `) AND p.deleted_at IS NULL AND q.deleted_at IS NULL
 		`
 		idRows, idQueryErr = global.DB.Query(idSQL, categoryID)
 	}
@@ -199,11 +207,11 @@ func GetQuestionList(c *gin.Context) {
 	// 第二阶段：查询选中题目的详细信息
 	querySQL := fmt.Sprintf(`
 		SELECT q.id, q.knowledge_point_id, q.question_text, 
-		       q.option1, q.option1_img, q.option2, q.option2_img, 
-		       q.option3, q.option3_img, q.option4, q.option4_img, 
-		       q.correct_answer, q.explanation, 
+		       IFNULL(q.option1, ''), IFNULL(q.option1_img, ''), IFNULL(q.option2, ''), IFNULL(q.option2_img, ''), 
+		       IFNULL(q.option3, ''), IFNULL(q.option3_img, ''), IFNULL(q.option4, ''), IFNULL(q.option4_img, ''), 
+		       q.correct_answer, IFNULL(q.explanation, ''), 
 		       IFNULL(un.note, '') as user_note, 
-		       q.create_time 
+		       q.create_time, q.version 
 		FROM questions q
 		LEFT JOIN question_user_notes un ON q.id = un.question_id AND un.user_id = ?
 		WHERE q.id IN (%s)
@@ -229,6 +237,7 @@ func GetQuestionList(c *gin.Context) {
 			&q.CorrectAnswer, &q.Explanation,
 			&q.Note, // 这里存入的是用户的私有备注
 			&q.CreateTime,
+			&q.Version,
 		)
 		if err != nil {
 			global.GetLog(c).Errorf("Scan error: %v", err) // 建议加上日志，方便排查
@@ -255,28 +264,29 @@ func CreateQuestion(c *gin.Context) {
 
 	// --- 权限检查 ---
 	var subjectCreatorCode string
+	var permSubjectID int
 	var creatorName string
 	var creatorEmail sql.NullString
 
 	checkSQL := `
-		SELECT s.creator_code, IFNULL(u.nickname, u.username), u.email
+		SELECT s.creator_code, s.id, IFNULL(u.nickname, u.username), u.email
 		FROM knowledge_points p
 		JOIN knowledge_categories c ON p.categorie_id = c.id
 		JOIN subjects s ON c.subject_id = s.id
 		LEFT JOIN users u ON s.creator_code = u.user_code
-		WHERE p.id = ?
+		WHERE p.id = ? AND p.deleted_at IS NULL
 	`
-	err := global.DB.QueryRow(checkSQL, req.KnowledgePointID).Scan(&subjectCreatorCode, &creatorName, &creatorEmail)
+	err := global.DB.QueryRow(checkSQL, req.KnowledgePointID).Scan(&subjectCreatorCode, &permSubjectID, &creatorName, &creatorEmail)
 	if err != nil {
 		c.JSON(404, gin.H{"code": 404, "msg": "所属知识点不存在"})
 		return
 	}
 
-	if subjectCreatorCode != currentUserCodeStr {
+	if !hasSubjectRole(permSubjectID, subjectCreatorCode, currentUserCodeStr, RoleEditor) {
 		// ★★★ Warn ★★★
 		global.GetLog(c).Warnf("创建题目被拒: 无权操作 (User: %s, PointID: %d)", currentUserCodeStr, req.KnowledgePointID)
 		contactInfo := getContactInfo(creatorName, creatorEmail)
-		c.JSON(403, gin.H{"code": 403, "msg": "创建失败：您不是该科目的作者，请联系 " + contactInfo})
+		c.JSON(403, gin.H{"code": 403, "msg": "创建失败：您没有该科目的编辑权限，请联系 " + contactInfo})
 		return
 	}
 
@@ -290,7 +300,15 @@ func CreateQuestion(c *gin.Context) {
 		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	res, err := global.DB.Exec(insertSQL,
+	tx, err := global.DB.Begin()
+	if err != nil {
+		global.GetLog(c).Errorf("开启事务失败: %v", err)
+		c.JSON(500, gin.H{"code": 500, "msg": "系统错误"})
+		return
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(insertSQL,
 		req.KnowledgePointID, req.QuestionText,
 		req.Option1, req.Option1Img, req.Option2, req.Option2Img,
 		req.Option3, req.Option3Img, req.Option4, req.Option4Img,
@@ -305,7 +323,20 @@ func CreateQuestion(c *gin.Context) {
 	}
 
 	id, _ := res.LastInsertId()
+
+	// 记录第 1 条修订，作答记录据此判分
+	if _, err := recordQuestionRevisionTx(tx, int(id), model.RevisionActionCreate, currentUserCodeStr); err != nil {
+		global.GetLog(c).Errorf("记录题目修订失败 (ID: %d): %v", id, err)
+		c.JSON(500, gin.H{"code": 500, "msg": "创建失败"})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		global.GetLog(c).Errorf("提交题目创建失败: %v", err)
+		c.JSON(500, gin.H{"code": 500, "msg": "创建失败"})
+		return
+	}
 	// ★★★ Info ★★★
+	pushContentChange(c, permSubjectID, "question", id, "create")
 	global.GetLog(c).Infof("用户[%s] 创建题目成功: ID=%d", currentUserCodeStr, id)
 	c.JSON(200, gin.H{"code": 200, "msg": "创建成功", "data": gin.H{"id": id}})
 }
@@ -328,31 +359,44 @@ func UpdateQuestion(c *gin.Context) {
 
 	// --- 权限检查 ---
 	var subjectCreatorCode string
+	var permSubjectID int
+	var currentVersion int
 	var creatorName string
 	var creatorEmail sql.NullString
 
 	checkSQL := `
-		SELECT s.creator_code, IFNULL(u.nickname, u.username), u.email
+		SELECT s.creator_code, s.id, q.version, IFNULL(u.nickname, u.username), u.email
 		FROM questions q
 		JOIN knowledge_points p ON q.knowledge_point_id = p.id
 		JOIN knowledge_categories c ON p.categorie_id = c.id
 		JOIN subjects s ON c.subject_id = s.id
 		LEFT JOIN users u ON s.creator_code = u.user_code
-		WHERE q.id = ?
+		WHERE q.id = ? AND q.deleted_at IS NULL
 	`
-	err := global.DB.QueryRow(checkSQL, id).Scan(&subjectCreatorCode, &creatorName, &creatorEmail)
+	err := global.DB.QueryRow(checkSQL, id).Scan(&subjectCreatorCode, &permSubjectID, &currentVersion, &creatorName, &creatorEmail)
 	if err != nil {
 		c.JSON(404, gin.H{"code": 404, "msg": "题目不存在"})
 		return
 	}
 
-	if subjectCreatorCode != currentUserCodeStr {
+	if !hasSubjectRole(permSubjectID, subjectCreatorCode, currentUserCodeStr, RoleEditor) {
 		global.GetLog(c).Warnf("修改题目被拒: 无权操作 (User: %s, QuestionID: %d)", currentUserCodeStr, id)
 		contactInfo := getContactInfo(creatorName, creatorEmail)
 		c.JSON(403, gin.H{"code": 403, "msg": "修改失败：请联系科目作者 " + contactInfo})
 		return
 	}
 
+	// --- 乐观锁：版本不一致说明已被他人修改 ---
+	clientVersion, ok := resolveEditVersion(c, req.Version, currentVersion)
+	if !ok {
+		return
+	}
+	if clientVersion != currentVersion {
+		global.GetLog(c).Warnf("修改题目版本冲突 (User: %s, QuestionID: %d, Client: %d, Server: %d)", currentUserCodeStr, id, clientVersion, currentVersion)
+		respondQuestionConflict(c, id)
+		return
+	}
+
 	// 修改点：SQL 中去掉了 note=?, 以及参数中的 req.Note
 	updateSQL := `
         UPDATE questions SET 
@@ -363,10 +407,26 @@ func UpdateQuestion(c *gin.Context) {
         option4=?, option4_img=?, 
         correct_answer=?, explanation=?, 
         -- note=?,  <-- 删掉这一行，不再更新原表的 note
-        update_time=CURRENT_TIMESTAMP
-        WHERE id=?
+        update_time=CURRENT_TIMESTAMP,
+        version=version+1
+        WHERE id=? AND version=?
     `
-	_, err = global.DB.Exec(updateSQL,
+	tx, err := global.DB.Begin()
+	if err != nil {
+		global.GetLog(c).Errorf("开启事务失败: %v", err)
+		c.JSON(500, gin.H{"code": 500, "msg": "系统错误"})
+		return
+	}
+	defer tx.Rollback()
+
+	// 修订功能上线前创建的题目，先保留修改前的内容
+	if err := ensureInitialQuestionRevisionTx(tx, id); err != nil {
+		global.GetLog(c).Errorf("记录题目原始版本失败 (ID: %d): %v", id, err)
+		c.JSON(500, gin.H{"code": 500, "msg": "更新失败"})
+		return
+	}
+
+	res, err := tx.Exec(updateSQL,
 		req.QuestionText,
 		req.Option1, req.Option1Img,
 		req.Option2, req.Option2Img,
@@ -374,7 +434,7 @@ func UpdateQuestion(c *gin.Context) {
 		req.Option4, req.Option4Img,
 		req.CorrectAnswer, req.Explanation,
 		// req.Note, <-- 删掉这一行参数
-		id,
+		id, clientVersion,
 	)
 
 	if err != nil {
@@ -382,9 +442,57 @@ func UpdateQuestion(c *gin.Context) {
 		c.JSON(500, gin.H{"code": 500, "msg": "更新失败"})
 		return
 	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		// 校验之后、写入之前被他人抢先保存
+		tx.Rollback()
+		respondQuestionConflict(c, id)
+		return
+	}
+
+	if _, err := recordQuestionRevisionTx(tx, id, model.RevisionActionUpdate, currentUserCodeStr); err != nil {
+		global.GetLog(c).Errorf("记录题目修订失败 (ID: %d): %v", id, err)
+		c.JSON(500, gin.H{"code": 500, "msg": "更新失败"})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		global.GetLog(c).Errorf("提交题目更新失败 (ID: %d): %v", id, err)
+		c.JSON(500, gin.H{"code": 500, "msg": "更新失败"})
+		return
+	}
 
+	pushContentChange(c, permSubjectID, "question", int64(id), "update")
 	global.GetLog(c).Infof("用户[%s] 更新题目成功 (ID: %d)", currentUserCodeStr, id)
-	c.JSON(200, gin.H{"code": 200, "msg": "更新成功"})
+	setVersionHeader(c, clientVersion+1)
+	c.JSON(200, gin.H{"code": 200, "msg": "更新成功", "data": gin.H{"version": clientVersion + 1}})
+}
+
+// getQuestionData 读取题目当前内容 (不含用户备注)
+func getQuestionData(id int) (*model.Question, error) {
+	var q model.Question
+	err := global.DB.QueryRow(`
+		SELECT id, knowledge_point_id, question_text,
+		       IFNULL(option1, ''), IFNULL(option1_img, ''), IFNULL(option2, ''), IFNULL(option2_img, ''),
+		       IFNULL(option3, ''), IFNULL(option3_img, ''), IFNULL(option4, ''), IFNULL(option4_img, ''),
+		       correct_answer, IFNULL(explanation, ''), create_time, update_time, version
+		FROM questions WHERE id = ? AND deleted_at IS NULL`, id).Scan(
+		&q.ID, &q.KnowledgePointID, &q.QuestionText,
+		&q.Option1, &q.Option1Img, &q.Option2, &q.Option2Img,
+		&q.Option3, &q.Option3Img, &q.Option4, &q.Option4Img,
+		&q.CorrectAnswer, &q.Explanation, &q.CreateTime, &q.UpdateTime, &q.Version)
+	if err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+// respondQuestionConflict 题目版本冲突，附带服务器当前内容
+func respondQuestionConflict(c *gin.Context, id int) {
+	q, err := getQuestionData(id)
+	if err != nil {
+		c.JSON(404, gin.H{"code": 404, "msg": "题目不存在"})
+		return
+	}
+	respondVersionConflict(c, q.Version, q)
 }
 
 // =================================================================================
@@ -448,40 +556,42 @@ func DeleteQuestion(c *gin.Context) {
 
 	// --- 权限检查 ---
 	var subjectCreatorCode string
+	var permSubjectID int
 	var creatorName string
 	var creatorEmail sql.NullString
 
 	checkSQL := `
-		SELECT s.creator_code, IFNULL(u.nickname, u.username), u.email
+		SELECT s.creator_code, s.id, IFNULL(u.nickname, u.username), u.email
 		FROM questions q
 		JOIN knowledge_points p ON q.knowledge_point_id = p.id
 		JOIN knowledge_categories c ON p.categorie_id = c.id
 		JOIN subjects s ON c.subject_id = s.id
 		LEFT JOIN users u ON s.creator_code = u.user_code
-		WHERE q.id = ?
+		WHERE q.id = ? AND q.deleted_at IS NULL
 	`
-	err := global.DB.QueryRow(checkSQL, id).Scan(&subjectCreatorCode, &creatorName, &creatorEmail)
+	err := global.DB.QueryRow(checkSQL, id).Scan(&subjectCreatorCode, &permSubjectID, &creatorName, &creatorEmail)
 	if err != nil {
 		c.JSON(404, gin.H{"code": 404, "msg": "题目不存在"})
 		return
 	}
 
-	if subjectCreatorCode != currentUserCodeStr {
+	if !hasSubjectRole(permSubjectID, subjectCreatorCode, currentUserCodeStr, RoleEditor) {
 		global.GetLog(c).Warnf("删除题目被拒: 无权操作 (User: %s, QuestionID: %s)", currentUserCodeStr, id)
 		contactInfo := getContactInfo(creatorName, creatorEmail)
 		c.JSON(403, gin.H{"code": 403, "msg": "删除失败：请联系科目作者 " + contactInfo})
 		return
 	}
 
-	// --- 执行删除 ---
-	_, err = global.DB.Exec("DELETE FROM questions WHERE id = ?", id)
+	// --- 移入回收站 ---
+	questionID, _ := strconv.Atoi(id)
+	err = softDelete(model.TrashTypeQuestion, questionID, currentUserCodeStr)
 	if err != nil {
-		log.Println("Delete Question Error:", err)
 		global.GetLog(c).Errorf("删除题目DB错误 (ID: %s): %v", id, err)
 		c.JSON(500, gin.H{"code": 500, "msg": "删除失败"})
 		return
 	}
 
+	pushContentChange(c, permSubjectID, "question", int64(questionID), "delete")
 	global.GetLog(c).Infof("用户[%s] 删除题目成功 (ID: %s)", currentUserCodeStr, id)
 	c.JSON(200, gin.H{"code": 200, "msg": "删除成功"})
 }
@@ -0,0 +1,177 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"practice_problems/deepseek"
+	"practice_problems/global"
+	"practice_problems/model"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// ==========================================
+// AI 错题解析：按 (题目, 所选选项) 缓存，未命中缓存时扣除 AI 时长
+// ==========================================
+
+// explainWrongAnswerPrompt 错题解析的 System Prompt
+const explainWrongAnswerPrompt = `你是一位耐心的辅导老师。学生做错了一道单项选择题，请根据提供的知识点内容：
+1. 说明学生所选选项错在哪里；
+2. 说明正确选项为什么正确；
+3. 如有必要，点出容易混淆的概念。
+回答要简洁，使用中文，不超过 400 字，不要编造知识点内容中没有的事实。`
+
+// =================================================================================
+// ExplainWrongAnswer 针对学生的错误选项生成 AI 解析
+// =================================================================================
+func ExplainWrongAnswer(c *gin.Context) {
+	questionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+
+	var req model.ExplainAnswerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "参数错误: " + err.Error()})
+		return
+	}
+
+	userIDVal, _ := c.Get("userID")
+	userID, _ := userIDVal.(int)
+	userCodeVal, _ := c.Get("userCode")
+	userCode, _ := userCodeVal.(string)
+
+	// 1. 查询题目与知识点内容，同时校验访问权限 (创建者或有效授权)
+	var q model.Question
+	var pointTitle string
+	var pointContent sql.NullString
+	var explanation sql.NullString
+	var option1, option2, option3, option4 sql.NullString
+	err = global.DB.QueryRow(`
+		SELECT q.id, q.question_text, q.option1, q.option2, q.option3, q.option4,
+		       q.correct_answer, q.explanation, q.update_time, p.title, p.content
+		FROM questions q
+		JOIN knowledge_points p ON q.knowledge_point_id = p.id
+		JOIN knowledge_categories c ON p.categorie_id = c.id
+		JOIN subjects s ON c.subject_id = s.id
+		LEFT JOIN user_subjects us ON us.subject_id = s.id AND us.user_id = ?
+		WHERE q.id = ? AND q.deleted_at IS NULL
+		  AND (
+		      s.creator_code = ?
+		      OR
+		      (us.id IS NOT NULL AND us.status = 1 AND (us.expire_time IS NULL OR us.expire_time > datetime('now')))
+		  )`, userID, questionID, userCode).Scan(
+		&q.ID, &q.QuestionText, &option1, &option2, &option3, &option4,
+		&q.CorrectAnswer, &explanation, &q.UpdateTime, &pointTitle, &pointContent)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "题目不存在或无权访问"})
+		return
+	} else if err != nil {
+		global.GetLog(c).Errorf("查询题目失败 (ID: %d): %v", questionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+
+	options := []string{option1.String, option2.String, option3.String, option4.String}
+	if strings.TrimSpace(options[req.ChosenOption-1]) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "所选选项不存在"})
+		return
+	}
+	if req.ChosenOption == q.CorrectAnswer {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "回答正确，无需解析"})
+		return
+	}
+
+	// 2. 命中缓存直接返回 (题目修改后缓存自动失效)
+	var cached string
+	err = global.DB.QueryRow(`
+		SELECT content FROM question_ai_explanations
+		WHERE question_id = ? AND chosen_option = ? AND question_update_time = ?`,
+		questionID, req.ChosenOption, q.UpdateTime).Scan(&cached)
+	if err == nil {
+		c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "success", "data": gin.H{"content": cached, "cached": true, "charged": 0}})
+		return
+	}
+
+	// 3. 未命中缓存，检查 AI 服务与时长余额
+	if ready, err := deepseek.IsReady(); !ready {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"code": 503, "msg": fmt.Sprintf("AI服务不可用: %v", err)})
+		return
+	}
+	// 先在同一事务中按余额条件预扣时长，再调用大模型，调用失败时退回
+	cost := global.AIExplainCostSeconds
+	remark := fmt.Sprintf("AI 错题解析 (QuestionID: %d)", questionID)
+	reserved, balance, err := reserveAIQuota(userID, cost, remark)
+	if err != nil {
+		global.GetLog(c).Errorf("预扣 AI 时长失败 (UserID: %d): %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "扣费失败"})
+		return
+	}
+	if !reserved {
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": fmt.Sprintf("AI 时长不足 (每次解析消耗 %d 秒)", cost), "data": gin.H{"balance": balance}})
+		return
+	}
+
+	// 4. 调用大模型
+	content := strings.TrimSpace(pointContent.String)
+	if r := []rune(content); len(r) > maxPromptContentRunes {
+		content = string(r[:maxPromptContentRunes]) + "……"
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("知识点：%s\n%s\n\n", pointTitle, content))
+	sb.WriteString(fmt.Sprintf("题目：%s\n", q.QuestionText))
+	for i, opt := range options {
+		if strings.TrimSpace(opt) != "" {
+			sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, opt))
+		}
+	}
+	sb.WriteString(fmt.Sprintf("\n正确答案：%d\n学生选择：%d\n", q.CorrectAnswer, req.ChosenOption))
+	if explanation.Valid && explanation.String != "" {
+		sb.WriteString(fmt.Sprintf("参考解析：%s\n", explanation.String))
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Minute)
+	defer cancel()
+	reply, usage, err := deepseek.ChatWithUsage(ctx, []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: explainWrongAnswerPrompt},
+		{Role: openai.ChatMessageRoleUser, Content: sb.String()},
+	})
+	if err != nil {
+		global.GetLog(c).Errorf("AI 错题解析调用失败 (QuestionID: %d): %v", questionID, err)
+		if _, rerr := refundAIQuota(userID, cost, remark); rerr != nil {
+			global.GetLog(c).Errorf("退回 AI 时长失败 (UserID: %d, Seconds: %d): %v", userID, cost, rerr)
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"code": 502, "msg": "AI 思考超时或服务繁忙，请重试"})
+		return
+	}
+	reply = strings.TrimSpace(reply)
+
+	// 5. 写缓存 (时长已预扣，缓存失败不影响本次返回)
+	_, err = global.DB.Exec(`
+		INSERT INTO question_ai_explanations (question_id, chosen_option, content, question_update_time, prompt_tokens, completion_tokens)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(question_id, chosen_option) DO UPDATE SET
+			content = excluded.content,
+			question_update_time = excluded.question_update_time,
+			prompt_tokens = excluded.prompt_tokens,
+			completion_tokens = excluded.completion_tokens,
+			create_time = CURRENT_TIMESTAMP`,
+		questionID, req.ChosenOption, reply, q.UpdateTime, usage.PromptTokens, usage.CompletionTokens)
+	if err != nil {
+		global.GetLog(c).Errorf("缓存 AI 解析失败 (QuestionID: %d): %v", questionID, err)
+	}
+
+	global.GetLog(c).Infof("用户[%s] 获取 AI 错题解析成功 (QuestionID: %d, Option: %d, Tokens: %d)", userCode, questionID, req.ChosenOption, usage.TotalTokens)
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "success",
+		"data": gin.H{"content": reply, "cached": false, "charged": cost, "balance": balance},
+	})
+}
@@ -0,0 +1,580 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"practice_problems/deepseek"
+	"practice_problems/global"
+	"practice_problems/model"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// ==========================================
+// AI 出题：根据知识点内容生成草稿题，作者审核后发布
+// ==========================================
+
+const (
+	defaultGenerateCount = 5
+	maxGenerateCount     = 10
+	// 发送给模型的知识点正文上限，过长的正文截断处理
+	maxGenerateContentRunes = 6000
+)
+
+// generateQuestionsPrompt 出题的 System Prompt，要求严格 JSON 输出
+const generateQuestionsPrompt = `你是一位严谨的出题老师。请根据用户提供的知识点内容出 %d 道单项选择题。
+要求：
+1. 题目必须能从知识点内容中找到依据，不要超纲。
+2. 每道题恰好 4 个选项，只有 1 个正确答案，干扰项要有迷惑性但明确错误。
+3. correctAnswer 为正确选项的序号 (1-4)。
+4. explanation 简要说明为什么正确、其他选项错在哪里。
+5. 只输出 JSON，不要输出任何其他文字或 Markdown 代码块，格式如下：
+{"questions":[{"questionText":"题干","options":["选项1","选项2","选项3","选项4"],"correctAnswer":1,"explanation":"解析"}]}`
+
+// validateQuestionFields 与 CreateQuestionRequest 一致的题目校验规则
+// 题干必填；正确答案为 1-4 且对应选项不能为空；至少有两个非空选项
+func validateQuestionFields(text string, options [4]string, correct int) error {
+	if strings.TrimSpace(text) == "" {
+		return errors.New("题干不能为空")
+	}
+	if correct < 1 || correct > 4 {
+		return errors.New("正确答案必须为 1-4")
+	}
+	if strings.TrimSpace(options[correct-1]) == "" {
+		return errors.New("正确答案对应的选项为空")
+	}
+	filled := 0
+	for _, opt := range options {
+		if strings.TrimSpace(opt) != "" {
+			filled++
+		}
+	}
+	if filled < 2 {
+		return errors.New("至少需要两个选项")
+	}
+	return nil
+}
+
+// normalizeQuestionText 去掉空白与标点并转小写，用于题目去重
+func normalizeQuestionText(text string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// extractJSONObject 从模型回复中截取 JSON 对象 (容忍 ```json 包裹或前后多余文字)
+func extractJSONObject(reply string) string {
+	start := strings.Index(reply, "{")
+	end := strings.LastIndex(reply, "}")
+	if start < 0 || end <= start {
+		return ""
+	}
+	return reply[start : end+1]
+}
+
+// loadExistingQuestionKeys 该知识点下已有题目与待审核草稿的去重键
+func loadExistingQuestionKeys(pointID int) (map[string]bool, error) {
+	keys := make(map[string]bool)
+	rows, err := global.DB.Query(`
+		SELECT question_text FROM questions WHERE knowledge_point_id = ? AND deleted_at IS NULL
+		UNION ALL
+		SELECT question_text FROM question_drafts WHERE knowledge_point_id = ? AND status = ?`,
+		pointID, pointID, model.DraftStatusPending)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var text string
+		if err := rows.Scan(&text); err == nil {
+			keys[normalizeQuestionText(text)] = true
+		}
+	}
+	return keys, nil
+}
+
+// checkPointRole 校验当前用户在知识点所属科目中的角色是否达到 minRole，失败时直接写回响应
+func checkPointRole(c *gin.Context, pointID int, minRole string, action string) bool {
+	currentUserCode, _ := c.Get("userCode")
+	currentUserCodeStr, _ := currentUserCode.(string)
+
+	var subjectCreatorCode string
+	var subjectID int
+	var creatorName string
+	var creatorEmail sql.NullString
+	err := global.DB.QueryRow(`
+		SELECT s.creator_code, s.id, IFNULL(u.nickname, u.username), u.email
+		FROM knowledge_points p
+		JOIN knowledge_categories c ON p.categorie_id = c.id
+		JOIN subjects s ON c.subject_id = s.id
+		LEFT JOIN users u ON s.creator_code = u.user_code
+		WHERE p.id = ? AND p.deleted_at IS NULL`, pointID).Scan(&subjectCreatorCode, &subjectID, &creatorName, &creatorEmail)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "所属知识点不存在"})
+		return false
+	}
+
+	if !hasSubjectRole(subjectID, subjectCreatorCode, currentUserCodeStr, minRole) {
+		global.GetLog(c).Warnf("%s被拒: 无权操作 (User: %s, PointID: %d)", action, currentUserCodeStr, pointID)
+		contactInfo := getContactInfo(creatorName, creatorEmail)
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": action + "失败：您没有该科目的相应权限，请联系 " + contactInfo})
+		return false
+	}
+	return true
+}
+
+// hasPointRole 判断用户在知识点所属科目中的角色是否达到 minRole (不写回响应)
+func hasPointRole(pointID int, userCode string, minRole string) bool {
+	var subjectCreatorCode string
+	var subjectID int
+	err := global.DB.QueryRow(`
+		SELECT s.creator_code, s.id
+		FROM knowledge_points p
+		JOIN knowledge_categories c ON p.categorie_id = c.id
+		JOIN subjects s ON c.subject_id = s.id
+		WHERE p.id = ? AND p.deleted_at IS NULL`, pointID).Scan(&subjectCreatorCode, &subjectID)
+	if err != nil {
+		return false
+	}
+	return hasSubjectRole(subjectID, subjectCreatorCode, userCode, minRole)
+}
+
+// =================================================================================
+// GenerateQuestions 根据知识点内容 AI 生成草稿题 (仅科目创建者)
+// =================================================================================
+func GenerateQuestions(c *gin.Context) {
+	pointID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+
+	var req model.GenerateQuestionsRequest
+	// 允许空 Body，使用默认数量
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "参数错误"})
+			return
+		}
+	}
+	if req.Count <= 0 {
+		req.Count = defaultGenerateCount
+	}
+	if req.Count > maxGenerateCount {
+		req.Count = maxGenerateCount
+	}
+
+	// AI 出题会消耗大模型额度，仅科目创建者可调用，协作成员不放开
+	var subjectID int
+	err = global.DB.QueryRow(`
+		SELECT c.subject_id FROM knowledge_points p
+		JOIN knowledge_categories c ON p.categorie_id = c.id
+		WHERE p.id = ? AND p.deleted_at IS NULL`, pointID).Scan(&subjectID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "所属知识点不存在"})
+		return
+	}
+	if !checkSubjectCreator(c, subjectID, "AI 出题") {
+		return
+	}
+
+	if ready, err := deepseek.IsReady(); !ready {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"code": 503, "msg": fmt.Sprintf("AI服务不可用: %v", err)})
+		return
+	}
+
+	// 1. 读取知识点内容
+	var title string
+	var content sql.NullString
+	err = global.DB.QueryRow("SELECT title, content FROM knowledge_points WHERE id = ? AND deleted_at IS NULL", pointID).Scan(&title, &content)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "未找到该知识点"})
+		return
+	}
+	body := strings.TrimSpace(content.String)
+	if body == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "知识点内容为空，无法出题"})
+		return
+	}
+	if r := []rune(body); len(r) > maxGenerateContentRunes {
+		body = string(r[:maxGenerateContentRunes])
+	}
+
+	// 2. 调用大模型
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Minute)
+	defer cancel()
+
+	messages := []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: fmt.Sprintf(generateQuestionsPrompt, req.Count)},
+		{Role: openai.ChatMessageRoleUser, Content: fmt.Sprintf("知识点标题：%s\n\n知识点内容：\n%s", title, body)},
+	}
+	reply, usage, err := deepseek.ChatWithUsage(ctx, messages)
+	if err != nil {
+		global.GetLog(c).Errorf("AI 出题调用失败 (PointID: %d): %v", pointID, err)
+		c.JSON(http.StatusBadGateway, gin.H{"code": 502, "msg": "AI 思考超时或服务繁忙，请重试"})
+		return
+	}
+
+	// 3. 解析严格 JSON
+	var parsed struct {
+		Questions []model.GeneratedQuestion `json:"questions"`
+	}
+	if err := json.Unmarshal([]byte(extractJSONObject(reply)), &parsed); err != nil {
+		global.GetLog(c).Warnf("AI 出题返回格式错误 (PointID: %d): %v", pointID, err)
+		c.JSON(http.StatusBadGateway, gin.H{"code": 502, "msg": "AI 返回的格式无法解析，请重试"})
+		return
+	}
+
+	// 4. 校验 + 去重
+	existing, err := loadExistingQuestionKeys(pointID)
+	if err != nil {
+		global.GetLog(c).Errorf("查询已有题目失败 (PointID: %d): %v", pointID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+
+	rejected := make([]gin.H, 0)
+	accepted := make([]model.GeneratedQuestion, 0, len(parsed.Questions))
+	for _, q := range parsed.Questions {
+		var options [4]string
+		if len(q.Options) > 4 {
+			rejected = append(rejected, gin.H{"questionText": q.QuestionText, "reason": "选项超过 4 个"})
+			continue
+		}
+		copy(options[:], q.Options)
+
+		if err := validateQuestionFields(q.QuestionText, options, q.CorrectAnswer); err != nil {
+			rejected = append(rejected, gin.H{"questionText": q.QuestionText, "reason": err.Error()})
+			continue
+		}
+		key := normalizeQuestionText(q.QuestionText)
+		if existing[key] {
+			rejected = append(rejected, gin.H{"questionText": q.QuestionText, "reason": "与已有题目重复"})
+			continue
+		}
+		existing[key] = true
+		q.Options = options[:]
+		accepted = append(accepted, q)
+	}
+
+	// 5. 存为草稿
+	currentUserCode, _ := c.Get("userCode")
+	tx, err := global.DB.Begin()
+	if err != nil {
+		global.GetLog(c).Errorf("开启事务失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "系统错误"})
+		return
+	}
+	defer tx.Rollback()
+
+	drafts := make([]model.QuestionDraft, 0, len(accepted))
+	for _, q := range accepted {
+		res, err := tx.Exec(`
+			INSERT INTO question_drafts (
+				knowledge_point_id, question_text, option1, option2, option3, option4,
+				correct_answer, explanation, status, creator_code
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			pointID, q.QuestionText, q.Options[0], q.Options[1], q.Options[2], q.Options[3],
+			q.CorrectAnswer, q.Explanation, model.DraftStatusPending, currentUserCode)
+		if err != nil {
+			global.GetLog(c).Errorf("保存草稿题失败 (PointID: %d): %v", pointID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "保存失败"})
+			return
+		}
+		id, _ := res.LastInsertId()
+		drafts = append(drafts, model.QuestionDraft{
+			ID:               int(id),
+			KnowledgePointID: pointID,
+			QuestionText:     q.QuestionText,
+			Option1:          q.Options[0],
+			Option2:          q.Options[1],
+			Option3:          q.Options[2],
+			Option4:          q.Options[3],
+			CorrectAnswer:    q.CorrectAnswer,
+			Explanation:      q.Explanation,
+			Status:           model.DraftStatusPending,
+		})
+	}
+	if err := tx.Commit(); err != nil {
+		global.GetLog(c).Errorf("提交事务失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "保存失败"})
+		return
+	}
+
+	global.GetLog(c).Infof("用户[%v] AI 出题成功 (PointID: %d, 生成: %d, 驳回: %d, Tokens: %d)",
+		currentUserCode, pointID, len(drafts), len(rejected), usage.TotalTokens)
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "生成成功",
+		"data": gin.H{
+			"drafts":   drafts,
+			"rejected": rejected,
+		},
+	})
+}
+
+// =================================================================================
+// GetQuestionDrafts 获取知识点下待审核的草稿题 (审核者及以上)
+// =================================================================================
+func GetQuestionDrafts(c *gin.Context) {
+	pointID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+	if !checkPointRole(c, pointID, RoleReviewer, "查看草稿题") {
+		return
+	}
+
+	status := model.DraftStatusPending
+	if s, err := strconv.Atoi(c.Query("status")); err == nil {
+		status = s
+	}
+
+	rows, err := global.DB.Query(`
+		SELECT id, knowledge_point_id, question_text,
+		       COALESCE(option1, ''), COALESCE(option2, ''), COALESCE(option3, ''), COALESCE(option4, ''),
+		       correct_answer, COALESCE(explanation, ''), status, question_id, create_time, update_time
+		FROM question_drafts
+		WHERE knowledge_point_id = ? AND status = ?
+		ORDER BY id ASC`, pointID, status)
+	if err != nil {
+		global.GetLog(c).Errorf("查询草稿题失败 (PointID: %d): %v", pointID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+	defer rows.Close()
+
+	list := make([]model.QuestionDraft, 0)
+	for rows.Next() {
+		var d model.QuestionDraft
+		var questionID sql.NullInt64
+		if err := rows.Scan(&d.ID, &d.KnowledgePointID, &d.QuestionText,
+			&d.Option1, &d.Option2, &d.Option3, &d.Option4,
+			&d.CorrectAnswer, &d.Explanation, &d.Status, &questionID, &d.CreateTime, &d.UpdateTime); err != nil {
+			continue
+		}
+		if questionID.Valid {
+			d.QuestionID = &questionID.Int64
+		}
+		list = append(list, d)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "success", "data": list})
+}
+
+// getDraftPointID 查询草稿所属知识点与状态
+func getDraftPointID(draftID int) (int, int, error) {
+	var pointID, status int
+	err := global.DB.QueryRow("SELECT knowledge_point_id, status FROM question_drafts WHERE id = ?", draftID).Scan(&pointID, &status)
+	return pointID, status, err
+}
+
+// =================================================================================
+// UpdateQuestionDraft 编辑草稿题
+// =================================================================================
+func UpdateQuestionDraft(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+
+	var req model.UpdateQuestionDraftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "参数错误: " + err.Error()})
+		return
+	}
+
+	pointID, status, err := getDraftPointID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "草稿不存在"})
+		return
+	}
+	if !checkPointRole(c, pointID, RoleEditor, "修改草稿题") {
+		return
+	}
+	if status != model.DraftStatusPending {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "该草稿已处理，无法修改"})
+		return
+	}
+
+	options := [4]string{req.Option1, req.Option2, req.Option3, req.Option4}
+	if err := validateQuestionFields(req.QuestionText, options, req.CorrectAnswer); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": err.Error()})
+		return
+	}
+
+	res, err := global.DB.Exec(`
+		UPDATE question_drafts SET
+			question_text = ?, option1 = ?, option2 = ?, option3 = ?, option4 = ?,
+			correct_answer = ?, explanation = ?
+		WHERE id = ? AND status = ?`,
+		req.QuestionText, req.Option1, req.Option2, req.Option3, req.Option4,
+		req.CorrectAnswer, req.Explanation, id, model.DraftStatusPending)
+	if err != nil {
+		global.GetLog(c).Errorf("更新草稿题失败 (ID: %d): %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "更新失败"})
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "该草稿已处理，无法修改"})
+		return
+	}
+
+	currentUserCode, _ := c.Get("userCode")
+	global.GetLog(c).Infof("用户[%v] 更新草稿题成功 (ID: %d)", currentUserCode, id)
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "更新成功"})
+}
+
+// =================================================================================
+// PublishQuestionDraft 发布草稿为正式题目
+// =================================================================================
+func PublishQuestionDraft(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+
+	pointID, status, err := getDraftPointID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "草稿不存在"})
+		return
+	}
+	if !checkPointRole(c, pointID, RoleReviewer, "发布草稿题") {
+		return
+	}
+	if status != model.DraftStatusPending {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "该草稿已处理"})
+		return
+	}
+
+	tx, err := global.DB.Begin()
+	if err != nil {
+		global.GetLog(c).Errorf("开启事务失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "系统错误"})
+		return
+	}
+	defer tx.Rollback()
+
+	// 先在事务内认领草稿，重复点击或多人同时发布时只有一次成功
+	res, err := tx.Exec("UPDATE question_drafts SET status = ? WHERE id = ? AND status = ?",
+		model.DraftStatusPublished, id, model.DraftStatusPending)
+	if err != nil {
+		global.GetLog(c).Errorf("更新草稿状态失败 (ID: %d): %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "发布失败"})
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "该草稿已处理"})
+		return
+	}
+
+	var d model.QuestionDraft
+	err = tx.QueryRow(`
+		SELECT question_text, COALESCE(option1, ''), COALESCE(option2, ''), COALESCE(option3, ''), COALESCE(option4, ''),
+		       correct_answer, COALESCE(explanation, '')
+		FROM question_drafts WHERE id = ?`, id).Scan(
+		&d.QuestionText, &d.Option1, &d.Option2, &d.Option3, &d.Option4, &d.CorrectAnswer, &d.Explanation)
+	if err != nil {
+		global.GetLog(c).Errorf("读取草稿题失败 (ID: %d): %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "发布失败"})
+		return
+	}
+
+	options := [4]string{d.Option1, d.Option2, d.Option3, d.Option4}
+	if err := validateQuestionFields(d.QuestionText, options, d.CorrectAnswer); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "草稿内容不合法: " + err.Error()})
+		return
+	}
+
+	res, err = tx.Exec(`
+		INSERT INTO questions (
+			knowledge_point_id, question_text,
+			option1, option2, option3, option4,
+			correct_answer, explanation
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		pointID, d.QuestionText, d.Option1, d.Option2, d.Option3, d.Option4, d.CorrectAnswer, d.Explanation)
+	if err != nil {
+		global.GetLog(c).Errorf("发布草稿题DB错误 (ID: %d): %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "发布失败"})
+		return
+	}
+	questionID, _ := res.LastInsertId()
+
+	currentUserCode, _ := c.Get("userCode")
+	currentUserCodeStr, _ := currentUserCode.(string)
+	if _, err := recordQuestionRevisionTx(tx, int(questionID), model.RevisionActionCreate, currentUserCodeStr); err != nil {
+		global.GetLog(c).Errorf("记录题目修订失败 (QuestionID: %d): %v", questionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "发布失败"})
+		return
+	}
+
+	if _, err := tx.Exec("UPDATE question_drafts SET question_id = ? WHERE id = ?", questionID, id); err != nil {
+		global.GetLog(c).Errorf("更新草稿状态失败 (ID: %d): %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "发布失败"})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		global.GetLog(c).Errorf("提交事务失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "发布失败"})
+		return
+	}
+
+	global.GetLog(c).Infof("用户[%s] 发布草稿题成功 (DraftID: %d, QuestionID: %d)", currentUserCodeStr, id, questionID)
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "发布成功", "data": gin.H{"id": questionID}})
+}
+
+// =================================================================================
+// DiscardQuestionDraft 丢弃草稿题
+// =================================================================================
+func DiscardQuestionDraft(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+
+	pointID, status, err := getDraftPointID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "草稿不存在"})
+		return
+	}
+	if !checkPointRole(c, pointID, RoleReviewer, "丢弃草稿题") {
+		return
+	}
+	if status != model.DraftStatusPending {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "该草稿已处理"})
+		return
+	}
+
+	res, err := global.DB.Exec("UPDATE question_drafts SET status = ? WHERE id = ? AND status = ?",
+		model.DraftStatusDiscarded, id, model.DraftStatusPending)
+	if err != nil {
+		global.GetLog(c).Errorf("丢弃草稿题失败 (ID: %d): %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "操作失败"})
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "该草稿已处理"})
+		return
+	}
+
+	currentUserCode, _ := c.Get("userCode")
+	global.GetLog(c).Infof("用户[%v] 丢弃草稿题成功 (ID: %d)", currentUserCode, id)
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "已丢弃"})
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"practice_problems/global"
+	"practice_problems/model"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPublishQuestionDraftOnlyOnce(t *testing.T) {
+	setupTestDB(t)
+
+	ownerID := createTestUser(t, "owner")
+	subjectID := createTestSubject(t, "Go", ownerID, "owner")
+	mustExec(t, "INSERT INTO knowledge_categories (id, subject_id, categorie_name) VALUES (1, ?, 'c')", subjectID)
+	mustExec(t, "INSERT INTO knowledge_points (id, categorie_id, title) VALUES (1, 1, 'p')")
+	mustExec(t, `INSERT INTO question_drafts (id, knowledge_point_id, question_text, option1, option2, correct_answer, status)
+		VALUES (1, 1, 'q', 'a', 'b', 1, ?)`, model.DraftStatusPending)
+	params := gin.Params{{Key: "id", Value: "1"}}
+
+	// 模拟重复点击 / 多人同时发布
+	var wg sync.WaitGroup
+	codes := make([]int, 5)
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			codes[i] = callHandler(PublishQuestionDraft, ownerID, "owner", params, "").Code
+		}(i)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, code := range codes {
+		if code == 200 {
+			succeeded++
+		}
+	}
+	var questions int
+	global.DB.QueryRow("SELECT COUNT(*) FROM questions WHERE knowledge_point_id = 1").Scan(&questions)
+	if succeeded != 1 || questions != 1 {
+		t.Errorf("publish results %v created %d questions, want exactly one success", codes, questions)
+	}
+
+	var status, questionID int
+	global.DB.QueryRow("SELECT status, IFNULL(question_id, 0) FROM question_drafts WHERE id = 1").Scan(&status, &questionID)
+	if status != model.DraftStatusPublished || questionID == 0 {
+		t.Errorf("draft status=%d question_id=%d, want published with question id", status, questionID)
+	}
+
+	// 已发布的草稿不能再丢弃或修改
+	if w := callHandler(DiscardQuestionDraft, ownerID, "owner", params, ""); w.Code != 400 {
+		t.Errorf("discard published draft: want 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,387 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"practice_problems/global"
+	"practice_problems/model"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==========================================
+// 题目修订与答题记录：每次创建/修改题目保存不可变快照，
+// 作答记录标记判分所依据的修订，更正答案后可对已存记录重新判分
+// ==========================================
+
+// recordQuestionRevisionTx 以题目当前内容为快照写入一条修订，返回修订 ID
+func recordQuestionRevisionTx(tx *sql.Tx, questionID int, action string, editorCode string) (int64, error) {
+	res, err := tx.Exec(`
+		INSERT INTO question_revisions (question_id, revision_no, question_text,
+			option1, option1_img, option2, option2_img, option3, option3_img, option4, option4_img,
+			correct_answer, explanation, action, editor_code)
+		SELECT id, (SELECT IFNULL(MAX(revision_no), 0) + 1 FROM question_revisions WHERE question_id = ?), question_text,
+			option1, option1_img, option2, option2_img, option3, option3_img, option4, option4_img,
+			correct_answer, explanation, ?, ?
+		FROM questions WHERE id = ?`,
+		questionID, action, editorCode, questionID)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// ensureInitialQuestionRevisionTx 题目还没有任何修订时 (修订功能上线前创建的题目)，先把当前内容记为初始版本
+func ensureInitialQuestionRevisionTx(tx *sql.Tx, questionID int) error {
+	var count int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM question_revisions WHERE question_id = ?", questionID).Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	_, err := recordQuestionRevisionTx(tx, questionID, model.RevisionActionInitial, "")
+	return err
+}
+
+// checkQuestionRole 校验当前用户在题目所属科目中的角色，失败时已写入响应
+func checkQuestionRole(c *gin.Context, questionID int, minRole string, action string) bool {
+	var pointID int
+	err := global.DB.QueryRow("SELECT knowledge_point_id FROM questions WHERE id = ? AND deleted_at IS NULL", questionID).Scan(&pointID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "题目不存在"})
+		return false
+	}
+	return checkPointRole(c, pointID, minRole, action)
+}
+
+// questionRevisionChanges 列出两条修订之间变化的字段
+func questionRevisionChanges(prev, cur *model.QuestionRevision) []string {
+	changes := make([]string, 0)
+	if prev == nil {
+		return changes
+	}
+	pairs := []struct {
+		field string
+		a, b  string
+	}{
+		{"questionText", prev.QuestionText, cur.QuestionText},
+		{"option1", prev.Option1, cur.Option1},
+		{"option1Img", prev.Option1Img, cur.Option1Img},
+		{"option2", prev.Option2, cur.Option2},
+		{"option2Img", prev.Option2Img, cur.Option2Img},
+		{"option3", prev.Option3, cur.Option3},
+		{"option3Img", prev.Option3Img, cur.Option3Img},
+		{"option4", prev.Option4, cur.Option4},
+		{"option4Img", prev.Option4Img, cur.Option4Img},
+		{"explanation", prev.Explanation, cur.Explanation},
+	}
+	for _, p := range pairs {
+		if p.a != p.b {
+			changes = append(changes, p.field)
+		}
+	}
+	if prev.CorrectAnswer != cur.CorrectAnswer {
+		changes = append(changes, "correctAnswer")
+	}
+	return changes
+}
+
+// =================================================================================
+// GetQuestionRevisions 获取题目修订历史 (科目成员可见，按修订序号倒序)
+// =================================================================================
+func GetQuestionRevisions(c *gin.Context) {
+	questionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+	if !checkQuestionRole(c, questionID, RoleViewer, "查看题目修订历史") {
+		return
+	}
+
+	rows, err := global.DB.Query(`
+		SELECT r.id, r.question_id, r.revision_no, r.question_text,
+			IFNULL(r.option1, ''), IFNULL(r.option1_img, ''), IFNULL(r.option2, ''), IFNULL(r.option2_img, ''),
+			IFNULL(r.option3, ''), IFNULL(r.option3_img, ''), IFNULL(r.option4, ''), IFNULL(r.option4_img, ''),
+			r.correct_answer, IFNULL(r.explanation, ''), r.action, IFNULL(r.editor_code, ''),
+			IFNULL(u.nickname, IFNULL(u.username, '')), r.create_time,
+			(SELECT COUNT(*) FROM question_attempts a WHERE a.answered_revision_id = r.id)
+		FROM question_revisions r
+		LEFT JOIN users u ON r.editor_code = u.user_code
+		WHERE r.question_id = ?
+		ORDER BY r.revision_no`, questionID)
+	if err != nil {
+		global.GetLog(c).Errorf("查询题目修订失败 (QuestionID: %d): %v", questionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+	defer rows.Close()
+
+	list := make([]model.QuestionRevision, 0)
+	for rows.Next() {
+		var r model.QuestionRevision
+		if err := rows.Scan(&r.ID, &r.QuestionID, &r.RevisionNo, &r.QuestionText,
+			&r.Option1, &r.Option1Img, &r.Option2, &r.Option2Img,
+			&r.Option3, &r.Option3Img, &r.Option4, &r.Option4Img,
+			&r.CorrectAnswer, &r.Explanation, &r.Action, &r.EditorCode,
+			&r.EditorName, &r.CreateTime, &r.AttemptCount); err != nil {
+			continue
+		}
+		r.CreateTime = formatTimeStr(r.CreateTime)
+		var prev *model.QuestionRevision
+		if len(list) > 0 {
+			prev = &list[len(list)-1]
+		}
+		r.Changes = questionRevisionChanges(prev, &r)
+		list = append(list, r)
+	}
+
+	// 最新修订在前
+	for i, j := 0, len(list)-1; i < j; i, j = i+1, j-1 {
+		list[i], list[j] = list[j], list[i]
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "success", "data": list})
+}
+
+// =================================================================================
+// SubmitAnswers 提交作答并判分 (按题目当前修订判分并记录)
+// 访问权限与刷题一致：科目作者或持有效授权的订阅者
+// =================================================================================
+func SubmitAnswers(c *gin.Context) {
+	var req model.SubmitAnswersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "参数错误: " + err.Error()})
+		return
+	}
+
+	userIDVal, _ := c.Get("userID")
+	userID, _ := userIDVal.(int)
+	userCodeVal, _ := c.Get("userCode")
+	userCode, _ := userCodeVal.(string)
+
+	tx, err := global.DB.Begin()
+	if err != nil {
+		global.GetLog(c).Errorf("开启事务失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "系统错误"})
+		return
+	}
+	defer tx.Rollback()
+
+	results := make([]model.AnswerResult, 0, len(req.Answers))
+	correctCount := 0
+	for _, ans := range req.Answers {
+		var allowed int
+		err := tx.QueryRow(`
+			SELECT COUNT(*)
+			FROM questions q
+			JOIN knowledge_points p ON q.knowledge_point_id = p.id
+			JOIN knowledge_categories c ON p.categorie_id = c.id
+			JOIN subjects s ON c.subject_id = s.id
+			LEFT JOIN user_subjects us ON us.subject_id = s.id AND us.user_id = ?
+			WHERE q.id = ? AND q.deleted_at IS NULL AND s.deleted_at IS NULL
+			  AND (
+			      s.creator_code = ?
+			      OR
+			      (us.id IS NOT NULL AND us.status = 1 AND (us.expire_time IS NULL OR us.expire_time > datetime('now')))
+			  )`, userID, ans.QuestionID, userCode).Scan(&allowed)
+		if err != nil || allowed == 0 {
+			c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "题目不存在或无权访问 (ID: " + strconv.Itoa(ans.QuestionID) + ")"})
+			return
+		}
+
+		if err := ensureInitialQuestionRevisionTx(tx, ans.QuestionID); err != nil {
+			global.GetLog(c).Errorf("记录题目初始修订失败 (QuestionID: %d): %v", ans.QuestionID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "提交失败"})
+			return
+		}
+
+		var revID, revNo, correct int
+		err = tx.QueryRow(`SELECT id, revision_no, correct_answer FROM question_revisions
+			WHERE question_id = ? ORDER BY revision_no DESC LIMIT 1`, ans.QuestionID).Scan(&revID, &revNo, &correct)
+		if err != nil {
+			global.GetLog(c).Errorf("查询题目修订失败 (QuestionID: %d): %v", ans.QuestionID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "提交失败"})
+			return
+		}
+
+		isCorrect := ans.ChosenOption == correct
+		res, err := tx.Exec(`
+			INSERT INTO question_attempts (user_id, question_id, exam_tag, chosen_option, answered_revision_id, graded_revision_id, is_correct)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			userID, ans.QuestionID, req.ExamTag, ans.ChosenOption, revID, revID, isCorrect)
+		if err != nil {
+			global.GetLog(c).Errorf("保存答题记录失败 (UID: %d, QuestionID: %d): %v", userID, ans.QuestionID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "提交失败"})
+			return
+		}
+		attemptID, _ := res.LastInsertId()
+		if isCorrect {
+			correctCount++
+		}
+		results = append(results, model.AnswerResult{
+			AttemptID:     attemptID,
+			QuestionID:    ans.QuestionID,
+			ChosenOption:  ans.ChosenOption,
+			CorrectAnswer: correct,
+			IsCorrect:     isCorrect,
+			RevisionNo:    revNo,
+		})
+	}
+
+	if err := tx.Commit(); err != nil {
+		global.GetLog(c).Errorf("提交答题记录失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "提交失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "success",
+		"data": gin.H{"results": results, "total": len(results), "correct": correctCount},
+	})
+}
+
+// =================================================================================
+// GetMyAttempts 获取我的答题记录 (?examTag=&questionId=)
+// 同时返回作答时与当前判分依据的正确答案，便于核对申诉
+// =================================================================================
+func GetMyAttempts(c *gin.Context) {
+	userIDVal, _ := c.Get("userID")
+	userID, _ := userIDVal.(int)
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 200 {
+		pageSize = 20
+	}
+
+	where := "WHERE a.user_id = ?"
+	args := []interface{}{userID}
+	if tag := c.Query("examTag"); tag != "" {
+		where += " AND a.exam_tag = ?"
+		args = append(args, tag)
+	}
+	if qid, _ := strconv.Atoi(c.Query("questionId")); qid > 0 {
+		where += " AND a.question_id = ?"
+		args = append(args, qid)
+	}
+
+	var total int
+	global.DB.QueryRow("SELECT COUNT(*) FROM question_attempts a "+where, args...).Scan(&total)
+
+	rows, err := global.DB.Query(`
+		SELECT a.id, a.question_id, IFNULL(q.question_text, ''), IFNULL(a.exam_tag, ''), a.chosen_option, a.is_correct,
+			ar.revision_no, ar.correct_answer, gr.revision_no, gr.correct_answer, a.regrade_time, a.create_time
+		FROM question_attempts a
+		JOIN question_revisions ar ON a.answered_revision_id = ar.id
+		JOIN question_revisions gr ON a.graded_revision_id = gr.id
+		LEFT JOIN questions q ON a.question_id = q.id
+		`+where+`
+		ORDER BY a.id DESC
+		LIMIT ? OFFSET ?`, append(args, pageSize, (page-1)*pageSize)...)
+	if err != nil {
+		global.GetLog(c).Errorf("查询答题记录失败 (UID: %d): %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+	defer rows.Close()
+
+	list := make([]model.QuestionAttempt, 0)
+	for rows.Next() {
+		var a model.QuestionAttempt
+		var regradeTime sql.NullString
+		if err := rows.Scan(&a.ID, &a.QuestionID, &a.QuestionText, &a.ExamTag, &a.ChosenOption, &a.IsCorrect,
+			&a.AnsweredRevisionNo, &a.AnsweredCorrect, &a.GradedRevisionNo, &a.GradedCorrect, &regradeTime, &a.CreateTime); err != nil {
+			continue
+		}
+		if regradeTime.Valid {
+			t := formatTimeStr(regradeTime.String)
+			a.RegradeTime = &t
+		}
+		a.CreateTime = formatTimeStr(a.CreateTime)
+		list = append(list, a)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "success", "data": gin.H{"list": list, "total": total}})
+}
+
+// =================================================================================
+// RegradeQuestion 按指定修订 (缺省为最新修订) 重新判分该题全部答题记录 (编辑者及以上)
+// =================================================================================
+func RegradeQuestion(c *gin.Context) {
+	questionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+	var req model.RegradeQuestionRequest
+	// 允许空 Body，按最新修订判分
+	c.ShouldBindJSON(&req)
+
+	if !checkQuestionRole(c, questionID, RoleEditor, "重新判分") {
+		return
+	}
+	currentUserCode, _ := c.Get("userCode")
+	currentUserCodeStr, _ := currentUserCode.(string)
+
+	var revID, revNo, correct int
+	if req.RevisionID > 0 {
+		err = global.DB.QueryRow("SELECT id, revision_no, correct_answer FROM question_revisions WHERE id = ? AND question_id = ?",
+			req.RevisionID, questionID).Scan(&revID, &revNo, &correct)
+	} else {
+		err = global.DB.QueryRow("SELECT id, revision_no, correct_answer FROM question_revisions WHERE question_id = ? ORDER BY revision_no DESC LIMIT 1",
+			questionID).Scan(&revID, &revNo, &correct)
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "题目修订不存在"})
+		return
+	}
+
+	tx, err := global.DB.Begin()
+	if err != nil {
+		global.GetLog(c).Errorf("开启事务失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "系统错误"})
+		return
+	}
+	defer tx.Rollback()
+
+	// 先统计判分结果会发生变化的记录
+	var toCorrect, toWrong int
+	tx.QueryRow(`SELECT
+			IFNULL(SUM(CASE WHEN is_correct = 0 AND chosen_option = ? THEN 1 ELSE 0 END), 0),
+			IFNULL(SUM(CASE WHEN is_correct = 1 AND chosen_option != ? THEN 1 ELSE 0 END), 0)
+		FROM question_attempts WHERE question_id = ? AND graded_revision_id != ?`, correct, correct, questionID, revID).Scan(&toCorrect, &toWrong)
+
+	now := model.FormatDBTime(time.Now())
+	res, err := tx.Exec(`
+		UPDATE question_attempts
+		SET is_correct = (chosen_option = ?), graded_revision_id = ?, regrade_time = ?
+		WHERE question_id = ? AND graded_revision_id != ?`,
+		correct, revID, now, questionID, revID)
+	if err != nil {
+		global.GetLog(c).Errorf("重新判分失败 (QuestionID: %d): %v", questionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "重新判分失败"})
+		return
+	}
+	regraded, _ := res.RowsAffected()
+
+	if err := tx.Commit(); err != nil {
+		global.GetLog(c).Errorf("提交重新判分失败 (QuestionID: %d): %v", questionID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "重新判分失败"})
+		return
+	}
+
+	global.GetLog(c).Infof("用户[%s] 按修订 #%d 重新判分题目 (ID: %d): 处理 %d 条，改判正确 %d 条，改判错误 %d 条",
+		currentUserCodeStr, revNo, questionID, regraded, toCorrect, toWrong)
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "重新判分完成",
+		"data": gin.H{"revisionNo": revNo, "correctAnswer": correct, "regraded": regraded, "toCorrect": toCorrect, "toWrong": toWrong},
+	})
+}
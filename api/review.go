@@ -0,0 +1,380 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"practice_problems/global"
+	"practice_problems/model"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==========================================
+// 科目评价：获得过授权的用户 (user_subjects 有记录，含已到期、已撤销) 每个科目可评价一次，可修改
+// 作者可以回复评价；管理员可以隐藏不当评价，被隐藏的评价只有本人能看到，且不计入评分
+// ==========================================
+
+// 评价内容长度限制
+const (
+	reviewContentMaxLen = 1000
+	reviewReplyMaxLen   = 500
+)
+
+// subjectRatingColumnsSQL 科目评分汇总字段 (平均分、评价数)，科目表别名为 s
+const subjectRatingColumnsSQL = `(SELECT ROUND(IFNULL(AVG(r.rating), 0), 1) FROM subject_reviews r WHERE r.subject_id = s.id AND r.hidden = 0) AS rating_avg,
+	(SELECT COUNT(*) FROM subject_reviews r WHERE r.subject_id = s.id AND r.hidden = 0) AS rating_count`
+
+// shareCodeRatingColumnsSQL 分享码所含科目的评分汇总字段 (平均分、评价数)，用于公告列表
+const shareCodeRatingColumnsSQL = `(SELECT ROUND(IFNULL(AVG(r.rating), 0), 1) FROM subject_reviews r
+		JOIN share_code_subjects scs ON scs.subject_id = r.subject_id JOIN share_codes sc ON scs.share_code_id = sc.id
+		WHERE sc.code = share_announcements.share_code AND r.hidden = 0),
+	(SELECT COUNT(*) FROM subject_reviews r
+		JOIN share_code_subjects scs ON scs.subject_id = r.subject_id JOIN share_codes sc ON scs.share_code_id = sc.id
+		WHERE sc.code = share_announcements.share_code AND r.hidden = 0)`
+
+// reviewSelectSQL 评价列表的查询字段
+const reviewSelectSQL = `
+	SELECT r.id, r.subject_id, IFNULL(s.name, ''), r.user_id, IFNULL(NULLIF(u.nickname, ''), IFNULL(u.username, '')),
+		r.rating, IFNULL(r.content, ''), IFNULL(r.reply, ''), r.reply_time, r.hidden, IFNULL(r.hidden_reason, ''),
+		r.create_time, r.update_time
+	FROM subject_reviews r
+	LEFT JOIN subjects s ON r.subject_id = s.id
+	LEFT JOIN users u ON r.user_id = u.id`
+
+// scanReviews 读取评价列表
+func scanReviews(rows *sql.Rows) []model.SubjectReview {
+	list := make([]model.SubjectReview, 0)
+	for rows.Next() {
+		var r model.SubjectReview
+		if err := rows.Scan(&r.ID, &r.SubjectID, &r.SubjectName, &r.UserID, &r.UserName,
+			&r.Rating, &r.Content, &r.Reply, &r.ReplyTime, &r.Hidden, &r.HiddenReason,
+			&r.CreateTime, &r.UpdateTime); err != nil {
+			continue
+		}
+		list = append(list, r)
+	}
+	return list
+}
+
+// canReviewSubject 用户是否可以评价该科目 (非作者、非协作成员，且获得过授权)
+func canReviewSubject(userID int, userCode string, subjectID int) bool {
+	var ok bool
+	global.DB.QueryRow(`SELECT EXISTS (
+		SELECT 1 FROM subjects s JOIN user_subjects us ON us.subject_id = s.id
+		WHERE s.id = ? AND s.deleted_at IS NULL AND s.creator_code != ? AND us.user_id = ?
+		  AND NOT EXISTS (SELECT 1 FROM subject_members m WHERE m.subject_id = s.id AND m.user_id = ?))`,
+		subjectID, userCode, userID, userID).Scan(&ok)
+	return ok
+}
+
+// =================================================================================
+// GetSubjectReviews 科目评价列表 (含评分汇总与分布、我的评价；rating 按星级筛选)
+// =================================================================================
+func GetSubjectReviews(c *gin.Context) {
+	subjectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+	userID, userCode := currentUser(c)
+
+	var exists int
+	global.DB.QueryRow("SELECT COUNT(*) FROM subjects WHERE id = ? AND deleted_at IS NULL", subjectID).Scan(&exists)
+	if exists == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "未找到该科目"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	// 评分汇总与各星级数量
+	var summary model.RatingSummary
+	if err := global.DB.QueryRow(`SELECT ROUND(IFNULL(AVG(rating), 0), 1), COUNT(*) FROM subject_reviews
+		WHERE subject_id = ? AND hidden = 0`, subjectID).Scan(&summary.Average, &summary.Count); err != nil {
+		global.GetLog(c).Errorf("统计科目评分失败 (SubjectID: %d): %v", subjectID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+	distribution := map[int]int{1: 0, 2: 0, 3: 0, 4: 0, 5: 0}
+	rows, err := global.DB.Query(`SELECT rating, COUNT(*) FROM subject_reviews
+		WHERE subject_id = ? AND hidden = 0 GROUP BY rating`, subjectID)
+	if err == nil {
+		for rows.Next() {
+			var rating, count int
+			if err := rows.Scan(&rating, &count); err == nil {
+				distribution[rating] = count
+			}
+		}
+		rows.Close()
+	}
+
+	where := "r.subject_id = ? AND r.hidden = 0"
+	args := []interface{}{subjectID}
+	if rating, _ := strconv.Atoi(c.Query("rating")); rating >= 1 && rating <= 5 {
+		where += " AND r.rating = ?"
+		args = append(args, rating)
+	}
+	var listTotal int
+	global.DB.QueryRow("SELECT COUNT(*) FROM subject_reviews r WHERE "+where, args...).Scan(&listTotal)
+
+	rows, err = global.DB.Query(reviewSelectSQL+`
+		WHERE `+where+`
+		ORDER BY r.update_time DESC, r.id DESC LIMIT ? OFFSET ?`, append(args, pageSize, (page-1)*pageSize)...)
+	if err != nil {
+		global.GetLog(c).Errorf("查询科目评价失败 (SubjectID: %d): %v", subjectID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+	list := scanReviews(rows)
+	rows.Close()
+
+	// 我的评价 (被隐藏时也返回，便于本人查看原因)
+	var mine *model.SubjectReview
+	rows, err = global.DB.Query(reviewSelectSQL+" WHERE r.subject_id = ? AND r.user_id = ?", subjectID, userID)
+	if err == nil {
+		if my := scanReviews(rows); len(my) > 0 {
+			mine = &my[0]
+		}
+		rows.Close()
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "success", "data": gin.H{
+		"summary":      summary,
+		"distribution": distribution,
+		"list":         list,
+		"total":        listTotal,
+		"page":         page,
+		"pageSize":     pageSize,
+		"mine":         mine,
+		"canReview":    canReviewSubject(userID, userCode, subjectID),
+	}})
+}
+
+// =================================================================================
+// SaveSubjectReview 发表 / 修改我对科目的评价
+// =================================================================================
+func SaveSubjectReview(c *gin.Context) {
+	subjectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+	var req model.SaveReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "参数错误：评分为 1~5 分"})
+		return
+	}
+	req.Content = strings.TrimSpace(req.Content)
+	if utf8.RuneCountInString(req.Content) > reviewContentMaxLen {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": fmt.Sprintf("评价内容最多%d字", reviewContentMaxLen)})
+		return
+	}
+
+	userID, userCode := currentUser(c)
+	if !canReviewSubject(userID, userCode, subjectID) {
+		global.GetLog(c).Warnf("评价科目被拒: 未获得过授权或为作者/成员 (User: %d, SubjectID: %d)", userID, subjectID)
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "只有获得过该科目授权的用户才能评价，作者与协作成员不能评价"})
+		return
+	}
+
+	var existing int
+	global.DB.QueryRow("SELECT COUNT(*) FROM subject_reviews WHERE subject_id = ? AND user_id = ?", subjectID, userID).Scan(&existing)
+	created := existing == 0
+
+	// 修改评价不改变隐藏状态与作者回复
+	var reviewID int
+	err = global.DB.QueryRow(`
+		INSERT INTO subject_reviews (subject_id, user_id, rating, content) VALUES (?, ?, ?, ?)
+		ON CONFLICT(subject_id, user_id) DO UPDATE SET
+			rating = excluded.rating, content = excluded.content, update_time = CURRENT_TIMESTAMP
+		RETURNING id`, subjectID, userID, req.Rating, req.Content).Scan(&reviewID)
+	if err != nil {
+		global.GetLog(c).Errorf("保存科目评价失败 (User: %d, SubjectID: %d): %v", userID, subjectID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "保存失败"})
+		return
+	}
+
+	if created {
+		var creatorID int
+		if global.DB.QueryRow("SELECT u.id FROM subjects s JOIN users u ON s.creator_code = u.user_code WHERE s.id = ?",
+			subjectID).Scan(&creatorID) == nil {
+			notifyUsers(c, []int{creatorID}, notifyEvent{
+				Type:    model.NotifySubjectReviewed,
+				Title:   "科目收到新评价",
+				Content: fmt.Sprintf("%s 给科目%s打了 %d 星", userDisplayName(userID), subjectNamesText([]int{subjectID}), req.Rating),
+				Link:    "/",
+			})
+		}
+	}
+
+	global.GetLog(c).Infof("用户[%d] 评价科目 (SubjectID: %d, ReviewID: %d, Rating: %d)", userID, subjectID, reviewID, req.Rating)
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "评价已保存", "data": gin.H{"id": reviewID}})
+}
+
+// =================================================================================
+// DeleteSubjectReview 删除我对科目的评价
+// =================================================================================
+func DeleteSubjectReview(c *gin.Context) {
+	subjectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+	userID, _ := currentUser(c)
+
+	res, err := global.DB.Exec("DELETE FROM subject_reviews WHERE subject_id = ? AND user_id = ?", subjectID, userID)
+	if err != nil {
+		global.GetLog(c).Errorf("删除科目评价失败 (User: %d, SubjectID: %d): %v", userID, subjectID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "删除失败"})
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "您还没有评价过该科目"})
+		return
+	}
+
+	global.GetLog(c).Infof("用户[%d] 删除科目评价 (SubjectID: %d)", userID, subjectID)
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "删除成功"})
+}
+
+// =================================================================================
+// ReplySubjectReview 作者回复评价 (回复为空表示删除回复)
+// =================================================================================
+func ReplySubjectReview(c *gin.Context) {
+	reviewID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+	var req model.ReplyReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "参数错误"})
+		return
+	}
+	req.Reply = strings.TrimSpace(req.Reply)
+	if utf8.RuneCountInString(req.Reply) > reviewReplyMaxLen {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": fmt.Sprintf("回复最多%d字", reviewReplyMaxLen)})
+		return
+	}
+
+	var subjectID, reviewerID int
+	var hidden bool
+	if err := global.DB.QueryRow("SELECT subject_id, user_id, hidden FROM subject_reviews WHERE id = ?", reviewID).
+		Scan(&subjectID, &reviewerID, &hidden); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "评价不存在"})
+		return
+	}
+	if hidden {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "该评价已被管理员隐藏，无法回复"})
+		return
+	}
+	if !checkSubjectCreator(c, subjectID, "回复评价") {
+		return
+	}
+
+	var reply interface{}
+	if req.Reply != "" {
+		reply = req.Reply
+	}
+	if _, err := global.DB.Exec(`UPDATE subject_reviews SET reply = ?,
+		reply_time = CASE WHEN ? IS NULL THEN NULL ELSE CURRENT_TIMESTAMP END WHERE id = ?`, reply, reply, reviewID); err != nil {
+		global.GetLog(c).Errorf("回复科目评价失败 (ReviewID: %d): %v", reviewID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "回复失败"})
+		return
+	}
+
+	userID, _ := currentUser(c)
+	if req.Reply != "" {
+		notifyUsers(c, []int{reviewerID}, notifyEvent{
+			Type:    model.NotifyReviewReplied,
+			Title:   "您的评价收到回复",
+			Content: fmt.Sprintf("%s 回复了您对科目%s的评价：%s", userDisplayName(userID), subjectNamesText([]int{subjectID}), firstRunes(req.Reply, 100)),
+			Link:    "/",
+		})
+	}
+
+	global.GetLog(c).Infof("用户[%d] 回复科目评价 (ReviewID: %d, 删除回复: %v)", userID, reviewID, req.Reply == "")
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "回复已保存"})
+}
+
+// =================================================================================
+// AdminGetReviews 评价管理列表 (管理员；hidden=1 只看已隐藏，subject_id 按科目筛选)
+// =================================================================================
+func AdminGetReviews(c *gin.Context) {
+	query := reviewSelectSQL + " WHERE 1 = 1"
+	var args []interface{}
+	if c.Query("hidden") == "1" {
+		query += " AND r.hidden = 1"
+	}
+	if sid := c.Query("subject_id"); sid != "" {
+		query += " AND r.subject_id = ?"
+		args = append(args, sid)
+	}
+	if rating, _ := strconv.Atoi(c.Query("rating")); rating >= 1 && rating <= 5 {
+		query += " AND r.rating = ?"
+		args = append(args, rating)
+	}
+	query += " ORDER BY r.id DESC LIMIT 500"
+
+	rows, err := global.DB.Query(query, args...)
+	if err != nil {
+		global.GetLog(c).Errorf("查询评价管理列表失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+	defer rows.Close()
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "success", "data": scanReviews(rows)})
+}
+
+// =================================================================================
+// AdminHideReview 隐藏 / 恢复评价 (管理员)
+// =================================================================================
+func AdminHideReview(c *gin.Context) {
+	reviewID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+	var req model.HideReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "参数错误"})
+		return
+	}
+	userID, _ := currentUser(c)
+
+	var res sql.Result
+	if *req.Hidden {
+		res, err = global.DB.Exec(`UPDATE subject_reviews SET hidden = 1, hidden_reason = ?, hidden_by = ? WHERE id = ?`,
+			strings.TrimSpace(req.Reason), userID, reviewID)
+	} else {
+		res, err = global.DB.Exec(`UPDATE subject_reviews SET hidden = 0, hidden_reason = NULL, hidden_by = NULL WHERE id = ?`, reviewID)
+	}
+	if err != nil {
+		global.GetLog(c).Errorf("修改评价隐藏状态失败 (ReviewID: %d): %v", reviewID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "操作失败"})
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "评价不存在"})
+		return
+	}
+
+	global.GetLog(c).Infof("管理员[%d] 修改评价隐藏状态 (ReviewID: %d, Hidden: %v, 原因: %s)", userID, reviewID, *req.Hidden, req.Reason)
+	msg := "已恢复显示"
+	if *req.Hidden {
+		msg = "已隐藏"
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": msg})
+}
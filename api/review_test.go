@@ -0,0 +1,49 @@
+package api
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSubjectReviewExcludesMembers(t *testing.T) {
+	setupTestDB(t)
+
+	ownerID := createTestUser(t, "owner")
+	learnerID := createTestUser(t, "learner")
+	memberID := createTestUser(t, "member")
+	strangerID := createTestUser(t, "stranger")
+	subjectID := createTestSubject(t, "Go", ownerID, "owner")
+	params := gin.Params{{Key: "id", Value: strconv.Itoa(subjectID)}}
+
+	// learner 通过分享码获得授权；member 是协作成员，同时持有成员开通的授权
+	mustExec(t, "INSERT INTO user_subjects (user_id, subject_id, status, expire_time, source_share_code_id) VALUES (?, ?, 1, NULL, 1)", learnerID, subjectID)
+	mustExec(t, "INSERT INTO user_subjects (user_id, subject_id, status, expire_time, member_grant) VALUES (?, ?, 1, NULL, 1)", memberID, subjectID)
+	mustExec(t, "INSERT INTO subject_members (subject_id, user_id, role, invited_by) VALUES (?, ?, 'editor', 'owner')", subjectID, memberID)
+
+	cases := []struct {
+		name     string
+		userID   int
+		userCode string
+		want     bool
+	}{
+		{"learner", learnerID, "learner", true},
+		{"member", memberID, "member", false},
+		{"owner", ownerID, "owner", false},
+		{"stranger", strangerID, "stranger", false},
+	}
+	for _, tc := range cases {
+		if got := canReviewSubject(tc.userID, tc.userCode, subjectID); got != tc.want {
+			t.Errorf("%s: canReviewSubject = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	// 协作成员即使持有授权也不能给自己参与编写的科目打分
+	if w := callHandler(SaveSubjectReview, memberID, "member", params, `{"rating":5,"content":"great"}`); w.Code != 403 {
+		t.Errorf("member review: want 403, got %d (%s)", w.Code, w.Body.String())
+	}
+	if w := callHandler(SaveSubjectReview, learnerID, "learner", params, `{"rating":4,"content":"ok"}`); w.Code != 200 {
+		t.Errorf("learner review: want 200, got %d (%s)", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"net/http/httptest"
+	"practice_problems/global"
+	"practice_problems/initialize"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// setupTestDB 在临时目录中初始化一份完整建表、迁移后的 SQLite 数据库并赋给 global.DB
+func setupTestDB(t *testing.T) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	global.Log = zap.NewNop().Sugar()
+
+	t.Chdir(t.TempDir())
+	initialize.InitSQLite()
+	t.Cleanup(func() { global.DB.Close() })
+}
+
+// mustExec 执行测试数据准备语句，失败直接终止测试
+func mustExec(t *testing.T, query string, args ...interface{}) {
+	t.Helper()
+	if _, err := global.DB.Exec(query, args...); err != nil {
+		t.Fatalf("exec %q: %v", query, err)
+	}
+}
+
+// createTestUser 插入一个测试用户，返回其 ID (user_code 与 username 相同)
+func createTestUser(t *testing.T, username string) int {
+	t.Helper()
+	res, err := global.DB.Exec("INSERT INTO users (username, user_code, password, nickname) VALUES (?, ?, 'x', ?)", username, username, username)
+	if err != nil {
+		t.Fatalf("create user %s: %v", username, err)
+	}
+	id, _ := res.LastInsertId()
+	return int(id)
+}
+
+// createTestSubject 插入一个由 creatorCode 创建的科目，并像 CreateSubject 一样为创建者开通永久访问
+func createTestSubject(t *testing.T, name string, creatorID int, creatorCode string) int {
+	t.Helper()
+	res, err := global.DB.Exec("INSERT INTO subjects (name, creator_code) VALUES (?, ?)", name, creatorCode)
+	if err != nil {
+		t.Fatalf("create subject %s: %v", name, err)
+	}
+	id, _ := res.LastInsertId()
+	mustExec(t, "INSERT INTO user_subjects (user_id, subject_id, status, expire_time) VALUES (?, ?, 1, NULL)", creatorID, id)
+	return int(id)
+}
+
+// callHandler 以指定登录用户调用接口处理函数，返回响应记录
+func callHandler(h gin.HandlerFunc, userID int, userCode string, params gin.Params, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	method := "GET"
+	if body != "" {
+		method = "POST"
+	}
+	c.Request = httptest.NewRequest(method, "/", strings.NewReader(body))
+	if body != "" {
+		c.Request.Header.Set("Content-Type", "application/json")
+	}
+	c.Params = params
+	c.Set("userID", userID)
+	c.Set("userCode", userCode)
+	h(c)
+	return w
+}
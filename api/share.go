@@ -3,7 +3,6 @@ package api
 import (
 	"database/sql"
 	"fmt"
-	"math/rand"
 	"net/http"
 	"practice_problems/global"
 	"practice_problems/model"
@@ -16,17 +15,16 @@ import (
 )
 
 // =================================================================================
-// 辅助函数：计算过期时间
+// 辅助函数：计算过期时间 (forever 返回空值，即永久)
 // =================================================================================
-func calculateExpireTime(durationStr string) *time.Time {
+func calculateExpireTime(durationStr string) model.UTCTime {
 	if durationStr == "forever" {
-		return nil
+		return model.UTCTime{}
 	}
 	re := regexp.MustCompile(`^(\d+)([dwmy])$`)
 	matches := re.FindStringSubmatch(durationStr)
 	if len(matches) != 3 {
-		t := time.Now().AddDate(0, 0, 7)
-		return &t
+		return model.NewUTCTime(time.Now().AddDate(0, 0, 7))
 	}
 	num, _ := strconv.Atoi(matches[1])
 	unit := matches[2]
@@ -44,7 +42,7 @@ func calculateExpireTime(durationStr string) *time.Time {
 	default:
 		expireTime = now.AddDate(0, 0, 7)
 	}
-	return &expireTime
+	return model.NewUTCTime(expireTime)
 }
 
 // =================================================================================
@@ -78,7 +76,7 @@ func CreateShare(c *gin.Context) {
 	// 1. 校验所有科目归属权
 	for _, sid := range req.SubjectIDs {
 		var count int
-		err := tx.QueryRow("SELECT count(*) FROM subjects WHERE id = ? AND creator_code = ?", sid, userCode).Scan(&count)
+		err := tx.QueryRow("SELECT count(*) FROM subjects WHERE id = ? AND creator_code = ? AND deleted_at IS NULL", sid, userCode).Scan(&count)
 		if err != nil || count == 0 {
 			global.GetLog(c).Warnf("创建分享被拒: 科目非本人所有 (User: %s, SubjectID: %d)", userCodeStr, sid)
 			c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": fmt.Sprintf("科目ID %d 不属于您或不存在", sid)})
@@ -105,11 +103,18 @@ func CreateShare(c *gin.Context) {
 			}
 		}
 
-		count := handleDirectShareTx(tx, req, userID.(int), c)
+		grantedUserIDs := handleDirectShareTx(tx, req, userID.(int), c)
 		tx.Commit()
 
+		notifyUsers(c, grantedUserIDs, notifyEvent{
+			Type:    model.NotifyShareReceived,
+			Title:   "收到新的科目分享",
+			Content: fmt.Sprintf("%s 向您分享了科目：%s", userDisplayName(userID.(int)), subjectNamesText(req.SubjectIDs)),
+			Link:    "/",
+		})
+
 		global.GetLog(c).Infof("用户[%s] 定向分享成功: 目标数=%d", userCodeStr, len(req.Targets))
-		c.JSON(http.StatusOK, gin.H{"code": 200, "msg": fmt.Sprintf("成功授权给 %d 位用户", count)})
+		c.JSON(http.StatusOK, gin.H{"code": 200, "msg": fmt.Sprintf("成功授权给 %d 位用户", len(grantedUserIDs))})
 
 	} else {
 		// === 生成分享码 (公开) ===
@@ -135,23 +140,16 @@ func CreateShare(c *gin.Context) {
 // =================================================================================
 // 事务处理：直接分享
 // =================================================================================
-func handleDirectShareTx(tx *sql.Tx, req model.CreateShareRequest, operatorID int, c *gin.Context) int {
-	expireTimeObj := calculateExpireTime(req.Duration)
+func handleDirectShareTx(tx *sql.Tx, req model.CreateShareRequest, operatorID int, c *gin.Context) []int {
+	expireTime := calculateExpireTime(req.Duration)
 
-	var expireTimeStr interface{}
-	if expireTimeObj == nil {
-		expireTimeStr = nil
-	} else {
-		expireTimeStr = expireTimeObj.Format("2006-01-02 15:04:05")
-	}
-
-	successCount := 0
+	grantedUserIDs := make([]int, 0, len(req.Targets))
 
 	sqlStr := `
 		INSERT INTO user_subjects (user_id, subject_id, status, expire_time) 
 		VALUES (?, ?, 1, ?)
 		ON CONFLICT(user_id, subject_id) 
-		DO UPDATE SET expire_time = excluded.expire_time, status = 1
+		DO UPDATE SET expire_time = excluded.expire_time, status = 1, member_grant = 0
 	`
 
 	for _, targetCode := range req.Targets {
@@ -164,24 +162,25 @@ func handleDirectShareTx(tx *sql.Tx, req model.CreateShareRequest, operatorID in
 		}
 
 		for _, subID := range req.SubjectIDs {
-			_, err := tx.Exec(sqlStr, realUserID, subID, expireTimeStr)
+			_, err := tx.Exec(sqlStr, realUserID, subID, expireTime)
 			if err != nil {
 				global.GetLog(c).Errorf("定向授权写入失败 (User: %s, Sub: %d): %v", targetCode, subID, err)
 			}
 		}
-		successCount++
+		grantedUserIDs = append(grantedUserIDs, realUserID)
 	}
-	return successCount
+	return grantedUserIDs
 }
 
 // =================================================================================
 // 事务处理：生成分享码
 // =================================================================================
 func handleCodeShareTx(tx *sql.Tx, req model.CreateShareRequest, operatorID int) (string, error) {
-	// 1. 生成随机 Code
-	rand.Seed(time.Now().UnixNano())
-	randomStr := fmt.Sprintf("%06X", rand.Intn(16777215))
-	shareCode := "SHARE-" + randomStr
+	// 1. 生成随机 Code (crypto/rand，长度可配置)
+	shareCode, err := generateUniqueShareCode(tx)
+	if err != nil {
+		return "", err
+	}
 
 	// 2. 设定有效期
 	durationStr := req.CodeDuration
@@ -191,7 +190,7 @@ func handleCodeShareTx(tx *sql.Tx, req model.CreateShareRequest, operatorID int)
 
 	var num int
 	var unit string
-	_, err := fmt.Sscanf(durationStr, "%d%s", &num, &unit)
+	_, err = fmt.Sscanf(durationStr, "%d%s", &num, &unit)
 	if err != nil {
 		num = 3
 		unit = "d"
@@ -217,12 +216,11 @@ func handleCodeShareTx(tx *sql.Tx, req model.CreateShareRequest, operatorID int)
 		return "", fmt.Errorf("非法操作：分享码有效期不能超过 1 年")
 	}
 
-	codeExpireTime := time.Now().Add(checkDuration)
-	expireTimeStr := codeExpireTime.Format("2006-01-02 15:04:05")
+	codeExpireTime := model.NewUTCTime(time.Now().Add(checkDuration))
 
 	// 3. 插入主表
-	insertMainSQL := `INSERT INTO share_codes (code, creator_id, duration_str, expire_time) VALUES (?, ?, ?, ?)`
-	res, err := tx.Exec(insertMainSQL, shareCode, operatorID, req.Duration, expireTimeStr)
+	insertMainSQL := `INSERT INTO share_codes (code, creator_id, duration_str, expire_time, max_uses, require_approval) VALUES (?, ?, ?, ?, ?, ?)`
+	res, err := tx.Exec(insertMainSQL, shareCode, operatorID, req.Duration, codeExpireTime, req.MaxUses, req.RequireApproval)
 	if err != nil {
 		return "", err
 	}
@@ -253,21 +251,44 @@ func BindSubject(c *gin.Context) {
 
 	currentUserID, _ := c.Get("userID")
 	userIDInt := currentUserID.(int)
+	req.Code = normalizeShareCode(req.Code)
+
+	// 0. 限流：按用户与 IP 限制尝试频率，连续输错锁定一段时间
+	guardKeys := bindGuardKeys(userIDInt, c.ClientIP())
+	if ok, wait := acquireBindAttempt(guardKeys); !ok {
+		global.GetLog(c).Warnf("绑定分享码被限流 (User: %d, IP: %s)", userIDInt, c.ClientIP())
+		respondBindLocked(c, wait)
+		return
+	}
+	bindFailed := func(status int, msg string) {
+		if recordBindFailure(guardKeys) {
+			global.GetLog(c).Warnf("绑定分享码失败次数过多，已锁定 (User: %d, IP: %s)", userIDInt, c.ClientIP())
+		}
+		c.JSON(status, gin.H{"code": status, "msg": msg})
+	}
 
+	redeemShareCode(c, userIDInt, req.Code, bindFailed)
+}
+
+// redeemShareCode 使用分享码 (手动输入绑定与科目目录一键申请共用)
+// 分享码不存在、已过期时调用 fail 写回响应 (计入绑定失败次数)，其余情况直接写回响应
+func redeemShareCode(c *gin.Context, userIDInt int, code string, fail func(status int, msg string)) {
 	// 1. 查主表信息
 	var shareCodeID int
 	var resourceDurationStr string
 	var creatorID int
-	var expireTimeStr string
+	var codeExpireTime model.UTCTime
 	var currentUsedCount int
+	var maxUses int
+	var requireApproval bool
 
 	err := global.DB.QueryRow(
-		"SELECT id, creator_id, duration_str, expire_time, used_count FROM share_codes WHERE code = ? AND status = 1",
-		req.Code,
-	).Scan(&shareCodeID, &creatorID, &resourceDurationStr, &expireTimeStr, &currentUsedCount)
+		"SELECT id, creator_id, duration_str, expire_time, used_count, IFNULL(max_uses, 0), IFNULL(require_approval, 0) FROM share_codes WHERE code = ? AND status = 1",
+		code,
+	).Scan(&shareCodeID, &creatorID, &resourceDurationStr, &codeExpireTime, &currentUsedCount, &maxUses, &requireApproval)
 
 	if err == sql.ErrNoRows {
-		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "分享码无效或已失效"})
+		fail(http.StatusNotFound, "分享码无效或已失效")
 		return
 	} else if err != nil {
 		global.GetLog(c).Errorf("绑定查询分享码失败: %v", err)
@@ -281,15 +302,8 @@ func BindSubject(c *gin.Context) {
 	}
 
 	// 2. 校验分享码有效期
-	var codeExpireTime time.Time
-	var parseErr error
-	codeExpireTime, parseErr = time.ParseInLocation("2006-01-02 15:04:05", expireTimeStr, time.Local)
-	if parseErr != nil {
-		codeExpireTime, _ = time.Parse(time.RFC3339, expireTimeStr)
-	}
-
-	if time.Now().After(codeExpireTime) {
-		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "该分享码已失效 (超过有效期)"})
+	if codeExpireTime.Expired(time.Now()) {
+		fail(http.StatusBadRequest, "该分享码已失效 (超过有效期)")
 		return
 	}
 
@@ -314,6 +328,19 @@ func BindSubject(c *gin.Context) {
 		return
 	}
 
+	// 审核模式：已有待审核的申请时直接返回，不重复占用名额
+	if requireApproval {
+		var pendingID int
+		err := global.DB.QueryRow("SELECT id FROM share_code_requests WHERE share_code_id = ? AND user_id = ? AND status = ?",
+			shareCodeID, userIDInt, model.ShareRequestPending).Scan(&pendingID)
+		if err == nil {
+			c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "您已提交过申请，请等待创建者审核", "data": gin.H{
+				"pending": true, "request_id": pendingID,
+			}})
+			return
+		}
+	}
+
 	// 4. 开启事务
 	tx, err := global.DB.Begin()
 	if err != nil {
@@ -322,26 +349,102 @@ func BindSubject(c *gin.Context) {
 	}
 	defer tx.Rollback()
 
-	// 统计逻辑
-	usageSQL := `INSERT OR IGNORE INTO share_code_usage (share_code_id, user_id) VALUES (?, ?)`
-	res, err := tx.Exec(usageSQL, shareCodeID, userIDInt)
-	if err == nil {
-		affected, _ := res.RowsAffected()
-		if affected > 0 {
-			tx.Exec("UPDATE share_codes SET used_count = used_count + 1 WHERE id = ?", shareCodeID)
-			currentUsedCount++
+	// 统计逻辑：首次使用才占用名额，名额校验与计数在同一条 UPDATE 中完成，并发下也不会超发
+	usageSQL := `INSERT OR IGNORE INTO share_code_usage (share_code_id, user_id, ip) VALUES (?, ?, ?)`
+	res, err := tx.Exec(usageSQL, shareCodeID, userIDInt, c.ClientIP())
+	if err != nil {
+		global.GetLog(c).Errorf("记录分享码使用失败 (Code: %s): %v", code, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "系统错误"})
+		return
+	}
+	if affected, _ := res.RowsAffected(); affected > 0 {
+		res, err := tx.Exec(`UPDATE share_codes SET used_count = used_count + 1
+			WHERE id = ? AND (IFNULL(max_uses, 0) = 0 OR used_count < max_uses)`, shareCodeID)
+		if err != nil {
+			global.GetLog(c).Errorf("更新分享码使用次数失败 (Code: %s): %v", code, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "系统错误"})
+			return
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": fmt.Sprintf("该分享码已达使用上限 (%d 人)", maxUses)})
+			return
+		}
+		currentUsedCount++
+	}
+
+	// 审核模式：只记录申请 (重新申请时覆盖之前的审核结果)，由创建者审核通过后再授权
+	if requireApproval {
+		var requestID int
+		err := tx.QueryRow(`
+			INSERT INTO share_code_requests (share_code_id, user_id, status) VALUES (?, ?, ?)
+			ON CONFLICT(share_code_id, user_id) DO UPDATE SET
+				status = excluded.status, review_note = NULL, reviewer_id = NULL, review_time = NULL,
+				create_time = CURRENT_TIMESTAMP
+			RETURNING id`, shareCodeID, userIDInt, model.ShareRequestPending).Scan(&requestID)
+		if err != nil {
+			global.GetLog(c).Errorf("提交分享码申请失败 (User: %d, Code: %s): %v", userIDInt, code, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "提交申请失败"})
+			return
 		}
+		if err := tx.Commit(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "提交申请失败"})
+			return
+		}
+		notifyUsers(c, []int{creatorID}, notifyEvent{
+			Type:    model.NotifyShareRequest,
+			Title:   "分享码收到新的申请",
+			Content: fmt.Sprintf("%s 申请通过分享码 %s 获取科目：%s，请及时审核", userDisplayName(userIDInt), code, subjectNamesText(subjectIDs)),
+			Link:    "/",
+		})
+		global.GetLog(c).Infof("用户[%d] 提交分享码申请: %s (RequestID: %d)", userIDInt, code, requestID)
+		c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "该分享码需要创建者审核，申请已提交", "data": gin.H{
+			"pending": true, "request_id": requestID,
+		}})
+		return
 	}
 
-	// 计算新的过期时间
-	userResourceExpireObj := calculateExpireTime(resourceDurationStr)
-	var userResourceExpireStr interface{}
-	if userResourceExpireObj == nil {
-		userResourceExpireStr = nil
+	successCount, skippedCount := grantShareSubjectsTx(tx, c, userIDInt, shareCodeID, subjectIDs, resourceDurationStr)
+
+	tx.Commit()
+
+	msg := ""
+	if successCount > 0 {
+		msg = fmt.Sprintf("成功绑定 %d 个新科目！", successCount)
+		if skippedCount > 0 {
+			msg += fmt.Sprintf(" (另有 %d 个科目您已拥有且未过期，已跳过)", skippedCount)
+		}
+		notifyUsers(c, []int{creatorID}, notifyEvent{
+			Type:    model.NotifyShareBound,
+			Title:   "分享码被绑定",
+			Content: fmt.Sprintf("%s 通过分享码 %s 绑定了 %d 个科目", userDisplayName(userIDInt), code, successCount),
+			Link:    "/",
+		})
+		global.GetLog(c).Infof("用户[%d] 绑定分享码成功: %s (新增: %d)", userIDInt, code, successCount)
 	} else {
-		userResourceExpireStr = userResourceExpireObj.Format("2006-01-02 15:04:05")
+		if skippedCount > 0 {
+			msg = "您已拥有该分享码包含的所有科目，且均在有效期内，无需重复绑定。"
+		} else {
+			msg = "绑定操作完成，但没有科目发生变更。"
+		}
 	}
 
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  msg,
+		"data": gin.H{
+			"success_count": successCount,
+			"skipped_count": skippedCount,
+			"total_users":   currentUsedCount,
+		},
+	})
+}
+
+// grantShareSubjectsTx 按分享码授权科目 (直接绑定与审核通过共用)
+// 资源有效期从授权时刻开始计算；已拥有且未过期的科目跳过
+func grantShareSubjectsTx(tx *sql.Tx, c *gin.Context, userID, shareCodeID int, subjectIDs []int, resourceDurationStr string) (successCount, skippedCount int) {
+	// 计算新的过期时间
+	userResourceExpire := calculateExpireTime(resourceDurationStr)
+
 	bindSQL := `
 		INSERT INTO user_subjects (user_id, subject_id, status, expire_time, source_share_code_id) 
 		VALUES (?, ?, 1, ?, ?)
@@ -349,60 +452,33 @@ func BindSubject(c *gin.Context) {
 		DO UPDATE SET 
 			expire_time = excluded.expire_time, 
 			status = 1,
-			source_share_code_id = excluded.source_share_code_id
+			source_share_code_id = excluded.source_share_code_id,
+			member_grant = 0
 	`
 
-	successCount := 0
-	skippedCount := 0
-
 	for _, sid := range subjectIDs {
 		checkSQL := `
 			SELECT id FROM user_subjects 
 			WHERE user_id = ? AND subject_id = ? AND status = 1 
-			AND (expire_time IS NULL OR expire_time > datetime('now', 'localtime'))
+			AND (expire_time IS NULL OR expire_time > datetime('now'))
 		`
 		var existingID int
-		err := tx.QueryRow(checkSQL, userIDInt, sid).Scan(&existingID)
+		err := tx.QueryRow(checkSQL, userID, sid).Scan(&existingID)
 
 		if err == nil {
 			skippedCount++
 			continue
 		}
 
-		_, err = tx.Exec(bindSQL, userIDInt, sid, userResourceExpireStr, shareCodeID)
+		_, err = tx.Exec(bindSQL, userID, sid, userResourceExpire, shareCodeID)
 		if err != nil {
-			global.GetLog(c).Errorf("绑定科目失败 (User: %d, Sub: %d): %v", userIDInt, sid, err)
+			global.GetLog(c).Errorf("绑定科目失败 (User: %d, Sub: %d): %v", userID, sid, err)
 			continue
 		}
 		successCount++
 	}
 
-	tx.Commit()
-
-	msg := ""
-	if successCount > 0 {
-		msg = fmt.Sprintf("成功绑定 %d 个新科目！", successCount)
-		if skippedCount > 0 {
-			msg += fmt.Sprintf(" (另有 %d 个科目您已拥有且未过期，已跳过)", skippedCount)
-		}
-		global.GetLog(c).Infof("用户[%d] 绑定分享码成功: %s (新增: %d)", userIDInt, req.Code, successCount)
-	} else {
-		if skippedCount > 0 {
-			msg = "您已拥有该分享码包含的所有科目，且均在有效期内，无需重复绑定。"
-		} else {
-			msg = "绑定操作完成，但没有科目发生变更。"
-		}
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"code": 200,
-		"msg":  msg,
-		"data": gin.H{
-			"success_count": successCount,
-			"skipped_count": skippedCount,
-			"total_users":   currentUsedCount,
-		},
-	})
+	return successCount, skippedCount
 }
 
 // =================================================================================
@@ -414,7 +490,9 @@ func GetMyShareCodes(c *gin.Context) {
 	sqlStr := `
 		SELECT 
 			sc.id, sc.code, sc.duration_str, sc.expire_time, sc.used_count, sc.create_time,
-			(SELECT COUNT(*) FROM share_code_subjects WHERE share_code_id = sc.id) as subject_count
+			(SELECT COUNT(*) FROM share_code_subjects WHERE share_code_id = sc.id) as subject_count,
+			IFNULL(sc.max_uses, 0), IFNULL(sc.require_approval, 0),
+			(SELECT COUNT(*) FROM share_code_requests WHERE share_code_id = sc.id AND status = 0) as pending_count
 		FROM share_codes sc
 		WHERE sc.creator_id = ? AND sc.status = 1
 		ORDER BY sc.create_time DESC
@@ -430,37 +508,36 @@ func GetMyShareCodes(c *gin.Context) {
 
 	var list []gin.H
 	for rows.Next() {
-		var id, usedCount, subjectCount int
-		var code, durationStr, expireTimeStr, createTimeStr string
+		var id, usedCount, subjectCount, maxUses, pendingCount int
+		var requireApproval bool
+		var code, durationStr string
+		var expireTime, createTime model.UTCTime
 
-		err = rows.Scan(&id, &code, &durationStr, &expireTimeStr, &usedCount, &createTimeStr, &subjectCount)
+		err = rows.Scan(&id, &code, &durationStr, &expireTime, &usedCount, &createTime, &subjectCount, &maxUses, &requireApproval, &pendingCount)
 		if err != nil {
 			continue
 		}
 
-		expireTimeStr = strings.Replace(expireTimeStr, "T", " ", 1)
-		expireTimeStr = strings.Split(expireTimeStr, "+")[0]
-		expireTimeStr = strings.TrimSuffix(expireTimeStr, "Z")
-
 		status := "active"
-		expireTime, err := time.ParseInLocation("2006-01-02 15:04:05", expireTimeStr, time.Local)
-		if err != nil {
-			expireTime, _ = time.Parse(time.RFC3339, expireTimeStr)
-		}
-
-		if time.Now().After(expireTime) {
+		if expireTime.Expired(time.Now()) {
 			status = "expired"
+		} else if maxUses > 0 && usedCount >= maxUses {
+			status = "exhausted"
 		}
 
 		list = append(list, gin.H{
 			"id":            id,
 			"code":          code,
 			"resource_time": durationStr,
-			"expire_time":   expireTimeStr,
+			"expire_time":   expireTime,
 			"used_count":    usedCount,
+			"max_uses":      maxUses,
 			"subject_count": subjectCount,
-			"create_time":   createTimeStr,
+			"create_time":   createTime,
 			"status":        status,
+
+			"require_approval": requireApproval,
+			"pending_count":    pendingCount,
 		})
 	}
 
@@ -492,6 +569,12 @@ func DeleteShareCode(c *gin.Context) {
 		return
 	}
 
+	// 分享码删除后，未审核的绑定申请一并拒绝，申请人可以看到结果
+	if _, err := global.DB.Exec(`UPDATE share_code_requests SET status = ?, review_note = '分享码已删除', reviewer_id = ?, review_time = CURRENT_TIMESTAMP
+		WHERE share_code_id = ? AND status = ?`, model.ShareRequestRejected, userID, id, model.ShareRequestPending); err != nil {
+		global.GetLog(c).Errorf("拒绝已删除分享码的待审核申请失败 (ID: %d): %v", id, err)
+	}
+
 	global.GetLog(c).Infof("用户[%v] 删除分享码成功 (ID: %d)", userID, id)
 	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "删除成功"})
 }
@@ -507,6 +590,9 @@ func UpdateShareCode(c *gin.Context) {
 	var req struct {
 		NewExpireDate string `json:"new_expire_date"`
 		NewDuration   string `json:"new_duration"`
+		NewMaxUses    *int   `json:"new_max_uses"` // 0 为不限
+
+		NewRequireApproval *bool `json:"new_require_approval"` // 关闭后已提交的申请仍需手动审核
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "参数错误"})
@@ -517,22 +603,22 @@ func UpdateShareCode(c *gin.Context) {
 	sqlStr := "UPDATE share_codes SET "
 
 	if req.NewExpireDate != "" {
-		newTime, err := time.ParseInLocation("2006-01-02 15:04:05", req.NewExpireDate, time.Local)
+		newTime, err := model.ParseInputTime(req.NewExpireDate)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "截止时间格式错误"})
 			return
 		}
 
-		var createTime time.Time
+		var createTime model.UTCTime
 		err = global.DB.QueryRow("SELECT create_time FROM share_codes WHERE id = ? AND status = 1", id).Scan(&createTime)
 		if err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "找不到该分享码或已删除"})
 			return
 		}
 
-		limitTime := createTime.AddDate(1, 0, 0)
+		limitTime := createTime.Time.AddDate(1, 0, 0)
 		if newTime.After(limitTime) {
-			limitStr := limitTime.Format("2006-01-02 15:04:05")
+			limitStr := limitTime.In(time.Local).Format(model.DBTimeLayout)
 			msg := fmt.Sprintf("非法操作：该码最晚有效期只能到 %s (创建后1年内)", limitStr)
 			global.GetLog(c).Warnf("修改分享码被拒(超期): %v", msg)
 			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": msg})
@@ -544,7 +630,7 @@ func UpdateShareCode(c *gin.Context) {
 		}
 
 		sqlStr += "expire_time = ?, "
-		args = append(args, req.NewExpireDate)
+		args = append(args, model.FormatDBTime(newTime))
 	}
 
 	if req.NewDuration != "" {
@@ -552,6 +638,21 @@ func UpdateShareCode(c *gin.Context) {
 		args = append(args, req.NewDuration)
 	}
 
+	if req.NewMaxUses != nil {
+		if *req.NewMaxUses < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "使用上限不能为负数"})
+			return
+		}
+		// 上限可以低于已使用人数，此时只是不再接受新用户
+		sqlStr += "max_uses = ?, "
+		args = append(args, *req.NewMaxUses)
+	}
+
+	if req.NewRequireApproval != nil {
+		sqlStr += "require_approval = ?, "
+		args = append(args, *req.NewRequireApproval)
+	}
+
 	if len(args) == 0 {
 		c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "无变更"})
 		return
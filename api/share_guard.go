@@ -0,0 +1,219 @@
+package api
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"practice_problems/global"
+	"practice_problems/model"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==========================================
+// 分享码安全：crypto/rand 生成、绑定尝试限流与失败锁定、使用记录
+// 限流状态保存在内存中 (与 TokenStore 一致)，服务重启后清零
+// ==========================================
+
+// shareCodeAlphabet 去掉易混淆的 0/O、1/I，共 32 个字符，每位 5 bit
+const shareCodeAlphabet = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+// newShareCode 生成 "SHARE-" + 随机串
+func newShareCode(length int) (string, error) {
+	buf := make([]byte, length)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		buf[i] = shareCodeAlphabet[int(b)%len(shareCodeAlphabet)] // 256 是 32 的整数倍，无取模偏差
+	}
+	return "SHARE-" + string(buf), nil
+}
+
+// generateUniqueShareCode 生成库中不存在的分享码
+func generateUniqueShareCode(tx *sql.Tx) (string, error) {
+	for i := 0; i < 5; i++ {
+		code, err := newShareCode(global.ShareCodeLength)
+		if err != nil {
+			return "", err
+		}
+		var count int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM share_codes WHERE code = ?", code).Scan(&count); err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return code, nil
+		}
+	}
+	return "", fmt.Errorf("生成分享码多次重复，请重试")
+}
+
+// normalizeShareCode 统一用户输入的分享码 (去空白、转大写)
+func normalizeShareCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// bindAttemptState 单个用户或 IP 的绑定尝试记录
+type bindAttemptState struct {
+	attempts    []time.Time // 最近一分钟内的尝试
+	failures    []time.Time // 统计窗口内的失败
+	lockedUntil time.Time
+}
+
+var bindGuard = struct {
+	sync.Mutex
+	Data map[string]*bindAttemptState
+}{
+	Data: make(map[string]*bindAttemptState),
+}
+
+// bindGuardKeys 同时按用户与 IP 限制，换账号或换 IP 都绕不过去
+func bindGuardKeys(userID int, ip string) []string {
+	return []string{"user:" + strconv.Itoa(userID), "ip:" + ip}
+}
+
+// pruneTimes 丢弃早于 cutoff 的时间点
+func pruneTimes(times []time.Time, cutoff time.Time) []time.Time {
+	i := 0
+	for i < len(times) && times[i].Before(cutoff) {
+		i++
+	}
+	return times[i:]
+}
+
+// acquireBindAttempt 检查是否允许本次绑定尝试并计数；不允许时返回需要等待的时长
+func acquireBindAttempt(keys []string) (bool, time.Duration) {
+	bindGuard.Lock()
+	defer bindGuard.Unlock()
+
+	now := time.Now()
+	if len(bindGuard.Data) > 10000 {
+		// 定期清理已无记录的条目，避免无限增长
+		for k, st := range bindGuard.Data {
+			if now.After(st.lockedUntil) && len(pruneTimes(st.attempts, now.Add(-time.Minute))) == 0 &&
+				len(pruneTimes(st.failures, now.Add(-time.Duration(global.ShareBindFailureWindow)*time.Minute))) == 0 {
+				delete(bindGuard.Data, k)
+			}
+		}
+	}
+
+	var wait time.Duration
+	states := make([]*bindAttemptState, 0, len(keys))
+	for _, k := range keys {
+		st := bindGuard.Data[k]
+		if st == nil {
+			st = &bindAttemptState{}
+			bindGuard.Data[k] = st
+		}
+		st.attempts = pruneTimes(st.attempts, now.Add(-time.Minute))
+		if now.Before(st.lockedUntil) {
+			wait = max(wait, st.lockedUntil.Sub(now))
+		} else if len(st.attempts) >= global.ShareBindRatePerMinute {
+			wait = max(wait, st.attempts[0].Add(time.Minute).Sub(now))
+		}
+		states = append(states, st)
+	}
+	if wait > 0 {
+		return false, wait
+	}
+	for _, st := range states {
+		st.attempts = append(st.attempts, now)
+	}
+	return true, 0
+}
+
+// recordBindFailure 记录一次失败 (无效或过期的分享码)，达到上限时锁定；返回是否已锁定
+func recordBindFailure(keys []string) bool {
+	bindGuard.Lock()
+	defer bindGuard.Unlock()
+
+	now := time.Now()
+	locked := false
+	for _, k := range keys {
+		st := bindGuard.Data[k]
+		if st == nil {
+			st = &bindAttemptState{}
+			bindGuard.Data[k] = st
+		}
+		st.failures = append(pruneTimes(st.failures, now.Add(-time.Duration(global.ShareBindFailureWindow)*time.Minute)), now)
+		if len(st.failures) >= global.ShareBindMaxFailures {
+			st.lockedUntil = now.Add(time.Duration(global.ShareBindLockMinutes) * time.Minute)
+			st.failures = nil
+			locked = true
+		}
+	}
+	return locked
+}
+
+// respondBindLocked 绑定尝试过于频繁或已锁定
+func respondBindLocked(c *gin.Context, wait time.Duration) {
+	seconds := int(wait.Seconds()) + 1
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(http.StatusTooManyRequests, gin.H{"code": 429, "msg": fmt.Sprintf("尝试次数过多，请 %d 秒后再试", seconds)})
+}
+
+// =================================================================================
+// GetShareCodeRedeemers 分享码使用记录 (仅创建者可见)
+// =================================================================================
+func GetShareCodeRedeemers(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+	userID, _ := currentUser(c)
+
+	var creatorID int
+	if err := global.DB.QueryRow("SELECT creator_id FROM share_codes WHERE id = ?", id).Scan(&creatorID); err != nil || creatorID != userID {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "分享码不存在或无权查看"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	var total int
+	global.DB.QueryRow("SELECT COUNT(*) FROM share_code_usage WHERE share_code_id = ?", id).Scan(&total)
+
+	// 授权状态取该用户经由此码获得的授权 (可能已被其他分享码或定向授权覆盖)
+	rows, err := global.DB.Query(`
+		SELECT u.id, u.user_code, u.username, IFNULL(u.nickname, ''), scu.use_time, IFNULL(scu.ip, ''),
+			(SELECT COUNT(*) FROM user_subjects us
+			 WHERE us.user_id = u.id AND us.source_share_code_id = scu.share_code_id AND us.status = 1
+			   AND (us.expire_time IS NULL OR us.expire_time > datetime('now'))) > 0,
+			(SELECT CASE WHEN COUNT(*) > COUNT(us.expire_time) THEN NULL ELSE MAX(us.expire_time) END FROM user_subjects us
+			 WHERE us.user_id = u.id AND us.source_share_code_id = scu.share_code_id)
+		FROM share_code_usage scu
+		JOIN users u ON scu.user_id = u.id
+		WHERE scu.share_code_id = ?
+		ORDER BY scu.use_time DESC, scu.id DESC
+		LIMIT ? OFFSET ?`, id, pageSize, (page-1)*pageSize)
+	if err != nil {
+		global.GetLog(c).Errorf("查询分享码使用记录失败 (ID: %d): %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+	defer rows.Close()
+
+	list := make([]model.ShareCodeRedeemer, 0)
+	for rows.Next() {
+		var r model.ShareCodeRedeemer
+		if err := rows.Scan(&r.UserID, &r.UserCode, &r.Username, &r.Nickname, &r.UseTime, &r.IP, &r.Active, &r.ExpireTime); err != nil {
+			continue
+		}
+		list = append(list, r)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "success", "data": gin.H{"list": list, "total": total, "page": page, "pageSize": pageSize}})
+}
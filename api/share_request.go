@@ -0,0 +1,369 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"practice_problems/global"
+	"practice_problems/model"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==========================================
+// 分享码审核模式：require_approval = 1 的分享码绑定时只生成申请
+// 创建者通过后按分享码授权科目 (资源有效期从通过时刻开始计算)；拒绝时释放占用的使用名额
+// ==========================================
+
+// loadShareCodeSubjectNames 批量查询分享码包含的科目名称
+func loadShareCodeSubjectNames(codeIDs []int) (map[int][]string, error) {
+	names := make(map[int][]string)
+	if len(codeIDs) == 0 {
+		return names, nil
+	}
+	placeholders, args := idArgs(codeIDs)
+	rows, err := global.DB.Query(`
+		SELECT scs.share_code_id, s.name FROM share_code_subjects scs
+		JOIN subjects s ON scs.subject_id = s.id
+		WHERE scs.share_code_id IN (`+placeholders+`)
+		ORDER BY scs.id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var codeID int
+		var name string
+		if err := rows.Scan(&codeID, &name); err == nil {
+			names[codeID] = append(names[codeID], name)
+		}
+	}
+	return names, rows.Err()
+}
+
+// scanShareApplications 读取申请列表并补充科目名称
+func scanShareApplications(rows *sql.Rows, withUser bool) ([]model.ShareCodeApplication, error) {
+	list := make([]model.ShareCodeApplication, 0)
+	var codeIDs []int
+	for rows.Next() {
+		var a model.ShareCodeApplication
+		var note sql.NullString
+		dest := []interface{}{&a.ID, &a.ShareCodeID, &a.Code, &a.Status, &note, &a.CreateTime, &a.ReviewTime}
+		if withUser {
+			dest = append(dest, &a.UserID, &a.UserCode, &a.Username, &a.Nickname)
+		}
+		if err := rows.Scan(dest...); err != nil {
+			continue
+		}
+		a.ReviewNote = note.String
+		list = append(list, a)
+		codeIDs = append(codeIDs, a.ShareCodeID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	names, err := loadShareCodeSubjectNames(codeIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		list[i].SubjectNames = names[list[i].ShareCodeID]
+		if list[i].SubjectNames == nil {
+			list[i].SubjectNames = make([]string, 0)
+		}
+	}
+	return list, nil
+}
+
+// parseShareRequestStatus 解析 status 参数：pending / approved / rejected / all，默认 pending
+func parseShareRequestStatus(c *gin.Context, def string) (int, bool, bool) {
+	switch c.DefaultQuery("status", def) {
+	case "pending":
+		return model.ShareRequestPending, false, true
+	case "approved":
+		return model.ShareRequestApproved, false, true
+	case "rejected":
+		return model.ShareRequestRejected, false, true
+	case "all":
+		return 0, true, true
+	}
+	return 0, false, false
+}
+
+// =================================================================================
+// GetShareCodeRequests 我创建的分享码收到的绑定申请 (创建者)
+// =================================================================================
+func GetShareCodeRequests(c *gin.Context) {
+	userID, _ := currentUser(c)
+
+	status, all, ok := parseShareRequestStatus(c, "pending")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "status 参数错误"})
+		return
+	}
+
+	where := "sc.creator_id = ?"
+	args := []interface{}{userID}
+	if !all {
+		where += " AND r.status = ?"
+		args = append(args, status)
+	}
+	if codeID, err := strconv.Atoi(c.Query("share_code_id")); err == nil && codeID > 0 {
+		where += " AND r.share_code_id = ?"
+		args = append(args, codeID)
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	var total int
+	global.DB.QueryRow(`SELECT COUNT(*) FROM share_code_requests r JOIN share_codes sc ON r.share_code_id = sc.id WHERE `+where, args...).Scan(&total)
+
+	rows, err := global.DB.Query(`
+		SELECT r.id, r.share_code_id, sc.code, r.status, r.review_note, r.create_time, r.review_time,
+			u.id, u.user_code, u.username, IFNULL(u.nickname, '')
+		FROM share_code_requests r
+		JOIN share_codes sc ON r.share_code_id = sc.id
+		JOIN users u ON r.user_id = u.id
+		WHERE `+where+`
+		ORDER BY r.create_time ASC, r.id ASC
+		LIMIT ? OFFSET ?`, append(args, pageSize, (page-1)*pageSize)...)
+	if err != nil {
+		global.GetLog(c).Errorf("查询分享码申请失败 (User: %d): %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+	defer rows.Close()
+
+	list, err := scanShareApplications(rows, true)
+	if err != nil {
+		global.GetLog(c).Errorf("读取分享码申请失败 (User: %d): %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "success", "data": gin.H{"list": list, "total": total, "page": page, "pageSize": pageSize}})
+}
+
+// =================================================================================
+// GetMyShareRequests 我提交的绑定申请及审核状态 (申请人)
+// =================================================================================
+func GetMyShareRequests(c *gin.Context) {
+	userID, _ := currentUser(c)
+
+	status, all, ok := parseShareRequestStatus(c, "all")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "status 参数错误"})
+		return
+	}
+
+	where := "r.user_id = ?"
+	args := []interface{}{userID}
+	if !all {
+		where += " AND r.status = ?"
+		args = append(args, status)
+	}
+
+	rows, err := global.DB.Query(`
+		SELECT r.id, r.share_code_id, sc.code, r.status, r.review_note, r.create_time, r.review_time
+		FROM share_code_requests r
+		JOIN share_codes sc ON r.share_code_id = sc.id
+		WHERE `+where+`
+		ORDER BY r.create_time DESC, r.id DESC
+		LIMIT 200`, args...)
+	if err != nil {
+		global.GetLog(c).Errorf("查询我的分享码申请失败 (User: %d): %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+	defer rows.Close()
+
+	list, err := scanShareApplications(rows, false)
+	if err != nil {
+		global.GetLog(c).Errorf("读取我的分享码申请失败 (User: %d): %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "success", "data": list})
+}
+
+// =================================================================================
+// ReviewShareRequest 审核单个绑定申请
+// =================================================================================
+func ReviewShareRequest(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+	var req model.ReviewShareRequestsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "参数错误: " + err.Error()})
+		return
+	}
+	req.IDs = []int{id}
+
+	result, ok := reviewShareRequests(c, req)
+	if !ok {
+		return
+	}
+	if len(result["skipped_ids"].([]int)) > 0 {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "申请不存在、无权审核或已处理"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "审核完成", "data": result})
+}
+
+// =================================================================================
+// BatchReviewShareRequests 批量审核绑定申请
+// =================================================================================
+func BatchReviewShareRequests(c *gin.Context) {
+	var req model.ReviewShareRequestsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "参数错误: " + err.Error()})
+		return
+	}
+	if len(req.IDs) == 0 || len(req.IDs) > 200 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "请选择 1~200 条申请"})
+		return
+	}
+
+	result, ok := reviewShareRequests(c, req)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "审核完成", "data": result})
+}
+
+// reviewShareRequests 在一个事务中审核申请；不属于当前用户或已处理的申请计入 skipped_ids
+// 出错时已写出响应并返回 false
+func reviewShareRequests(c *gin.Context, req model.ReviewShareRequestsRequest) (gin.H, bool) {
+	userID, _ := currentUser(c)
+	approve := req.Action == "approve"
+	note := strings.TrimSpace(req.Note)
+
+	tx, err := global.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "事务开启失败"})
+		return nil, false
+	}
+	defer tx.Rollback()
+
+	handled := make([]int, 0)
+	skipped := make([]int, 0)
+	granted := 0
+	seen := make(map[int]bool)
+	var reviewed []notifyTarget // 审核完成后通知申请人
+	for _, id := range req.IDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		var shareCodeID, applicantID int
+		var durationStr, code string
+		err := tx.QueryRow(`
+			SELECT r.share_code_id, r.user_id, sc.duration_str, sc.code
+			FROM share_code_requests r JOIN share_codes sc ON r.share_code_id = sc.id
+			WHERE r.id = ? AND r.status = ? AND sc.creator_id = ? AND sc.status = 1`,
+			id, model.ShareRequestPending, userID).Scan(&shareCodeID, &applicantID, &durationStr, &code)
+		if err == sql.ErrNoRows {
+			skipped = append(skipped, id)
+			continue
+		} else if err != nil {
+			global.GetLog(c).Errorf("查询分享码申请失败 (ID: %d): %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "审核失败"})
+			return nil, false
+		}
+
+		newStatus := model.ShareRequestRejected
+		if approve {
+			newStatus = model.ShareRequestApproved
+			subjectIDs, err := shareCodeSubjectIDs(tx, shareCodeID)
+			if err != nil {
+				global.GetLog(c).Errorf("查询分享码科目失败 (CodeID: %d): %v", shareCodeID, err)
+				c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "审核失败"})
+				return nil, false
+			}
+			success, _ := grantShareSubjectsTx(tx, c, applicantID, shareCodeID, subjectIDs, durationStr)
+			granted += success
+		} else {
+			// 拒绝后释放使用名额，申请人可以再次申请
+			res, err := tx.Exec("DELETE FROM share_code_usage WHERE share_code_id = ? AND user_id = ?", shareCodeID, applicantID)
+			if err == nil {
+				if n, _ := res.RowsAffected(); n > 0 {
+					_, err = tx.Exec("UPDATE share_codes SET used_count = MAX(used_count - 1, 0) WHERE id = ?", shareCodeID)
+				}
+			}
+			if err != nil {
+				global.GetLog(c).Errorf("释放分享码名额失败 (CodeID: %d, User: %d): %v", shareCodeID, applicantID, err)
+				c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "审核失败"})
+				return nil, false
+			}
+		}
+
+		if _, err := tx.Exec(`UPDATE share_code_requests SET status = ?, review_note = ?, reviewer_id = ?, review_time = CURRENT_TIMESTAMP
+			WHERE id = ?`, newStatus, note, userID, id); err != nil {
+			global.GetLog(c).Errorf("更新分享码申请状态失败 (ID: %d): %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "审核失败"})
+			return nil, false
+		}
+		handled = append(handled, id)
+
+		ev := notifyEvent{Type: model.NotifyShareReviewed, Link: "/"}
+		if approve {
+			ev.Title = "分享码申请已通过"
+			ev.Content = fmt.Sprintf("您通过分享码 %s 提交的申请已通过，相关科目已授权", code)
+		} else {
+			ev.Title = "分享码申请未通过"
+			ev.Content = fmt.Sprintf("您通过分享码 %s 提交的申请未通过", code)
+		}
+		if note != "" {
+			ev.Content += "，备注：" + note
+		}
+		reviewed = append(reviewed, notifyTarget{userID: applicantID, event: ev})
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "审核失败"})
+		return nil, false
+	}
+	for _, r := range reviewed {
+		notifyUsers(c, []int{r.userID}, r.event)
+	}
+
+	global.GetLog(c).Infof("用户[%d] 审核分享码申请: %s %v (跳过: %v)", userID, req.Action, handled, skipped)
+	return gin.H{
+		"action":        req.Action,
+		"handled_ids":   handled,
+		"skipped_ids":   skipped,
+		"granted_count": granted, // 审核通过后新授权的科目数
+	}, true
+}
+
+// shareCodeSubjectIDs 分享码包含的科目 ID
+func shareCodeSubjectIDs(tx *sql.Tx, shareCodeID int) ([]int, error) {
+	rows, err := tx.Query("SELECT subject_id FROM share_code_subjects WHERE share_code_id = ?", shareCodeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []int
+	for rows.Next() {
+		var sid int
+		if err := rows.Scan(&sid); err == nil {
+			ids = append(ids, sid)
+		}
+	}
+	return ids, rows.Err()
+}
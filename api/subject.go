@@ -28,16 +28,17 @@ func GetSubjectList(c *gin.Context) {
 	sqlStr := `
 		SELECT 
 			s.id, s.name, s.status, s.creator_code, s.create_time, s.update_time,
-			u.email, u.nickname
+			u.email, u.nickname, COALESCE(m.role, ''), us.expire_time, IFNULL(s.grace_days, 0)
 		FROM subjects s 
 		JOIN user_subjects us ON s.id = us.subject_id 
 		LEFT JOIN users u ON s.creator_code = u.user_code 
+		LEFT JOIN subject_members m ON m.subject_id = s.id AND m.user_id = us.user_id
 		WHERE us.user_id = ? 
-		  AND s.status = 1
+		  AND s.status = 1 AND s.deleted_at IS NULL
 		  AND us.status = 1
 		  AND (
 		      s.creator_code = ? OR
-		      (us.expire_time IS NULL OR us.expire_time > datetime('now', 'localtime'))
+		      ` + subscriptionReadableSQL + `
 		  )
 		ORDER BY s.create_time DESC
 	`
@@ -57,11 +58,19 @@ func GetSubjectList(c *gin.Context) {
 		var id int
 		var name, statusStr, creatorCode, createTime, updateTime string
 		var creatorEmail, creatorNick sql.NullString
+		var role string
+		var expireTime model.UTCTime
+		var graceDays int
 
-		err := rows.Scan(&id, &name, &statusStr, &creatorCode, &createTime, &updateTime, &creatorEmail, &creatorNick)
+		err := rows.Scan(&id, &name, &statusStr, &creatorCode, &createTime, &updateTime, &creatorEmail, &creatorNick, &role, &expireTime, &graceDays)
 		if err != nil {
 			continue
 		}
+		if creatorCode == userCode {
+			role = RoleOwner
+		}
+		// 授权已到期但仍在宽限期内：只读
+		readOnly := creatorCode != userCode && expireTime.Expired(time.Now())
 
 		list = append(list, gin.H{
 			"id":           id,
@@ -72,6 +81,10 @@ func GetSubjectList(c *gin.Context) {
 			"updateTime":   updateTime,
 			"creatorEmail": creatorEmail.String,
 			"creatorName":  creatorNick.String,
+			"myRole":       role,
+			"expireTime":   expireTime,
+			"graceDays":    graceDays,
+			"readOnly":     readOnly,
 		})
 	}
 
@@ -90,25 +103,30 @@ func GetSubjectDetail(c *gin.Context) {
 	sqlStr := `
 		SELECT 
 			s.id, s.name, s.status, s.creator_code, s.create_time, s.update_time,
-			u.email, u.nickname
+			u.email, u.nickname, us.expire_time, IFNULL(s.grace_days, 0),
+			` + subjectRatingColumnsSQL + `
 		FROM subjects s 
 		JOIN user_subjects us ON s.id = us.subject_id 
 		LEFT JOIN users u ON s.creator_code = u.user_code
-		WHERE s.id = ? 
+		WHERE s.id = ? AND s.deleted_at IS NULL
 		  AND us.user_id = ?
 		  AND us.status = 1
 		  AND (
 		      s.creator_code = ? OR
-		      (us.expire_time IS NULL OR us.expire_time > datetime('now', 'localtime'))
+		      ` + subscriptionReadableSQL + `
 		  )
 	`
 
 	var id int
 	var name, statusStr, creatorCode, createTime, updateTime string
 	var creatorEmail, creatorNick sql.NullString
+	var expireTime model.UTCTime
+	var graceDays int
+	var rating model.RatingSummary
 
 	err := global.DB.QueryRow(sqlStr, subjectID, userID, userCode).Scan(
-		&id, &name, &statusStr, &creatorCode, &createTime, &updateTime, &creatorEmail, &creatorNick,
+		&id, &name, &statusStr, &creatorCode, &createTime, &updateTime, &creatorEmail, &creatorNick, &expireTime, &graceDays,
+		&rating.Average, &rating.Count,
 	)
 
 	if err != nil {
@@ -126,6 +144,10 @@ func GetSubjectDetail(c *gin.Context) {
 		"updateTime":   updateTime,
 		"creatorEmail": creatorEmail.String,
 		"creatorName":  creatorNick.String,
+		"expireTime":   expireTime,
+		"graceDays":    graceDays,
+		"readOnly":     creatorCode != userCode && expireTime.Expired(time.Now()),
+		"rating":       rating,
 	}
 
 	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "success", "data": data})
@@ -204,14 +226,16 @@ func UpdateSubject(c *gin.Context) {
 	var creatorCode string
 	var creatorEmail sql.NullString
 	var creatorName string
+	var oldName string
+	var oldStatus int
 
 	checkSQL := `
-		SELECT s.creator_code, u.email, IFNULL(u.nickname, u.username)
+		SELECT s.creator_code, u.email, IFNULL(u.nickname, u.username), s.name, IFNULL(s.status, 0)
 		FROM subjects s
 		LEFT JOIN users u ON s.creator_code = u.user_code
-		WHERE s.id = ?
+		WHERE s.id = ? AND s.deleted_at IS NULL
 	`
-	err = global.DB.QueryRow(checkSQL, id).Scan(&creatorCode, &creatorEmail, &creatorName)
+	err = global.DB.QueryRow(checkSQL, id).Scan(&creatorCode, &creatorEmail, &creatorName, &oldName, &oldStatus)
 
 	if err == sql.ErrNoRows {
 		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "未找到该科目"})
@@ -222,7 +246,7 @@ func UpdateSubject(c *gin.Context) {
 	}
 
 	currentUserCodeStr, ok := currentUserCode.(string)
-	if !ok || creatorCode != currentUserCodeStr {
+	if !ok || !hasSubjectRole(id, creatorCode, currentUserCodeStr, RoleEditor) {
 		// ★★★ Warn ★★★
 		global.GetLog(c).Warnf("修改科目被拒: 无权操作 (User: %v, SubjectID: %d)", currentUserCode, id)
 		contactInfo := creatorName
@@ -231,7 +255,7 @@ func UpdateSubject(c *gin.Context) {
 		} else {
 			contactInfo = creatorName + " (未设置邮箱)"
 		}
-		msg := "修改失败：您不是创建者，请联系作者邮箱: " + contactInfo + " 进行修改"
+		msg := "修改失败：您没有该科目的编辑权限，请联系作者邮箱: " + contactInfo + " 进行修改"
 		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": msg})
 		return
 	}
@@ -246,6 +270,25 @@ func UpdateSubject(c *gin.Context) {
 		return
 	}
 
+	// 名称或状态有变化时通知订阅者
+	var changes []string
+	if req.Name != oldName {
+		changes = append(changes, fmt.Sprintf("名称由「%s」改为「%s」", oldName, req.Name))
+	}
+	if req.Status != oldStatus {
+		changes = append(changes, "状态已变更")
+	}
+	if len(changes) > 0 {
+		operatorID, _ := currentUser(c)
+		notifySubjectSubscribers(c, id, operatorID, notifyEvent{
+			Type:    model.NotifySubjectUpdated,
+			Title:   fmt.Sprintf("科目「%s」有更新", req.Name),
+			Content: fmt.Sprintf("您订阅的科目「%s」%s", oldName, strings.Join(changes, "，")),
+			Link:    fmt.Sprintf("/?subjectId=%d", id),
+		})
+	}
+
+	pushContentChange(c, id, "subject", int64(id), "update")
 	global.GetLog(c).Infof("用户[%s] 更新科目成功 (ID: %d)", currentUserCodeStr, id)
 	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "更新成功"})
 }
@@ -269,7 +312,7 @@ func DeleteSubject(c *gin.Context) {
 		SELECT s.creator_code, u.email, IFNULL(u.nickname, u.username)
 		FROM subjects s
 		LEFT JOIN users u ON s.creator_code = u.user_code
-		WHERE s.id = ?
+		WHERE s.id = ? AND s.deleted_at IS NULL
 	`
 	err = global.DB.QueryRow(checkSQL, id).Scan(&creatorCode, &creatorEmail, &creatorName)
 
@@ -295,38 +338,75 @@ func DeleteSubject(c *gin.Context) {
 		return
 	}
 
-	updateSQL := "UPDATE subjects SET status = 0, update_time = ? WHERE id = ?"
-	nowTime := time.Now().Format("2006-01-02 15:04:05")
-
-	_, err = global.DB.Exec(updateSQL, nowTime, id)
+	// 移入回收站，分类、知识点、题目一并软删除
+	err = softDelete(model.TrashTypeSubject, id, currentUserCodeStr)
 	if err != nil {
 		global.GetLog(c).Errorf("删除科目DB失败 (ID: %d): %v", id, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "删除失败"})
 		return
 	}
 
+	pushContentChange(c, id, "subject", int64(id), "delete")
 	global.GetLog(c).Infof("用户[%s] 删除科目成功 (ID: %d)", currentUserCodeStr, id)
 	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "删除成功"})
 }
 
+// checkAuthRecordsOwner 校验授权记录 (user_subjects.id) 均属于当前用户创建的科目，失败时直接写回响应
+func checkAuthRecordsOwner(c *gin.Context, ids []int, action string) bool {
+	currentUserCode, _ := c.Get("userCode")
+	currentUserCodeStr, _ := currentUserCode.(string)
+
+	query := fmt.Sprintf(`
+		SELECT count(*)
+		FROM user_subjects us
+		JOIN subjects s ON us.subject_id = s.id
+		WHERE us.id IN (%s) AND s.creator_code != ?`,
+		strings.Trim(strings.Repeat("?,", len(ids)), ","))
+	args := []interface{}{}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	args = append(args, currentUserCodeStr)
+
+	var count int
+	if err := global.DB.QueryRow(query, args...).Scan(&count); err != nil {
+		global.GetLog(c).Errorf("%s鉴权查询失败: %v", action, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "系统繁忙"})
+		return false
+	}
+	if count > 0 {
+		global.GetLog(c).Warnf("%s被拒: 无权操作 (User: %s, Ids: %v)", action, currentUserCodeStr, ids)
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "无权操作：只有科目所有者可以管理授权"})
+		return false
+	}
+	return true
+}
+
+// UpdateSubjectAuth 修改单个授权的有效期
 // =================================================================================
 func UpdateSubjectAuth(c *gin.Context) {
 	idStr := c.Param("id")
-	// ... (鉴权逻辑略，假设前端已保证) ...
+	relID, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+	if !checkAuthRecordsOwner(c, []int{relID}, "更新授权有效期") {
+		return
+	}
 
 	var req struct {
 		NewExpireDate string `json:"new_expire_date"`
 	}
 	c.ShouldBindJSON(&req)
 
-	var expireVal interface{}
-	if req.NewExpireDate == "forever" || req.NewExpireDate == "" {
-		expireVal = nil
-	} else {
-		expireVal = req.NewExpireDate
+	expireVal, err := model.ParseExpireInput(req.NewExpireDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "有效期格式错误"})
+		return
 	}
 
-	_, err := global.DB.Exec("UPDATE user_subjects SET expire_time = ? WHERE id = ?", expireVal, idStr)
+	_, err = global.DB.Exec("UPDATE user_subjects SET expire_time = ?, member_grant = 0 WHERE id = ?", expireVal, idStr)
 	if err != nil {
 		global.GetLog(c).Errorf("更新授权有效期失败 (RelID: %s): %v", idStr, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "更新失败"})
@@ -340,8 +420,16 @@ func UpdateSubjectAuth(c *gin.Context) {
 // =================================================================================
 func RemoveSubjectAuth(c *gin.Context) {
 	idStr := c.Param("id")
+	relID, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+	if !checkAuthRecordsOwner(c, []int{relID}, "解除授权") {
+		return
+	}
 
-	_, err := global.DB.Exec("UPDATE user_subjects SET status = 0 WHERE id = ?", idStr)
+	_, err = global.DB.Exec("UPDATE user_subjects SET status = 0 WHERE id = ?", idStr)
 	if err != nil {
 		global.GetLog(c).Errorf("解除授权失败 (RelID: %s): %v", idStr, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "操作失败"})
@@ -411,29 +499,20 @@ func GetSubjectAuthorizedUsers(c *gin.Context) {
 	var list []gin.H
 	for rows.Next() {
 		var id, uid int
-		var expireTimeStr sql.NullString
-		var bindTimeStr, uCode, uName, uNick, uEmail string
-		rows.Scan(&id, &uid, &expireTimeStr, &bindTimeStr, &uCode, &uName, &uNick, &uEmail)
-
-		expireDisplay := "永久"
-		if expireTimeStr.Valid && expireTimeStr.String != "" {
-			tStr := strings.Replace(expireTimeStr.String, "T", " ", 1)
-			expireDisplay = strings.Split(tStr, "+")[0]
-			expireDisplay = strings.TrimSuffix(expireDisplay, "Z")
-		}
-
-		bindTimeStr = strings.Replace(bindTimeStr, "T", " ", 1)
-		bindTimeStr = strings.Split(bindTimeStr, "+")[0]
-		bindTimeStr = strings.TrimSuffix(bindTimeStr, "Z")
+		var expireTime, bindTime model.UTCTime
+		var uCode, uName, uNick, uEmail string
+		rows.Scan(&id, &uid, &expireTime, &bindTime, &uCode, &uName, &uNick, &uEmail)
 
 		list = append(list, gin.H{
 			"id":          id,
 			"user_code":   uCode,
 			"nickname":    uNick,
 			"email":       uEmail,
-			"bind_time":   bindTimeStr,
-			"expire_time": expireDisplay,
-			"raw_expire":  expireTimeStr.String,
+			"bind_time":   bindTime,
+			"expire_time": expireTime, // RFC3339，null 为永久
+			"forever":     !expireTime.Valid,
+			"expired":     expireTime.Expired(time.Now()),
+			"raw_expire":  expireTime.String(), // 兼容旧字段，永久为空串
 		})
 	}
 
@@ -451,15 +530,17 @@ func BatchUpdateAuth(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "参数错误"})
 		return
 	}
+	if !checkAuthRecordsOwner(c, req.Ids, "批量更新授权") {
+		return
+	}
 
-	var expireVal interface{}
-	if req.NewExpireDate == "forever" || req.NewExpireDate == "" {
-		expireVal = nil
-	} else {
-		expireVal = req.NewExpireDate
+	expireVal, err := model.ParseExpireInput(req.NewExpireDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "有效期格式错误"})
+		return
 	}
 
-	query := fmt.Sprintf("UPDATE user_subjects SET expire_time = ? WHERE id IN (%s)",
+	query := fmt.Sprintf("UPDATE user_subjects SET expire_time = ?, member_grant = 0 WHERE id IN (%s)",
 		strings.Trim(strings.Repeat("?,", len(req.Ids)), ","))
 
 	args := []interface{}{expireVal}
@@ -467,7 +548,7 @@ func BatchUpdateAuth(c *gin.Context) {
 		args = append(args, id)
 	}
 
-	_, err := global.DB.Exec(query, args...)
+	_, err = global.DB.Exec(query, args...)
 	if err != nil {
 		global.GetLog(c).Errorf("批量更新授权失败: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "批量更新失败"})
@@ -487,6 +568,9 @@ func BatchRemoveAuth(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "参数错误"})
 		return
 	}
+	if !checkAuthRecordsOwner(c, req.Ids, "批量移除授权") {
+		return
+	}
 
 	query := fmt.Sprintf("UPDATE user_subjects SET status = 0 WHERE id IN (%s)",
 		strings.Trim(strings.Repeat("?,", len(req.Ids)), ","))
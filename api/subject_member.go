@@ -0,0 +1,251 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"practice_problems/global"
+	"practice_problems/model"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==========================================
+// 科目协作成员与角色
+// owner 始终是 subjects.creator_code 对应的用户，不写入 subject_members
+// ==========================================
+
+// 科目角色
+const (
+	RoleOwner    = "owner"    // 所有者：全部权限，管理成员、分享与授权
+	RoleEditor   = "editor"   // 编辑者：增删改分类、知识点、题目、绑定与图片
+	RoleReviewer = "reviewer" // 审核者：只读 + 审核 AI 草稿题
+	RoleViewer   = "viewer"   // 查看者：只读
+)
+
+// roleLevels 角色等级，高等级包含低等级的全部权限
+var roleLevels = map[string]int{
+	RoleViewer:   1,
+	RoleReviewer: 2,
+	RoleEditor:   3,
+	RoleOwner:    4,
+}
+
+// getSubjectRole 查询用户在科目中的角色，不是成员时返回空字符串
+func getSubjectRole(subjectID int, creatorCode string, userCode string) string {
+	if userCode != "" && creatorCode == userCode {
+		return RoleOwner
+	}
+	var role string
+	err := global.DB.QueryRow(`
+		SELECT m.role
+		FROM subject_members m
+		JOIN users u ON m.user_id = u.id
+		WHERE m.subject_id = ? AND u.user_code = ?`, subjectID, userCode).Scan(&role)
+	if err != nil {
+		return ""
+	}
+	return role
+}
+
+// hasSubjectRole 判断用户在科目中的角色是否达到 minRole
+// creatorCode 由调用方的权限查询一并取出，避免重复查库
+func hasSubjectRole(subjectID int, creatorCode string, userCode string, minRole string) bool {
+	role := getSubjectRole(subjectID, creatorCode, userCode)
+	return roleLevels[role] >= roleLevels[minRole]
+}
+
+// =================================================================================
+// GetSubjectMembers 获取科目协作成员列表 (成员可见)
+// =================================================================================
+func GetSubjectMembers(c *gin.Context) {
+	subjectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+	currentUserCode, _ := c.Get("userCode")
+	currentUserCodeStr, _ := currentUserCode.(string)
+
+	var creatorCode, creatorName string
+	var creatorEmail sql.NullString
+	err = global.DB.QueryRow(`
+		SELECT s.creator_code, IFNULL(u.nickname, u.username), u.email
+		FROM subjects s
+		LEFT JOIN users u ON s.creator_code = u.user_code
+		WHERE s.id = ? AND s.deleted_at IS NULL`, subjectID).Scan(&creatorCode, &creatorName, &creatorEmail)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "未找到该科目"})
+		return
+	}
+	if !hasSubjectRole(subjectID, creatorCode, currentUserCodeStr, RoleViewer) {
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "无权查看该科目成员"})
+		return
+	}
+
+	list := []model.SubjectMember{{
+		UserCode: creatorCode,
+		Nickname: creatorName,
+		Email:    creatorEmail.String,
+		Role:     RoleOwner,
+	}}
+
+	rows, err := global.DB.Query(`
+		SELECT m.user_id, u.user_code, IFNULL(u.nickname, u.username), COALESCE(u.email, ''), m.role, m.create_time
+		FROM subject_members m
+		JOIN users u ON m.user_id = u.id
+		WHERE m.subject_id = ?
+		ORDER BY m.create_time ASC`, subjectID)
+	if err != nil {
+		global.GetLog(c).Errorf("查询科目成员失败 (SubjectID: %d): %v", subjectID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m model.SubjectMember
+		if err := rows.Scan(&m.UserID, &m.UserCode, &m.Nickname, &m.Email, &m.Role, &m.CreateTime); err != nil {
+			continue
+		}
+		list = append(list, m)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "success",
+		"data": gin.H{
+			"list":   list,
+			"myRole": getSubjectRole(subjectID, creatorCode, currentUserCodeStr),
+		},
+	})
+}
+
+// =================================================================================
+// SaveSubjectMember 添加成员或修改成员角色 (仅所有者)
+// =================================================================================
+func SaveSubjectMember(c *gin.Context) {
+	subjectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+
+	var req model.SaveSubjectMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "参数错误: " + err.Error()})
+		return
+	}
+	if !checkSubjectCreator(c, subjectID, "管理科目成员") {
+		return
+	}
+
+	currentUserCode, _ := c.Get("userCode")
+	currentUserCodeStr, _ := currentUserCode.(string)
+	if req.UserCode == currentUserCodeStr {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "不能修改所有者自己的角色"})
+		return
+	}
+
+	var memberID int
+	if err := global.DB.QueryRow("SELECT id FROM users WHERE user_code = ?", req.UserCode).Scan(&memberID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "用户不存在"})
+		return
+	}
+
+	tx, err := global.DB.Begin()
+	if err != nil {
+		global.GetLog(c).Errorf("开启事务失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "系统错误"})
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO subject_members (subject_id, user_id, role, invited_by)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(subject_id, user_id) DO UPDATE SET role = excluded.role, update_time = CURRENT_TIMESTAMP`,
+		subjectID, memberID, req.Role, currentUserCodeStr)
+	if err != nil {
+		global.GetLog(c).Errorf("保存科目成员失败 (SubjectID: %d): %v", subjectID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "保存失败"})
+		return
+	}
+
+	// 成员需要一条有效的 user_subjects 记录，读接口沿用原有的授权判断。
+	// 已有有效授权 (分享、分享码等) 时保持原样；没有或已失效时才开通一条成员授权 (member_grant = 1)
+	_, err = tx.Exec(`
+		INSERT INTO user_subjects (user_id, subject_id, status, expire_time, member_grant)
+		VALUES (?, ?, 1, NULL, 1)
+		ON CONFLICT(user_id, subject_id) DO UPDATE SET status = 1, expire_time = NULL, member_grant = 1
+		WHERE user_subjects.status != 1
+		   OR (user_subjects.expire_time IS NOT NULL AND user_subjects.expire_time <= datetime('now'))`,
+		memberID, subjectID)
+	if err != nil {
+		global.GetLog(c).Errorf("为成员开通科目访问失败 (SubjectID: %d): %v", subjectID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "保存失败"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		global.GetLog(c).Errorf("提交事务失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "保存失败"})
+		return
+	}
+
+	global.GetLog(c).Infof("用户[%s] 设置科目成员成功 (SubjectID: %d, Member: %s, Role: %s)", currentUserCodeStr, subjectID, req.UserCode, req.Role)
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "保存成功"})
+}
+
+// =================================================================================
+// RemoveSubjectMember 移除成员 (仅所有者)，只收回因成员身份开通的科目访问
+// =================================================================================
+func RemoveSubjectMember(c *gin.Context) {
+	subjectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+	memberID, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "用户ID参数错误"})
+		return
+	}
+	if !checkSubjectCreator(c, subjectID, "管理科目成员") {
+		return
+	}
+
+	tx, err := global.DB.Begin()
+	if err != nil {
+		global.GetLog(c).Errorf("开启事务失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "系统错误"})
+		return
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("DELETE FROM subject_members WHERE subject_id = ? AND user_id = ?", subjectID, memberID)
+	if err != nil {
+		global.GetLog(c).Errorf("移除科目成员失败 (SubjectID: %d): %v", subjectID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "移除失败"})
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "该用户不是科目成员"})
+		return
+	}
+	// 通过分享、分享码获得的授权不受影响
+	if _, err := tx.Exec("UPDATE user_subjects SET status = 0, member_grant = 0 WHERE subject_id = ? AND user_id = ? AND member_grant = 1", subjectID, memberID); err != nil {
+		global.GetLog(c).Errorf("收回成员科目访问失败 (SubjectID: %d): %v", subjectID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "移除失败"})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		global.GetLog(c).Errorf("提交事务失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "移除失败"})
+		return
+	}
+
+	currentUserCode, _ := c.Get("userCode")
+	global.GetLog(c).Infof("用户[%v] 移除科目成员成功 (SubjectID: %d, MemberID: %d)", currentUserCode, subjectID, memberID)
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "移除成功"})
+}
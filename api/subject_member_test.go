@@ -0,0 +1,92 @@
+package api
+
+import (
+	"database/sql"
+	"practice_problems/global"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type grantRow struct {
+	status      int
+	expireTime  sql.NullString
+	memberGrant int
+}
+
+func loadGrant(t *testing.T, userID, subjectID int) (grantRow, bool) {
+	t.Helper()
+	var g grantRow
+	err := global.DB.QueryRow("SELECT status, strftime('%Y-%m-%d %H:%M:%S', expire_time), member_grant FROM user_subjects WHERE user_id = ? AND subject_id = ?",
+		userID, subjectID).Scan(&g.status, &g.expireTime, &g.memberGrant)
+	if err == sql.ErrNoRows {
+		return g, false
+	} else if err != nil {
+		t.Fatalf("load grant: %v", err)
+	}
+	return g, true
+}
+
+func TestSubjectMemberGrantHandling(t *testing.T) {
+	setupTestDB(t)
+
+	ownerID := createTestUser(t, "owner")
+	sharedID := createTestUser(t, "shared")
+	revokedID := createTestUser(t, "revoked")
+	freshID := createTestUser(t, "fresh")
+	subjectID := createTestSubject(t, "Go", ownerID, "owner")
+	params := gin.Params{{Key: "id", Value: strconv.Itoa(subjectID)}}
+
+	// shared 通过分享码持有一条限期授权；revoked 的授权已被解除
+	mustExec(t, "INSERT INTO user_subjects (user_id, subject_id, status, expire_time, source_share_code_id) VALUES (?, ?, 1, '2999-01-01 00:00:00', 7)", sharedID, subjectID)
+	mustExec(t, "INSERT INTO user_subjects (user_id, subject_id, status, expire_time) VALUES (?, ?, 0, NULL)", revokedID, subjectID)
+
+	for _, code := range []string{"shared", "revoked", "fresh"} {
+		w := callHandler(SaveSubjectMember, ownerID, "owner", params, `{"userCode":"`+code+`","role":"editor"}`)
+		if w.Code != 200 {
+			t.Fatalf("add member %s: status %d, body %s", code, w.Code, w.Body.String())
+		}
+	}
+
+	// 已有有效授权的成员保持原授权不变
+	if g, _ := loadGrant(t, sharedID, subjectID); g.status != 1 || g.expireTime.String != "2999-01-01 00:00:00" || g.memberGrant != 0 {
+		t.Errorf("shared grant overwritten by membership: %+v", g)
+	}
+	// 没有或已失效的授权由成员身份开通
+	for name, id := range map[string]int{"revoked": revokedID, "fresh": freshID} {
+		if g, ok := loadGrant(t, id, subjectID); !ok || g.status != 1 || g.expireTime.Valid || g.memberGrant != 1 {
+			t.Errorf("%s: want active member grant, got %+v (exists %v)", name, g, ok)
+		}
+	}
+
+	// 修改角色不影响授权来源
+	if w := callHandler(SaveSubjectMember, ownerID, "owner", params, `{"userCode":"shared","role":"viewer"}`); w.Code != 200 {
+		t.Fatalf("change role: status %d", w.Code)
+	}
+	if g, _ := loadGrant(t, sharedID, subjectID); g.memberGrant != 0 || g.expireTime.String != "2999-01-01 00:00:00" {
+		t.Errorf("role change touched shared grant: %+v", g)
+	}
+
+	for _, id := range []int{sharedID, revokedID, freshID} {
+		p := gin.Params{{Key: "id", Value: strconv.Itoa(subjectID)}, {Key: "userId", Value: strconv.Itoa(id)}}
+		if w := callHandler(RemoveSubjectMember, ownerID, "owner", p, ""); w.Code != 200 {
+			t.Fatalf("remove member %d: status %d, body %s", id, w.Code, w.Body.String())
+		}
+	}
+
+	// 移除成员只收回成员开通的授权
+	if g, _ := loadGrant(t, sharedID, subjectID); g.status != 1 || g.expireTime.String != "2999-01-01 00:00:00" {
+		t.Errorf("removing member revoked share code grant: %+v", g)
+	}
+	for name, id := range map[string]int{"revoked": revokedID, "fresh": freshID} {
+		if g, _ := loadGrant(t, id, subjectID); g.status != 0 || g.memberGrant != 0 {
+			t.Errorf("%s: member grant not reverted: %+v", name, g)
+		}
+	}
+
+	// 非所有者不能管理成员
+	if w := callHandler(SaveSubjectMember, sharedID, "shared", params, `{"userCode":"fresh","role":"editor"}`); w.Code != 403 {
+		t.Errorf("non-owner add member: want 403, got %d", w.Code)
+	}
+}
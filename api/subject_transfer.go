@@ -0,0 +1,393 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"practice_problems/global"
+	"practice_problems/model"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==========================================
+// 科目所有权转让：所有者或管理员发起，接收人确认后在同一事务内完成迁移
+// subject_transfers 表同时作为转让审计记录
+// ==========================================
+
+// isAdminUser 判断用户是否为管理员
+func isAdminUser(userID int) bool {
+	var isAdmin int
+	if err := global.DB.QueryRow("SELECT is_admin FROM users WHERE id = ?", userID).Scan(&isAdmin); err != nil {
+		return false
+	}
+	return isAdmin == 1
+}
+
+// transferSelectSQL 转让记录查询的公共部分
+const transferSelectSQL = `
+	SELECT t.id, t.subject_id, COALESCE(s.name, ''), t.from_code, t.to_code, t.initiator_code, t.status,
+	       COALESCE(t.remark, ''), t.share_codes_moved, t.share_codes_detached, t.announcements_moved,
+	       t.create_time, COALESCE(t.handle_time, '')
+	FROM subject_transfers t
+	LEFT JOIN subjects s ON t.subject_id = s.id`
+
+// scanTransfers 扫描转让记录列表
+func scanTransfers(rows *sql.Rows) []model.SubjectTransfer {
+	list := make([]model.SubjectTransfer, 0)
+	for rows.Next() {
+		var t model.SubjectTransfer
+		if err := rows.Scan(&t.ID, &t.SubjectID, &t.SubjectName, &t.FromCode, &t.ToCode, &t.InitiatorCode, &t.Status,
+			&t.Remark, &t.ShareCodesMoved, &t.ShareCodesDetached, &t.AnnouncementsMoved,
+			&t.CreateTime, &t.HandleTime); err != nil {
+			continue
+		}
+		list = append(list, t)
+	}
+	return list
+}
+
+// =================================================================================
+// CreateSubjectTransfer 发起科目转让 (所有者或管理员)
+// =================================================================================
+func CreateSubjectTransfer(c *gin.Context) {
+	subjectID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+
+	var req model.CreateSubjectTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "参数错误: " + err.Error()})
+		return
+	}
+
+	userIDVal, _ := c.Get("userID")
+	userID, _ := userIDVal.(int)
+	userCodeVal, _ := c.Get("userCode")
+	userCode, _ := userCodeVal.(string)
+
+	var creatorCode string
+	err = global.DB.QueryRow("SELECT creator_code FROM subjects WHERE id = ? AND status = 1", subjectID).Scan(&creatorCode)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "未找到该科目"})
+		return
+	}
+	if creatorCode != userCode && !isAdminUser(userID) {
+		global.GetLog(c).Warnf("发起科目转让被拒: 无权操作 (User: %s, SubjectID: %d)", userCode, subjectID)
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "只有科目所有者或管理员可以发起转让"})
+		return
+	}
+
+	req.ToUserCode = strings.TrimSpace(req.ToUserCode)
+	if req.ToUserCode == creatorCode {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "接收人已经是该科目的所有者"})
+		return
+	}
+	var toUserID int
+	if err := global.DB.QueryRow("SELECT id FROM users WHERE user_code = ?", req.ToUserCode).Scan(&toUserID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "接收人不存在"})
+		return
+	}
+
+	var pending int
+	global.DB.QueryRow("SELECT COUNT(*) FROM subject_transfers WHERE subject_id = ? AND status = ?", subjectID, model.TransferStatusPending).Scan(&pending)
+	if pending > 0 {
+		c.JSON(http.StatusConflict, gin.H{"code": 409, "msg": "该科目已有待接收的转让，请先撤销"})
+		return
+	}
+
+	res, err := global.DB.Exec(`
+		INSERT INTO subject_transfers (subject_id, from_code, to_code, initiator_code, status, remark)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		subjectID, creatorCode, req.ToUserCode, userCode, model.TransferStatusPending, req.Remark)
+	if err != nil {
+		global.GetLog(c).Errorf("发起科目转让失败 (SubjectID: %d): %v", subjectID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "发起失败"})
+		return
+	}
+	transferID, _ := res.LastInsertId()
+
+	global.GetLog(c).Infof("用户[%s] 发起科目转让 (TransferID: %d, SubjectID: %d, %s -> %s)", userCode, transferID, subjectID, creatorCode, req.ToUserCode)
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "已发起转让，等待对方接收", "data": gin.H{"id": transferID}})
+}
+
+// =================================================================================
+// GetMySubjectTransfers 我发起的、转出的与待我接收的转让记录
+// =================================================================================
+func GetMySubjectTransfers(c *gin.Context) {
+	userCodeVal, _ := c.Get("userCode")
+	userCode, _ := userCodeVal.(string)
+
+	rows, err := global.DB.Query(transferSelectSQL+`
+		WHERE t.to_code = ? OR t.from_code = ? OR t.initiator_code = ?
+		ORDER BY t.id DESC`, userCode, userCode, userCode)
+	if err != nil {
+		global.GetLog(c).Errorf("查询科目转让记录失败 (User: %s): %v", userCode, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+	defer rows.Close()
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "success", "data": scanTransfers(rows)})
+}
+
+// =================================================================================
+// AdminGetSubjectTransfers 管理员查看全部转让审计记录，可按 subject_id 过滤
+// =================================================================================
+func AdminGetSubjectTransfers(c *gin.Context) {
+	query := transferSelectSQL
+	var args []interface{}
+	if sid := c.Query("subject_id"); sid != "" {
+		query += " WHERE t.subject_id = ?"
+		args = append(args, sid)
+	}
+	query += " ORDER BY t.id DESC LIMIT 500"
+
+	rows, err := global.DB.Query(query, args...)
+	if err != nil {
+		global.GetLog(c).Errorf("查询科目转让审计记录失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+	defer rows.Close()
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "success", "data": scanTransfers(rows)})
+}
+
+// loadPendingTransfer 读取待处理的转让记录
+func loadPendingTransfer(c *gin.Context) (*model.SubjectTransfer, bool) {
+	transferID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return nil, false
+	}
+	var t model.SubjectTransfer
+	err = global.DB.QueryRow(`
+		SELECT id, subject_id, from_code, to_code, initiator_code, status
+		FROM subject_transfers WHERE id = ?`, transferID).Scan(&t.ID, &t.SubjectID, &t.FromCode, &t.ToCode, &t.InitiatorCode, &t.Status)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "转让记录不存在"})
+		return nil, false
+	}
+	if t.Status != model.TransferStatusPending {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "该转让已处理"})
+		return nil, false
+	}
+	return &t, true
+}
+
+// =================================================================================
+// AcceptSubjectTransfer 接收人确认转让，在同一事务内迁移科目、分享码与公告
+// =================================================================================
+func AcceptSubjectTransfer(c *gin.Context) {
+	t, ok := loadPendingTransfer(c)
+	if !ok {
+		return
+	}
+	userIDVal, _ := c.Get("userID")
+	userID, _ := userIDVal.(int)
+	userCodeVal, _ := c.Get("userCode")
+	userCode, _ := userCodeVal.(string)
+	if t.ToCode != userCode {
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "只有接收人可以确认转让"})
+		return
+	}
+
+	tx, err := global.DB.Begin()
+	if err != nil {
+		global.GetLog(c).Errorf("开启事务失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "系统错误"})
+		return
+	}
+	defer tx.Rollback()
+
+	// 0. 在事务内认领转让：期间已被拒绝 / 撤销则不再执行
+	res, err := tx.Exec("UPDATE subject_transfers SET status = ?, handle_time = CURRENT_TIMESTAMP WHERE id = ? AND status = ?",
+		model.TransferStatusAccepted, t.ID, model.TransferStatusPending)
+	if err != nil {
+		global.GetLog(c).Errorf("更新转让记录失败 (TransferID: %d): %v", t.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "转让失败"})
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		c.JSON(http.StatusConflict, gin.H{"code": 409, "msg": "该转让已处理"})
+		return
+	}
+
+	// 1. 更新科目所有者 (所有者在发起后已变化则转让失效)
+	res, err = tx.Exec("UPDATE subjects SET creator_code = ? WHERE id = ? AND creator_code = ?", t.ToCode, t.SubjectID, t.FromCode)
+	if err != nil {
+		global.GetLog(c).Errorf("转让科目失败 (TransferID: %d): %v", t.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "转让失败"})
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		tx.Rollback()
+		global.DB.Exec("UPDATE subject_transfers SET status = ?, handle_time = CURRENT_TIMESTAMP, remark = COALESCE(remark, '') || ' [科目所有者已变化，转让失效]' WHERE id = ? AND status = ?",
+			model.TransferStatusCancelled, t.ID, model.TransferStatusPending)
+		c.JSON(http.StatusConflict, gin.H{"code": 409, "msg": "科目所有者已变化，该转让已失效"})
+		return
+	}
+
+	// 2. 新所有者不再作为协作成员，并保证其拥有永久访问
+	if _, err := tx.Exec("DELETE FROM subject_members WHERE subject_id = ? AND user_id = ?", t.SubjectID, userID); err != nil {
+		global.GetLog(c).Errorf("转让科目清理成员失败 (TransferID: %d): %v", t.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "转让失败"})
+		return
+	}
+	_, err = tx.Exec(`
+		INSERT INTO user_subjects (user_id, subject_id, status, expire_time)
+		VALUES (?, ?, 1, NULL)
+		ON CONFLICT(user_id, subject_id) DO UPDATE SET status = 1, expire_time = NULL, member_grant = 0`, userID, t.SubjectID)
+	if err != nil {
+		global.GetLog(c).Errorf("转让科目开通访问失败 (TransferID: %d): %v", t.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "转让失败"})
+		return
+	}
+
+	// 3. 迁移分享码：只包含本科目的分享码整体转给新所有者，连同其公告；
+	//    同时包含原所有者其他科目的分享码，仅移除本科目 (原所有者已无权再分享它)
+	var fromUserID int
+	if err := tx.QueryRow("SELECT id FROM users WHERE user_code = ?", t.FromCode).Scan(&fromUserID); err != nil {
+		fromUserID = 0
+	}
+	rows, err := tx.Query(`
+		SELECT sc.id, sc.code,
+		       (SELECT COUNT(*) FROM share_code_subjects x WHERE x.share_code_id = sc.id AND x.subject_id != ?)
+		FROM share_codes sc
+		JOIN share_code_subjects scs ON scs.share_code_id = sc.id
+		WHERE sc.creator_id = ? AND scs.subject_id = ?`, t.SubjectID, fromUserID, t.SubjectID)
+	if err != nil {
+		global.GetLog(c).Errorf("转让科目查询分享码失败 (TransferID: %d): %v", t.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "转让失败"})
+		return
+	}
+	var movedIDs, detachedIDs []int
+	var movedCodes []string
+	for rows.Next() {
+		var id, others int
+		var code string
+		if err := rows.Scan(&id, &code, &others); err != nil {
+			continue
+		}
+		if others == 0 {
+			movedIDs = append(movedIDs, id)
+			movedCodes = append(movedCodes, code)
+		} else {
+			detachedIDs = append(detachedIDs, id)
+		}
+	}
+	rows.Close()
+
+	announcementsMoved := 0
+	for i, id := range movedIDs {
+		if _, err := tx.Exec("UPDATE share_codes SET creator_id = ? WHERE id = ?", userID, id); err != nil {
+			global.GetLog(c).Errorf("转让分享码失败 (TransferID: %d, ShareCodeID: %d): %v", t.ID, id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "转让失败"})
+			return
+		}
+		res, err := tx.Exec("UPDATE share_announcements SET creator_code = ? WHERE share_code = ? AND creator_code = ?", t.ToCode, movedCodes[i], t.FromCode)
+		if err != nil {
+			global.GetLog(c).Errorf("转让分享公告失败 (TransferID: %d, ShareCode: %s): %v", t.ID, movedCodes[i], err)
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "转让失败"})
+			return
+		}
+		n, _ := res.RowsAffected()
+		announcementsMoved += int(n)
+	}
+	for _, id := range detachedIDs {
+		if _, err := tx.Exec("DELETE FROM share_code_subjects WHERE share_code_id = ? AND subject_id = ?", id, t.SubjectID); err != nil {
+			global.GetLog(c).Errorf("分享码移除科目失败 (TransferID: %d, ShareCodeID: %d): %v", t.ID, id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "转让失败"})
+			return
+		}
+	}
+
+	// 4. 写入审计结果
+	_, err = tx.Exec(`
+		UPDATE subject_transfers
+		SET share_codes_moved = ?, share_codes_detached = ?, announcements_moved = ?
+		WHERE id = ?`,
+		len(movedIDs), len(detachedIDs), announcementsMoved, t.ID)
+	if err != nil {
+		global.GetLog(c).Errorf("更新转让记录失败 (TransferID: %d): %v", t.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "转让失败"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		global.GetLog(c).Errorf("提交事务失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "转让失败"})
+		return
+	}
+
+	global.GetLog(c).Infof("科目转让完成 (TransferID: %d, SubjectID: %d, %s -> %s, 分享码转移 %d, 分享码移除科目 %d, 公告 %d)",
+		t.ID, t.SubjectID, t.FromCode, t.ToCode, len(movedIDs), len(detachedIDs), announcementsMoved)
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "转让完成",
+		"data": gin.H{
+			"shareCodesMoved":    len(movedIDs),
+			"shareCodesDetached": len(detachedIDs),
+			"announcementsMoved": announcementsMoved,
+		},
+	})
+}
+
+// =================================================================================
+// RejectSubjectTransfer 接收人拒绝转让
+// =================================================================================
+func RejectSubjectTransfer(c *gin.Context) {
+	t, ok := loadPendingTransfer(c)
+	if !ok {
+		return
+	}
+	userCodeVal, _ := c.Get("userCode")
+	userCode, _ := userCodeVal.(string)
+	if t.ToCode != userCode {
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "只有接收人可以拒绝转让"})
+		return
+	}
+	finishSubjectTransfer(c, t, model.TransferStatusRejected, "已拒绝")
+}
+
+// =================================================================================
+// CancelSubjectTransfer 发起人、原所有者或管理员撤销转让
+// =================================================================================
+func CancelSubjectTransfer(c *gin.Context) {
+	t, ok := loadPendingTransfer(c)
+	if !ok {
+		return
+	}
+	userIDVal, _ := c.Get("userID")
+	userID, _ := userIDVal.(int)
+	userCodeVal, _ := c.Get("userCode")
+	userCode, _ := userCodeVal.(string)
+	if t.InitiatorCode != userCode && t.FromCode != userCode && !isAdminUser(userID) {
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "无权撤销该转让"})
+		return
+	}
+	finishSubjectTransfer(c, t, model.TransferStatusCancelled, "已撤销")
+}
+
+// finishSubjectTransfer 将待处理的转让标记为拒绝 / 撤销
+func finishSubjectTransfer(c *gin.Context, t *model.SubjectTransfer, status int, msg string) {
+	res, err := global.DB.Exec("UPDATE subject_transfers SET status = ?, handle_time = CURRENT_TIMESTAMP WHERE id = ? AND status = ?",
+		status, t.ID, model.TransferStatusPending)
+	if err != nil {
+		global.GetLog(c).Errorf("更新转让记录失败 (TransferID: %d): %v", t.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "操作失败"})
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "该转让已处理"})
+		return
+	}
+	userCode, _ := c.Get("userCode")
+	global.GetLog(c).Infof("用户[%v] 处理科目转让: %s (TransferID: %d, SubjectID: %d)", userCode, msg, t.ID, t.SubjectID)
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": fmt.Sprintf("转让%s", msg)})
+}
@@ -0,0 +1,466 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"practice_problems/global"
+	"practice_problems/model"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+	"github.com/gin-gonic/gin"
+)
+
+// ==========================================
+// 回收站：科目 / 分类 / 知识点 / 题目统一软删除
+// 一次删除操作连带删除的子内容共享 delete_batch，恢复时按批次一并恢复
+// ==========================================
+
+// trashLevels 软删除的层级，由上到下；parentSQL 用于按父级批次连带删除子内容
+var trashLevels = []struct {
+	Type      string
+	Table     string
+	ParentSQL string // 子内容的父级条件 (参数为父级批次号)
+}{
+	{model.TrashTypeSubject, "subjects", ""},
+	{model.TrashTypeCategory, "knowledge_categories", "subject_id IN (SELECT id FROM subjects WHERE delete_batch = ?)"},
+	{model.TrashTypePoint, "knowledge_points", "categorie_id IN (SELECT id FROM knowledge_categories WHERE delete_batch = ?)"},
+	{model.TrashTypeQuestion, "questions", "knowledge_point_id IN (SELECT id FROM knowledge_points WHERE delete_batch = ?)"},
+}
+
+// softDeleteTx 软删除一条记录及其全部未删除的子内容，返回本次删除的批次号
+func softDeleteTx(tx *sql.Tx, itemType string, id int, userCode string) (string, error) {
+	now := model.FormatDBTime(time.Now())
+	batch := fmt.Sprintf("%s-%d-%d", itemType, id, time.Now().UnixNano())
+
+	started := false
+	for _, level := range trashLevels {
+		if level.Type == itemType {
+			extra := ""
+			if level.Table == "subjects" {
+				extra = "status = 0, "
+			}
+			res, err := tx.Exec(fmt.Sprintf("UPDATE %s SET %sdeleted_at = ?, deleted_by = ?, delete_batch = ? WHERE id = ? AND deleted_at IS NULL", level.Table, extra),
+				now, userCode, batch, id)
+			if err != nil {
+				return "", err
+			}
+			if n, _ := res.RowsAffected(); n == 0 {
+				return "", sql.ErrNoRows
+			}
+			started = true
+			if level.Table == "knowledge_categories" {
+				// 子分类与所删分类同批次，恢复时一并恢复
+				_, err := tx.Exec(`UPDATE knowledge_categories SET deleted_at = ?, deleted_by = ?, delete_batch = ?
+					WHERE deleted_at IS NULL AND id IN (`+descendantCategoriesSQL+`)`, now, userCode, batch, id)
+				if err != nil {
+					return "", err
+				}
+			}
+			continue
+		}
+		if !started {
+			continue
+		}
+		_, err := tx.Exec(fmt.Sprintf("UPDATE %s SET deleted_at = ?, deleted_by = ?, delete_batch = ? WHERE deleted_at IS NULL AND %s", level.Table, level.ParentSQL),
+			now, userCode, batch, batch)
+		if err != nil {
+			return "", err
+		}
+	}
+	if !started {
+		return "", fmt.Errorf("未知的删除类型: %s", itemType)
+	}
+	return batch, nil
+}
+
+// softDelete 在独立事务中执行软删除
+func softDelete(itemType string, id int, userCode string) error {
+	tx, err := global.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := softDeleteTx(tx, itemType, id, userCode); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// trashListSQL 回收站根节点查询：父级不在同一批次的已删除记录
+const trashListSQL = `
+	SELECT 'subject' AS type, s.id AS id, s.name AS name, s.id AS subject_id, s.name AS subject_name,
+	       s.deleted_at AS deleted_at, COALESCE(s.deleted_by, '') AS deleted_by, s.delete_batch AS delete_batch, s.creator_code AS creator_code
+	FROM subjects s
+	WHERE s.deleted_at IS NOT NULL
+	UNION ALL
+	SELECT 'category', c.id, c.categorie_name, s.id, s.name, c.deleted_at, COALESCE(c.deleted_by, ''), c.delete_batch, s.creator_code
+	FROM knowledge_categories c
+	JOIN subjects s ON c.subject_id = s.id
+	LEFT JOIN knowledge_categories pc ON c.parent_id = pc.id
+	WHERE c.deleted_at IS NOT NULL AND COALESCE(s.delete_batch, '') != c.delete_batch AND COALESCE(pc.delete_batch, '') != c.delete_batch
+	UNION ALL
+	SELECT 'point', p.id, p.title, s.id, s.name, p.deleted_at, COALESCE(p.deleted_by, ''), p.delete_batch, s.creator_code
+	FROM knowledge_points p
+	JOIN knowledge_categories c ON p.categorie_id = c.id
+	JOIN subjects s ON c.subject_id = s.id
+	WHERE p.deleted_at IS NOT NULL AND COALESCE(c.delete_batch, '') != p.delete_batch
+	UNION ALL
+	SELECT 'question', q.id, q.question_text, s.id, s.name, q.deleted_at, COALESCE(q.deleted_by, ''), q.delete_batch, s.creator_code
+	FROM questions q
+	JOIN knowledge_points p ON q.knowledge_point_id = p.id
+	JOIN knowledge_categories c ON p.categorie_id = c.id
+	JOIN subjects s ON c.subject_id = s.id
+	WHERE q.deleted_at IS NOT NULL AND COALESCE(p.delete_batch, '') != q.delete_batch`
+
+// =================================================================================
+// GetTrash 我的回收站：我删除的内容 + 我拥有的科目中被删除的内容
+// =================================================================================
+func GetTrash(c *gin.Context) {
+	userCodeVal, _ := c.Get("userCode")
+	userCode, _ := userCodeVal.(string)
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	where := " WHERE (t.deleted_by = ? OR t.creator_code = ?)"
+	args := []interface{}{userCode, userCode}
+	if itemType := c.Query("type"); itemType != "" {
+		where += " AND t.type = ?"
+		args = append(args, itemType)
+	}
+
+	var total int
+	global.DB.QueryRow("SELECT COUNT(*) FROM ("+trashListSQL+") t"+where, args...).Scan(&total)
+
+	rows, err := global.DB.Query(`
+		SELECT t.*,
+		       (SELECT COUNT(*) FROM knowledge_categories WHERE delete_batch = t.delete_batch)
+		     + (SELECT COUNT(*) FROM knowledge_points WHERE delete_batch = t.delete_batch)
+		     + (SELECT COUNT(*) FROM questions WHERE delete_batch = t.delete_batch)
+		FROM (`+trashListSQL+`) t`+where+`
+		ORDER BY t.deleted_at DESC
+		LIMIT ? OFFSET ?`, append(args, pageSize, (page-1)*pageSize)...)
+	if err != nil {
+		global.GetLog(c).Errorf("查询回收站失败 (User: %s): %v", userCode, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "查询失败"})
+		return
+	}
+	defer rows.Close()
+
+	list := make([]model.TrashItem, 0)
+	for rows.Next() {
+		var item model.TrashItem
+		var batch, creatorCode string
+		var children int
+		if err := rows.Scan(&item.Type, &item.ID, &item.Name, &item.SubjectID, &item.SubjectName,
+			&item.DeletedAt, &item.DeletedBy, &batch, &creatorCode, &children); err != nil {
+			continue
+		}
+		// 子内容数量不含根节点自身 (科目不在上面三张表的统计里)
+		if item.Type != model.TrashTypeSubject {
+			children--
+		}
+		item.ChildCount = children
+		if item.DeletedAt.Valid {
+			item.PurgeTime = model.NewUTCTime(item.DeletedAt.Time.AddDate(0, 0, global.TrashRetentionDays))
+		}
+		list = append(list, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 200,
+		"msg":  "success",
+		"data": gin.H{"list": list, "total": total, "retentionDays": global.TrashRetentionDays},
+	})
+}
+
+// =================================================================================
+// RestoreTrashItem 从回收站恢复 (连同同批次删除的子内容)
+// 科目仅所有者可恢复，其余内容需要科目编辑权限；上级仍在回收站时需先恢复上级
+// =================================================================================
+func RestoreTrashItem(c *gin.Context) {
+	itemType := c.Param("type")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "ID参数错误"})
+		return
+	}
+	userCodeVal, _ := c.Get("userCode")
+	userCode, _ := userCodeVal.(string)
+
+	var query, parentName string
+	switch itemType {
+	case model.TrashTypeSubject:
+		query = `SELECT s.delete_batch, s.id, s.creator_code, NULL FROM subjects s WHERE s.id = ?`
+	case model.TrashTypeCategory:
+		query = `SELECT c.delete_batch, s.id, s.creator_code, COALESCE(s.deleted_at, pc.deleted_at)
+			FROM knowledge_categories c
+			JOIN subjects s ON c.subject_id = s.id
+			LEFT JOIN knowledge_categories pc ON c.parent_id = pc.id WHERE c.id = ?`
+		parentName = "科目或上级分类"
+	case model.TrashTypePoint:
+		query = `SELECT p.delete_batch, s.id, s.creator_code, c.deleted_at
+			FROM knowledge_points p
+			JOIN knowledge_categories c ON p.categorie_id = c.id
+			JOIN subjects s ON c.subject_id = s.id WHERE p.id = ?`
+		parentName = "分类"
+	case model.TrashTypeQuestion:
+		query = `SELECT q.delete_batch, s.id, s.creator_code, p.deleted_at
+			FROM questions q
+			JOIN knowledge_points p ON q.knowledge_point_id = p.id
+			JOIN knowledge_categories c ON p.categorie_id = c.id
+			JOIN subjects s ON c.subject_id = s.id WHERE q.id = ?`
+		parentName = "知识点"
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "类型参数错误"})
+		return
+	}
+
+	var batch sql.NullString
+	var subjectID int
+	var creatorCode string
+	var parentDeletedAt sql.NullString
+	if err := global.DB.QueryRow(query, id).Scan(&batch, &subjectID, &creatorCode, &parentDeletedAt); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "内容不存在"})
+		return
+	}
+	if !batch.Valid || batch.String == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "该内容不在回收站中"})
+		return
+	}
+
+	minRole := RoleEditor
+	if itemType == model.TrashTypeSubject {
+		minRole = RoleOwner
+	}
+	if !hasSubjectRole(subjectID, creatorCode, userCode, minRole) {
+		global.GetLog(c).Warnf("恢复回收站内容被拒: 无权操作 (User: %s, Type: %s, ID: %d)", userCode, itemType, id)
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "msg": "无权恢复该内容"})
+		return
+	}
+	if parentDeletedAt.Valid {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "所属" + parentName + "仍在回收站中，请先恢复" + parentName})
+		return
+	}
+
+	tx, err := global.DB.Begin()
+	if err != nil {
+		global.GetLog(c).Errorf("开启事务失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "系统错误"})
+		return
+	}
+	defer tx.Rollback()
+
+	restored := 0
+	for _, level := range trashLevels {
+		extra := ""
+		if level.Table == "subjects" {
+			extra = "status = 1, "
+		}
+		res, err := tx.Exec(fmt.Sprintf("UPDATE %s SET %sdeleted_at = NULL, deleted_by = NULL, delete_batch = NULL WHERE delete_batch = ?", level.Table, extra), batch.String)
+		if err != nil {
+			global.GetLog(c).Errorf("恢复回收站内容失败 (Type: %s, ID: %d): %v", itemType, id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "恢复失败"})
+			return
+		}
+		n, _ := res.RowsAffected()
+		restored += int(n)
+	}
+	if err := tx.Commit(); err != nil {
+		global.GetLog(c).Errorf("提交事务失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "恢复失败"})
+		return
+	}
+
+	global.GetLog(c).Infof("用户[%s] 从回收站恢复内容 (Type: %s, ID: %d, Batch: %s, Count: %d)", userCode, itemType, id, batch.String, restored)
+	c.JSON(http.StatusOK, gin.H{"code": 200, "msg": "恢复成功", "data": gin.H{"restored": restored}})
+}
+
+// =================================================================================
+// 后台清理：超过保留期的软删除内容彻底删除，并清理不再被引用的图片
+// =================================================================================
+
+// uploadPathPattern 匹配内容中引用的本地上传文件路径
+var uploadPathPattern = regexp.MustCompile(`/uploads/[A-Za-z0-9_\-./]+`)
+
+// StartTrashPurger 启动回收站清理任务 (启动时执行一次，之后每小时执行)
+func StartTrashPurger() {
+	go func() {
+		PurgeExpiredTrash()
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			PurgeExpiredTrash()
+		}
+	}()
+}
+
+// PurgeExpiredTrash 彻底删除超过保留期的内容
+func PurgeExpiredTrash() {
+	// deleted_at 与 datetime('now') 同为 UTC，直接比较
+	cutoff := fmt.Sprintf("-%d days", global.TrashRetentionDays)
+
+	// 1. 收集将被删除内容引用的图片
+	images := map[string]bool{}
+	// 知识点修订随知识点一起删除，历史版本里删掉的图片也在这里一并收集
+	if rows, err := global.DB.Query(`
+		SELECT COALESCE(local_image_names, '[]'), COALESCE(content, '')
+		FROM knowledge_points WHERE deleted_at IS NOT NULL AND deleted_at < datetime('now', ?)
+		UNION ALL
+		SELECT COALESCE(local_image_names, '[]'), COALESCE(content, '')
+		FROM point_revisions
+		WHERE point_id IN (SELECT id FROM knowledge_points WHERE deleted_at IS NOT NULL AND deleted_at < datetime('now', ?))`, cutoff, cutoff); err == nil {
+		for rows.Next() {
+			var imagesJSON, content string
+			if rows.Scan(&imagesJSON, &content) != nil {
+				continue
+			}
+			var items []model.ImageItem
+			if json.Unmarshal([]byte(imagesJSON), &items) == nil {
+				for _, item := range items {
+					images[item.Url] = true
+				}
+			}
+			for _, path := range uploadPathPattern.FindAllString(content, -1) {
+				images[path] = true
+			}
+		}
+		rows.Close()
+	}
+	if rows, err := global.DB.Query(`
+		SELECT COALESCE(question_text, '') || ' ' || COALESCE(explanation, '') || ' ' ||
+		       COALESCE(option1_img, '') || ' ' || COALESCE(option2_img, '') || ' ' ||
+		       COALESCE(option3_img, '') || ' ' || COALESCE(option4_img, '')
+		FROM questions WHERE deleted_at IS NOT NULL AND deleted_at < datetime('now', ?)
+		UNION ALL
+		SELECT COALESCE(question_text, '') || ' ' || COALESCE(explanation, '') || ' ' ||
+		       COALESCE(option1_img, '') || ' ' || COALESCE(option2_img, '') || ' ' ||
+		       COALESCE(option3_img, '') || ' ' || COALESCE(option4_img, '')
+		FROM question_revisions
+		WHERE question_id IN (SELECT id FROM questions WHERE deleted_at IS NOT NULL AND deleted_at < datetime('now', ?))`, cutoff, cutoff); err == nil {
+		for rows.Next() {
+			var text string
+			if rows.Scan(&text) != nil {
+				continue
+			}
+			for _, path := range uploadPathPattern.FindAllString(text, -1) {
+				images[path] = true
+			}
+		}
+		rows.Close()
+	}
+
+	// 2. 由下到上彻底删除 (知识点绑定没有级联外键，需要先删；修订历史随知识点一起删除)
+	tx, err := global.DB.Begin()
+	if err != nil {
+		global.GetLog(nil).Errorf("回收站清理开启事务失败: %v", err)
+		return
+	}
+	defer tx.Rollback()
+
+	expiredPoints := "SELECT id FROM knowledge_points WHERE deleted_at IS NOT NULL AND deleted_at < datetime('now', ?)"
+	expiredQuestions := "SELECT id FROM questions WHERE deleted_at IS NOT NULL AND deleted_at < datetime('now', ?)"
+	// counted 为 false 的是附属数据 (绑定、关系、修订、答题记录)，不计入清理条数
+	stmts := []struct {
+		sql     string
+		args    []interface{}
+		counted bool
+	}{
+		{"DELETE FROM question_attempts WHERE question_id IN (" + expiredQuestions + ")", []interface{}{cutoff}, false},
+		{"DELETE FROM question_revisions WHERE question_id IN (" + expiredQuestions + ")", []interface{}{cutoff}, false},
+		{"DELETE FROM questions WHERE deleted_at IS NOT NULL AND deleted_at < datetime('now', ?)", []interface{}{cutoff}, true},
+		{"DELETE FROM point_bindings WHERE source_point_id IN (" + expiredPoints + ") OR target_point_id IN (" + expiredPoints + ")", []interface{}{cutoff, cutoff}, false},
+		{"DELETE FROM point_revisions WHERE point_id IN (" + expiredPoints + ")", []interface{}{cutoff}, false},
+		{"DELETE FROM point_relations WHERE from_point_id IN (" + expiredPoints + ") OR to_point_id IN (" + expiredPoints + ")", []interface{}{cutoff, cutoff}, false},
+		{"DELETE FROM knowledge_points WHERE deleted_at IS NOT NULL AND deleted_at < datetime('now', ?)", []interface{}{cutoff}, true},
+		{"DELETE FROM knowledge_categories WHERE deleted_at IS NOT NULL AND deleted_at < datetime('now', ?)", []interface{}{cutoff}, true},
+		{`DELETE FROM point_bindings WHERE source_subject_id IN (SELECT id FROM subjects WHERE deleted_at IS NOT NULL AND deleted_at < datetime('now', ?))
+		     OR target_subject_id IN (SELECT id FROM subjects WHERE deleted_at IS NOT NULL AND deleted_at < datetime('now', ?))`, []interface{}{cutoff, cutoff}, false},
+		{"DELETE FROM subjects WHERE deleted_at IS NOT NULL AND deleted_at < datetime('now', ?)", []interface{}{cutoff}, true},
+	}
+	var purged int64
+	for _, stmt := range stmts {
+		res, err := tx.Exec(stmt.sql, stmt.args...)
+		if err != nil {
+			global.GetLog(nil).Errorf("回收站清理失败: %v", err)
+			return
+		}
+		if stmt.counted {
+			n, _ := res.RowsAffected()
+			purged += n
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		global.GetLog(nil).Errorf("回收站清理提交失败: %v", err)
+		return
+	}
+
+	// 3. 删除不再被任何内容引用的图片
+	removed := 0
+	for path := range images {
+		if !strings.HasPrefix(path, "/uploads/") || isUploadReferenced(path) {
+			continue
+		}
+		if removeUploadedFile(path) == nil {
+			removed++
+		}
+	}
+
+	if purged > 0 || removed > 0 {
+		global.GetLog(nil).Infof("回收站清理完成: 彻底删除 %d 条内容，清理图片 %d 张 (保留 %d 天)", purged, removed, global.TrashRetentionDays)
+	}
+}
+
+// isUploadReferenced 判断文件是否仍被引用：知识点、题目 (含回收站中的内容)、
+// 历史修订 (回滚时需要恢复) 以及待审核的 AI 草稿题
+func isUploadReferenced(path string) bool {
+	like := "%" + path + "%"
+	var count int
+	err := global.DB.QueryRow(`
+		SELECT (SELECT COUNT(*) FROM knowledge_points WHERE local_image_names LIKE ? OR content LIKE ?)
+		     + (SELECT COUNT(*) FROM point_revisions WHERE local_image_names LIKE ? OR content LIKE ?)
+		     + (SELECT COUNT(*) FROM questions
+		        WHERE question_text LIKE ? OR explanation LIKE ?
+		           OR option1_img = ? OR option2_img = ? OR option3_img = ? OR option4_img = ?)
+		     + (SELECT COUNT(*) FROM question_revisions
+		        WHERE question_text LIKE ? OR explanation LIKE ?
+		           OR option1_img = ? OR option2_img = ? OR option3_img = ? OR option4_img = ?)
+		     + (SELECT COUNT(*) FROM question_drafts
+		        WHERE question_text LIKE ? OR explanation LIKE ?
+		           OR option1 LIKE ? OR option2 LIKE ? OR option3 LIKE ? OR option4 LIKE ?)`,
+		like, like,
+		like, like,
+		like, like, path, path, path, path,
+		like, like, path, path, path, path,
+		like, like, like, like, like, like).Scan(&count)
+	// 查询失败时按仍被引用处理，宁可保留文件
+	return err != nil || count > 0
+}
+
+// removeUploadedFile 删除本地或 OSS 上的上传文件
+func removeUploadedFile(path string) error {
+	if !global.IsOssUploadEnabled() {
+		return RemoveFileFromDisk(path)
+	}
+	client, err := oss.New(global.GetOssUploadEndpoint(), global.OssAccessKeyID, global.OssAccessKeySecret)
+	if err != nil {
+		return err
+	}
+	bucket, err := client.Bucket(global.OssBucket)
+	if err != nil {
+		return err
+	}
+	return bucket.DeleteObject(strings.TrimPrefix(path, "/"))
+}
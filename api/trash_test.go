@@ -0,0 +1,49 @@
+package api
+
+import (
+	"practice_problems/global"
+	"practice_problems/model"
+	"testing"
+	"time"
+)
+
+func TestTrashTimesStoredInUTC(t *testing.T) {
+	setupTestDB(t)
+
+	// 非 UTC 时区下删除时间也必须与 CURRENT_TIMESTAMP 一致
+	oldLocal := time.Local
+	time.Local = time.FixedZone("UTC+8", 8*3600)
+	t.Cleanup(func() { time.Local = oldLocal })
+
+	ownerID := createTestUser(t, "owner")
+	recentID := createTestSubject(t, "recent", ownerID, "owner")
+	expiredID := createTestSubject(t, "expired", ownerID, "owner")
+
+	tx, err := global.DB.Begin()
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	if _, err := softDeleteTx(tx, model.TrashTypeSubject, recentID, "owner"); err != nil {
+		t.Fatalf("soft delete: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	var drift int
+	global.DB.QueryRow("SELECT ABS(strftime('%s', deleted_at) - strftime('%s', 'now')) FROM subjects WHERE id = ?", recentID).Scan(&drift)
+	if drift > 5 {
+		t.Errorf("deleted_at is %d seconds away from UTC now", drift)
+	}
+
+	mustExec(t, "UPDATE subjects SET status = 0, deleted_at = datetime('now', '-31 days'), delete_batch = 'old' WHERE id = ?", expiredID)
+	PurgeExpiredTrash()
+
+	var remaining int
+	global.DB.QueryRow("SELECT COUNT(*) FROM subjects WHERE id IN (?, ?)", recentID, expiredID).Scan(&remaining)
+	var recentLeft int
+	global.DB.QueryRow("SELECT COUNT(*) FROM subjects WHERE id = ?", recentID).Scan(&recentLeft)
+	if remaining != 1 || recentLeft != 1 {
+		t.Errorf("purge kept %d subjects (recent kept: %v), want only the recently deleted one", remaining, recentLeft == 1)
+	}
+}
@@ -123,6 +123,12 @@ func CreateUser(c *gin.Context) {
 		return
 	}
 
+	req.Email = strings.TrimSpace(req.Email)
+	if req.Email != "" && !validateEmail(req.Email) {
+		c.JSON(400, gin.H{"code": 400, "msg": "邮箱格式不正确"})
+		return
+	}
+
 	// 检查用户表是否为空，如果为空则第一个用户设置为管理员
 	var userCount int
 	_ = global.DB.QueryRow("SELECT COUNT(*) FROM users").Scan(&userCount)
@@ -154,7 +160,7 @@ func CreateUser(c *gin.Context) {
 		global.GetLog(c).Infof("第一个用户注册，自动设置为管理员: %s", req.Username)
 	}
 
-	_, err = global.DB.Exec(
+	result, err := global.DB.Exec(
 		"INSERT INTO users (username, password, user_code, nickname, email, is_admin) VALUES (?, ?, ?, ?, ?, ?)",
 		req.Username, string(hash), userCode, req.Nickname, req.Email, isAdmin,
 	)
@@ -165,8 +171,19 @@ func CreateUser(c *gin.Context) {
 		return
 	}
 
+	// 填写了邮箱则发送验证邮件 (发送失败不影响注册，可在个人资料中重新发送)
+	msg := "注册成功"
+	if req.Email != "" {
+		newUserID, _ := result.LastInsertId()
+		if err := sendVerificationEmail(int(newUserID), req.Email); err != nil {
+			global.GetLog(c).Errorf("注册发送验证邮件失败 (User: %s): %v", req.Username, err)
+		} else {
+			msg = "注册成功，验证邮件已发送至 " + req.Email
+		}
+	}
+
 	global.GetLog(c).Infof("新用户注册成功: %s (Code: %s)", req.Username, userCode)
-	c.JSON(200, gin.H{"code": 200, "msg": "注册成功"})
+	c.JSON(200, gin.H{"code": 200, "msg": msg})
 }
 
 // =======================
@@ -219,9 +236,9 @@ func tryTokenLogin(c *gin.Context) bool {
 
 	var user model.DbUser
 	err = global.DB.QueryRow(
-		"SELECT id, username, password, user_code, nickname, email, is_admin, status FROM users WHERE id = ?",
+		"SELECT id, username, password, user_code, nickname, email, is_admin, status, IFNULL(email_verified, 0) FROM users WHERE id = ?",
 		claims.UserID,
-	).Scan(&user.Id, &user.Username, &user.Password, &user.UserCode, &user.Nickname, &user.Email, &user.IsAdmin, &user.Status)
+	).Scan(&user.Id, &user.Username, &user.Password, &user.UserCode, &user.Nickname, &user.Email, &user.IsAdmin, &user.Status, &user.EmailVerified)
 
 	if err != nil {
 		return false
@@ -259,6 +276,7 @@ func tryTokenLogin(c *gin.Context) bool {
 			"username":        user.Username,
 			"nickname":        user.Nickname.String,
 			"email":           user.Email.String,
+			"email_verified":  user.EmailVerified,
 			"is_admin":        user.IsAdmin,
 			"need_change_pwd": false,
 			"oss_url":         global.GetOssUrl(), // OSS 地址（未配置时为空）
@@ -279,9 +297,9 @@ func tryPasswordLogin(c *gin.Context) {
 
 	var user model.DbUser
 	err := global.DB.QueryRow(
-		"SELECT id, username, password, user_code, nickname, email, is_admin, status FROM users WHERE username = ?",
+		"SELECT id, username, password, user_code, nickname, email, is_admin, status, IFNULL(email_verified, 0) FROM users WHERE username = ?",
 		req.Username,
-	).Scan(&user.Id, &user.Username, &user.Password, &user.UserCode, &user.Nickname, &user.Email, &user.IsAdmin, &user.Status)
+	).Scan(&user.Id, &user.Username, &user.Password, &user.UserCode, &user.Nickname, &user.Email, &user.IsAdmin, &user.Status, &user.EmailVerified)
 
 	if err == sql.ErrNoRows {
 		global.GetLog(c).Warnf("登录失败: 用户不存在 (%s)", req.Username)
@@ -355,6 +373,7 @@ func tryPasswordLogin(c *gin.Context) {
 			"username":        user.Username,
 			"nickname":        user.Nickname.String,
 			"email":           user.Email.String,
+			"email_verified":  user.EmailVerified,
 			"is_admin":        user.IsAdmin,
 			"need_change_pwd": forceChangePwd,
 			"oss_url":         global.GetOssUrl(), // OSS 地址（未配置时为空）
@@ -374,6 +393,7 @@ func UserLogout(c *gin.Context) {
 	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 
 	global.RemoveToken(tokenString)
+	disconnectTokenConnections(tokenString)
 
 	userCode, _ := c.Get("userCode")
 	global.GetLog(c).Infof("用户[%v] 退出登录", userCode)
@@ -442,6 +462,15 @@ func UpdateUser(c *gin.Context) {
 	}
 
 	// 4. 处理修改基本信息逻辑
+	req.Email = strings.TrimSpace(req.Email)
+	if req.Email != "" && !validateEmail(req.Email) {
+		c.JSON(400, gin.H{"code": 400, "msg": "邮箱格式不正确"})
+		return
+	}
+	var oldEmail string
+	global.DB.QueryRow("SELECT IFNULL(email, '') FROM users WHERE id = ?", userID).Scan(&oldEmail)
+	emailChanged := req.Email != "" && req.Email != oldEmail
+
 	if req.Nickname != "" || req.Email != "" {
 		var err error
 		if req.Nickname != "" && req.Email == "" {
@@ -460,5 +489,24 @@ func UpdateUser(c *gin.Context) {
 		global.GetLog(c).Infof("用户[%v] 更新资料成功", userID)
 	}
 
+	// 邮箱变更后需要重新验证
+	if emailChanged {
+		uid := userID.(int)
+		if _, err := global.DB.Exec("UPDATE users SET email_verified = 0 WHERE id = ?", uid); err != nil {
+			global.GetLog(c).Errorf("重置邮箱验证状态失败 (User: %d): %v", uid, err)
+		}
+		// 与重发验证邮件共用冷却时间，防止通过反复修改邮箱刷邮件
+		if verificationRecentlySent(uid) {
+			c.JSON(200, gin.H{"code": 200, "msg": "更新成功，验证邮件发送过于频繁，请稍后在个人资料中重新发送"})
+			return
+		}
+		if err := sendVerificationEmail(uid, req.Email); err != nil {
+			global.GetLog(c).Errorf("发送验证邮件失败 (User: %d): %v", uid, err)
+		} else {
+			c.JSON(200, gin.H{"code": 200, "msg": "更新成功，验证邮件已发送至 " + req.Email})
+			return
+		}
+	}
+
 	c.JSON(200, gin.H{"code": 200, "msg": "更新成功"})
 }
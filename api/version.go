@@ -0,0 +1,67 @@
+package api
+
+import (
+	"net/http"
+	"practice_problems/global"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==========================================
+// 乐观锁：知识点 / 题目 / 分类带 version 字段，每次修改 +1
+// 客户端通过 If-Match 头或请求体 version 字段提交读取时的版本，
+// 与服务器不一致时返回 409 以及服务器当前数据，由客户端合并后重试；
+// 未携带版本号时仅在 server.require_edit_version 开启后拒绝 (428)
+// ==========================================
+
+// requestVersion 读取客户端提交的版本号
+// 优先 If-Match 头 (支持 3 / "3" / W/"3")，其次请求体中的 version
+func requestVersion(c *gin.Context, bodyVersion *int) (int, bool) {
+	if h := strings.TrimSpace(c.GetHeader("If-Match")); h != "" {
+		h = strings.TrimPrefix(h, "W/")
+		v, err := strconv.Atoi(strings.Trim(h, `"`))
+		if err != nil || v <= 0 {
+			return 0, false
+		}
+		return v, true
+	}
+	if bodyVersion != nil && *bodyVersion > 0 {
+		return *bodyVersion, true
+	}
+	return 0, false
+}
+
+// resolveEditVersion 确定本次修改基于的版本号，返回 false 时已写回响应
+// 未携带版本号且未强制要求时跳过检查，以服务器当前版本为准
+func resolveEditVersion(c *gin.Context, bodyVersion *int, currentVersion int) (int, bool) {
+	if v, ok := requestVersion(c, bodyVersion); ok {
+		return v, true
+	}
+	if strings.TrimSpace(c.GetHeader("If-Match")) != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "If-Match 版本号格式错误"})
+		return 0, false
+	}
+	if global.RequireEditVersion {
+		respondVersionRequired(c)
+		return 0, false
+	}
+	return currentVersion, true
+}
+
+// setVersionHeader 在详情响应中附带 ETag，便于客户端直接回传 If-Match
+func setVersionHeader(c *gin.Context, version int) {
+	c.Header("ETag", `"`+strconv.Itoa(version)+`"`)
+}
+
+// respondVersionRequired 修改请求未携带版本号
+func respondVersionRequired(c *gin.Context) {
+	c.JSON(http.StatusPreconditionRequired, gin.H{"code": 428, "msg": "缺少版本号：请通过 If-Match 头或 version 字段提交读取时的版本"})
+}
+
+// respondVersionConflict 版本冲突，返回服务器当前数据
+func respondVersionConflict(c *gin.Context, version int, current interface{}) {
+	setVersionHeader(c, version)
+	c.JSON(http.StatusConflict, gin.H{"code": 409, "msg": "内容已被他人修改，请基于最新内容重新编辑", "data": current})
+}
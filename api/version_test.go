@@ -0,0 +1,47 @@
+package api
+
+import (
+	"practice_problems/global"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestUpdateCategoryVersionCheck(t *testing.T) {
+	setupTestDB(t)
+
+	ownerID := createTestUser(t, "owner")
+	subjectID := createTestSubject(t, "Go", ownerID, "owner")
+	mustExec(t, "INSERT INTO knowledge_categories (id, subject_id, categorie_name) VALUES (1, ?, '1. 基础')", subjectID)
+	params := gin.Params{{Key: "id", Value: "1"}}
+
+	version := func() int {
+		var v int
+		global.DB.QueryRow("SELECT version FROM knowledge_categories WHERE id = 1").Scan(&v)
+		return v
+	}
+
+	// 旧客户端不回传版本号：默认跳过检查
+	if w := callHandler(UpdateCategory, ownerID, "owner", params, `{"categoryName":"语法"}`); w.Code != 200 {
+		t.Fatalf("update without version: want 200, got %d (%s)", w.Code, w.Body.String())
+	}
+	current := version()
+
+	// 携带过期版本号：冲突
+	stale := `{"categoryName":"并发","version":` + strconv.Itoa(current-1) + `}`
+	if w := callHandler(UpdateCategory, ownerID, "owner", params, stale); w.Code != 409 {
+		t.Errorf("update with stale version: want 409, got %d (%s)", w.Code, w.Body.String())
+	}
+	fresh := `{"categoryName":"并发","version":` + strconv.Itoa(current) + `}`
+	if w := callHandler(UpdateCategory, ownerID, "owner", params, fresh); w.Code != 200 {
+		t.Errorf("update with current version: want 200, got %d (%s)", w.Code, w.Body.String())
+	}
+
+	// 开启强制后，未携带版本号的修改被拒绝
+	global.RequireEditVersion = true
+	t.Cleanup(func() { global.RequireEditVersion = false })
+	if w := callHandler(UpdateCategory, ownerID, "owner", params, `{"categoryName":"语法"}`); w.Code != 428 {
+		t.Errorf("update without version when required: want 428, got %d (%s)", w.Code, w.Body.String())
+	}
+}
@@ -18,7 +18,7 @@ type AliyunConfig struct {
 	AccessKeySecret  string `mapstructure:"access_key_secret"` // AccessKey Secret
 	Bucket           string `mapstructure:"bucket"`            // Bucket 名称
 	InternalEndpoint string `mapstructure:"internal_endpoint"` // 内网 Endpoint (用于上传)
-	VoiceAppKey      string `mapstructure:"voice_app_key`
+	VoiceAppKey      string `mapstructure:"voice_app_key"`     // 阿里云语音模型 API Key
 }
 
 type ServerConfig struct {
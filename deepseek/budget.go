@@ -0,0 +1,158 @@
+package deepseek
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// ==========================================
+// 上下文预算：基于 Token 估算裁剪对话历史
+// ==========================================
+
+const (
+	// 每条消息的固定开销 (role、分隔符等)
+	messageOverheadTokens = 4
+
+	// 摘要消息的前缀，便于模型识别
+	summaryPrefix = "【此前对话摘要】\n"
+)
+
+// EstimateTokens 粗略估算一段文本的 Token 数
+// DeepSeek 的分词器大致为：1 个中文字符 ≈ 0.6 token，1 个英文字符 ≈ 0.3 token
+// 这里按整数运算 (×10) 估算，宁可略微高估也不要低估
+func EstimateTokens(text string) int {
+	units := 0
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r):
+			units += 6
+		case r < 128:
+			units += 3
+		default:
+			// 全角标点、emoji 等
+			units += 10
+		}
+	}
+	return (units + 9) / 10
+}
+
+// EstimateMessagesTokens 估算一组消息的 Token 总数
+func EstimateMessagesTokens(messages []openai.ChatCompletionMessage) int {
+	total := 0
+	for _, m := range messages {
+		total += messageOverheadTokens + EstimateTokens(m.Content)
+	}
+	return total
+}
+
+// ContextBudget 上下文预算配置
+type ContextBudget struct {
+	MaxTokens  int // 发送给模型的上下文上限 (估算值)
+	KeepRecent int // 触发压缩时，原样保留的最近消息条数
+}
+
+// FitResult 预算裁剪的结果
+type FitResult struct {
+	Messages []openai.ChatCompletionMessage // 实际发送给模型的消息
+	Summary  string                         // 最新的滚动摘要 (未压缩时与传入值相同)
+	Folded   int                            // 本次被折叠进摘要的历史消息条数 (从 history[1] 开始计)
+	Usage    openai.Usage                   // 生成摘要所消耗的 Token
+}
+
+// Fit 按预算组装上下文
+// history[0] 必须是 System Prompt，永久保留；summary 为此前的滚动摘要
+// 超出预算时，将较早的对话交给模型压缩成摘要，只保留最近 KeepRecent 条原文
+func (b ContextBudget) Fit(ctx context.Context, history []openai.ChatCompletionMessage, summary string) (*FitResult, error) {
+	if len(history) == 0 {
+		return nil, errors.New("history is empty")
+	}
+
+	result := &FitResult{Summary: summary}
+	result.Messages = buildContext(history[0], summary, history[1:])
+
+	// 1. 没超预算，原样发送
+	if EstimateMessagesTokens(result.Messages) <= b.MaxTokens {
+		return result, nil
+	}
+
+	// 2. 超预算：折叠较早的消息
+	keep := b.KeepRecent
+	if keep < 1 {
+		keep = 1
+	}
+	if len(history)-1 > keep {
+		folded := history[1 : len(history)-keep]
+		newSummary, usage, err := summarize(ctx, summary, folded)
+		if err == nil {
+			result.Summary = newSummary
+			result.Folded = len(folded)
+			result.Usage = usage
+		}
+		// 摘要失败时不中断对话，退化为直接丢弃最早的消息
+	}
+
+	// 3. 仍然超预算 (例如单条消息过长)，从最早的消息开始丢弃，至少保留最后一条
+	recent := history[1+result.Folded:]
+	result.Messages = buildContext(history[0], result.Summary, recent)
+	for len(recent) > 1 && EstimateMessagesTokens(result.Messages) > b.MaxTokens {
+		recent = recent[1:]
+		result.Messages = buildContext(history[0], result.Summary, recent)
+	}
+
+	return result, nil
+}
+
+// buildContext 拼接 System Prompt + 摘要 + 最近消息
+func buildContext(system openai.ChatCompletionMessage, summary string, recent []openai.ChatCompletionMessage) []openai.ChatCompletionMessage {
+	messages := make([]openai.ChatCompletionMessage, 0, len(recent)+2)
+	messages = append(messages, system)
+	if summary != "" {
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: summaryPrefix + summary,
+		})
+	}
+	return append(messages, recent...)
+}
+
+// summarize 调用模型把旧摘要与新折叠的对话合并成新的摘要
+func summarize(ctx context.Context, summary string, folded []openai.ChatCompletionMessage) (string, openai.Usage, error) {
+	var sb strings.Builder
+	if summary != "" {
+		sb.WriteString("已有摘要：\n")
+		sb.WriteString(summary)
+		sb.WriteString("\n\n")
+	}
+	sb.WriteString("新增对话：\n")
+	for _, m := range folded {
+		speaker := "候选人"
+		if m.Role == openai.ChatMessageRoleAssistant {
+			speaker = "面试官"
+		}
+		sb.WriteString(fmt.Sprintf("%s：%s\n", speaker, m.Content))
+	}
+
+	messages := []openai.ChatCompletionMessage{
+		{
+			Role: openai.ChatMessageRoleSystem,
+			Content: "你是对话摘要助手。请把【已有摘要】和【新增对话】合并为一份简洁的中文摘要，" +
+				"保留面试官问过的问题、候选人回答的要点、已指出的错误和尚未解决的追问。只输出摘要正文，不超过 500 字。",
+		},
+		{Role: openai.ChatMessageRoleUser, Content: sb.String()},
+	}
+
+	reply, usage, err := ChatWithUsage(ctx, messages)
+	if err != nil {
+		return "", usage, err
+	}
+	reply = strings.TrimSpace(reply)
+	if reply == "" {
+		return "", usage, errors.New("empty summary")
+	}
+	return reply, usage, nil
+}
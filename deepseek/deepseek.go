@@ -96,9 +96,15 @@ func IsReady() (bool, error) {
 
 // Chat 核心对话方法
 func Chat(ctx context.Context, messages []openai.ChatCompletionMessage) (string, error) {
+	reply, _, err := ChatWithUsage(ctx, messages)
+	return reply, err
+}
+
+// ChatWithUsage 与 Chat 相同，但额外返回本次调用的 Token 用量 (用于成本统计)
+func ChatWithUsage(ctx context.Context, messages []openai.ChatCompletionMessage) (string, openai.Usage, error) {
 	cli := GetClient()
 	if cli == nil {
-		return "", errors.New("deepseek client is not ready")
+		return "", openai.Usage{}, errors.New("deepseek client is not ready")
 	}
 
 	// 调用 API
@@ -118,15 +124,15 @@ func Chat(ctx context.Context, messages []openai.ChatCompletionMessage) (string,
 	)
 
 	if err != nil {
-		return "", err
+		return "", openai.Usage{}, err
 	}
 
 	if len(resp.Choices) == 0 {
-		return "", errors.New("empty response from deepseek")
+		return "", resp.Usage, errors.New("empty response from deepseek")
 	}
 
 	// 获取回答内容
 	// 注意：deepseek-reasoner 的返回中，Content 是最终结论
 	// 如果你需要思维链内容(ReasoningContent)，需要 SDK 支持或自己解析，通常 Content 就够用了
-	return resp.Choices[0].Message.Content, nil
+	return resp.Choices[0].Message.Content, resp.Usage, nil
 }
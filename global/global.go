@@ -22,6 +22,59 @@ var (
 	OssInternalEndpoint string // 内网 Endpoint (用于上传)
 	DeepseekApiKey      string // Ai DeepseekApiKey API Key
 	VoiceAppKey         string // 阿里云的语音模型 API Key
+
+	// AI 面试配置
+	AIMaxConcurrentSessions       = 1  // 同一用户允许同时进行的 AI 面试会话数
+	AIExplainCostSeconds    int64 = 30 // 每次 AI 错题解析 (未命中缓存) 扣除的时长
+
+	// 回收站配置
+	TrashRetentionDays = 30 // 软删除内容保留天数，超期后由后台任务彻底清除
+
+	// 分享码配置
+	ShareCodeLength        = 12 // 分享码随机部分长度 (8-32 位，32 个字符的字母表，每位 5 bit)
+	ShareBindRatePerMinute = 10 // 每个用户 / IP 每分钟最多尝试绑定的次数
+	ShareBindMaxFailures   = 5  // 统计窗口内允许的失败次数，达到后锁定
+	ShareBindFailureWindow = 15 // 失败次数统计窗口 (分钟)
+	ShareBindLockMinutes   = 30 // 锁定时长 (分钟)
+
+	// 到期提醒配置
+	ExpiryReminderEnabled         = true // 是否启用到期提醒后台任务
+	ExpiryReminderWindowHours     = 72   // 距到期多少小时内提醒
+	ExpiryReminderIntervalMinutes = 60   // 扫描间隔 (分钟)
+	ExpiryReminderEmail           = true // 提醒时是否同时发送邮件 (需用户填写了邮箱)
+
+	// 实时推送配置
+	PushHeartbeatSeconds = 25   // 心跳间隔 (秒)，需小于反向代理的空闲超时
+	PushReplayBufferSize = 1000 // 断线重连可补发的最近事件数
+	PushMaxSubjects      = 50   // 单个连接最多订阅的科目数
+
+	// 邮件配置
+	MailSender                                           = "log"     // 发送方式: log (只写日志) / file (写 .eml 文件) / smtp
+	MailFileDir                                          = "./mails" // file 方式的输出目录 (不要放在 uploads 下，否则可被公开访问)
+	MailSMTPHost              string                                 // SMTP 服务器
+	MailSMTPPort              = 465                                  // SMTP 端口
+	MailSMTPUsername          string                                 // SMTP 账号
+	MailSMTPPassword          string                                 // SMTP 密码 / 授权码
+	MailSMTPSecurity          = "ssl"                                // 加密方式: ssl / starttls / none
+	MailFrom                  = "noreply@localhost"                  // 发件地址
+	MailSiteName              = "Practice Problems"                  // 邮件中的站点名称
+	MailMaxAttempts           = 5                                    // 单封邮件最多尝试发送次数
+	MailOutboxIntervalSeconds = 30                                   // 发件箱扫描间隔 (秒)
+	SiteBaseURL               = "http://localhost:19527"             // 邮件链接使用的站点地址 (不带末尾斜杠)
+	EmailVerifyExpireHours    = 24                                   // 邮箱验证链接有效期 (小时)
+
+	// 找回密码配置
+	PasswordResetTokenMinutes   = 30 // 重置令牌有效期 (分钟)
+	PasswordResetAccountPerHour = 5  // 每个账号每小时最多申请次数
+	PasswordResetIPPerHour      = 20 // 每个 IP 每小时最多申请 / 确认次数
+
+	// 受信任的反向代理 (IP 或 CIDR)，只有来自这些地址的 X-Forwarded-For 才会被采信；
+	// 为空时不信任任何代理，ClientIP 取直连地址
+	TrustedProxies []string
+
+	// 修改知识点 / 题目 / 分类时是否必须携带版本号 (If-Match 或 version)
+	// 关闭时未携带版本号的请求跳过乐观锁检查，供尚未回传版本的旧客户端过渡
+	RequireEditVersion = false
 )
 
 // IsOssUploadEnabled 判断是否启用 OSS 上传
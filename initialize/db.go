@@ -2,10 +2,13 @@ package initialize
 
 import (
 	"database/sql"
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"practice_problems/global" // 确保这里是你项目实际的 global 包路径
+	"practice_problems/model"
+	"strings"
 	"time"
 
 	_ "modernc.org/sqlite" // 引入纯 Go 版 SQLite 驱动
@@ -723,6 +726,523 @@ func maintainingDatabaseTables(db *sql.DB) {
 			}
 		}
 	}
+
+	// =====================================================
+	// 9. 迁移 users.ai_quota 历史余额到 ai_quota_ledger (期初余额)
+	// =====================================================
+	result, err := db.Exec(`INSERT INTO ai_quota_ledger (user_id, change_seconds, balance_after, entry_type, remark)
+		SELECT id, ai_quota, ai_quota, 'adjust', '期初余额迁移'
+		FROM users
+		WHERE COALESCE(ai_quota, 0) > 0
+		  AND id NOT IN (SELECT DISTINCT user_id FROM ai_quota_ledger)`)
+	if err != nil {
+		if global.Log != nil {
+			global.GetLog(nil).Errorf("迁移 AI 时长期初余额失败: %v", err)
+		} else {
+			log.Printf("❌ 迁移 AI 时长期初余额失败: %v", err)
+		}
+	} else if n, _ := result.RowsAffected(); n > 0 {
+		if global.Log != nil {
+			global.GetLog(nil).Infof("✅ 已将 %d 个用户的 AI 时长余额迁移到 ai_quota_ledger", n)
+		} else {
+			log.Printf("✅ 已将 %d 个用户的 AI 时长余额迁移到 ai_quota_ledger", n)
+		}
+	}
+
+	// =====================================================
+	// 10. 导入旧版 uploads/prompt.txt 作为默认提示词模板
+	// =====================================================
+	var templateCount int
+	db.QueryRow("SELECT COUNT(*) FROM prompt_templates").Scan(&templateCount)
+	if templateCount == 0 {
+		if content, err := os.ReadFile("uploads/prompt.txt"); err == nil && len(content) > 0 {
+			// 旧格式使用 %s 作为题目占位符
+			converted := strings.ReplaceAll(string(content), "%s", "{{.Topic}}")
+			_, err := db.Exec(`INSERT INTO prompt_templates (name, version, description, content, is_default, status)
+				VALUES ('default', 1, '由 uploads/prompt.txt 导入', ?, 1, 1)`, converted)
+			if err != nil {
+				if global.Log != nil {
+					global.GetLog(nil).Errorf("导入 prompt.txt 失败: %v", err)
+				} else {
+					log.Printf("❌ 导入 prompt.txt 失败: %v", err)
+				}
+			} else {
+				if global.Log != nil {
+					global.GetLog(nil).Info("✅ 已将 uploads/prompt.txt 导入为默认提示词模板")
+				} else {
+					log.Println("✅ 已将 uploads/prompt.txt 导入为默认提示词模板")
+				}
+			}
+		}
+	}
+
+	// =====================================================
+	// 11. 软删除字段：subjects / knowledge_categories / knowledge_points / questions
+	//     增加 deleted_at、deleted_by、delete_batch (同一次删除连带的记录共享批次号)
+	// =====================================================
+	subjectsMigrated := false
+	for _, table := range []string{"subjects", "knowledge_categories", "knowledge_points", "questions"} {
+		existing := map[string]bool{}
+		colRows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+		if err != nil {
+			if global.Log != nil {
+				global.GetLog(nil).Warnf("检查 %s 表结构失败: %v", table, err)
+			} else {
+				log.Printf("⚠️ 检查 %s 表结构失败: %v", table, err)
+			}
+			continue
+		}
+		for colRows.Next() {
+			var cid, notnull, pk int
+			var name, ctype string
+			var dfltValue interface{}
+			if err := colRows.Scan(&cid, &name, &ctype, &notnull, &dfltValue, &pk); err == nil {
+				existing[name] = true
+			}
+		}
+		colRows.Close()
+
+		for _, col := range []string{"deleted_at DATETIME", "deleted_by TEXT", "delete_batch TEXT"} {
+			name := strings.Fields(col)[0]
+			if existing[name] {
+				continue
+			}
+			if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", table, col)); err != nil {
+				if global.Log != nil {
+					global.GetLog(nil).Errorf("向 %s 表添加 %s 字段失败: %v", table, name, err)
+				} else {
+					log.Printf("❌ 向 %s 表添加 %s 字段失败: %v", table, name, err)
+				}
+				continue
+			}
+			if global.Log != nil {
+				global.GetLog(nil).Infof("✅ 已成功向 %s 表添加 '%s' 字段", table, name)
+			} else {
+				log.Printf("✅ 已成功向 %s 表添加 '%s' 字段", table, name)
+			}
+			if table == "subjects" && name == "deleted_at" {
+				subjectsMigrated = true
+			}
+		}
+		db.Exec(fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_%s_deleted ON %s(deleted_at)", table, table))
+	}
+
+	// 旧版删除科目只是把 status 置 0，迁移到回收站 (子内容同批次，恢复科目时一并恢复)
+	// 删除时间记为迁移时刻 (UTC)，保证旧数据也有完整的保留期
+	if subjectsMigrated {
+		migratedAt := model.FormatDBTime(time.Now())
+		stmts := []string{
+			`UPDATE subjects SET deleted_at = ?, deleted_by = creator_code, delete_batch = 'legacy-' || id
+			 WHERE status = 0 AND deleted_at IS NULL`,
+			`UPDATE knowledge_categories SET deleted_at = s.deleted_at, deleted_by = s.deleted_by, delete_batch = s.delete_batch
+			 FROM subjects s WHERE knowledge_categories.subject_id = s.id AND s.delete_batch LIKE 'legacy-%' AND knowledge_categories.deleted_at IS NULL`,
+			`UPDATE knowledge_points SET deleted_at = c.deleted_at, deleted_by = c.deleted_by, delete_batch = c.delete_batch
+			 FROM knowledge_categories c WHERE knowledge_points.categorie_id = c.id AND c.delete_batch LIKE 'legacy-%' AND knowledge_points.deleted_at IS NULL`,
+			`UPDATE questions SET deleted_at = p.deleted_at, deleted_by = p.deleted_by, delete_batch = p.delete_batch
+			 FROM knowledge_points p WHERE questions.knowledge_point_id = p.id AND p.delete_batch LIKE 'legacy-%' AND questions.deleted_at IS NULL`,
+		}
+		for i, stmt := range stmts {
+			var args []interface{}
+			if i == 0 {
+				args = append(args, migratedAt)
+			}
+			if _, err := db.Exec(stmt, args...); err != nil {
+				if global.Log != nil {
+					global.GetLog(nil).Errorf("迁移已删除科目到回收站失败: %v", err)
+				} else {
+					log.Printf("❌ 迁移已删除科目到回收站失败: %v", err)
+				}
+				break
+			}
+		}
+	}
+
+	// =====================================================
+	// 12. 乐观锁版本号：knowledge_points / questions / knowledge_categories
+	//     每次修改 version + 1，客户端提交读取时的版本，不一致时拒绝覆盖
+	// =====================================================
+	for _, table := range []string{"knowledge_points", "questions", "knowledge_categories"} {
+		hasVersion := false
+		colRows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+		if err != nil {
+			if global.Log != nil {
+				global.GetLog(nil).Warnf("检查 %s 表结构失败: %v", table, err)
+			} else {
+				log.Printf("⚠️ 检查 %s 表结构失败: %v", table, err)
+			}
+			continue
+		}
+		for colRows.Next() {
+			var cid, notnull, pk int
+			var name, ctype string
+			var dfltValue interface{}
+			if err := colRows.Scan(&cid, &name, &ctype, &notnull, &dfltValue, &pk); err == nil && name == "version" {
+				hasVersion = true
+			}
+		}
+		colRows.Close()
+
+		if hasVersion {
+			continue
+		}
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN version INTEGER NOT NULL DEFAULT 1", table)); err != nil {
+			if global.Log != nil {
+				global.GetLog(nil).Errorf("向 %s 表添加 version 字段失败: %v", table, err)
+			} else {
+				log.Printf("❌ 向 %s 表添加 version 字段失败: %v", table, err)
+			}
+			continue
+		}
+		if global.Log != nil {
+			global.GetLog(nil).Infof("✅ 已成功向 %s 表添加 'version' 字段", table)
+		} else {
+			log.Printf("✅ 已成功向 %s 表添加 'version' 字段", table)
+		}
+	}
+
+	// =====================================================
+	// 13. 知识点绑定锚点：出现序号 + 前后文，内容修改后模糊重新定位
+	//     无法定位的绑定标记为失效 (status = 1)，由作者修复而不是直接删除
+	// =====================================================
+	bindingCols := map[string]bool{}
+	if colRows, err := db.Query("PRAGMA table_info(point_bindings)"); err == nil {
+		for colRows.Next() {
+			var cid, notnull, pk int
+			var name, ctype string
+			var dfltValue interface{}
+			if err := colRows.Scan(&cid, &name, &ctype, &notnull, &dfltValue, &pk); err == nil {
+				bindingCols[name] = true
+			}
+		}
+		colRows.Close()
+
+		for _, col := range []string{
+			"occurrence INTEGER DEFAULT 0", // bind_text 在内容中的第几次出现 (从 0 开始)
+			"anchor_prefix TEXT",           // 锚点前文
+			"anchor_suffix TEXT",           // 锚点后文
+			"status INTEGER DEFAULT 0",     // 0: 正常, 1: 失效
+			"broken_time DATETIME",
+		} {
+			name := strings.Fields(col)[0]
+			if bindingCols[name] {
+				continue
+			}
+			if _, err := db.Exec("ALTER TABLE point_bindings ADD COLUMN " + col); err != nil {
+				if global.Log != nil {
+					global.GetLog(nil).Errorf("向 point_bindings 表添加 %s 字段失败: %v", name, err)
+				} else {
+					log.Printf("❌ 向 point_bindings 表添加 %s 字段失败: %v", name, err)
+				}
+				continue
+			}
+			if global.Log != nil {
+				global.GetLog(nil).Infof("✅ 已成功向 point_bindings 表添加 '%s' 字段", name)
+			} else {
+				log.Printf("✅ 已成功向 point_bindings 表添加 '%s' 字段", name)
+			}
+		}
+		db.Exec("CREATE INDEX IF NOT EXISTS idx_point_bindings_source ON point_bindings(source_point_id)")
+	} else {
+		if global.Log != nil {
+			global.GetLog(nil).Warnf("检查 point_bindings 表结构失败: %v", err)
+		} else {
+			log.Printf("⚠️ 检查 point_bindings 表结构失败: %v", err)
+		}
+	}
+
+	// =====================================================
+	// 14. 分类层级：knowledge_categories.parent_id (NULL 为顶级)
+	//     已有的平铺分类保持 parent_id 为 NULL，即全部成为顶级分类
+	// =====================================================
+	hasParentID := false
+	if colRows, err := db.Query("PRAGMA table_info(knowledge_categories)"); err == nil {
+		for colRows.Next() {
+			var cid, notnull, pk int
+			var name, ctype string
+			var dfltValue interface{}
+			if err := colRows.Scan(&cid, &name, &ctype, &notnull, &dfltValue, &pk); err == nil && name == "parent_id" {
+				hasParentID = true
+			}
+		}
+		colRows.Close()
+
+		if !hasParentID {
+			if _, err := db.Exec("ALTER TABLE knowledge_categories ADD COLUMN parent_id INTEGER"); err != nil {
+				if global.Log != nil {
+					global.GetLog(nil).Errorf("向 knowledge_categories 表添加 parent_id 字段失败: %v", err)
+				} else {
+					log.Printf("❌ 向 knowledge_categories 表添加 parent_id 字段失败: %v", err)
+				}
+			} else {
+				var roots int
+				db.QueryRow("SELECT COUNT(*) FROM knowledge_categories").Scan(&roots)
+				if global.Log != nil {
+					global.GetLog(nil).Infof("✅ 已成功向 knowledge_categories 表添加 'parent_id' 字段，%d 个已有分类作为顶级分类", roots)
+				} else {
+					log.Printf("✅ 已成功向 knowledge_categories 表添加 'parent_id' 字段，%d 个已有分类作为顶级分类", roots)
+				}
+			}
+		}
+		db.Exec("CREATE INDEX IF NOT EXISTS idx_knowledge_categories_parent ON knowledge_categories(subject_id, parent_id)")
+	} else {
+		if global.Log != nil {
+			global.GetLog(nil).Warnf("检查 knowledge_categories 表结构失败: %v", err)
+		} else {
+			log.Printf("⚠️ 检查 knowledge_categories 表结构失败: %v", err)
+		}
+	}
+
+	// =====================================================
+	// 15. 分享码使用上限与使用记录 IP
+	//     share_codes.max_uses (0 为不限，与 used_count 一起原子校验)，share_code_usage.ip
+	// =====================================================
+	ensureColumns(db, []columnDef{
+		{"share_codes", "max_uses", "max_uses INTEGER DEFAULT 0"},
+		{"share_code_usage", "ip", "ip TEXT"},
+	})
+
+	// =====================================================
+	// 16. 分享码审核模式：share_codes.require_approval
+	//     为 1 时绑定只生成待审核申请 (share_code_requests)，创建者通过后才写入 user_subjects
+	// =====================================================
+	ensureColumns(db, []columnDef{
+		{"share_codes", "require_approval", "require_approval INTEGER DEFAULT 0"},
+	})
+
+	// =====================================================
+	// 17. 有效期统一存 UTC
+	//     旧版本按服务器本地时间写入 expire_time (公告的 create_time 同样如此)，
+	//     统一转换为 UTC "2006-01-02 15:04:05"，与 datetime('now') 直接比较；只执行一次
+	// =====================================================
+	runOnceMigration(db, "utc_expire_time", func(tx *sql.Tx) (int, error) {
+		total := 0
+		for _, item := range []struct{ table, column string }{
+			{"share_codes", "expire_time"},
+			{"user_subjects", "expire_time"},
+			{"collection_permissions", "expire_time"},
+			{"share_announcements", "expire_time"},
+			{"share_announcements", "create_time"},
+		} {
+			n, err := normalizeLegacyTimes(tx, item.table, item.column)
+			if err != nil {
+				return total, fmt.Errorf("%s.%s: %w", item.table, item.column, err)
+			}
+			total += n
+		}
+		return total, nil
+	})
+
+	// =====================================================
+	// 18. 科目到期宽限期：subjects.grace_days
+	//     授权到期后的 grace_days 天内仍可查看 (只读)，不能答题、不能使用 AI
+	// =====================================================
+	ensureColumns(db, []columnDef{
+		{"subjects", "grace_days", "grace_days INTEGER DEFAULT 0"},
+	})
+
+	// =====================================================
+	// 19. 邮箱验证状态：users.email_verified
+	//     未验证的邮箱不会收到提醒邮件；修改邮箱后需重新验证
+	// =====================================================
+	ensureColumns(db, []columnDef{
+		{"users", "email_verified", "email_verified INTEGER DEFAULT 0"},
+	})
+
+	// =====================================================
+	// 20. 授权来源：user_subjects.member_grant
+	//     1 表示该授权由添加协作成员时开通，移除成员只收回这类授权；
+	//     分享、分享码、转让、所有者修改有效期会把它改回 0。
+	//     旧数据按 "授权晚于成员加入且不来自分享码" 回填，只执行一次
+	// =====================================================
+	ensureColumns(db, []columnDef{
+		{"user_subjects", "member_grant", "member_grant INTEGER DEFAULT 0"},
+	})
+	runOnceMigration(db, "user_subjects_member_grant", func(tx *sql.Tx) (int, error) {
+		result, err := tx.Exec(`
+			UPDATE user_subjects SET member_grant = 1
+			WHERE COALESCE(source_share_code_id, 0) = 0
+			  AND expire_time IS NULL
+			  AND EXISTS (
+			      SELECT 1 FROM subject_members m
+			      WHERE m.subject_id = user_subjects.subject_id
+			        AND m.user_id = user_subjects.user_id
+			        AND user_subjects.create_time >= m.create_time
+			  )`)
+		if err != nil {
+			return 0, err
+		}
+		n, _ := result.RowsAffected()
+		return int(n), nil
+	})
+
+	// =====================================================
+	// 21. 知识点关系授权端：point_relations.owner_point_id
+	//     创建时校验编辑权限的知识点，删除时按它鉴权 (related 关系按 from < to 存储，可能与请求方向相反)；
+	//     旧数据中有方向的关系回填为 from_point_id，related 关系无法判断，保持 NULL (任一端的编辑者可删除)
+	// =====================================================
+	ensureColumns(db, []columnDef{
+		{"point_relations", "owner_point_id", "owner_point_id INTEGER"},
+	})
+	runOnceMigration(db, "point_relations_owner_point", func(tx *sql.Tx) (int, error) {
+		result, err := tx.Exec(`UPDATE point_relations SET owner_point_id = from_point_id
+			WHERE owner_point_id IS NULL AND relation_type != 'related'`)
+		if err != nil {
+			return 0, err
+		}
+		n, _ := result.RowsAffected()
+		return int(n), nil
+	})
+
+	// =====================================================
+	// 22. 回收站删除时间统一存 UTC
+	//     早期版本按服务器本地时间写入 deleted_at，转换为 UTC 与其他时间列一致；
+	//     本次启动才添加软删除字段时 (见第 11 步) 已全部按 UTC 写入，无需转换
+	// =====================================================
+	runOnceMigration(db, "utc_deleted_at", func(tx *sql.Tx) (int, error) {
+		if subjectsMigrated {
+			return 0, nil
+		}
+		total := 0
+		for _, table := range []string{"subjects", "knowledge_categories", "knowledge_points", "questions"} {
+			n, err := normalizeLegacyTimes(tx, table, "deleted_at")
+			if err != nil {
+				return total, fmt.Errorf("%s.deleted_at: %w", table, err)
+			}
+			total += n
+		}
+		return total, nil
+	})
+}
+
+// runOnceMigration 执行一次性数据迁移，成功后记录到 schema_migrations，失败则整体回滚、下次启动重试
+func runOnceMigration(db *sql.DB, name string, fn func(tx *sql.Tx) (int, error)) {
+	var applied int
+	db.QueryRow("SELECT COUNT(*) FROM schema_migrations WHERE name = ?", name).Scan(&applied)
+	if applied > 0 {
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		if global.Log != nil {
+			global.GetLog(nil).Errorf("数据迁移 %s 开启事务失败: %v", name, err)
+		} else {
+			log.Printf("❌ 数据迁移 %s 开启事务失败: %v", name, err)
+		}
+		return
+	}
+	defer tx.Rollback()
+
+	n, err := fn(tx)
+	if err == nil {
+		_, err = tx.Exec("INSERT INTO schema_migrations (name) VALUES (?)", name)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		if global.Log != nil {
+			global.GetLog(nil).Errorf("数据迁移 %s 失败: %v", name, err)
+		} else {
+			log.Printf("❌ 数据迁移 %s 失败: %v", name, err)
+		}
+		return
+	}
+	if global.Log != nil {
+		global.GetLog(nil).Infof("✅ 数据迁移 %s 完成 (更新 %d 条)", name, n)
+	} else {
+		log.Printf("✅ 数据迁移 %s 完成 (更新 %d 条)", name, n)
+	}
+}
+
+// normalizeLegacyTimes 将某一列的旧时间值转换为 UTC 存储格式，返回更新条数
+// 用字符串拼接读取原始文本，避免驱动把不带时区的本地时间当作 UTC 解析
+func normalizeLegacyTimes(tx *sql.Tx, table, column string) (int, error) {
+	rows, err := tx.Query(fmt.Sprintf("SELECT rowid, %s || '' FROM %s WHERE %s IS NOT NULL AND %s != ''", column, table, column, column))
+	if err != nil {
+		return 0, err
+	}
+	type pending struct {
+		rowID int64
+		value string
+	}
+	var updates []pending
+	for rows.Next() {
+		var rowID int64
+		var raw string
+		if err := rows.Scan(&rowID, &raw); err != nil {
+			continue
+		}
+		t, err := model.ParseLegacyTime(raw)
+		if err != nil {
+			// 无法识别的值保持原样，记录日志便于人工处理
+			if global.Log != nil {
+				global.GetLog(nil).Warnf("无法转换 %s.%s (rowid %d) 的时间 '%s'", table, column, rowID, raw)
+			} else {
+				log.Printf("⚠️ 无法转换 %s.%s (rowid %d) 的时间 '%s'", table, column, rowID, raw)
+			}
+			continue
+		}
+		if v := model.FormatDBTime(t); v != raw {
+			updates = append(updates, pending{rowID, v})
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, u := range updates {
+		if _, err := tx.Exec(fmt.Sprintf("UPDATE %s SET %s = ? WHERE rowid = ?", table, column), u.value, u.rowID); err != nil {
+			return 0, err
+		}
+	}
+	return len(updates), nil
+}
+
+// columnDef 待补充的字段：表名、字段名、ADD COLUMN 定义
+type columnDef struct{ table, column, def string }
+
+// ensureColumns 检查字段是否存在，不存在则 ALTER TABLE 添加
+func ensureColumns(db *sql.DB, items []columnDef) {
+	for _, item := range items {
+		hasColumn := false
+		colRows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", item.table))
+		if err != nil {
+			if global.Log != nil {
+				global.GetLog(nil).Warnf("检查 %s 表结构失败: %v", item.table, err)
+			} else {
+				log.Printf("⚠️ 检查 %s 表结构失败: %v", item.table, err)
+			}
+			continue
+		}
+		for colRows.Next() {
+			var cid, notnull, pk int
+			var name, ctype string
+			var dfltValue interface{}
+			if err := colRows.Scan(&cid, &name, &ctype, &notnull, &dfltValue, &pk); err == nil && name == item.column {
+				hasColumn = true
+			}
+		}
+		colRows.Close()
+
+		if hasColumn {
+			continue
+		}
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", item.table, item.def)); err != nil {
+			if global.Log != nil {
+				global.GetLog(nil).Errorf("向 %s 表添加 %s 字段失败: %v", item.table, item.column, err)
+			} else {
+				log.Printf("❌ 向 %s 表添加 %s 字段失败: %v", item.table, item.column, err)
+			}
+			continue
+		}
+		if global.Log != nil {
+			global.GetLog(nil).Infof("✅ 已成功向 %s 表添加 '%s' 字段", item.table, item.column)
+		} else {
+			log.Printf("✅ 已成功向 %s 表添加 '%s' 字段", item.table, item.column)
+		}
+	}
 }
 
 // initSQLiteTables 初始化 SQLite 表结构
@@ -1063,6 +1583,365 @@ func initSQLiteTables(db *sql.DB) {
 		 AFTER UPDATE ON point_user_notes BEGIN 
 			UPDATE point_user_notes SET update_time = CURRENT_TIMESTAMP WHERE id = OLD.id; 
 		 END;`,
+
+		// ==========================
+		// 20. AI 面试会话记录表 (时长与 Token 用量)
+		// ==========================
+		`CREATE TABLE IF NOT EXISTS ai_interview_sessions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			start_time DATETIME,
+			end_time DATETIME,
+			used_seconds INTEGER DEFAULT 0,
+			prompt_tokens INTEGER DEFAULT 0,
+			completion_tokens INTEGER DEFAULT 0,
+			total_tokens INTEGER DEFAULT 0,
+			create_time DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		);`,
+
+		// ==========================
+		// 21. AI 时长账本 (只追加，余额 = SUM(change_seconds))
+		// ==========================
+		`CREATE TABLE IF NOT EXISTS ai_quota_ledger (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			change_seconds INTEGER NOT NULL,     -- 正数增加，负数扣减
+			balance_after INTEGER NOT NULL,      -- 变动后余额
+			entry_type TEXT NOT NULL,            -- grant / revoke / consume / adjust
+			session_id INTEGER,                  -- 关联 ai_interview_sessions.id
+			operator_id INTEGER,                 -- 操作的管理员
+			remark TEXT,
+			create_time DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_ai_quota_ledger_user ON ai_quota_ledger (user_id, id);`,
+		`CREATE TRIGGER IF NOT EXISTS trg_ai_quota_ledger_no_update 
+		 BEFORE UPDATE ON ai_quota_ledger BEGIN 
+			SELECT RAISE(ABORT, 'ai_quota_ledger is append-only'); 
+		 END;`,
+
+		// ==========================
+		// 22. AI 提示词模板表 (同名模板按版本递增)
+		// ==========================
+		`CREATE TABLE IF NOT EXISTS prompt_templates (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			version INTEGER NOT NULL DEFAULT 1,
+			description TEXT,
+			content TEXT NOT NULL,             -- text/template 语法，变量：{{.Topic}} {{.Content}} {{.Difficulty}} {{.Language}}
+			is_default INTEGER DEFAULT 0,      -- 1: 全局默认模板
+			status INTEGER DEFAULT 1,          -- 0: 已删除
+			creator_code TEXT,
+			create_time DATETIME DEFAULT CURRENT_TIMESTAMP,
+			CONSTRAINT uk_prompt_name_version UNIQUE (name, version)
+		);`,
+
+		// ==========================
+		// 23. 科目提示词设置表
+		// ==========================
+		`CREATE TABLE IF NOT EXISTS subject_prompts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			subject_id INTEGER NOT NULL UNIQUE,
+			template_name TEXT,                -- 选用的模板 (始终使用最新版本)
+			custom_content TEXT,               -- 科目自定义提示词，非空时优先于模板
+			language TEXT,
+			create_time DATETIME DEFAULT CURRENT_TIMESTAMP,
+			update_time DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (subject_id) REFERENCES subjects(id) ON DELETE CASCADE
+		);`,
+
+		// ==========================
+		// 24. 草稿题表 (AI 生成，作者审核后发布到 questions)
+		// ==========================
+		`CREATE TABLE IF NOT EXISTS question_drafts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			knowledge_point_id INTEGER NOT NULL,
+			question_text TEXT NOT NULL,
+			option1 TEXT,
+			option2 TEXT,
+			option3 TEXT,
+			option4 TEXT,
+			correct_answer INTEGER NOT NULL,
+			explanation TEXT,
+			status INTEGER DEFAULT 0,          -- 0: 待审核, 1: 已发布, 2: 已丢弃
+			question_id INTEGER,               -- 发布后对应的 questions.id
+			creator_code TEXT,
+			create_time DATETIME DEFAULT CURRENT_TIMESTAMP,
+			update_time DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (knowledge_point_id) REFERENCES knowledge_points(id) ON DELETE CASCADE
+		);`,
+		`CREATE TRIGGER IF NOT EXISTS trg_update_question_drafts_time 
+		 AFTER UPDATE ON question_drafts BEGIN 
+			UPDATE question_drafts SET update_time = CURRENT_TIMESTAMP WHERE id = OLD.id; 
+		 END;`,
+
+		// ==========================
+		// 25. AI 错题解析缓存表 (按题目 + 所选选项缓存)
+		// ==========================
+		`CREATE TABLE IF NOT EXISTS question_ai_explanations (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			question_id INTEGER NOT NULL,
+			chosen_option INTEGER NOT NULL,
+			content TEXT NOT NULL,
+			question_update_time DATETIME,     -- 生成时题目的 update_time，题目修改后缓存失效
+			prompt_tokens INTEGER DEFAULT 0,
+			completion_tokens INTEGER DEFAULT 0,
+			create_time DATETIME DEFAULT CURRENT_TIMESTAMP,
+			CONSTRAINT uk_question_option UNIQUE (question_id, chosen_option),
+			FOREIGN KEY (question_id) REFERENCES questions(id) ON DELETE CASCADE
+		);`,
+
+		// ==========================
+		// 26. 科目协作成员表 (所有者即 subjects.creator_code，不在此表)
+		// ==========================
+		`CREATE TABLE IF NOT EXISTS subject_members (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			subject_id INTEGER NOT NULL,
+			user_id INTEGER NOT NULL,
+			role TEXT NOT NULL,                -- editor / reviewer / viewer
+			invited_by TEXT,                   -- 邀请人 user_code
+			create_time DATETIME DEFAULT CURRENT_TIMESTAMP,
+			update_time DATETIME DEFAULT CURRENT_TIMESTAMP,
+			CONSTRAINT uk_subject_member UNIQUE (subject_id, user_id),
+			FOREIGN KEY (subject_id) REFERENCES subjects(id) ON DELETE CASCADE,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		);`,
+		`CREATE TRIGGER IF NOT EXISTS trg_update_subject_members_time 
+		 AFTER UPDATE ON subject_members BEGIN 
+			UPDATE subject_members SET update_time = CURRENT_TIMESTAMP WHERE id = OLD.id; 
+		 END;`,
+
+		// ==========================
+		// 27. 科目转让表 (兼作转让审计记录)
+		// ==========================
+		`CREATE TABLE IF NOT EXISTS subject_transfers (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			subject_id INTEGER NOT NULL,
+			from_code TEXT NOT NULL,           -- 原所有者
+			to_code TEXT NOT NULL,             -- 接收人
+			initiator_code TEXT NOT NULL,      -- 发起人 (原所有者或管理员)
+			status INTEGER DEFAULT 0,          -- 0: 待接收, 1: 已完成, 2: 已拒绝, 3: 已撤销
+			remark TEXT,
+			share_codes_moved INTEGER DEFAULT 0,
+			share_codes_detached INTEGER DEFAULT 0,
+			announcements_moved INTEGER DEFAULT 0,
+			create_time DATETIME DEFAULT CURRENT_TIMESTAMP,
+			handle_time DATETIME
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_subject_transfers_subject ON subject_transfers(subject_id, status);`,
+
+		// ==========================
+		// 28. 知识点修订历史表 (每次保存一条完整快照)
+		// ==========================
+		`CREATE TABLE IF NOT EXISTS point_revisions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			point_id INTEGER NOT NULL,
+			revision_no INTEGER NOT NULL,      -- 该知识点内的修订序号，从 1 开始
+			title TEXT,
+			content TEXT,
+			reference_links TEXT,
+			local_image_names TEXT,
+			video_url TEXT,
+			difficulty INTEGER DEFAULT 0,
+			action TEXT NOT NULL,              -- initial: 首次修改前的原始内容, update: 保存, rollback: 回滚
+			rollback_from INTEGER,             -- 回滚时的目标修订 ID
+			removed_bindings TEXT,             -- 本次保存失效的绑定 (JSON)
+			editor_code TEXT,
+			create_time DATETIME DEFAULT CURRENT_TIMESTAMP,
+			CONSTRAINT uk_point_revision UNIQUE (point_id, revision_no),
+			FOREIGN KEY (point_id) REFERENCES knowledge_points(id) ON DELETE CASCADE
+		);`,
+
+		// ==========================
+		// 29. 题目修订表 (只追加，不修改)
+		// ==========================
+		`CREATE TABLE IF NOT EXISTS question_revisions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			question_id INTEGER NOT NULL,
+			revision_no INTEGER NOT NULL,      -- 该题目内的修订序号，从 1 开始
+			question_text TEXT NOT NULL,
+			option1 TEXT, option1_img TEXT,
+			option2 TEXT, option2_img TEXT,
+			option3 TEXT, option3_img TEXT,
+			option4 TEXT, option4_img TEXT,
+			correct_answer INTEGER NOT NULL,
+			explanation TEXT,
+			action TEXT NOT NULL,              -- initial: 启用修订前的原始内容, create: 创建, update: 修改
+			editor_code TEXT,
+			create_time DATETIME DEFAULT CURRENT_TIMESTAMP,
+			CONSTRAINT uk_question_revision UNIQUE (question_id, revision_no),
+			FOREIGN KEY (question_id) REFERENCES questions(id) ON DELETE CASCADE
+		);`,
+
+		// ==========================
+		// 30. 答题记录表 (记录判分所依据的题目修订，答案更正后可重新判分)
+		// ==========================
+		`CREATE TABLE IF NOT EXISTS question_attempts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			question_id INTEGER NOT NULL,
+			exam_tag TEXT,                     -- 前端传入的考试/练习批次标识
+			chosen_option INTEGER NOT NULL,
+			answered_revision_id INTEGER NOT NULL, -- 作答时的题目修订
+			graded_revision_id INTEGER NOT NULL,   -- 当前判分依据的题目修订
+			is_correct INTEGER NOT NULL,
+			regrade_time DATETIME,
+			create_time DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+			FOREIGN KEY (question_id) REFERENCES questions(id) ON DELETE CASCADE
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_question_attempts_question ON question_attempts(question_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_question_attempts_user ON question_attempts(user_id, exam_tag);`,
+
+		// ==========================
+		// 31. 知识点关系表 (前置 / 延伸 / 相关，与正文文字绑定相互独立)
+		// ==========================
+		`CREATE TABLE IF NOT EXISTS point_relations (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			from_point_id INTEGER NOT NULL,
+			to_point_id INTEGER NOT NULL,
+			relation_type TEXT NOT NULL,       -- prerequisite: 先学 from 再学 to, extends: to 延伸自 from, related: 相关 (from < to)
+			note TEXT,
+			creator_code TEXT,
+			create_time DATETIME DEFAULT CURRENT_TIMESTAMP,
+			CONSTRAINT uk_point_relation UNIQUE (from_point_id, to_point_id, relation_type),
+			FOREIGN KEY (from_point_id) REFERENCES knowledge_points(id) ON DELETE CASCADE,
+			FOREIGN KEY (to_point_id) REFERENCES knowledge_points(id) ON DELETE CASCADE
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_point_relations_to ON point_relations(to_point_id);`,
+
+		// ==========================
+		// 32. 分享码绑定申请表 (审核模式的分享码，创建者通过后才授权)
+		// ==========================
+		`CREATE TABLE IF NOT EXISTS share_code_requests (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			share_code_id INTEGER NOT NULL,
+			user_id INTEGER NOT NULL,
+			status INTEGER DEFAULT 0,          -- 0: 待审核, 1: 已通过, 2: 已拒绝
+			review_note TEXT,
+			reviewer_id INTEGER,
+			create_time DATETIME DEFAULT CURRENT_TIMESTAMP,
+			review_time DATETIME,
+			CONSTRAINT uk_share_request UNIQUE (share_code_id, user_id),
+			FOREIGN KEY (share_code_id) REFERENCES share_codes(id) ON DELETE CASCADE,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_share_code_requests_user ON share_code_requests(user_id);`,
+
+		// ==========================
+		// 33. 一次性数据迁移记录表
+		// ==========================
+		`CREATE TABLE IF NOT EXISTS schema_migrations (
+			name TEXT PRIMARY KEY,
+			applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);`,
+
+		// ==========================
+		// 34. 站内通知表 (ref_key 用于去重，同一用户同一事件只通知一次)
+		// ==========================
+		`CREATE TABLE IF NOT EXISTS notifications (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			type TEXT NOT NULL,
+			title TEXT NOT NULL,
+			content TEXT,
+			link TEXT,
+			ref_key TEXT,
+			is_read INTEGER DEFAULT 0,
+			create_time DATETIME DEFAULT CURRENT_TIMESTAMP,
+			read_time DATETIME,
+			CONSTRAINT uk_notification_ref UNIQUE (user_id, ref_key),
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications(user_id, is_read, id);`,
+
+		// ==========================
+		// 35. 通知偏好表 (按类型开关站内通知与邮件，没有记录时按类型默认值)
+		// ==========================
+		`CREATE TABLE IF NOT EXISTS notification_preferences (
+			user_id INTEGER NOT NULL,
+			type TEXT NOT NULL,
+			in_app INTEGER DEFAULT 1,
+			email INTEGER,
+			update_time DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, type),
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		);`,
+
+		// ==========================
+		// 36. 发件箱 (邮件异步发送，失败按退避重试)
+		// ==========================
+		`CREATE TABLE IF NOT EXISTS mail_outbox (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			to_addr TEXT NOT NULL,
+			template TEXT,
+			subject TEXT NOT NULL,
+			body TEXT NOT NULL,
+			status INTEGER DEFAULT 0,       -- 0 待发送, 1 已发送, 2 失败
+			attempts INTEGER DEFAULT 0,
+			last_error TEXT,
+			next_attempt_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			create_time DATETIME DEFAULT CURRENT_TIMESTAMP,
+			sent_time DATETIME
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_mail_outbox_pending ON mail_outbox(status, next_attempt_at);`,
+
+		// ==========================
+		// 37. 邮件令牌 (邮箱验证、密码重置)，只存 SHA-256 摘要，一次性使用
+		// ==========================
+		`CREATE TABLE IF NOT EXISTS email_tokens (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			purpose TEXT NOT NULL,          -- verify_email / reset_password
+			token_hash TEXT NOT NULL UNIQUE,
+			email TEXT,                     -- 签发时的邮箱，邮箱变更后旧令牌失效
+			expire_time DATETIME NOT NULL,
+			used_time DATETIME,
+			create_time DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_email_tokens_user ON email_tokens(user_id, purpose);`,
+
+		// ==========================
+		// 38. 科目目录 (作者把科目发布到目录，用户可搜索并一键申请，授权沿用分享码流程)
+		// ==========================
+		`CREATE TABLE IF NOT EXISTS subject_listings (
+			subject_id INTEGER PRIMARY KEY,
+			title TEXT NOT NULL,
+			description TEXT,
+			cover TEXT,
+			tags TEXT DEFAULT '[]',             -- JSON 数组
+			sample_point_ids TEXT DEFAULT '[]', -- 试看知识点 ID (JSON 数组)
+			share_code_id INTEGER NOT NULL,     -- 申请时使用的分享码
+			status INTEGER DEFAULT 1,           -- 1 已上架, 0 已下架
+			publish_time DATETIME DEFAULT CURRENT_TIMESTAMP,
+			update_time DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (subject_id) REFERENCES subjects(id) ON DELETE CASCADE
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_subject_listings_status ON subject_listings(status, publish_time);`,
+
+		// ==========================
+		// 39. 科目评价 (获得过授权的用户每个科目一条，作者可回复，管理员可隐藏)
+		// ==========================
+		`CREATE TABLE IF NOT EXISTS subject_reviews (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			subject_id INTEGER NOT NULL,
+			user_id INTEGER NOT NULL,
+			rating INTEGER NOT NULL,        -- 1~5 分
+			content TEXT,
+			reply TEXT,                     -- 作者回复
+			reply_time DATETIME,
+			hidden INTEGER DEFAULT 0,       -- 管理员隐藏后不展示、不计入评分
+			hidden_reason TEXT,
+			hidden_by INTEGER,
+			create_time DATETIME DEFAULT CURRENT_TIMESTAMP,
+			update_time DATETIME DEFAULT CURRENT_TIMESTAMP,
+			CONSTRAINT uk_subject_review UNIQUE (subject_id, user_id),
+			FOREIGN KEY (subject_id) REFERENCES subjects(id) ON DELETE CASCADE,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_subject_reviews_subject ON subject_reviews(subject_id, hidden);`,
 	}
 
 	if global.Log != nil {
@@ -0,0 +1,133 @@
+package initialize
+
+import (
+	"database/sql"
+	"path/filepath"
+	"practice_problems/global"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// openLegacyDB 打开临时数据库并建出启用回收站之前的表结构 (subjects 等尚无 deleted_at)
+func openLegacyDB(t *testing.T) *sql.DB {
+	t.Helper()
+	global.Log = zap.NewNop().Sugar()
+
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "data.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	// 与 InitSQLite 一致使用 WAL，迁移过程中的读连接不会阻塞 ALTER TABLE
+	if _, err := db.Exec("PRAGMA journal_mode=WAL"); err != nil {
+		t.Fatalf("enable wal: %v", err)
+	}
+	initSQLiteTables(db)
+	return db
+}
+
+func TestLegacySoftDeleteMigrationKeepsRetentionWindow(t *testing.T) {
+	// 非 UTC 时区下也不能被后续的 deleted_at 时区转换再平移一次
+	oldLocal := time.Local
+	time.Local = time.FixedZone("UTC+8", 8*3600)
+	t.Cleanup(func() { time.Local = oldLocal })
+
+	db := openLegacyDB(t)
+
+	// 旧版删除只是 status = 0，update_time 为很久以前的 UTC 时间
+	for _, stmt := range []string{
+		`INSERT INTO subjects (id, name, status, creator_code, update_time) VALUES (1, 'old', 0, 'u1', '2020-01-01 00:00:00')`,
+		`INSERT INTO subjects (id, name, status, creator_code, update_time) VALUES (2, 'live', 1, 'u1', '2020-01-01 00:00:00')`,
+		`INSERT INTO knowledge_categories (id, subject_id, categorie_name) VALUES (1, 1, 'c')`,
+		`INSERT INTO knowledge_points (id, categorie_id, title) VALUES (1, 1, 'p')`,
+		`INSERT INTO questions (id, knowledge_point_id, question_text, correct_answer) VALUES (1, 1, 'q', 1)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("seed %q: %v", stmt, err)
+		}
+	}
+
+	before := time.Now().Add(-time.Second)
+	maintainingDatabaseTables(db)
+	after := time.Now().Add(time.Second)
+
+	var deletedAt sql.NullString
+	var batch string
+	if err := db.QueryRow("SELECT strftime('%Y-%m-%d %H:%M:%S', deleted_at), delete_batch FROM subjects WHERE id = 1").Scan(&deletedAt, &batch); err != nil {
+		t.Fatalf("load subject: %v", err)
+	}
+	if batch != "legacy-1" {
+		t.Errorf("delete_batch = %q, want legacy-1", batch)
+	}
+	// deleted_at 按 UTC 存储，删除时间必须是迁移时刻而非旧的 update_time
+	got, err := time.ParseInLocation("2006-01-02 15:04:05", deletedAt.String, time.UTC)
+	if err != nil {
+		t.Fatalf("parse deleted_at %q: %v", deletedAt.String, err)
+	}
+	if got.Before(before.Truncate(time.Second)) || got.After(after) {
+		t.Errorf("deleted_at = %s, want migration time between %s and %s", got, before, after)
+	}
+
+	for _, table := range []string{"knowledge_categories", "knowledge_points", "questions"} {
+		var childAt sql.NullString
+		var childBatch sql.NullString
+		if err := db.QueryRow("SELECT strftime('%Y-%m-%d %H:%M:%S', deleted_at), delete_batch FROM "+table+" WHERE id = 1").Scan(&childAt, &childBatch); err != nil {
+			t.Fatalf("load %s: %v", table, err)
+		}
+		if childAt.String != deletedAt.String || childBatch.String != "legacy-1" {
+			t.Errorf("%s: deleted_at=%q batch=%q, want %q legacy-1", table, childAt.String, childBatch.String, deletedAt.String)
+		}
+	}
+
+	var liveDeleted sql.NullString
+	db.QueryRow("SELECT deleted_at FROM subjects WHERE id = 2").Scan(&liveDeleted)
+	if liveDeleted.Valid {
+		t.Errorf("active subject was moved to trash: %q", liveDeleted.String)
+	}
+}
+
+func TestDeletedAtMigratedToUTC(t *testing.T) {
+	// 模拟东八区服务器，旧版本按本地时间写入 deleted_at
+	oldLocal := time.Local
+	time.Local = time.FixedZone("UTC+8", 8*3600)
+	t.Cleanup(func() { time.Local = oldLocal })
+
+	db := openLegacyDB(t)
+	maintainingDatabaseTables(db)
+
+	// 已有回收站字段的数据库：本次启动新加字段的情况已按 UTC 写入，这里重新模拟一次升级
+	for _, stmt := range []string{
+		`DELETE FROM schema_migrations WHERE name = 'utc_deleted_at'`,
+		`INSERT INTO subjects (id, name, status, creator_code, deleted_at, delete_batch) VALUES (1, 's', 0, 'u1', '2026-03-01 08:00:00', 'subject-1-1')`,
+		`INSERT INTO knowledge_categories (id, subject_id, categorie_name, deleted_at, delete_batch) VALUES (1, 1, 'c', '2026-03-01 08:00:00', 'subject-1-1')`,
+		`INSERT INTO subjects (id, name, status, creator_code) VALUES (2, 'live', 1, 'u1')`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("seed %q: %v", stmt, err)
+		}
+	}
+	maintainingDatabaseTables(db)
+
+	for _, table := range []string{"subjects", "knowledge_categories"} {
+		var got sql.NullString
+		db.QueryRow("SELECT strftime('%Y-%m-%d %H:%M:%S', deleted_at) FROM " + table + " WHERE id = 1").Scan(&got)
+		if got.String != "2026-03-01 00:00:00" {
+			t.Errorf("%s.deleted_at = %q, want 2026-03-01 00:00:00 (UTC)", table, got.String)
+		}
+	}
+	var live sql.NullString
+	db.QueryRow("SELECT deleted_at FROM subjects WHERE id = 2").Scan(&live)
+	if live.Valid {
+		t.Errorf("active subject got deleted_at %q", live.String)
+	}
+
+	// 只转换一次
+	maintainingDatabaseTables(db)
+	var again string
+	db.QueryRow("SELECT strftime('%Y-%m-%d %H:%M:%S', deleted_at) FROM subjects WHERE id = 1").Scan(&again)
+	if again != "2026-03-01 00:00:00" {
+		t.Errorf("deleted_at converted twice: %q", again)
+	}
+}
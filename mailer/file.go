@@ -0,0 +1,31 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync/atomic"
+	"time"
+)
+
+// FileSender 把邮件写成 .eml 文件 (开发环境代替 SMTP，可直接用邮件客户端打开)
+type FileSender struct {
+	Dir  string
+	From string // 写入文件的发件地址
+}
+
+var fileSeq uint64
+
+// 文件名中只保留安全字符
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9@._-]`)
+
+// Send 实现 Sender
+func (s FileSender) Send(msg Message) error {
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		return fmt.Errorf("创建邮件目录失败: %w", err)
+	}
+	name := fmt.Sprintf("%s_%04d_%s.eml",
+		time.Now().Format("20060102_150405"), atomic.AddUint64(&fileSeq, 1)%10000, unsafeFileChars.ReplaceAllString(msg.To, "_"))
+	return os.WriteFile(filepath.Join(s.Dir, name), buildMIME(s.From, "", msg), 0644)
+}
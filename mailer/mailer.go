@@ -0,0 +1,60 @@
+package mailer
+
+import (
+	"practice_problems/global"
+	"sync"
+)
+
+// ==========================================
+// 邮件发送：业务代码只依赖 Sender 接口，具体实现在启动时按配置注册 (见 Init)
+//   - LogSender  只写日志，不真正发送 (默认)
+//   - FileSender 写成 .eml 文件，本地开发代替 SMTP
+//   - SMTPSender 通过 SMTP 服务器发送
+// 业务代码一般调用 Enqueue 写入发件箱，由后台任务发送并重试
+// ==========================================
+
+// Message 一封邮件
+type Message struct {
+	To      string
+	Subject string
+	Body    string // 纯文本正文
+}
+
+// Sender 邮件发送器
+type Sender interface {
+	Send(msg Message) error
+}
+
+var (
+	mu     sync.RWMutex
+	sender Sender = LogSender{}
+)
+
+// SetSender 注册邮件发送器，传 nil 恢复为 LogSender
+func SetSender(s Sender) {
+	mu.Lock()
+	defer mu.Unlock()
+	if s == nil {
+		s = LogSender{}
+	}
+	sender = s
+}
+
+// Send 使用当前注册的发送器发送邮件
+func Send(msg Message) error {
+	mu.RLock()
+	s := sender
+	mu.RUnlock()
+	return s.Send(msg)
+}
+
+// LogSender 只把邮件内容写入日志
+type LogSender struct{}
+
+// Send 实现 Sender
+func (LogSender) Send(msg Message) error {
+	if global.Log != nil {
+		global.GetLog(nil).Infof("[mail] To: %s, Subject: %s\n%s", msg.To, msg.Subject, msg.Body)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package mailer
+
+import (
+	"fmt"
+	"practice_problems/global"
+	"time"
+)
+
+// ==========================================
+// 发件箱：业务代码只负责把邮件写入 mail_outbox，由后台任务异步发送
+// 发送失败按指数退避重试 (1 分钟起，最长 1 小时)，达到 global.MailMaxAttempts 次后标记为失败
+// ==========================================
+
+// 发件箱状态
+const (
+	OutboxPending = 0 // 待发送 (含等待重试)
+	OutboxSent    = 1 // 已发送
+	OutboxFailed  = 2 // 重试次数用尽
+)
+
+// 退避时间范围
+const (
+	retryBaseDelay = time.Minute
+	retryMaxDelay  = time.Hour
+)
+
+// outboxBatchSize 每轮最多处理的邮件数
+const outboxBatchSize = 50
+
+// wakeCh 有新邮件入队时唤醒后台任务，不必等到下一个扫描周期
+var wakeCh = make(chan struct{}, 1)
+
+// Init 按配置注册发送器并启动发件箱任务 (需在数据库初始化之后调用)
+func Init() {
+	switch global.MailSender {
+	case "smtp":
+		SetSender(SMTPSender{
+			Host:     global.MailSMTPHost,
+			Port:     global.MailSMTPPort,
+			Username: global.MailSMTPUsername,
+			Password: global.MailSMTPPassword,
+			From:     global.MailFrom,
+			FromName: global.MailSiteName,
+			Security: global.MailSMTPSecurity,
+		})
+	case "file":
+		SetSender(FileSender{Dir: global.MailFileDir, From: global.MailFrom})
+	default:
+		SetSender(nil)
+	}
+	global.GetLog(nil).Infof("邮件发送方式: %s", global.MailSender)
+	startOutboxWorker()
+}
+
+// Enqueue 渲染模板并写入发件箱
+func Enqueue(to, tmpl string, data map[string]interface{}) error {
+	subject, body, err := Render(tmpl, data)
+	if err != nil {
+		return err
+	}
+	if _, err := global.DB.Exec(`INSERT INTO mail_outbox (to_addr, template, subject, body) VALUES (?, ?, ?, ?)`,
+		to, tmpl, subject, body); err != nil {
+		return fmt.Errorf("写入发件箱失败: %w", err)
+	}
+	select {
+	case wakeCh <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// startOutboxWorker 启动发件箱任务
+func startOutboxWorker() {
+	go func() {
+		ticker := time.NewTicker(time.Duration(global.MailOutboxIntervalSeconds) * time.Second)
+		defer ticker.Stop()
+		for {
+			ProcessOutbox()
+			select {
+			case <-ticker.C:
+			case <-wakeCh:
+			}
+		}
+	}()
+}
+
+// outboxItem 一封待发送的邮件
+type outboxItem struct {
+	id       int
+	attempts int
+	msg      Message
+}
+
+// ProcessOutbox 发送到期的待发邮件，返回发送成功的数量
+func ProcessOutbox() int {
+	rows, err := global.DB.Query(`
+		SELECT id, to_addr, subject, body, attempts FROM mail_outbox
+		WHERE status = ? AND next_attempt_at <= datetime('now')
+		ORDER BY id LIMIT ?`, OutboxPending, outboxBatchSize)
+	if err != nil {
+		global.GetLog(nil).Errorf("查询发件箱失败: %v", err)
+		return 0
+	}
+	var items []outboxItem
+	for rows.Next() {
+		var it outboxItem
+		if err := rows.Scan(&it.id, &it.msg.To, &it.msg.Subject, &it.msg.Body, &it.attempts); err == nil {
+			items = append(items, it)
+		}
+	}
+	rows.Close()
+
+	sent := 0
+	for _, it := range items {
+		if err := Send(it.msg); err != nil {
+			markOutboxFailure(it, err)
+			continue
+		}
+		if _, err := global.DB.Exec(`UPDATE mail_outbox SET status = ?, attempts = attempts + 1, last_error = NULL,
+			sent_time = CURRENT_TIMESTAMP WHERE id = ?`, OutboxSent, it.id); err != nil {
+			global.GetLog(nil).Errorf("更新发件箱状态失败 (ID: %d): %v", it.id, err)
+		}
+		sent++
+	}
+	if sent > 0 {
+		global.GetLog(nil).Infof("发件箱: 本轮发送邮件 %d 封", sent)
+	}
+	return sent
+}
+
+// markOutboxFailure 记录发送失败，未达到上限时安排重试
+func markOutboxFailure(it outboxItem, sendErr error) {
+	attempts := it.attempts + 1
+	if attempts >= global.MailMaxAttempts {
+		global.GetLog(nil).Errorf("邮件发送失败，已放弃 (ID: %d, To: %s, 次数: %d): %v", it.id, it.msg.To, attempts, sendErr)
+		global.DB.Exec(`UPDATE mail_outbox SET status = ?, attempts = ?, last_error = ? WHERE id = ?`,
+			OutboxFailed, attempts, sendErr.Error(), it.id)
+		return
+	}
+
+	delay := retryBaseDelay << (attempts - 1)
+	if delay > retryMaxDelay {
+		delay = retryMaxDelay
+	}
+	global.GetLog(nil).Warnf("邮件发送失败，%v 后重试 (ID: %d, To: %s, 次数: %d): %v", delay, it.id, it.msg.To, attempts, sendErr)
+	global.DB.Exec(`UPDATE mail_outbox SET attempts = ?, last_error = ?, next_attempt_at = datetime('now', ?) WHERE id = ?`,
+		attempts, sendErr.Error(), fmt.Sprintf("+%d seconds", int(delay.Seconds())), it.id)
+}
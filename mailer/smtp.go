@@ -0,0 +1,125 @@
+package mailer
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTP 连接加密方式
+const (
+	SMTPSecuritySSL      = "ssl"      // 隐式 TLS (一般为 465 端口)
+	SMTPSecuritySTARTTLS = "starttls" // 明文连接后升级 (一般为 587 端口)
+	SMTPSecurityNone     = "none"     // 不加密 (本地调试用的 SMTP 服务)
+)
+
+// SMTPSender 通过 SMTP 服务器发送邮件
+type SMTPSender struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string // 发件地址，为空时使用 Username
+	FromName string // 发件人显示名
+	Security string // ssl / starttls / none
+	Timeout  time.Duration
+}
+
+// Send 实现 Sender
+func (s SMTPSender) Send(msg Message) error {
+	from := s.From
+	if from == "" {
+		from = s.Username
+	}
+	if _, err := mail.ParseAddress(msg.To); err != nil {
+		return fmt.Errorf("收件地址不合法: %w", err)
+	}
+
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = 15 * time.Second
+	}
+	addr := net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+	tlsConfig := &tls.Config{ServerName: s.Host}
+
+	var conn net.Conn
+	var err error
+	if s.Security == SMTPSecuritySSL {
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: timeout}, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = net.DialTimeout("tcp", addr, timeout)
+	}
+	if err != nil {
+		return fmt.Errorf("连接 SMTP 服务器失败: %w", err)
+	}
+	conn.SetDeadline(time.Now().Add(timeout * 2))
+
+	client, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("SMTP 握手失败: %w", err)
+	}
+	defer client.Close()
+
+	if s.Security == SMTPSecuritySTARTTLS {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("STARTTLS 失败: %w", err)
+		}
+	}
+	if s.Username != "" {
+		if ok, _ := client.Extension("AUTH"); ok {
+			if err := client.Auth(smtp.PlainAuth("", s.Username, s.Password, s.Host)); err != nil {
+				return fmt.Errorf("SMTP 认证失败: %w", err)
+			}
+		}
+	}
+
+	if err := client.Mail(from); err != nil {
+		return fmt.Errorf("MAIL FROM 失败: %w", err)
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return fmt.Errorf("RCPT TO 失败: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("DATA 失败: %w", err)
+	}
+	if _, err := w.Write(buildMIME(from, s.FromName, msg)); err != nil {
+		w.Close()
+		return fmt.Errorf("写入邮件内容失败: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("提交邮件失败: %w", err)
+	}
+	return client.Quit()
+}
+
+// buildMIME 生成纯文本邮件 (UTF-8，正文 base64 编码)
+func buildMIME(from, fromName string, msg Message) []byte {
+	var buf bytes.Buffer
+	fromHeader := (&mail.Address{Name: fromName, Address: from}).String()
+
+	fmt.Fprintf(&buf, "From: %s\r\n", fromHeader)
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(strings.ReplaceAll(msg.Body, "\n", "\r\n")))
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded + "\r\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,70 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	"practice_problems/global"
+	"strings"
+	"text/template"
+)
+
+// ==========================================
+// 邮件模板：每个模板由 "<name>.subject" 与 "<name>.body" 两部分组成
+// 渲染时自动注入 SiteName，正文为纯文本
+// ==========================================
+
+// 邮件模板名
+const (
+	TemplateVerifyEmail   = "verify_email"   // 邮箱验证 (Link, ExpireHours)
+	TemplatePasswordReset = "password_reset" // 密码重置 (Link, ExpireMinutes)
+	TemplateNotification  = "notification"   // 站内通知的邮件副本 (Title, Content, Link)
+)
+
+var templates = template.Must(template.New("mail").Parse(`
+{{define "verify_email.subject"}}【{{.SiteName}}】请验证您的邮箱{{end}}
+{{define "verify_email.body"}}{{.Name}}，您好：
+
+请点击下面的链接完成邮箱验证：
+{{.Link}}
+
+链接 {{.ExpireHours}} 小时内有效。如果这不是您本人的操作，请忽略本邮件。
+
+—— {{.SiteName}}
+{{end}}
+
+{{define "password_reset.subject"}}【{{.SiteName}}】重置密码{{end}}
+{{define "password_reset.body"}}{{.Name}}，您好：
+
+我们收到了重置您账号密码的请求，请点击下面的链接设置新密码：
+{{.Link}}
+
+链接 {{.ExpireMinutes}} 分钟内有效，且只能使用一次。如果这不是您本人的操作，请忽略本邮件，您的密码不会改变。
+
+—— {{.SiteName}}
+{{end}}
+
+{{define "notification.subject"}}【{{.SiteName}}】{{.Title}}{{end}}
+{{define "notification.body"}}{{.Content}}
+{{if .Link}}
+查看详情：{{.Link}}
+{{end}}
+—— {{.SiteName}}
+{{end}}
+`))
+
+// Render 渲染模板，返回标题与正文
+func Render(name string, data map[string]interface{}) (subject, body string, err error) {
+	vars := map[string]interface{}{"SiteName": global.MailSiteName}
+	for k, v := range data {
+		vars[k] = v
+	}
+
+	var sb, bb bytes.Buffer
+	if err := templates.ExecuteTemplate(&sb, name+".subject", vars); err != nil {
+		return "", "", fmt.Errorf("渲染邮件标题失败 (%s): %w", name, err)
+	}
+	if err := templates.ExecuteTemplate(&bb, name+".body", vars); err != nil {
+		return "", "", fmt.Errorf("渲染邮件正文失败 (%s): %w", name, err)
+	}
+	return strings.TrimSpace(sb.String()), strings.TrimSpace(bb.String()) + "\n", nil
+}
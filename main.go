@@ -3,10 +3,13 @@ package main
 import (
 	"fmt"
 	"log"
+	"practice_problems/api"
 	"practice_problems/deepseek"
 	"practice_problems/global"
 	"practice_problems/initialize"
+	"practice_problems/mailer"
 	"practice_problems/router"
+	"strings"
 
 	"github.com/spf13/viper"
 )
@@ -20,6 +23,12 @@ func main() {
 	initialize.InitSQLite()
 	defer global.DB.Close() // 程序结束时关闭数据库
 	deepseek.Init(global.DeepseekApiKey)
+	// 回收站超期清理
+	api.StartTrashPurger()
+	// 邮件发送 (发件箱后台任务)
+	mailer.Init()
+	// 授权到期提醒
+	api.StartExpiryReminder()
 	// 4. 初始化路由
 	r := router.InitRouter()
 
@@ -53,6 +62,95 @@ func loadConfig() {
 	global.OssInternalEndpoint = v.GetString("aliyun.internal_endpoint")
 	global.DeepseekApiKey = v.GetString("deepseek.api_key")
 	global.VoiceAppKey = v.GetString("aliyun.voice_app_key")
+	if n := v.GetInt("ai.max_concurrent_sessions"); n > 0 {
+		global.AIMaxConcurrentSessions = n
+	}
+	if n := v.GetInt64("ai.explain_cost_seconds"); n > 0 {
+		global.AIExplainCostSeconds = n
+	}
+	if n := v.GetInt("trash.retention_days"); n > 0 {
+		global.TrashRetentionDays = n
+	}
+	if n := v.GetInt("share.code_length"); n >= 8 && n <= 32 {
+		global.ShareCodeLength = n
+	}
+	if n := v.GetInt("share.bind_rate_per_minute"); n > 0 {
+		global.ShareBindRatePerMinute = n
+	}
+	if n := v.GetInt("share.bind_max_failures"); n > 0 {
+		global.ShareBindMaxFailures = n
+	}
+	if n := v.GetInt("share.bind_failure_window_minutes"); n > 0 {
+		global.ShareBindFailureWindow = n
+	}
+	if n := v.GetInt("share.bind_lock_minutes"); n > 0 {
+		global.ShareBindLockMinutes = n
+	}
+	if v.IsSet("reminder.enabled") {
+		global.ExpiryReminderEnabled = v.GetBool("reminder.enabled")
+	}
+	if n := v.GetInt("reminder.window_hours"); n > 0 {
+		global.ExpiryReminderWindowHours = n
+	}
+	if n := v.GetInt("reminder.interval_minutes"); n > 0 {
+		global.ExpiryReminderIntervalMinutes = n
+	}
+	if v.IsSet("reminder.email") {
+		global.ExpiryReminderEmail = v.GetBool("reminder.email")
+	}
+	if n := v.GetInt("push.heartbeat_seconds"); n > 0 {
+		global.PushHeartbeatSeconds = n
+	}
+	if n := v.GetInt("push.replay_buffer_size"); n > 0 {
+		global.PushReplayBufferSize = n
+	}
+	if n := v.GetInt("push.max_subjects"); n > 0 {
+		global.PushMaxSubjects = n
+	}
+	if s := v.GetString("mail.sender"); s != "" {
+		global.MailSender = s
+	}
+	if s := v.GetString("mail.file_dir"); s != "" {
+		global.MailFileDir = s
+	}
+	global.MailSMTPHost = v.GetString("mail.smtp.host")
+	if n := v.GetInt("mail.smtp.port"); n > 0 {
+		global.MailSMTPPort = n
+	}
+	global.MailSMTPUsername = v.GetString("mail.smtp.username")
+	global.MailSMTPPassword = v.GetString("mail.smtp.password")
+	if s := v.GetString("mail.smtp.security"); s != "" {
+		global.MailSMTPSecurity = s
+	}
+	if s := v.GetString("mail.from"); s != "" {
+		global.MailFrom = s
+	}
+	if s := v.GetString("mail.site_name"); s != "" {
+		global.MailSiteName = s
+	}
+	if n := v.GetInt("mail.max_attempts"); n > 0 {
+		global.MailMaxAttempts = n
+	}
+	if n := v.GetInt("mail.outbox_interval_seconds"); n > 0 {
+		global.MailOutboxIntervalSeconds = n
+	}
+	if s := v.GetString("site.base_url"); s != "" {
+		global.SiteBaseURL = strings.TrimRight(s, "/")
+	}
+	if n := v.GetInt("mail.verify_expire_hours"); n > 0 {
+		global.EmailVerifyExpireHours = n
+	}
+	if n := v.GetInt("password_reset.token_minutes"); n > 0 {
+		global.PasswordResetTokenMinutes = n
+	}
+	if n := v.GetInt("password_reset.account_per_hour"); n > 0 {
+		global.PasswordResetAccountPerHour = n
+	}
+	if n := v.GetInt("password_reset.ip_per_hour"); n > 0 {
+		global.PasswordResetIPPerHour = n
+	}
+	global.TrustedProxies = v.GetStringSlice("server.trusted_proxies")
+	global.RequireEditVersion = v.GetBool("server.require_edit_version")
 
 	// 如果配置了 OSS，打印日志
 	if global.OssEndpoint != "" {
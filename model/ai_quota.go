@@ -0,0 +1,21 @@
+package model
+
+// AIQuotaChangeRequest 管理员发放/扣回 AI 面试时长请求
+type AIQuotaChangeRequest struct {
+	UserID  int    `json:"userId" binding:"required"`
+	Seconds int64  `json:"seconds" binding:"required,gt=0"` // 变动秒数 (正数)
+	Remark  string `json:"remark"`
+}
+
+// AIQuotaLedgerEntry AI 时长流水
+type AIQuotaLedgerEntry struct {
+	ID            int    `json:"id"`
+	UserID        int    `json:"userId"`
+	ChangeSeconds int64  `json:"changeSeconds"` // 正数为增加，负数为扣减
+	BalanceAfter  int64  `json:"balanceAfter"`  // 变动后余额
+	EntryType     string `json:"entryType"`     // grant / consume / revoke / adjust / refund
+	SessionID     *int64 `json:"sessionId"`     // 关联的面试会话 (consume 时有值)
+	OperatorID    *int   `json:"operatorId"`    // 操作的管理员 (grant / revoke 时有值)
+	Remark        string `json:"remark"`
+	CreateTime    string `json:"createTime"`
+}
@@ -0,0 +1,55 @@
+package model
+
+// 科目目录上架状态
+const (
+	ListingUnpublished = 0
+	ListingPublished   = 1
+)
+
+// 当前用户对目录中科目的访问状态
+const (
+	CatalogAccessNone    = "none"    // 未获得授权，可申请
+	CatalogAccessPending = "pending" // 已提交申请，等待审核
+	CatalogAccessOwned   = "owned"   // 已拥有 (作者或授权有效)
+)
+
+// SubjectListing 目录中的科目
+type SubjectListing struct {
+	SubjectID       int           `json:"subjectId"`
+	SubjectName     string        `json:"subjectName"`
+	Title           string        `json:"title"`
+	Description     string        `json:"description"`
+	Cover           string        `json:"cover"`
+	Tags            []string      `json:"tags"`
+	CreatorName     string        `json:"creatorName"`
+	PointCount      int           `json:"pointCount"`
+	QuestionCount   int           `json:"questionCount"`
+	Redemptions     int           `json:"redemptions"`     // 通过分享码获得该科目的人数
+	Duration        string        `json:"duration"`        // 申请通过后的授权时长 (分享码的资源有效期)
+	RequireApproval bool          `json:"requireApproval"` // 申请是否需要作者审核
+	Full            bool          `json:"full"`            // 分享码名额已满，暂不可申请
+	Access          string        `json:"access"`          // none / pending / owned
+	Rating          RatingSummary `json:"rating"`
+	PublishTime     UTCTime       `json:"publishTime"`
+	UpdateTime      UTCTime       `json:"updateTime"`
+
+	SamplePoints []CatalogSamplePoint `json:"samplePoints,omitempty"` // 仅详情返回
+}
+
+// CatalogSamplePoint 目录详情中的试看知识点
+type CatalogSamplePoint struct {
+	ID       int    `json:"id"`
+	Title    string `json:"title"`
+	Category string `json:"category"`
+	Summary  string `json:"summary"`
+}
+
+// SaveSubjectListingRequest 发布 / 修改科目目录信息
+type SaveSubjectListingRequest struct {
+	Title          string   `json:"title" binding:"required"`
+	Description    string   `json:"description"`
+	Cover          string   `json:"cover"`
+	Tags           []string `json:"tags"`
+	SamplePointIDs []int    `json:"samplePointIds"`
+	ShareCode      string   `json:"shareCode" binding:"required"` // 申请时使用的分享码，只能包含该科目
+}
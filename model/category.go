@@ -4,12 +4,14 @@ package model
 type KnowledgeCategory struct {
 	ID           int    `json:"id"`
 	SubjectID    int    `json:"subjectId"`    // 关联的科目ID
+	ParentID     *int   `json:"parentId"`     // 上级分类ID，顶级分类为 null
 	CategoryName string `json:"categoryName"` // 对应数据库 categorie_name
 	CreateTime   string `json:"createTime"`
 	UpdateTime   string `json:"updateTime"`
 	SortOrder    int    `json:"sortOrder"`  // 新增：排序字段
 	Difficulty   int    `json:"difficulty"` // 新增：难度 (0-简单, 1-普通, 2-困难, 3-地狱)
 	CreatorCode  string `json:"creatorCode"`
+	Version      int    `json:"version"` // 乐观锁版本号，修改时需回传
 }
 
 // CreateCategoryRequest 创建分类时的参数
@@ -17,10 +19,23 @@ type CreateCategoryRequest struct {
 	SubjectID    int    `json:"subjectId" binding:"required"`    // 必须指定属于哪个科目
 	CategoryName string `json:"categoryName" binding:"required"` // 分类名称必填
 	Difficulty   int    `json:"difficulty"`                      // 难度：0-简单，1-中等，2-困难，3-重点
+	ParentID     *int   `json:"parentId"`                        // 上级分类ID，不传为顶级分类
 }
 
 // UpdateCategoryRequest 更新分类时的参数
 type UpdateCategoryRequest struct {
 	CategoryName string `json:"categoryName" binding:"required"`
 	Difficulty   *int   `json:"difficulty"`
+	Version      *int   `json:"version"` // 读取时的版本号 (也可通过 If-Match 头提交)
+}
+
+// CategoryTreeNode 分类树节点 (带子树统计)
+type CategoryTreeNode struct {
+	KnowledgeCategory
+	Depth                int                 `json:"depth"`                // 层级，顶级为 1
+	PointCount           int                 `json:"pointCount"`           // 本分类直属知识点数
+	QuestionCount        int                 `json:"questionCount"`        // 本分类直属题目数
+	SubtreePointCount    int                 `json:"subtreePointCount"`    // 含全部子分类的知识点数
+	SubtreeQuestionCount int                 `json:"subtreeQuestionCount"` // 含全部子分类的题目数
+	Children             []*CategoryTreeNode `json:"children"`
 }
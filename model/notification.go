@@ -0,0 +1,83 @@
+package model
+
+// 通知类型
+const (
+	NotifySubjectExpiring    = "subject_expiring"    // 科目授权即将到期
+	NotifySubjectGrace       = "subject_grace"       // 科目授权已到期，进入只读宽限期
+	NotifyCollectionExpiring = "collection_expiring" // 集合授权即将到期
+	NotifyShareReceived      = "share_received"      // 收到定向分享的科目
+	NotifyShareBound         = "share_bound"         // 我的分享码被绑定
+	NotifyShareRequest       = "share_request"       // 我的分享码收到待审核申请
+	NotifyShareReviewed      = "share_reviewed"      // 我的分享码申请已审核
+	NotifyCollectionGranted  = "collection_granted"  // 获得集合授权
+	NotifySubjectUpdated     = "subject_updated"     // 已订阅的科目信息变更
+	NotifySubjectReviewed    = "subject_reviewed"    // 我的科目收到新评价
+	NotifyReviewReplied      = "review_replied"      // 我的评价收到作者回复
+)
+
+// NotificationType 通知类型说明及默认偏好
+type NotificationType struct {
+	Type         string
+	Name         string
+	EmailCapable bool // 是否支持邮件通知
+	DefaultEmail bool // 未设置偏好时是否发邮件
+}
+
+// NotificationTypes 所有通知类型 (偏好设置按此顺序展示)
+var NotificationTypes = []NotificationType{
+	{Type: NotifySubjectExpiring, Name: "科目授权即将到期", EmailCapable: true, DefaultEmail: true},
+	{Type: NotifySubjectGrace, Name: "科目授权进入宽限期", EmailCapable: true, DefaultEmail: true},
+	{Type: NotifyCollectionExpiring, Name: "集合授权即将到期", EmailCapable: true, DefaultEmail: true},
+	{Type: NotifyShareReceived, Name: "收到科目分享"},
+	{Type: NotifyShareBound, Name: "分享码被绑定"},
+	{Type: NotifyShareRequest, Name: "分享码待审核申请"},
+	{Type: NotifyShareReviewed, Name: "分享码申请审核结果"},
+	{Type: NotifyCollectionGranted, Name: "获得集合授权"},
+	{Type: NotifySubjectUpdated, Name: "订阅科目变更"},
+	{Type: NotifySubjectReviewed, Name: "科目收到评价"},
+	{Type: NotifyReviewReplied, Name: "评价收到回复"},
+}
+
+// FindNotificationType 按类型查找，不存在返回 false
+func FindNotificationType(t string) (NotificationType, bool) {
+	for _, nt := range NotificationTypes {
+		if nt.Type == t {
+			return nt, true
+		}
+	}
+	return NotificationType{}, false
+}
+
+// Notification 站内通知
+type Notification struct {
+	ID         int     `json:"id"`
+	Type       string  `json:"type"`
+	Title      string  `json:"title"`
+	Content    string  `json:"content"`
+	Link       string  `json:"link"`
+	IsRead     bool    `json:"isRead"`
+	CreateTime UTCTime `json:"createTime"`
+}
+
+// NotificationPreference 某类通知的偏好
+type NotificationPreference struct {
+	Type         string `json:"type"`
+	Name         string `json:"name"`
+	InApp        bool   `json:"inApp"`
+	Email        bool   `json:"email"`
+	EmailCapable bool   `json:"emailCapable"`
+}
+
+// UpdateNotificationPreferencesRequest 批量修改通知偏好 (未传的字段保持不变)
+type UpdateNotificationPreferencesRequest struct {
+	Items []struct {
+		Type  string `json:"type" binding:"required"`
+		InApp *bool  `json:"inApp"`
+		Email *bool  `json:"email"`
+	} `json:"items" binding:"required,min=1,dive"`
+}
+
+// UpdateGracePeriodRequest 设置科目到期宽限期
+type UpdateGracePeriodRequest struct {
+	GraceDays int `json:"graceDays" binding:"min=0,max=30"`
+}
@@ -34,4 +34,6 @@ type UpdatePointRequest struct {
 	Difficulty *int `json:"difficulty"`
 	// ★★★ 新增 ★★★
 	CategoryID *int `json:"categoryId"`
+	// 乐观锁：读取时的版本号 (也可通过 If-Match 头提交)
+	Version *int `json:"version"`
 }
@@ -1,15 +1,26 @@
 package model
 
+// 绑定状态
+const (
+	BindingStatusNormal = 0 // 正常
+	BindingStatusBroken = 1 // 失效：内容修改后无法重新定位，等待作者修复
+)
+
 // PointBinding 知识点绑定关系
 type PointBinding struct {
-	ID              int    `json:"id"`
-	SourceSubjectID int    `json:"sourceSubjectId"` // 源科目ID
-	SourcePointID   int    `json:"sourcePointId"`   // 源知识点ID
-	TargetSubjectID int    `json:"targetSubjectId"` // 目标科目ID
-	TargetPointID   int    `json:"targetPointId"`   // 目标知识点ID
-	BindText        string `json:"bindText"`        // 绑定的文字（选中的文字）
-	UserID          int    `json:"userId"`          // 创建者ID
-	CreateTime      string `json:"createTime"`
+	ID              int     `json:"id"`
+	SourceSubjectID int     `json:"sourceSubjectId"` // 源科目ID
+	SourcePointID   int     `json:"sourcePointId"`   // 源知识点ID
+	TargetSubjectID int     `json:"targetSubjectId"` // 目标科目ID
+	TargetPointID   int     `json:"targetPointId"`   // 目标知识点ID
+	BindText        string  `json:"bindText"`        // 绑定的文字（选中的文字）
+	UserID          int     `json:"userId"`          // 创建者ID
+	CreateTime      string  `json:"createTime"`
+	Occurrence      int     `json:"occurrence"`   // bind_text 在内容中的第几次出现 (从 0 开始)
+	AnchorPrefix    string  `json:"anchorPrefix"` // 锚点前文
+	AnchorSuffix    string  `json:"anchorSuffix"` // 锚点后文
+	Status          int     `json:"status"`       // 0: 正常, 1: 失效
+	BrokenTime      *string `json:"brokenTime"`
 }
 
 // CreateBindingRequest 创建绑定请求
@@ -19,6 +30,32 @@ type CreateBindingRequest struct {
 	TargetSubjectID int    `json:"targetSubjectId" binding:"required"`
 	TargetPointID   int    `json:"targetPointId" binding:"required"`
 	BindText        string `json:"bindText" binding:"required"`
+	// 以下可选：选中文字重复出现时用于区分是哪一处
+	Occurrence   *int   `json:"occurrence"`   // 第几次出现 (从 0 开始)
+	AnchorPrefix string `json:"anchorPrefix"` // 选区前文
+	AnchorSuffix string `json:"anchorSuffix"` // 选区后文
+}
+
+// RepairBindingRequest 修复失效绑定 (重新指定锚定文字)
+type RepairBindingRequest struct {
+	BindText   string `json:"bindText" binding:"required"`
+	Occurrence int    `json:"occurrence"`
+}
+
+// BrokenBinding 失效绑定报告条目
+type BrokenBinding struct {
+	ID               int     `json:"id"`
+	SourcePointID    int     `json:"sourcePointId"`
+	SourcePointTitle string  `json:"sourcePointTitle"`
+	SourceCategoryID int     `json:"sourceCategoryId"`
+	SourceCategory   string  `json:"sourceCategory"`
+	TargetSubjectID  int     `json:"targetSubjectId"`
+	TargetPointID    int     `json:"targetPointId"`
+	TargetPointTitle string  `json:"targetPointTitle"`
+	BindText         string  `json:"bindText"`
+	AnchorPrefix     string  `json:"anchorPrefix"`
+	AnchorSuffix     string  `json:"anchorSuffix"`
+	BrokenTime       *string `json:"brokenTime"`
 }
 
 // BindingWithDetails 带详情的绑定信息（用于查询返回）
@@ -0,0 +1,66 @@
+package model
+
+// 悬空绑定原因
+const (
+	DanglingReasonDeleted = "deleted" // 目标知识点 (或其分类/科目) 已进入回收站，恢复后绑定自动可用
+	DanglingReasonMissing = "missing" // 目标知识点已被彻底删除
+)
+
+// PointBacklink 反向链接：哪些知识点绑定到了当前知识点
+type PointBacklink struct {
+	BindingID          int    `json:"bindingId"`
+	SourcePointID      int    `json:"sourcePointId"`
+	SourcePointTitle   string `json:"sourcePointTitle"`
+	SourceCategoryID   int    `json:"sourceCategoryId"`
+	SourceCategoryName string `json:"sourceCategoryName"`
+	SourceSubjectID    int    `json:"sourceSubjectId"`
+	SourceSubjectName  string `json:"sourceSubjectName"`
+	BindText           string `json:"bindText"`
+	Status             int    `json:"status"`
+	CreateTime         string `json:"createTime"`
+}
+
+// GraphNode 知识图谱节点 (知识点)
+type GraphNode struct {
+	ID           int    `json:"id"`
+	Title        string `json:"title"`
+	CategoryID   int    `json:"categoryId"`
+	CategoryName string `json:"categoryName"`
+	SubjectID    int    `json:"subjectId"`
+	SubjectName  string `json:"subjectName"`
+	External     bool   `json:"external"` // 不属于查询的科目 (跨科目绑定的另一端)
+	Depth        int    `json:"depth"`    // 距中心知识点的跳数，科目图谱中恒为 0
+}
+
+// GraphEdge 知识图谱边 (绑定)
+type GraphEdge struct {
+	ID       int    `json:"id"`
+	Source   int    `json:"source"`
+	Target   int    `json:"target"`
+	BindText string `json:"bindText"`
+	Status   int    `json:"status"`
+}
+
+// KnowledgeGraph 图谱查询结果
+type KnowledgeGraph struct {
+	Nodes       []GraphNode `json:"nodes"`
+	Edges       []GraphEdge `json:"edges"`
+	HiddenEdges int         `json:"hiddenEdges"` // 另一端无权访问而被隐藏的绑定数
+	Dangling    int         `json:"dangling"`    // 另一端已删除的绑定数
+	Truncated   bool        `json:"truncated"`   // 节点数超过上限被截断
+}
+
+// DanglingBinding 悬空绑定：目标知识点已删除
+type DanglingBinding struct {
+	ID                 int    `json:"id"`
+	SourcePointID      int    `json:"sourcePointId"`
+	SourcePointTitle   string `json:"sourcePointTitle"`
+	SourceCategoryID   int    `json:"sourceCategoryId"`
+	SourceCategoryName string `json:"sourceCategoryName"`
+	TargetSubjectID    int    `json:"targetSubjectId"`
+	TargetPointID      int    `json:"targetPointId"`
+	TargetPointTitle   string `json:"targetPointTitle"` // 彻底删除时为空
+	BindText           string `json:"bindText"`
+	Reason             string `json:"reason"` // deleted / missing
+	CreateTime         string `json:"createTime"`
+}
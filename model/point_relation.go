@@ -0,0 +1,66 @@
+package model
+
+// 知识点关系类型 (与正文中的文字绑定相互独立)
+const (
+	RelationPrerequisite = "prerequisite" // from 是 to 的前置知识：先学 from 再学 to
+	RelationExtends      = "extends"      // to 是 from 的延伸：同样要求先学 from
+	RelationRelated      = "related"      // 相关知识点，无方向、不参与学习顺序
+)
+
+// 掌握状态
+const (
+	MasteryNoQuestions = "no_questions" // 没有题目，无法通过练习判断，不阻塞后续知识点
+	MasteryNotStarted  = "not_started"
+	MasteryLearning    = "learning"
+	MasteryMastered    = "mastered"
+)
+
+// PointRelation 知识点关系
+type PointRelation struct {
+	ID           int    `json:"id"`
+	FromPointID  int    `json:"fromPointId"`
+	ToPointID    int    `json:"toPointId"`
+	RelationType string `json:"relationType"`
+	Note         string `json:"note"`
+	CreatorCode  string `json:"creatorCode"`
+	CreateTime   string `json:"createTime"`
+}
+
+// PointRelationDetail 知识点关系 (带另一端信息，用于知识点详情)
+type PointRelationDetail struct {
+	PointRelation
+	Direction       string `json:"direction"` // outgoing: 当前知识点是 from, incoming: 当前知识点是 to
+	OtherPointID    int    `json:"otherPointId"`
+	OtherPointTitle string `json:"otherPointTitle"`
+	OtherSubjectID  int    `json:"otherSubjectId"`
+}
+
+// CreatePointRelationRequest 创建知识点关系
+type CreatePointRelationRequest struct {
+	FromPointID  int    `json:"fromPointId" binding:"required"`
+	ToPointID    int    `json:"toPointId" binding:"required"`
+	RelationType string `json:"relationType" binding:"required,oneof=prerequisite extends related"`
+	Note         string `json:"note" binding:"max=200"`
+}
+
+// PointMastery 用户对知识点的掌握情况 (按每道题最近一次作答统计)
+type PointMastery struct {
+	PointID       int     `json:"pointId"`
+	QuestionCount int     `json:"questionCount"`
+	AnsweredCount int     `json:"answeredCount"`
+	CorrectCount  int     `json:"correctCount"`
+	CorrectRate   float64 `json:"correctRate"`
+	Status        string  `json:"status"`
+}
+
+// LearningPathNode 学习路径中的一个知识点
+type LearningPathNode struct {
+	PointID       int    `json:"pointId"`
+	Title         string `json:"title"`
+	CategoryID    int    `json:"categoryId"`
+	CategoryName  string `json:"categoryName"`
+	Level         int    `json:"level"`         // 前置链长度，同一层可并行学习
+	Prerequisites []int  `json:"prerequisites"` // 范围内的前置知识点
+	External      []int  `json:"external"`      // 范围外 (其他分类/科目) 的前置知识点
+	InCycle       bool   `json:"inCycle"`       // 历史数据中存在环，无法排序
+}
@@ -0,0 +1,48 @@
+package model
+
+// 修订动作
+const (
+	RevisionActionInitial  = "initial"  // 首次修改前的原始内容
+	RevisionActionUpdate   = "update"   // 普通保存
+	RevisionActionRollback = "rollback" // 回滚到历史版本
+)
+
+// PointRevision 知识点修订记录 (列表只返回概要)
+type PointRevision struct {
+	ID                  int    `json:"id"`
+	PointID             int    `json:"pointId"`
+	RevisionNo          int    `json:"revisionNo"`
+	Title               string `json:"title"`
+	Action              string `json:"action"`
+	RollbackFrom        *int   `json:"rollbackFrom"`
+	RemovedBindingCount int    `json:"removedBindingCount"` // 本次保存失效的绑定数
+	EditorCode          string `json:"editorCode"`
+	EditorName          string `json:"editorName"`
+	CreateTime          string `json:"createTime"`
+}
+
+// PointRevisionDetail 修订详情 (完整快照)
+type PointRevisionDetail struct {
+	PointRevision
+	Content         string         `json:"content"`
+	ReferenceLinks  string         `json:"referenceLinks"`
+	LocalImageNames string         `json:"localImageNames"`
+	VideoUrl        string         `json:"videoUrl"`
+	Difficulty      int            `json:"difficulty"`
+	RemovedBindings []PointBinding `json:"removedBindings"`
+}
+
+// DiffLine 文本差异行
+type DiffLine struct {
+	Op      string `json:"op"` // equal / add / delete
+	OldLine int    `json:"oldLine,omitempty"`
+	NewLine int    `json:"newLine,omitempty"`
+	Text    string `json:"text"`
+}
+
+// FieldChange 非正文字段的变化
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
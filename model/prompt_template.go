@@ -0,0 +1,51 @@
+package model
+
+// PromptTemplate AI 提示词模板 (同名模板按版本递增保存)
+type PromptTemplate struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Version     int    `json:"version"`
+	Description string `json:"description"`
+	Content     string `json:"content"`
+	IsDefault   int    `json:"isDefault"` // 1: 全局默认模板
+	CreatorCode string `json:"creatorCode"`
+	CreateTime  string `json:"createTime"`
+}
+
+// PromptVars 模板可用的变量 (模板中以 {{.Topic}} 形式引用)
+type PromptVars struct {
+	Topic      string `json:"topic"`      // 题目 / 知识点标题
+	Content    string `json:"content"`    // 知识点正文
+	Difficulty string `json:"difficulty"` // 难度描述：简单 / 中等 / 困难 / 重点
+	Language   string `json:"language"`   // 面试语言，如 zh-CN、en
+}
+
+// CreatePromptTemplateRequest 创建模板请求
+type CreatePromptTemplateRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	Content     string `json:"content" binding:"required"`
+}
+
+// UpdatePromptTemplateRequest 修改模板请求 (生成新版本)
+type UpdatePromptTemplateRequest struct {
+	Description string `json:"description"`
+	Content     string `json:"content" binding:"required"`
+}
+
+// PreviewPromptTemplateRequest 预览渲染请求
+// 传 content 时直接渲染；否则按 name + version 取模板 (version 为 0 取最新版)
+type PreviewPromptTemplateRequest struct {
+	Name    string     `json:"name"`
+	Version int        `json:"version"`
+	Content string     `json:"content"`
+	Vars    PromptVars `json:"vars"`
+}
+
+// SubjectPromptRequest 科目提示词设置
+// TemplateName 为空表示使用全局默认；CustomContent 非空时优先于模板
+type SubjectPromptRequest struct {
+	TemplateName  string `json:"templateName"`
+	CustomContent string `json:"customContent"`
+	Language      string `json:"language"`
+}
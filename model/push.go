@@ -0,0 +1,7 @@
+package model
+
+// UpdatePushSubscriptionsRequest 调整推送连接订阅的科目 (整体替换，传空数组取消全部订阅)
+type UpdatePushSubscriptionsRequest struct {
+	ConnID     string `json:"connId" binding:"required"`
+	SubjectIDs []int  `json:"subjectIds"`
+}
@@ -21,6 +21,7 @@ type Question struct {
 
 	CreateTime string `json:"createTime"`
 	UpdateTime string `json:"updateTime"`
+	Version    int    `json:"version"` // 乐观锁版本号，修改时需回传
 }
 
 // CreateQuestionRequest 创建请求
@@ -56,9 +57,15 @@ type UpdateQuestionRequest struct {
 
 	CorrectAnswer int    `json:"correctAnswer"`
 	Explanation   string `json:"explanation"`
+	Version       *int   `json:"version"` // 读取时的版本号 (也可通过 If-Match 头提交)
 }
 
 type UpdateNoteRequest struct {
 	QuestionID int    `json:"question_id" binding:"required"`
 	Note       string `json:"note"` // 允许为空，为空可能意味着清空备注
 }
+
+// ExplainAnswerRequest AI 错题解析请求
+type ExplainAnswerRequest struct {
+	ChosenOption int `json:"chosenOption" binding:"required,min=1,max=4"` // 学生选择的选项 1-4
+}
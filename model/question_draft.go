@@ -0,0 +1,49 @@
+package model
+
+// 草稿状态
+const (
+	DraftStatusPending   = 0 // 待审核
+	DraftStatusPublished = 1 // 已发布为正式题目
+	DraftStatusDiscarded = 2 // 已丢弃
+)
+
+// QuestionDraft 对应 question_drafts 表 (AI 生成、待作者审核的题目)
+type QuestionDraft struct {
+	ID               int    `json:"id"`
+	KnowledgePointID int    `json:"knowledgePointId"`
+	QuestionText     string `json:"questionText"`
+	Option1          string `json:"option1"`
+	Option2          string `json:"option2"`
+	Option3          string `json:"option3"`
+	Option4          string `json:"option4"`
+	CorrectAnswer    int    `json:"correctAnswer"`
+	Explanation      string `json:"explanation"`
+	Status           int    `json:"status"`     // 0: 待审核, 1: 已发布, 2: 已丢弃
+	QuestionID       *int64 `json:"questionId"` // 发布后对应的正式题目 ID
+	CreateTime       string `json:"createTime"`
+	UpdateTime       string `json:"updateTime"`
+}
+
+// GenerateQuestionsRequest AI 出题请求
+type GenerateQuestionsRequest struct {
+	Count int `json:"count"` // 生成数量，默认 5，最多 10
+}
+
+// GeneratedQuestion 约定的大模型输出结构
+type GeneratedQuestion struct {
+	QuestionText  string   `json:"questionText"`
+	Options       []string `json:"options"`
+	CorrectAnswer int      `json:"correctAnswer"`
+	Explanation   string   `json:"explanation"`
+}
+
+// UpdateQuestionDraftRequest 编辑草稿请求
+type UpdateQuestionDraftRequest struct {
+	QuestionText  string `json:"questionText" binding:"required"`
+	Option1       string `json:"option1"`
+	Option2       string `json:"option2"`
+	Option3       string `json:"option3"`
+	Option4       string `json:"option4"`
+	CorrectAnswer int    `json:"correctAnswer" binding:"required"`
+	Explanation   string `json:"explanation"`
+}
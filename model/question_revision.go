@@ -0,0 +1,73 @@
+package model
+
+// 题目修订动作 (initial / update 与知识点修订共用)
+const (
+	RevisionActionCreate = "create"
+)
+
+// QuestionRevision 题目修订 (完整快照，只追加)
+type QuestionRevision struct {
+	ID            int      `json:"id"`
+	QuestionID    int      `json:"questionId"`
+	RevisionNo    int      `json:"revisionNo"`
+	QuestionText  string   `json:"questionText"`
+	Option1       string   `json:"option1"`
+	Option1Img    string   `json:"option1Img"`
+	Option2       string   `json:"option2"`
+	Option2Img    string   `json:"option2Img"`
+	Option3       string   `json:"option3"`
+	Option3Img    string   `json:"option3Img"`
+	Option4       string   `json:"option4"`
+	Option4Img    string   `json:"option4Img"`
+	CorrectAnswer int      `json:"correctAnswer"`
+	Explanation   string   `json:"explanation"`
+	Action        string   `json:"action"`
+	EditorCode    string   `json:"editorCode"`
+	EditorName    string   `json:"editorName"`
+	CreateTime    string   `json:"createTime"`
+	Changes       []string `json:"changes"`      // 相比上一修订变化的字段
+	AttemptCount  int      `json:"attemptCount"` // 以该修订作答的记录数
+}
+
+// AnswerItem 单题作答
+type AnswerItem struct {
+	QuestionID   int `json:"questionId" binding:"required"`
+	ChosenOption int `json:"chosenOption" binding:"required,min=1,max=4"`
+}
+
+// SubmitAnswersRequest 提交作答 (练习或考试)
+type SubmitAnswersRequest struct {
+	ExamTag string       `json:"examTag"` // 考试/练习批次标识，可为空
+	Answers []AnswerItem `json:"answers" binding:"required,min=1,max=200,dive"`
+}
+
+// AnswerResult 单题判分结果
+type AnswerResult struct {
+	AttemptID     int64 `json:"attemptId"`
+	QuestionID    int   `json:"questionId"`
+	ChosenOption  int   `json:"chosenOption"`
+	CorrectAnswer int   `json:"correctAnswer"`
+	IsCorrect     bool  `json:"isCorrect"`
+	RevisionNo    int   `json:"revisionNo"`
+}
+
+// QuestionAttempt 答题记录
+type QuestionAttempt struct {
+	ID                 int     `json:"id"`
+	QuestionID         int     `json:"questionId"`
+	QuestionText       string  `json:"questionText"`
+	ExamTag            string  `json:"examTag"`
+	ChosenOption       int     `json:"chosenOption"`
+	IsCorrect          bool    `json:"isCorrect"`
+	AnsweredRevisionNo int     `json:"answeredRevisionNo"` // 作答时的修订
+	AnsweredCorrect    int     `json:"answeredCorrect"`    // 作答时的正确答案
+	GradedRevisionNo   int     `json:"gradedRevisionNo"`   // 当前判分依据的修订
+	GradedCorrect      int     `json:"gradedCorrect"`      // 当前判分依据的正确答案
+	RegradeTime        *string `json:"regradeTime"`
+	CreateTime         string  `json:"createTime"`
+}
+
+// RegradeQuestionRequest 重新判分请求 (缺省按最新修订)
+type RegradeQuestionRequest struct {
+	RevisionID int `json:"revisionId"`
+}
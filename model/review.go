@@ -0,0 +1,41 @@
+package model
+
+// SubjectReview 科目评价
+type SubjectReview struct {
+	ID           int     `json:"id"`
+	SubjectID    int     `json:"subjectId"`
+	SubjectName  string  `json:"subjectName,omitempty"`
+	UserID       int     `json:"userId"`
+	UserName     string  `json:"userName"`
+	Rating       int     `json:"rating"`
+	Content      string  `json:"content"`
+	Reply        string  `json:"reply"`
+	ReplyTime    UTCTime `json:"replyTime"`
+	Hidden       bool    `json:"hidden"`                 // 被管理员隐藏 (仅本人与管理员可见)
+	HiddenReason string  `json:"hiddenReason,omitempty"` // 隐藏原因
+	CreateTime   UTCTime `json:"createTime"`
+	UpdateTime   UTCTime `json:"updateTime"`
+}
+
+// RatingSummary 评分汇总 (不含被隐藏的评价)
+type RatingSummary struct {
+	Average float64 `json:"average"` // 保留一位小数，没有评价时为 0
+	Count   int     `json:"count"`
+}
+
+// SaveReviewRequest 发表 / 修改评价
+type SaveReviewRequest struct {
+	Rating  int    `json:"rating" binding:"required,min=1,max=5"`
+	Content string `json:"content"`
+}
+
+// ReplyReviewRequest 作者回复评价 (为空表示删除回复)
+type ReplyReviewRequest struct {
+	Reply string `json:"reply"`
+}
+
+// HideReviewRequest 管理员隐藏 / 恢复评价
+type HideReviewRequest struct {
+	Hidden *bool  `json:"hidden" binding:"required"`
+	Reason string `json:"reason" binding:"max=200"`
+}
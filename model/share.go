@@ -8,6 +8,9 @@ type CreateShareRequest struct {
 	CodeDuration string   `json:"code_duration"`               // ★★★ 新增：分享码有效期 (给码用的)
 	Type         int      `json:"type" binding:"required"`
 	Targets      []string `json:"targets"`
+	MaxUses      int      `json:"max_uses" binding:"min=0"` // 分享码最多可被多少人使用，0 为不限
+	// 为 true 时绑定只提交申请，创建者审核通过后才授权
+	RequireApproval bool `json:"require_approval"`
 }
 
 // BindShareRequest 绑定分享的请求参数
@@ -25,11 +28,55 @@ type ShareCode struct {
 }
 
 type ShareAnnouncement struct {
-	ID          int    `json:"id"`
-	CreatorCode string `json:"creatorCode"`
-	ShareCode   string `json:"shareCode"`
-	Note        string `json:"note"`
-	CreateTime  string `json:"createTime"`
-	ExpireTime  string `json:"expireTime"`
-	Status      int    `json:"status"`
+	ID          int     `json:"id"`
+	CreatorCode string  `json:"creatorCode"`
+	ShareCode   string  `json:"shareCode"`
+	Note        string  `json:"note"`
+	CreateTime  UTCTime `json:"createTime"`
+	ExpireTime  UTCTime `json:"expireTime"`
+	Status      int     `json:"status"`
+
+	Rating RatingSummary `json:"rating"` // 分享码所含科目的评分汇总
+}
+
+// ShareCodeRedeemer 分享码使用记录 (创建者可见)
+type ShareCodeRedeemer struct {
+	UserID     int     `json:"user_id"`
+	UserCode   string  `json:"user_code"`
+	Username   string  `json:"username"`
+	Nickname   string  `json:"nickname"`
+	UseTime    UTCTime `json:"use_time"`
+	IP         string  `json:"ip"`
+	Active     bool    `json:"active"`      // 通过该码获得的授权是否仍有效
+	ExpireTime UTCTime `json:"expire_time"` // 该用户授权的到期时间，null 为永久
+}
+
+// 分享码绑定申请状态
+const (
+	ShareRequestPending  = 0
+	ShareRequestApproved = 1
+	ShareRequestRejected = 2
+)
+
+// ShareCodeApplication 分享码绑定申请
+type ShareCodeApplication struct {
+	ID           int      `json:"id"`
+	ShareCodeID  int      `json:"share_code_id"`
+	Code         string   `json:"code"`
+	UserID       int      `json:"user_id,omitempty"`
+	UserCode     string   `json:"user_code,omitempty"`
+	Username     string   `json:"username,omitempty"`
+	Nickname     string   `json:"nickname,omitempty"`
+	SubjectNames []string `json:"subject_names"`
+	Status       int      `json:"status"` // 0: 待审核, 1: 已通过, 2: 已拒绝
+	ReviewNote   string   `json:"review_note"`
+	CreateTime   UTCTime  `json:"create_time"`
+	ReviewTime   UTCTime  `json:"review_time"`
+}
+
+// ReviewShareRequestsRequest 审核分享码绑定申请 (单个审核时 IDs 由路径参数给出)
+type ReviewShareRequestsRequest struct {
+	IDs    []int  `json:"ids"`
+	Action string `json:"action" binding:"required,oneof=approve reject"`
+	Note   string `json:"note" binding:"max=200"`
 }
@@ -21,3 +21,19 @@ type UpdateSubjectRequest struct {
 	Name   string `json:"name"`
 	Status int    `json:"status"`
 }
+
+// SubjectMember 科目协作成员
+type SubjectMember struct {
+	UserID     int    `json:"userId"`
+	UserCode   string `json:"userCode"`
+	Nickname   string `json:"nickname"`
+	Email      string `json:"email"`
+	Role       string `json:"role"` // owner / editor / reviewer / viewer
+	CreateTime string `json:"createTime"`
+}
+
+// SaveSubjectMemberRequest 添加成员 / 修改成员角色
+type SaveSubjectMemberRequest struct {
+	UserCode string `json:"userCode" binding:"required"`
+	Role     string `json:"role" binding:"required,oneof=editor reviewer viewer"`
+}
@@ -0,0 +1,32 @@
+package model
+
+// 科目转让状态
+const (
+	TransferStatusPending   = 0 // 待接收
+	TransferStatusAccepted  = 1 // 已接收 (转让完成)
+	TransferStatusRejected  = 2 // 接收人已拒绝
+	TransferStatusCancelled = 3 // 发起人撤销 / 科目所有者已变化而失效
+)
+
+// SubjectTransfer 对应 subject_transfers 表，同时作为转让审计记录
+type SubjectTransfer struct {
+	ID                 int    `json:"id"`
+	SubjectID          int    `json:"subjectId"`
+	SubjectName        string `json:"subjectName"`
+	FromCode           string `json:"fromCode"`      // 原所有者
+	ToCode             string `json:"toCode"`        // 接收人
+	InitiatorCode      string `json:"initiatorCode"` // 发起人 (原所有者或管理员)
+	Status             int    `json:"status"`        // 0: 待接收, 1: 已完成, 2: 已拒绝, 3: 已撤销
+	Remark             string `json:"remark"`
+	ShareCodesMoved    int    `json:"shareCodesMoved"`    // 随科目转移的分享码数量
+	ShareCodesDetached int    `json:"shareCodesDetached"` // 含其他科目、仅移除本科目的分享码数量
+	AnnouncementsMoved int    `json:"announcementsMoved"` // 随分享码转移的公告数量
+	CreateTime         string `json:"createTime"`
+	HandleTime         string `json:"handleTime"`
+}
+
+// CreateSubjectTransferRequest 发起科目转让
+type CreateSubjectTransferRequest struct {
+	ToUserCode string `json:"toUserCode" binding:"required"`
+	Remark     string `json:"remark"`
+}
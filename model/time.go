@@ -0,0 +1,186 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ==========================================
+// 时间约定：数据库一律存 UTC，格式 "2006-01-02 15:04:05"
+// 与 CURRENT_TIMESTAMP、datetime('now') 同格式同时区，SQL 中可直接比较
+// 接口输出 RFC3339 (服务器时区)；接口输入兼容 RFC3339 与服务器本地时间 "2006-01-02 15:04:05"
+// ==========================================
+
+// DBTimeLayout 数据库中的时间格式 (UTC)
+const DBTimeLayout = "2006-01-02 15:04:05"
+
+// 带时区的写法 (RFC3339 及 SQLite 驱动写入 time.Time 时的格式)
+var zonedLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05Z07:00",
+}
+
+// 不带时区的写法
+var naiveLayouts = []string{
+	DBTimeLayout,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// parseTime 先按带时区格式解析，失败再按 naiveLoc 解析不带时区的格式
+func parseTime(s string, naiveLoc *time.Location) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range zonedLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	for _, layout := range naiveLayouts {
+		if t, err := time.ParseInLocation(layout, s, naiveLoc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无法解析时间 '%s'", s)
+}
+
+// ParseDBTime 解析数据库中的时间 (不带时区的按 UTC)
+func ParseDBTime(s string) (time.Time, error) {
+	return parseTime(s, time.UTC)
+}
+
+// ParseInputTime 解析接口传入的时间 (不带时区的按服务器本地时间)
+func ParseInputTime(s string) (time.Time, error) {
+	return parseTime(s, time.Local)
+}
+
+// ParseLegacyTime 解析旧数据中的时间 (旧版本按服务器本地时间存储，仅供迁移使用)
+func ParseLegacyTime(s string) (time.Time, error) {
+	return parseTime(s, time.Local)
+}
+
+// FormatDBTime 转为数据库存储格式
+func FormatDBTime(t time.Time) string {
+	return t.UTC().Format(DBTimeLayout)
+}
+
+// FormatAPITime 转为接口输出格式
+func FormatAPITime(t time.Time) string {
+	return t.In(time.Local).Format(time.RFC3339)
+}
+
+// UTCTime 可为空的时间字段，用于有效期等 (NULL 表示永久)
+// 实现 sql.Scanner / driver.Valuer / json.Marshaler，读写数据库与接口时自动转换
+type UTCTime struct {
+	Time  time.Time
+	Valid bool
+}
+
+// NewUTCTime 构造非空时间
+func NewUTCTime(t time.Time) UTCTime {
+	return UTCTime{Time: t, Valid: true}
+}
+
+// ParseExpireInput 解析接口传入的有效期，空串或 "forever" 表示永久
+func ParseExpireInput(s string) (UTCTime, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "forever" {
+		return UTCTime{}, nil
+	}
+	t, err := ParseInputTime(s)
+	if err != nil {
+		return UTCTime{}, err
+	}
+	return NewUTCTime(t), nil
+}
+
+// Expired 有效期是否已过 (永久的不会过期)
+func (t UTCTime) Expired(now time.Time) bool {
+	return t.Valid && !now.Before(t.Time)
+}
+
+// String 接口输出格式，空值为 ""
+func (t UTCTime) String() string {
+	if !t.Valid {
+		return ""
+	}
+	return FormatAPITime(t.Time)
+}
+
+// Ptr 接口输出格式，空值为 nil
+func (t UTCTime) Ptr() *string {
+	if !t.Valid {
+		return nil
+	}
+	s := t.String()
+	return &s
+}
+
+// Scan 实现 sql.Scanner
+// SQLite 驱动对 DATETIME 列返回 time.Time (按 UTC 解析)，表达式结果返回字符串
+func (t *UTCTime) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*t = UTCTime{}
+		return nil
+	case time.Time:
+		*t = NewUTCTime(v)
+		return nil
+	case string:
+		return t.scanString(v)
+	case []byte:
+		return t.scanString(string(v))
+	}
+	return fmt.Errorf("UTCTime: 不支持的类型 %T", src)
+}
+
+func (t *UTCTime) scanString(s string) error {
+	if strings.TrimSpace(s) == "" {
+		*t = UTCTime{}
+		return nil
+	}
+	parsed, err := ParseDBTime(s)
+	if err != nil {
+		return err
+	}
+	*t = NewUTCTime(parsed)
+	return nil
+}
+
+// Value 实现 driver.Valuer
+func (t UTCTime) Value() (driver.Value, error) {
+	if !t.Valid {
+		return nil, nil
+	}
+	return FormatDBTime(t.Time), nil
+}
+
+// MarshalJSON 输出 RFC3339，空值为 null
+func (t UTCTime) MarshalJSON() ([]byte, error) {
+	if !t.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(t.String())
+}
+
+// UnmarshalJSON 兼容 RFC3339、服务器本地时间与 null
+func (t *UTCTime) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		*t = UTCTime{}
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	parsed, err := ParseExpireInput(s)
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
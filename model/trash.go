@@ -0,0 +1,22 @@
+package model
+
+// 回收站条目类型
+const (
+	TrashTypeSubject  = "subject"
+	TrashTypeCategory = "category"
+	TrashTypePoint    = "point"
+	TrashTypeQuestion = "question"
+)
+
+// TrashItem 回收站条目 (只列出每次删除操作的根节点，连带删除的子内容随根节点一起恢复)
+type TrashItem struct {
+	Type        string  `json:"type"` // subject / category / point / question
+	ID          int     `json:"id"`
+	Name        string  `json:"name"`
+	SubjectID   int     `json:"subjectId"`
+	SubjectName string  `json:"subjectName"`
+	DeletedAt   UTCTime `json:"deletedAt"`
+	DeletedBy   string  `json:"deletedBy"`
+	ChildCount  int     `json:"childCount"` // 同批次连带删除的子内容数量
+	PurgeTime   UTCTime `json:"purgeTime"`  // 预计彻底清除时间
+}
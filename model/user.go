@@ -24,6 +24,19 @@ type UpdateUserReq struct {
 	NewPassword string `json:"new_password"` // 修改密码时必填
 }
 
+// 申请重置密码
+type PasswordResetRequestReq struct {
+	Username string `json:"username" binding:"required"`
+	Method   string `json:"method"`    // email (默认) / totp
+	TotpCode string `json:"totp_code"` // method 为 totp 时必填
+}
+
+// 确认重置密码
+type PasswordResetConfirmReq struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"` // 与注册一致，前端 MD5 后传入
+}
+
 type DbUser struct {
 	Id            int
 	Username      string
@@ -35,4 +48,5 @@ type DbUser struct {
 	Status        int            // 0: 正常, 1: 禁用
 	LastLoginTime sql.NullString // 最后登录时间
 	TotpSecret    sql.NullString // Google Authenticator 密钥
+	EmailVerified bool           // 邮箱是否已验证
 }
@@ -2,6 +2,7 @@ package router
 
 import (
 	"practice_problems/api"
+	"practice_problems/global"
 	"practice_problems/middleware"
 
 	"github.com/gin-contrib/gzip"
@@ -12,6 +13,12 @@ func InitRouter() *gin.Engine {
 	// 使用 gin.New()，跳过默认的 Logger 和 Recovery，我们需要手动配置
 	r := gin.New()
 
+	// 只采信受信任代理转发的 X-Forwarded-For，否则客户端可伪造 IP 绕过按 IP 的限流
+	if err := r.SetTrustedProxies(global.TrustedProxies); err != nil {
+		global.GetLog(nil).Errorf("受信任代理配置无效，已改为不信任任何代理: %v", err)
+		r.SetTrustedProxies(nil)
+	}
+
 	// 1. ★★★ RequestID 中间件 (必须放在第一个) ★★★
 	// 它负责生成 ID，后续的 Logger 才能拿到
 	r.Use(middleware.RequestIDMiddleware())
@@ -28,6 +35,8 @@ func InitRouter() *gin.Engine {
 
 	// ★★★ WebSocket 路由 (不能使用 gzip，必须在 gzip 中间件之前注册) ★★★
 	r.GET("/api/v1/ws/ai-interview", api.AIInterviewWebSocket)
+	// ★★★ SSE 实时推送 (同样不能经过 gzip，否则事件会被缓冲) ★★★
+	r.GET("/api/v1/events/stream", api.PushEventStream)
 
 	// 5. gzip 压缩中间件 (放在 WebSocket 路由之后)
 	r.Use(gzip.Gzip(gzip.DefaultCompression))
@@ -41,8 +50,12 @@ func InitRouter() *gin.Engine {
 		// 公开接口 (无需 Token)
 		// ============================
 		// 用户认证
-		v1.POST("/auth/register", api.CreateUser) // 创建用户
-		v1.POST("/auth/login", api.UserLogin)     // 用户登录 (含空密码逻辑)
+		v1.POST("/auth/register", api.CreateUser)                         // 创建用户
+		v1.POST("/auth/login", api.UserLogin)                             // 用户登录 (含空密码逻辑)
+		v1.GET("/auth/verify-email", api.VerifyEmailPage)                 // 邮箱验证确认页 (验证邮件中的链接)
+		v1.POST("/auth/verify-email", api.VerifyEmail)                    // 确认邮箱验证 (使用令牌)
+		v1.POST("/auth/password-reset/request", api.RequestPasswordReset) // 申请重置密码 (邮箱 / TOTP)
+		v1.POST("/auth/password-reset/confirm", api.ConfirmPasswordReset) // 凭令牌设置新密码
 
 		// ============================
 		// 需要 JWT 认证的接口
@@ -53,6 +66,8 @@ func InitRouter() *gin.Engine {
 			// 用户相关
 			auth.PUT("/user/profile", api.UpdateUser) // 修改用户信息/密码
 			auth.POST("/auth/logout", api.UserLogout)
+			auth.GET("/user/ai-quota", api.GetMyAIQuota)                       // AI 面试时长余额与流水
+			auth.POST("/user/email/verification", api.ResendVerificationEmail) // 重新发送邮箱验证邮件
 
 			// TOTP相关（谷歌验证码）
 			auth.GET("/totp/check", api.CheckTotpBound)        // 检查是否已绑定
@@ -79,6 +94,11 @@ func InitRouter() *gin.Engine {
 			auth.GET("/share/list", api.GetMyShareCodes)
 			auth.DELETE("/share/:id", api.DeleteShareCode)
 			auth.PUT("/share/:id", api.UpdateShareCode)
+			auth.GET("/share/:id/usages", api.GetShareCodeRedeemers)          // 分享码使用记录
+			auth.GET("/share/requests", api.GetShareCodeRequests)             // 收到的绑定申请 (审核模式)
+			auth.PUT("/share/requests/:id", api.ReviewShareRequest)           // 审核单个申请
+			auth.POST("/share/requests/review", api.BatchReviewShareRequests) // 批量审核
+			auth.GET("/share/my-requests", api.GetMyShareRequests)            // 我提交的申请
 
 			// --- 科目 ---
 			auth.GET("/subjects", api.GetSubjectList)
@@ -91,13 +111,43 @@ func InitRouter() *gin.Engine {
 			auth.DELETE("/auth/:id", api.RemoveSubjectAuth)
 			auth.PUT("/auth/batch/update", api.BatchUpdateAuth)
 			auth.PUT("/auth/batch/remove", api.BatchRemoveAuth)
+			auth.GET("/subjects/:id/prompt", api.GetSubjectPrompt)                // 科目提示词设置 (创建者)
+			auth.PUT("/subjects/:id/prompt", api.UpdateSubjectPrompt)             // 修改科目提示词设置 (创建者)
+			auth.GET("/prompt-templates", api.GetPromptTemplates)                 // 可选提示词模板列表
+			auth.GET("/subjects/:id/members", api.GetSubjectMembers)              // 科目协作成员列表
+			auth.POST("/subjects/:id/members", api.SaveSubjectMember)             // 添加成员/修改角色 (所有者)
+			auth.DELETE("/subjects/:id/members/:userId", api.RemoveSubjectMember) // 移除成员 (所有者)
+			auth.POST("/subjects/:id/transfer", api.CreateSubjectTransfer)        // 发起科目转让 (所有者/管理员)
+			auth.GET("/subject-transfers", api.GetMySubjectTransfers)             // 我的转让记录
+			auth.POST("/subject-transfers/:id/accept", api.AcceptSubjectTransfer) // 接收转让
+			auth.POST("/subject-transfers/:id/reject", api.RejectSubjectTransfer) // 拒绝转让
+			auth.POST("/subject-transfers/:id/cancel", api.CancelSubjectTransfer) // 撤销转让
+
+			// --- 科目目录 ---
+			auth.GET("/catalog", api.GetCatalog)                              // 目录列表 (搜索、标签、按热度/评分/时间排序)
+			auth.GET("/catalog/:id", api.GetCatalogDetail)                    // 目录详情 (含试看知识点)
+			auth.POST("/catalog/:id/request", api.RequestCatalogAccess)       // 一键申请 (使用目录关联的分享码)
+			auth.GET("/subjects/:id/listing", api.GetSubjectListing)          // 科目的目录信息 (作者)
+			auth.PUT("/subjects/:id/listing", api.SaveSubjectListing)         // 发布 / 修改目录信息 (作者)
+			auth.DELETE("/subjects/:id/listing", api.UnpublishSubjectListing) // 从目录下架 (作者)
+
+			// --- 科目评价 ---
+			auth.GET("/subjects/:id/reviews", api.GetSubjectReviews)     // 评价列表 (含评分汇总、我的评价)
+			auth.PUT("/subjects/:id/review", api.SaveSubjectReview)      // 发表 / 修改我的评价
+			auth.DELETE("/subjects/:id/review", api.DeleteSubjectReview) // 删除我的评价
+			auth.PUT("/reviews/:id/reply", api.ReplySubjectReview)       // 作者回复评价
+
+			// --- 回收站 ---
+			auth.GET("/trash", api.GetTrash)                            // 我的回收站
+			auth.POST("/trash/:type/:id/restore", api.RestoreTrashItem) // 恢复 (连同一起删除的子内容)
 
 			// --- 分类 ---
 			auth.GET("/categories", api.GetCategoryList)
 			auth.POST("/categories", api.CreateCategory)
 			auth.PUT("/categories/:id", api.UpdateCategory)
 			auth.DELETE("/categories/:id", api.DeleteCategory)
-			auth.POST("/categories/:id/sort", api.UpdateCategorySort)
+			auth.POST("/categories/:id/sort", api.UpdateCategorySort)    // 同级排序 (top/up/down) 与移动 (move)
+			auth.GET("/subjects/:id/category-tree", api.GetCategoryTree) // 分类树 (含子树题目数)
 
 			// --- 知识点 ---
 			auth.GET("/points", api.GetPointList)
@@ -108,6 +158,12 @@ func InitRouter() *gin.Engine {
 			auth.DELETE("/points/:id", api.DeletePoint)
 			auth.DELETE("/points/:id/image", api.DeletePointImage)
 			auth.PUT("/points/:id/sort", api.UpdatePointSort)
+			auth.GET("/points/:id/revisions", api.GetPointRevisions)                      // 知识点修订历史
+			auth.GET("/points/:id/revisions/diff", api.DiffPointRevisions)                // 对比两条修订
+			auth.GET("/points/:id/revisions/:revId", api.GetPointRevision)                // 修订快照详情
+			auth.POST("/points/:id/revisions/:revId/rollback", api.RollbackPointRevision) // 回滚到指定修订
+			auth.POST("/points/:id/generate-questions", api.GenerateQuestions)            // AI 生成草稿题
+			auth.GET("/points/:id/question-drafts", api.GetQuestionDrafts)                // 草稿题列表
 
 			// --- 知识点笔记 ---
 			auth.GET("/points/:id/note", api.GetPointNote)   // 获取知识点笔记
@@ -117,6 +173,30 @@ func InitRouter() *gin.Engine {
 			auth.POST("/point-bindings", api.CreateBinding)
 			auth.GET("/point-bindings/:pointId", api.GetBindingsByPoint)
 			auth.DELETE("/point-bindings/:id", api.DeleteBinding)
+			auth.PUT("/point-bindings/:id/anchor", api.RepairBinding)            // 修复失效绑定
+			auth.GET("/subjects/:id/broken-bindings", api.GetBrokenBindings)     // 科目失效绑定报告
+			auth.GET("/subjects/:id/dangling-bindings", api.GetDanglingBindings) // 科目悬空绑定 (目标已删除)
+			auth.GET("/subjects/:id/graph", api.GetSubjectGraph)                 // 科目知识图谱
+			auth.GET("/points/:id/graph", api.GetPointGraph)                     // 知识点 N 跳邻域
+			auth.GET("/points/:id/backlinks", api.GetPointBacklinks)             // 反向链接
+			auth.POST("/point-relations", api.CreatePointRelation)               // 创建知识点关系 (前置/延伸/相关)
+			auth.DELETE("/point-relations/:id", api.DeletePointRelation)         // 删除知识点关系
+			auth.GET("/points/:id/relations", api.GetPointRelations)             // 知识点关系列表
+			auth.GET("/subjects/:id/learning-path", api.GetLearningPath)         // 学习路径 (可按 categoryId 限定)
+			auth.GET("/subjects/:id/next-point", api.GetNextRecommendedPoint)    // 推荐下一个知识点
+
+			// 授权到期：宽限期设置
+			auth.PUT("/subjects/:id/grace-period", api.UpdateSubjectGracePeriod) // 设置到期宽限期 (仅所有者)
+
+			// 站内通知中心
+			auth.GET("/notifications", api.GetNotifications)                          // 我的通知 (分页，含未读数)
+			auth.GET("/notifications/unread-count", api.GetUnreadNotificationCount)   // 未读通知数
+			auth.PUT("/notifications/:id/read", api.MarkNotificationRead)             // 标记已读
+			auth.PUT("/notifications/read-all", api.MarkAllNotificationsRead)         // 全部标记已读
+			auth.GET("/notifications/preferences", api.GetNotificationPreferences)    // 通知偏好
+			auth.PUT("/notifications/preferences", api.UpdateNotificationPreferences) // 修改通知偏好
+			auth.PUT("/events/subscriptions", api.UpdatePushSubscriptions)            // 调整推送连接订阅的科目
+
 			auth.GET("/binding/subjects/:subjectId/categories", api.GetCategoriesBySubjectForBinding)
 			auth.GET("/binding/categories/:categoryId/points", api.GetPointsByCategoryForBinding)
 
@@ -127,6 +207,16 @@ func InitRouter() *gin.Engine {
 			// ★★★ 新增：修改用户题目备注 ★★★
 			auth.POST("/questions/note", api.UpdateUserNote)
 			auth.DELETE("/questions/:id", api.DeleteQuestion)
+			auth.POST("/questions/:id/explain", api.ExplainWrongAnswer)    // AI 错题解析
+			auth.GET("/questions/:id/revisions", api.GetQuestionRevisions) // 题目修订历史
+			auth.POST("/questions/:id/regrade", api.RegradeQuestion)       // 按更正后的答案重新判分
+			auth.POST("/questions/answers", api.SubmitAnswers)             // 提交作答并判分
+			auth.GET("/question-attempts", api.GetMyAttempts)              // 我的答题记录
+
+			// --- 草稿题 (AI 出题) ---
+			auth.PUT("/question-drafts/:id", api.UpdateQuestionDraft)           // 编辑草稿
+			auth.POST("/question-drafts/:id/publish", api.PublishQuestionDraft) // 发布为正式题目
+			auth.DELETE("/question-drafts/:id", api.DiscardQuestionDraft)       // 丢弃草稿
 
 			// --- 集合 ---
 			auth.GET("/collections", api.GetCollections)                                // 获取集合列表
@@ -178,6 +268,27 @@ func InitRouter() *gin.Engine {
 				admin.DELETE("/db/tables/:table/columns/:column", api.DropColumn)             // 删除字段
 				admin.GET("/db/tables/:table/column-orders", api.GetColumnOrders)             // 获取字段排序
 				admin.POST("/db/tables/:table/column-orders", api.SaveColumnOrders)           // 保存字段排序
+
+				// AI 面试时长管理
+				admin.POST("/ai-quota/grant", api.AdminGrantAIQuota)     // 发放时长
+				admin.POST("/ai-quota/revoke", api.AdminRevokeAIQuota)   // 扣回时长
+				admin.GET("/ai-quota/ledger", api.AdminGetAIQuotaLedger) // 查看用户流水
+
+				// AI 提示词模板管理
+				admin.GET("/prompt-templates", api.GetPromptTemplates)                            // 模板列表 (最新版本)
+				admin.GET("/prompt-templates/:name/versions", api.AdminGetPromptTemplateVersions) // 模板历史版本
+				admin.POST("/prompt-templates", api.AdminCreatePromptTemplate)                    // 创建模板
+				admin.PUT("/prompt-templates/:name", api.AdminUpdatePromptTemplate)               // 修改模板 (生成新版本)
+				admin.DELETE("/prompt-templates/:name", api.AdminDeletePromptTemplate)            // 删除模板
+				admin.PUT("/prompt-templates/:name/default", api.AdminSetDefaultPromptTemplate)   // 设为默认模板
+				admin.POST("/prompt-templates/preview", api.AdminPreviewPromptTemplate)           // 预览渲染
+
+				// 科目转让审计
+				admin.GET("/subject-transfers", api.AdminGetSubjectTransfers) // 全部转让记录
+
+				// 科目评价管理
+				admin.GET("/reviews", api.AdminGetReviews)            // 评价列表 (可只看已隐藏)
+				admin.PUT("/reviews/:id/hidden", api.AdminHideReview) // 隐藏 / 恢复评价
 			}
 		}
 	}